@@ -0,0 +1,309 @@
+// Package analysis はプログラムを実行せずに調べるための解析を提供する。
+package analysis
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/al-keio/monkey-go/ast"
+	"github.com/al-keio/monkey-go/token"
+)
+
+// Main はトップレベルの (どの関数にも属さない) コードを表す呼び出し元の名前
+const Main = "<main>"
+
+type EdgeKind string
+
+const (
+	// Call は f(...) の形の直接の呼び出し
+	Call EdgeKind = "call"
+	// Reference は map(xs, f) のように関数を値として参照しているもの。高階関数から呼ばれうるとみなす。
+	Reference EdgeKind = "reference"
+)
+
+type Edge struct {
+	Caller string   `json:"caller"`
+	Callee string   `json:"callee"`
+	Kind   EdgeKind `json:"kind"`
+}
+
+// CallGraph はトップレベルで let に束縛された関数の間の呼び出し関係。
+// 関数の中で定義された関数からの呼び出しは、それを囲むトップレベルの関数からの呼び出しとして扱う。
+type CallGraph struct {
+	Functions []string `json:"functions"`
+	Edges     []Edge   `json:"edges"`
+}
+
+// BuildCallGraph は program の呼び出しグラフを作る。引数やローカルな let で隠された名前は辿らない。
+func BuildCallGraph(program *ast.Program) *CallGraph {
+	functions := map[string]*ast.FunctionLiteral{}
+	for _, stmt := range program.Statements {
+		if let, ok := stmt.(*ast.LetStatement); ok {
+			if fn, ok := let.Value.(*ast.FunctionLiteral); ok {
+				functions[let.Name.Value] = fn
+			}
+		}
+	}
+
+	b := &builder{functions: functions, edges: map[Edge]bool{}}
+	w := walker{visit: b.add}
+	for _, stmt := range program.Statements {
+		if let, ok := stmt.(*ast.LetStatement); ok {
+			if fn, ok := functions[let.Name.Value]; ok && let.Value == fn {
+				b.caller = let.Name.Value
+				w.walk(fn, scope{})
+				continue
+			}
+		}
+		b.caller = Main
+		w.walk(stmt, scope{})
+	}
+
+	g := &CallGraph{Functions: []string{}, Edges: []Edge{}}
+	for name := range functions {
+		g.Functions = append(g.Functions, name)
+	}
+	sort.Strings(g.Functions)
+
+	for edge := range b.edges {
+		g.Edges = append(g.Edges, edge)
+	}
+	sort.Slice(g.Edges, func(i, j int) bool {
+		a, b := g.Edges[i], g.Edges[j]
+		if a.Caller != b.Caller {
+			return a.Caller < b.Caller
+		}
+		if a.Callee != b.Callee {
+			return a.Callee < b.Callee
+		}
+		return a.Kind < b.Kind
+	})
+
+	return g
+}
+
+// Callees は name から呼ばれうる関数を返す
+func (g *CallGraph) Callees(name string) []string {
+	callees := []string{}
+	seen := map[string]bool{}
+	for _, edge := range g.Edges {
+		if edge.Caller == name && !seen[edge.Callee] {
+			seen[edge.Callee] = true
+			callees = append(callees, edge.Callee)
+		}
+	}
+	return callees
+}
+
+// Reachable は from から呼び出しを辿って到達できる関数の集合を返す (from 自身を含む)
+func (g *CallGraph) Reachable(from string) map[string]bool {
+	reachable := map[string]bool{from: true}
+	queue := []string{from}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		for _, callee := range g.Callees(name) {
+			if !reachable[callee] {
+				reachable[callee] = true
+				queue = append(queue, callee)
+			}
+		}
+	}
+	return reachable
+}
+
+// DOT は Graphviz の dot 形式で出力する。参照による辺は破線で描く。
+func (g *CallGraph) DOT() string {
+	var out bytes.Buffer
+
+	out.WriteString("digraph calls {\n")
+	fmt.Fprintf(&out, "\t%q;\n", Main)
+	for _, name := range g.Functions {
+		fmt.Fprintf(&out, "\t%q;\n", name)
+	}
+	for _, edge := range g.Edges {
+		if edge.Kind == Reference {
+			fmt.Fprintf(&out, "\t%q -> %q [style=dashed];\n", edge.Caller, edge.Callee)
+		} else {
+			fmt.Fprintf(&out, "\t%q -> %q;\n", edge.Caller, edge.Callee)
+		}
+	}
+	out.WriteString("}\n")
+
+	return out.String()
+}
+
+func (g *CallGraph) JSON() ([]byte, error) {
+	return json.MarshalIndent(g, "", "  ")
+}
+
+// scope はその位置で隠されている (ローカルに束縛された) 名前の集合
+type scope map[string]bool
+
+func (s scope) with(names ...string) scope {
+	extended := scope{}
+	for name := range s {
+		extended[name] = true
+	}
+	for _, name := range names {
+		extended[name] = true
+	}
+	return extended
+}
+
+type builder struct {
+	functions map[string]*ast.FunctionLiteral
+	caller    string
+	edges     map[Edge]bool
+}
+
+// walker は名前の隠蔽を考慮して木を辿り、隠されていない識別子の参照ごとに visit を呼ぶ
+type walker struct {
+	visit func(ident *ast.Identifier, kind EdgeKind, s scope)
+}
+
+func (b *builder) add(ident *ast.Identifier, kind EdgeKind, s scope) {
+	if _, ok := b.functions[ident.Value]; ok && !s[ident.Value] {
+		b.edges[Edge{Caller: b.caller, Callee: ident.Value, Kind: kind}] = true
+	}
+}
+
+func (w walker) walk(node ast.Node, s scope) {
+	switch node := node.(type) {
+	case *ast.ExpressionStatement:
+		w.walk(node.Expression, s)
+	case *ast.ReturnStatement:
+		w.walk(node.ReturnValue, s)
+	case *ast.DeferStatement:
+		w.walk(node.Call, s)
+	case *ast.OperatorStatement:
+		w.walk(node.Function, s.with(node.Operator))
+	case *ast.ClassStatement:
+		if node.Extends != nil {
+			w.walk(node.Extends, s)
+		}
+		for _, m := range node.Methods {
+			w.walk(m.Function, s.with(node.Name.Value))
+		}
+	case *ast.NewExpression:
+		w.walk(node.Class, s)
+		for _, arg := range node.Arguments {
+			w.walk(arg, s)
+		}
+	case *ast.LetStatement:
+		// let f = fn() { f() } のような再帰を参照できるよう、名前を先に束縛する
+		w.walk(node.Value, s.with(node.Name.Value))
+	case *ast.BlockStatement:
+		w.walkStatements(node.Statements, s)
+	case *ast.Identifier:
+		w.visit(node, Reference, s)
+	case *ast.PrefixExpression:
+		w.walk(node.Right, s)
+	case *ast.InfixExpression:
+		// 宣言した演算子は、その名前で束縛された関数を参照する
+		if node.Token.Type == token.CUSTOM_INFIX {
+			w.visit(&ast.Identifier{Token: node.Token, Value: node.Operator}, Reference, s)
+		}
+		w.walk(node.Left, s)
+		w.walk(node.Right, s)
+	case *ast.IndexExpression:
+		w.walk(node.Left, s)
+		w.walk(node.Index, s)
+	case *ast.MemberExpression:
+		w.walk(node.Object, s)
+	case *ast.IfExpression:
+		w.walk(node.Condition, s)
+		w.walk(node.Consequence, s)
+		if node.Alternative != nil {
+			w.walk(node.Alternative, s)
+		}
+	case *ast.ConditionalExpression:
+		w.walk(node.Condition, s)
+		w.walk(node.Consequence, s)
+		w.walk(node.Alternative, s)
+	case *ast.FunctionLiteral:
+		params := s.with(identifierNames(node.Parameters)...)
+		for _, cond := range node.Requires {
+			w.walk(cond, params)
+		}
+		for _, cond := range node.Ensures {
+			w.walk(cond, params.with("result"))
+		}
+		w.walk(node.Body, params)
+	case *ast.MacroLiteral:
+		w.walk(node.Body, s.with(identifierNames(node.Parameters)...))
+	case *ast.CallExpression:
+		if ident, ok := node.Function.(*ast.Identifier); ok {
+			w.visit(ident, Call, s)
+		} else {
+			w.walk(node.Function, s)
+		}
+		for _, arg := range node.Arguments {
+			w.walk(arg, s)
+		}
+	case *ast.ArrayLiteral:
+		for _, element := range node.Elements {
+			w.walk(element, s)
+		}
+	case *ast.HashLiteral:
+		for _, key := range node.SortedKeys() {
+			w.walk(key, s)
+			w.walk(node.Pairs[key], s)
+		}
+	case *ast.MatchExpression:
+		w.walk(node.Subject, s)
+		for _, arm := range node.Arms {
+			w.walk(arm.Body, s.with(patternNames(arm.Pattern)...))
+		}
+	case *ast.PipelineExpression:
+		w.walk(node.Original, s)
+	}
+}
+
+func (w walker) walkStatements(stmts []ast.Statement, s scope) {
+	for _, stmt := range stmts {
+		switch stmt := stmt.(type) {
+		case *ast.LetStatement:
+			s = s.with(stmt.Name.Value)
+		case *ast.OperatorStatement:
+			s = s.with(stmt.Operator)
+		case *ast.ClassStatement:
+			s = s.with(stmt.Name.Value)
+		}
+		w.walk(stmt, s)
+	}
+}
+
+func identifierNames(idents []*ast.Identifier) []string {
+	names := make([]string, len(idents))
+	for i, ident := range idents {
+		names[i] = ident.Value
+	}
+	return names
+}
+
+func patternNames(pattern ast.Pattern) []string {
+	switch pattern := pattern.(type) {
+	case *ast.BindingPattern:
+		return []string{pattern.Name.Value}
+	case *ast.ArrayPattern:
+		names := []string{}
+		for _, element := range pattern.Elements {
+			names = append(names, patternNames(element)...)
+		}
+		if pattern.Rest != nil {
+			names = append(names, pattern.Rest.Value)
+		}
+		return names
+	case *ast.HashPattern:
+		names := []string{}
+		for _, pair := range pattern.Pairs {
+			names = append(names, patternNames(pair.Value)...)
+		}
+		return names
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,85 @@
+package analysis
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/al-keio/monkey-go/ast"
+	"github.com/al-keio/monkey-go/internal/lexer"
+	"github.com/al-keio/monkey-go/internal/parser"
+)
+
+func TestBuildCallGraph(t *testing.T) {
+	input := `
+let double = fn(x) { x * 2 };
+let fib = fn(n) { if (n < 2) { n } else { fib(n - 1) + fib(n - 2) } };
+let apply = fn(xs) { map(xs, double) };
+let shadowed = fn(double) { double(1) };
+let local = fn() { let fib = fn(x) { x }; fib(1) };
+let matched = fn(x) { match (x) { [apply] => apply(1), _ => fib(2) } };
+let unused = fn() { 1 };
+let nested = fn() { fn() { double(3) } };
+apply([1, 2]);
+`
+	g := BuildCallGraph(parseProgram(t, input))
+
+	expectedFunctions := []string{"apply", "double", "fib", "local", "matched", "nested", "shadowed", "unused"}
+	if !reflect.DeepEqual(g.Functions, expectedFunctions) {
+		t.Errorf("wrong functions. want=%v, got=%v", expectedFunctions, g.Functions)
+	}
+
+	expectedEdges := []Edge{
+		{Main, "apply", Call},
+		{"apply", "double", Reference},
+		{"fib", "fib", Call},
+		{"matched", "fib", Call},
+		{"nested", "double", Call},
+	}
+	if !reflect.DeepEqual(g.Edges, expectedEdges) {
+		t.Errorf("wrong edges.\nwant=%v\ngot= %v", expectedEdges, g.Edges)
+	}
+
+	reachable := g.Reachable(Main)
+	for _, name := range []string{Main, "apply", "double"} {
+		if !reachable[name] {
+			t.Errorf("%s should be reachable from %s", name, Main)
+		}
+	}
+	if reachable["unused"] || reachable["fib"] {
+		t.Errorf("unexpected reachable functions: %v", reachable)
+	}
+}
+
+func TestCallGraphExport(t *testing.T) {
+	g := BuildCallGraph(parseProgram(t, `let f = fn(g) { g() }; let h = fn() { 1 }; f(h);`))
+
+	dot := g.DOT()
+	for _, line := range []string{`"<main>" -> "f";`, `"<main>" -> "h" [style=dashed];`} {
+		if !strings.Contains(dot, line) {
+			t.Errorf("DOT output does not contain %s:\n%s", line, dot)
+		}
+	}
+
+	data, err := g.JSON()
+	if err != nil {
+		t.Fatalf("JSON failed: %v", err)
+	}
+	var decoded CallGraph
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("could not decode JSON: %v\n%s", err, data)
+	}
+	if !reflect.DeepEqual(&decoded, g) {
+		t.Errorf("JSON round trip changed the graph. want=%+v, got=%+v", g, decoded)
+	}
+}
+
+func parseProgram(t *testing.T, input string) *ast.Program {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	return program
+}
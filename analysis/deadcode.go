@@ -0,0 +1,24 @@
+package analysis
+
+import (
+	"github.com/al-keio/monkey-go/ast"
+)
+
+// EliminateDeadFunctions はトップレベルのコードから呼び出しや参照を辿って到達できない、
+// let で束縛された関数の定義を取り除いたプログラムを返す。関数の定義には副作用がないので、
+// 取り除いても結果は変わらない。マクロの展開後のプログラムに対して使うこと。
+func EliminateDeadFunctions(program *ast.Program) *ast.Program {
+	reachable := BuildCallGraph(program).Reachable(Main)
+
+	statements := []ast.Statement{}
+	for _, stmt := range program.Statements {
+		if let, ok := stmt.(*ast.LetStatement); ok {
+			if _, ok := let.Value.(*ast.FunctionLiteral); ok && !reachable[let.Name.Value] {
+				continue
+			}
+		}
+		statements = append(statements, stmt)
+	}
+
+	return &ast.Program{Statements: statements}
+}
@@ -0,0 +1,42 @@
+package analysis
+
+import (
+	"testing"
+)
+
+func TestEliminateDeadFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`let used = fn() { helper() }; let helper = fn() { 1 }; let unused = fn() { used() }; used();`,
+			`let used = fn() helper();let helper = fn() 1;used()`,
+		},
+		{
+			`let cb = fn(x) { x }; let xs = map([1], cb); xs`,
+			`let cb = fn(x) x;let xs = map([1], cb);xs`,
+		},
+		{
+			`let a = fn() { b() }; let b = fn() { a() }; 1`,
+			`1`,
+		},
+		{
+			`let x = 1; x`,
+			`let x = 1;x`,
+		},
+	}
+
+	for _, tt := range tests {
+		program := parseProgram(t, tt.input)
+		original := program.String()
+
+		eliminated := EliminateDeadFunctions(program)
+		if eliminated.String() != tt.expected {
+			t.Errorf("wrong result for %s.\nwant=%q\ngot= %q", tt.input, tt.expected, eliminated.String())
+		}
+		if program.String() != original {
+			t.Errorf("original program was modified")
+		}
+	}
+}
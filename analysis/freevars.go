@@ -0,0 +1,28 @@
+package analysis
+
+import (
+	"sort"
+
+	"github.com/al-keio/monkey-go/ast"
+)
+
+// FreeVariables は fn の本体が参照する名前のうち、引数や本体の let、match のパターンで
+// 束縛されないものを名前順に返す。組み込み関数の名前も含む。
+// 束縛より前の参照や if のブロックの中の let のように判断のつかないものは自由変数とみなすので、
+// 結果は実際に外側の環境から読む名前を漏れなく含む。
+func FreeVariables(fn *ast.FunctionLiteral) []string {
+	free := map[string]bool{}
+	w := walker{visit: func(ident *ast.Identifier, kind EdgeKind, s scope) {
+		if !s[ident.Value] {
+			free[ident.Value] = true
+		}
+	}}
+	w.walk(fn, scope{})
+
+	names := make([]string, 0, len(free))
+	for name := range free {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,35 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/al-keio/monkey-go/ast"
+)
+
+func TestFreeVariables(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{`fn(x) { x + y }`, []string{"y"}},
+		{`fn() { let a = 1; a + b }`, []string{"b"}},
+		{`fn() { c + 1; let c = 2; c }`, []string{"c"}},
+		{`fn(x) { fn(y) { x + y + z } }`, []string{"z"}},
+		{`fn() { let f = fn(n) { f(n - 1) }; f(len(xs)) }`, []string{"len", "xs"}},
+		{`fn(s) { match (s) { [h, ...t] => h + t + u } }`, []string{"u"}},
+		{`fn() { string.split(s, sep) }`, []string{"s", "sep", "string"}},
+		{`fn() { quote(unquote(q)) }`, []string{"q", "quote", "unquote"}},
+		{`fn() { operator <+> (a, b) { a + c }; 1 <+> x }`, []string{"c", "x"}},
+		{`fn() { 1 }`, []string{}},
+	}
+
+	for _, tt := range tests {
+		program := parseProgram(t, tt.input)
+		fn := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.FunctionLiteral)
+
+		if got := FreeVariables(fn); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("wrong free variables for %s. want=%v, got=%v", tt.input, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package analysis
+
+import "github.com/al-keio/monkey-go/ast"
+
+type SymbolKind string
+
+const (
+	Variable SymbolKind = "variable"
+	Function SymbolKind = "function"
+	Macro    SymbolKind = "macro"
+)
+
+// Symbol は let で束縛された名前。Start と End は let 文の範囲 (入力の先頭からのバイト位置)。
+// Children は関数の本体で定義された名前。
+type Symbol struct {
+	Name     string     `json:"name"`
+	Kind     SymbolKind `json:"kind"`
+	Start    int        `json:"start"`
+	End      int        `json:"end"`
+	Children []Symbol   `json:"children,omitempty"`
+}
+
+// Outline は program のトップレベルの let と、関数の中で入れ子に定義された let を出現順に返す
+func Outline(program *ast.Program) []Symbol {
+	return outline(program.Statements)
+}
+
+func outline(stmts []ast.Statement) []Symbol {
+	symbols := []Symbol{}
+	for _, stmt := range stmts {
+		let, ok := stmt.(*ast.LetStatement)
+		if !ok || let.Name == nil {
+			continue
+		}
+
+		symbol := Symbol{Name: let.Name.Value, Kind: Variable, Start: let.Token.Offset, End: let.End}
+		switch value := let.Value.(type) {
+		case *ast.FunctionLiteral:
+			symbol.Kind = Function
+			if value.Body != nil {
+				symbol.Children = outline(value.Body.Statements)
+			}
+		case *ast.MacroLiteral:
+			symbol.Kind = Macro
+			if value.Body != nil {
+				symbol.Children = outline(value.Body.Statements)
+			}
+		}
+		if len(symbol.Children) == 0 {
+			symbol.Children = nil
+		}
+		symbols = append(symbols, symbol)
+	}
+	return symbols
+}
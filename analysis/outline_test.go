@@ -0,0 +1,41 @@
+package analysis
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOutline(t *testing.T) {
+	input := `let limit = 10;
+let add = fn(a, b) {
+	let sum = a + b
+	let clamp = fn(x) { let m = limit; x }
+	clamp(sum)
+};
+let unless = macro(c, body) { quote(if (!unquote(c)) { unquote(body) }) };
+let name = "monkey"
+add(1, 2);
+`
+	expected := []Symbol{
+		{Name: "limit", Kind: Variable, Start: 0, End: 15},
+		{Name: "add", Kind: Function, Start: 16, End: 108, Children: []Symbol{
+			{Name: "sum", Kind: Variable, Start: 38, End: 53},
+			{Name: "clamp", Kind: Function, Start: 55, End: 93, Children: []Symbol{
+				{Name: "m", Kind: Variable, Start: 75, End: 89},
+			}},
+		}},
+		{Name: "unless", Kind: Macro, Start: 109, End: 183},
+		{Name: "name", Kind: Variable, Start: 184, End: 203},
+	}
+
+	outline := Outline(parseProgram(t, input))
+	if !reflect.DeepEqual(outline, expected) {
+		t.Errorf("wrong outline.\nwant=%+v\ngot= %+v", expected, outline)
+	}
+	for _, symbol := range outline {
+		if text := input[symbol.Start:symbol.End]; !strings.HasPrefix(text, "let "+symbol.Name) {
+			t.Errorf("span of %s is %q", symbol.Name, text)
+		}
+	}
+}
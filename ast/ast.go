@@ -2,8 +2,11 @@ package ast
 
 import (
 	"bytes"
+	"math/big"
+	"sort"
 	"strings"
 
+	"github.com/al-keio/monkey-go/symbol"
 	"github.com/al-keio/monkey-go/token"
 )
 
@@ -23,8 +26,31 @@ type Expression interface {
 	expressionNode()
 }
 
+// Trivia は文に付いたコメント。コメントは // や /* */ を含めて書かれたとおりに持つ。
+// 整形やドキュメントの抽出のためのもので、String には含めない。
+type Trivia struct {
+	Leading  []string // 文の前にあるコメント
+	Trailing string   // 文と同じ行の後ろにあるコメント
+}
+
+func (t *Trivia) Comments() *Trivia { return t }
+
+func (t Trivia) copy() Trivia {
+	if t.Leading != nil {
+		t.Leading = append([]string{}, t.Leading...)
+	}
+	return t
+}
+
+// Commented は Trivia を持つ文
+type Commented interface {
+	Statement
+	Comments() *Trivia
+}
+
 type Program struct {
-	Statements []Statement
+	Statements  []Statement
+	EndComments []string // 最後の文の後ろにあるコメント
 }
 
 func (p *Program) TokenLiteral() string {
@@ -44,17 +70,23 @@ func (p *Program) String() string {
 	return out.String()
 }
 func (p *Program) Copy() Node {
+	if p == nil {
+		return p
+	}
 	statements := []Statement{}
 	for _, stmt := range p.Statements {
-		statements = append(statements, stmt.Copy().(Statement))
+		statements = append(statements, copyStatement(stmt))
 	}
-	return &Program{Statements: statements}
+	return &Program{Statements: statements, EndComments: append([]string(nil), p.EndComments...)}
 }
 
 type LetStatement struct {
 	Token token.Token
 	Name  *Identifier
 	Value Expression
+	Doc   string
+	End   int // 文の最後のトークンの直後のバイト位置
+	Trivia
 }
 
 func (ls *LetStatement) statementNode()       {}
@@ -75,12 +107,16 @@ func (ls *LetStatement) String() string {
 	return out.String()
 }
 func (ls *LetStatement) Copy() Node {
-	return &LetStatement{Token: ls.Token, Name: ls.Name.Copy().(*Identifier), Value: ls.Value.Copy().(Expression)}
+	if ls == nil {
+		return ls
+	}
+	return &LetStatement{Token: ls.Token, Name: ls.Name.Copy().(*Identifier), Value: copyExpression(ls.Value), Doc: ls.Doc, End: ls.End, Trivia: ls.Trivia.copy()}
 }
 
 type ReturnStatement struct {
 	Token       token.Token
 	ReturnValue Expression
+	Trivia
 }
 
 func (rs *ReturnStatement) statementNode()       {}
@@ -99,12 +135,127 @@ func (rs *ReturnStatement) String() string {
 	return out.String()
 }
 func (rs *ReturnStatement) Copy() Node {
-	return &ReturnStatement{Token: rs.Token, ReturnValue: rs.ReturnValue.Copy().(Expression)}
+	if rs == nil {
+		return rs
+	}
+	return &ReturnStatement{Token: rs.Token, ReturnValue: copyExpression(rs.ReturnValue), Trivia: rs.Trivia.copy()}
+}
+
+// DeferStatement は defer 文。Call は囲む関数 (トップレベルではプログラム) を抜けるときに評価する。
+type DeferStatement struct {
+	Token token.Token
+	Call  Expression
+	Trivia
+}
+
+func (ds *DeferStatement) statementNode()       {}
+func (ds *DeferStatement) TokenLiteral() string { return ds.Token.Literal }
+func (ds *DeferStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(ds.TokenLiteral() + " ")
+
+	if ds.Call != nil {
+		out.WriteString(ds.Call.String())
+	}
+
+	out.WriteString(";")
+
+	return out.String()
+}
+func (ds *DeferStatement) Copy() Node {
+	if ds == nil {
+		return ds
+	}
+	return &DeferStatement{Token: ds.Token, Call: copyExpression(ds.Call), Trivia: ds.Trivia.copy()}
+}
+
+// OperatorStatement は operator <+> sum (a, b) { ... } の形の中置演算子の宣言。
+// Precedence は優先順位の名前で、省略したときは空文字列 (sum と同じ)。
+type OperatorStatement struct {
+	Token      token.Token
+	Operator   string
+	Precedence string
+	Function   *FunctionLiteral
+	Trivia
+}
+
+func (ops *OperatorStatement) statementNode()       {}
+func (ops *OperatorStatement) TokenLiteral() string { return ops.Token.Literal }
+func (ops *OperatorStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(ops.TokenLiteral() + " " + ops.Operator + " ")
+	if ops.Precedence != "" {
+		out.WriteString(ops.Precedence + " ")
+	}
+	if ops.Function != nil {
+		out.WriteString(ops.Function.String())
+	}
+	out.WriteString(";")
+
+	return out.String()
+}
+func (ops *OperatorStatement) Copy() Node {
+	if ops == nil {
+		return ops
+	}
+	var function *FunctionLiteral
+	if ops.Function != nil {
+		function = ops.Function.Copy().(*FunctionLiteral)
+	}
+	return &OperatorStatement{Token: ops.Token, Operator: ops.Operator, Precedence: ops.Precedence, Function: function, Trivia: ops.Trivia.copy()}
+}
+
+// ClassStatement は class Name extends Base { init(x) { ... } method() { ... } }。
+// メソッドを値に持つプロトタイプのハッシュを作って Name に束縛する。Extends は省略すると nil。
+type ClassStatement struct {
+	Token   token.Token
+	Name    *Identifier
+	Extends Expression
+	Methods []*ClassMethod
+	Trivia
+}
+
+type ClassMethod struct {
+	Name     *Identifier
+	Function *FunctionLiteral
+}
+
+func (cs *ClassStatement) statementNode()       {}
+func (cs *ClassStatement) TokenLiteral() string { return cs.Token.Literal }
+func (cs *ClassStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(cs.TokenLiteral() + " " + cs.Name.String() + " ")
+	if cs.Extends != nil {
+		out.WriteString("extends " + cs.Extends.String() + " ")
+	}
+
+	methods := []string{}
+	for _, m := range cs.Methods {
+		// fn(x) ... の fn を名前に置き換える
+		methods = append(methods, m.Name.String()+strings.TrimPrefix(m.Function.String(), m.Function.TokenLiteral()))
+	}
+	out.WriteString("{ " + strings.Join(methods, "; ") + " }")
+
+	return out.String()
+}
+func (cs *ClassStatement) Copy() Node {
+	if cs == nil {
+		return cs
+	}
+	methods := []*ClassMethod{}
+	for _, m := range cs.Methods {
+		methods = append(methods, &ClassMethod{Name: m.Name.Copy().(*Identifier), Function: m.Function.Copy().(*FunctionLiteral)})
+	}
+	return &ClassStatement{Token: cs.Token, Name: cs.Name.Copy().(*Identifier), Extends: copyExpression(cs.Extends), Methods: methods, Trivia: cs.Trivia.copy()}
 }
 
 type ExpressionStatement struct {
 	Token      token.Token
 	Expression Expression
+	Trivia
 }
 
 func (es *ExpressionStatement) statementNode()       {}
@@ -116,12 +267,24 @@ func (es *ExpressionStatement) String() string {
 	return ""
 }
 func (es *ExpressionStatement) Copy() Node {
-	return &ExpressionStatement{Token: es.Token, Expression: es.Expression.Copy().(Expression)}
+	if es == nil {
+		return es
+	}
+	return &ExpressionStatement{Token: es.Token, Expression: copyExpression(es.Expression), Trivia: es.Trivia.copy()}
 }
 
 type Identifier struct {
-	Token token.Token
-	Value string
+	Token  token.Token
+	Value  string
+	Symbol symbol.ID // パーサーが Value を interning したもの。0 なら未設定
+}
+
+// ID は識別子の symbol.ID を返す。パーサーを経ずに作られたノードでは Value から求める。
+func (i *Identifier) ID() symbol.ID {
+	if i.Symbol != 0 {
+		return i.Symbol
+	}
+	return symbol.Intern(i.Value)
 }
 
 func (i *Identifier) expressionNode()      {}
@@ -130,7 +293,10 @@ func (i *Identifier) String() string {
 	return i.Value
 }
 func (i *Identifier) Copy() Node {
-	return &Identifier{Token: i.Token, Value: i.Value}
+	if i == nil {
+		return i
+	}
+	return &Identifier{Token: i.Token, Value: i.Value, Symbol: i.Symbol}
 }
 
 type IntegerLiteral struct {
@@ -142,9 +308,46 @@ func (il *IntegerLiteral) expressionNode()      {}
 func (il *IntegerLiteral) TokenLiteral() string { return il.Token.Literal }
 func (il *IntegerLiteral) String() string       { return il.Token.Literal }
 func (il *IntegerLiteral) Copy() Node {
+	if il == nil {
+		return il
+	}
 	return &IntegerLiteral{Token: il.Token, Value: il.Value}
 }
 
+// BigIntLiteral は 123456789012345678901234567890n のような末尾に n を付けた整数のリテラル。
+// int64 に収まらない大きさも書ける。
+type BigIntLiteral struct {
+	Token token.Token
+	Value *big.Int
+}
+
+func (bl *BigIntLiteral) expressionNode()      {}
+func (bl *BigIntLiteral) TokenLiteral() string { return bl.Token.Literal }
+func (bl *BigIntLiteral) String() string       { return bl.Token.Literal }
+func (bl *BigIntLiteral) Copy() Node {
+	if bl == nil {
+		return bl
+	}
+	return &BigIntLiteral{Token: bl.Token, Value: new(big.Int).Set(bl.Value)}
+}
+
+// DecimalLiteral は 12.34d のような十進数のリテラルと、3.14 や 1e-3 のような浮動小数点数のリテラル。
+// どちらも正確な十進数として評価する。Token.Type で書き方を区別する。
+type DecimalLiteral struct {
+	Token token.Token
+	Value *big.Rat
+}
+
+func (dl *DecimalLiteral) expressionNode()      {}
+func (dl *DecimalLiteral) TokenLiteral() string { return dl.Token.Literal }
+func (dl *DecimalLiteral) String() string       { return dl.Token.Literal }
+func (dl *DecimalLiteral) Copy() Node {
+	if dl == nil {
+		return dl
+	}
+	return &DecimalLiteral{Token: dl.Token, Value: new(big.Rat).Set(dl.Value)}
+}
+
 type StringLiteral struct {
 	Token token.Token
 	Value string
@@ -154,6 +357,9 @@ func (sl *StringLiteral) expressionNode()      {}
 func (sl *StringLiteral) TokenLiteral() string { return sl.Token.Literal }
 func (sl *StringLiteral) String() string       { return sl.Token.Literal }
 func (sl *StringLiteral) Copy() Node {
+	if sl == nil {
+		return sl
+	}
 	return &StringLiteral{Token: sl.Token, Value: sl.Value}
 }
 
@@ -166,6 +372,9 @@ func (b *Boolean) expressionNode()      {}
 func (b *Boolean) TokenLiteral() string { return b.Token.Literal }
 func (b *Boolean) String() string       { return b.Token.Literal }
 func (b *Boolean) Copy() Node {
+	if b == nil {
+		return b
+	}
 	return &Boolean{Token: b.Token, Value: b.Value}
 }
 
@@ -191,26 +400,47 @@ func (al *ArrayLiteral) String() string {
 	return out.String()
 }
 func (al *ArrayLiteral) Copy() Node {
+	if al == nil {
+		return al
+	}
 	elements := []Expression{}
 	for _, el := range al.Elements {
-		elements = append(elements, el.Copy().(Expression))
+		elements = append(elements, copyExpression(el))
 	}
 	return &ArrayLiteral{Token: al.Token, Elements: elements}
 }
 
 type HashLiteral struct {
 	Token token.Token
+	// Pairs を range で辿る順は実行ごとに変わる。出力や評価の順が結果に表れる処理では SortedKeys の順に辿ること。
 	Pairs map[Expression]Expression
 }
 
 func (hl *HashLiteral) expressionNode()      {}
 func (hl *HashLiteral) TokenLiteral() string { return hl.Token.Literal }
+
+// SortedKeys は Pairs のキーを String() の順に並べて返す。String() が同じキーは値の String() の順にする。
+func (hl *HashLiteral) SortedKeys() []Expression {
+	keys := make([]Expression, 0, len(hl.Pairs))
+	for key := range hl.Pairs {
+		keys = append(keys, key)
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		ki, kj := keys[i].String(), keys[j].String()
+		if ki != kj {
+			return ki < kj
+		}
+		return hl.Pairs[keys[i]].String() < hl.Pairs[keys[j]].String()
+	})
+	return keys
+}
+
 func (hl *HashLiteral) String() string {
 	var out bytes.Buffer
 
 	elements := []string{}
-	for key, value := range hl.Pairs {
-		elements = append(elements, key.String()+":"+value.String())
+	for _, key := range hl.SortedKeys() {
+		elements = append(elements, key.String()+":"+hl.Pairs[key].String())
 	}
 
 	out.WriteString("{")
@@ -220,9 +450,12 @@ func (hl *HashLiteral) String() string {
 	return out.String()
 }
 func (hl *HashLiteral) Copy() Node {
+	if hl == nil {
+		return hl
+	}
 	pairs := make(map[Expression]Expression)
-	for key, value := range hl.Pairs {
-		pairs[key.Copy().(Expression)] = value.Copy().(Expression)
+	for _, key := range hl.SortedKeys() {
+		pairs[copyExpression(key)] = copyExpression(hl.Pairs[key])
 	}
 	return &HashLiteral{Token: hl.Token, Pairs: pairs}
 }
@@ -248,7 +481,29 @@ func (ie *IndexExpression) String() string {
 	return out.String()
 }
 func (ie *IndexExpression) Copy() Node {
-	return &IndexExpression{Token: ie.Token, Left: ie.Left.Copy().(Expression), Index: ie.Index.Copy().(Expression)}
+	if ie == nil {
+		return ie
+	}
+	return &IndexExpression{Token: ie.Token, Left: copyExpression(ie.Left), Index: copyExpression(ie.Index)}
+}
+
+// MemberExpression は object.member で、object["member"] と同じく評価される
+type MemberExpression struct {
+	Token  token.Token
+	Object Expression
+	Member *Identifier
+}
+
+func (me *MemberExpression) expressionNode()      {}
+func (me *MemberExpression) TokenLiteral() string { return me.Token.Literal }
+func (me *MemberExpression) String() string {
+	return "(" + me.Object.String() + "." + me.Member.String() + ")"
+}
+func (me *MemberExpression) Copy() Node {
+	if me == nil {
+		return me
+	}
+	return &MemberExpression{Token: me.Token, Object: copyExpression(me.Object), Member: me.Member.Copy().(*Identifier)}
 }
 
 type PrefixExpression struct {
@@ -270,7 +525,10 @@ func (pe *PrefixExpression) String() string {
 	return out.String()
 }
 func (pe *PrefixExpression) Copy() Node {
-	return &PrefixExpression{Token: pe.Token, Operator: pe.Operator, Right: pe.Right.Copy().(Expression)}
+	if pe == nil {
+		return pe
+	}
+	return &PrefixExpression{Token: pe.Token, Operator: pe.Operator, Right: copyExpression(pe.Right)}
 }
 
 type InfixExpression struct {
@@ -294,7 +552,10 @@ func (ie *InfixExpression) String() string {
 	return out.String()
 }
 func (ie *InfixExpression) Copy() Node {
-	return &InfixExpression{Token: ie.Token, Left: ie.Left.Copy().(Expression), Operator: ie.Operator, Right: ie.Right.Copy().(Expression)}
+	if ie == nil {
+		return ie
+	}
+	return &InfixExpression{Token: ie.Token, Left: copyExpression(ie.Left), Operator: ie.Operator, Right: copyExpression(ie.Right)}
 }
 
 type IfExpression struct {
@@ -322,12 +583,73 @@ func (ie *IfExpression) String() string {
 	return out.String()
 }
 func (ie *IfExpression) Copy() Node {
-	return &IfExpression{Token: ie.Token, Condition: ie.Condition.Copy().(Expression), Consequence: ie.Consequence.Copy().(*BlockStatement), Alternative: ie.Alternative.Copy().(*BlockStatement)}
+	if ie == nil {
+		return ie
+	}
+	return &IfExpression{Token: ie.Token, Condition: copyExpression(ie.Condition), Consequence: ie.Consequence.Copy().(*BlockStatement), Alternative: ie.Alternative.Copy().(*BlockStatement)}
+}
+
+type ConditionalExpression struct {
+	Token       token.Token
+	Condition   Expression
+	Consequence Expression
+	Alternative Expression
+}
+
+func (ce *ConditionalExpression) expressionNode()      {}
+func (ce *ConditionalExpression) TokenLiteral() string { return ce.Token.Literal }
+func (ce *ConditionalExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(ce.Condition.String())
+	out.WriteString(" ? ")
+	out.WriteString(ce.Consequence.String())
+	out.WriteString(" : ")
+	out.WriteString(ce.Alternative.String())
+	out.WriteString(")")
+
+	return out.String()
+}
+func (ce *ConditionalExpression) Copy() Node {
+	if ce == nil {
+		return ce
+	}
+	return &ConditionalExpression{Token: ce.Token, Condition: copyExpression(ce.Condition), Consequence: copyExpression(ce.Consequence), Alternative: copyExpression(ce.Alternative)}
+}
+
+// PipelineExpression は map や filter の呼び出しの連鎖で、最適化によって作られる。
+// Original は最適化前の呼び出しで、map や filter が組み込み関数でない場合にはこちらを評価する。
+type PipelineExpression struct {
+	Token    token.Token
+	Source   Expression
+	Stages   []*PipelineStage
+	Original *CallExpression
+}
+
+type PipelineStage struct {
+	Name     *Identifier
+	Function Expression
+}
+
+func (pe *PipelineExpression) expressionNode()      {}
+func (pe *PipelineExpression) TokenLiteral() string { return pe.Token.Literal }
+func (pe *PipelineExpression) String() string       { return pe.Original.String() }
+func (pe *PipelineExpression) Copy() Node {
+	if pe == nil {
+		return pe
+	}
+	stages := make([]*PipelineStage, len(pe.Stages))
+	for i, stage := range pe.Stages {
+		stages[i] = &PipelineStage{Name: stage.Name.Copy().(*Identifier), Function: copyExpression(stage.Function)}
+	}
+	return &PipelineExpression{Token: pe.Token, Source: copyExpression(pe.Source), Stages: stages, Original: pe.Original.Copy().(*CallExpression)}
 }
 
 type BlockStatement struct {
-	Token      token.Token
-	Statements []Statement
+	Token       token.Token
+	Statements  []Statement
+	EndComments []string // 最後の文と } の間にあるコメント
 }
 
 func (bs *BlockStatement) statementNode()       {}
@@ -341,17 +663,23 @@ func (bs *BlockStatement) String() string {
 	return out.String()
 }
 func (bs *BlockStatement) Copy() Node {
+	if bs == nil {
+		return bs
+	}
 	statements := []Statement{}
 	for _, stmt := range bs.Statements {
-		statements = append(statements, stmt.Copy().(Statement))
+		statements = append(statements, copyStatement(stmt))
 	}
-	return &BlockStatement{Token: bs.Token, Statements: statements}
+	return &BlockStatement{Token: bs.Token, Statements: statements, EndComments: append([]string(nil), bs.EndComments...)}
 }
 
 type FunctionLiteral struct {
 	Token      token.Token
 	Parameters []*Identifier
 	Body       *BlockStatement
+	Doc        string
+	Requires   []Expression // 呼び出し時に真でなければならない条件
+	Ensures    []Expression // 戻るときに真でなければならない条件。戻り値は result で参照する
 }
 
 func (fl *FunctionLiteral) expressionNode()      {}
@@ -368,16 +696,26 @@ func (fl *FunctionLiteral) String() string {
 	out.WriteString("(")
 	out.WriteString(strings.Join(params, ", "))
 	out.WriteString(") ")
+	for _, cond := range fl.Requires {
+		out.WriteString("requires " + cond.String() + " ")
+	}
+	for _, cond := range fl.Ensures {
+		out.WriteString("ensures " + cond.String() + " ")
+	}
 	out.WriteString(fl.Body.String())
 
 	return out.String()
 }
 func (fl *FunctionLiteral) Copy() Node {
+	if fl == nil {
+		return fl
+	}
 	identifiers := []*Identifier{}
 	for _, identifier := range fl.Parameters {
 		identifiers = append(identifiers, identifier.Copy().(*Identifier))
 	}
-	return &FunctionLiteral{Token: fl.Token, Parameters: identifiers, Body: fl.Body.Copy().(*BlockStatement)}
+	return &FunctionLiteral{Token: fl.Token, Parameters: identifiers, Body: fl.Body.Copy().(*BlockStatement), Doc: fl.Doc,
+		Requires: copyExpressions(fl.Requires), Ensures: copyExpressions(fl.Ensures)}
 }
 
 type CallExpression struct {
@@ -404,11 +742,37 @@ func (ce *CallExpression) String() string {
 	return out.String()
 }
 func (ce *CallExpression) Copy() Node {
+	if ce == nil {
+		return ce
+	}
 	args := []Expression{}
 	for _, arg := range ce.Arguments {
-		args = append(args, arg.Copy().(Expression))
+		args = append(args, copyExpression(arg))
 	}
-	return &CallExpression{Token: ce.Token, Function: ce.Function.Copy().(Expression), Arguments: args}
+	return &CallExpression{Token: ce.Token, Function: copyExpression(ce.Function), Arguments: args}
+}
+
+// NewExpression は new Point(1, 2)。Class のプロトタイプを持つハッシュを作り、init があればそれで初期化する。
+type NewExpression struct {
+	Token     token.Token
+	Class     Expression
+	Arguments []Expression
+}
+
+func (ne *NewExpression) expressionNode()      {}
+func (ne *NewExpression) TokenLiteral() string { return ne.Token.Literal }
+func (ne *NewExpression) String() string {
+	args := []string{}
+	for _, a := range ne.Arguments {
+		args = append(args, a.String())
+	}
+	return ne.TokenLiteral() + " " + ne.Class.String() + "(" + strings.Join(args, ", ") + ")"
+}
+func (ne *NewExpression) Copy() Node {
+	if ne == nil {
+		return ne
+	}
+	return &NewExpression{Token: ne.Token, Class: copyExpression(ne.Class), Arguments: copyExpressions(ne.Arguments)}
 }
 
 type MacroLiteral struct {
@@ -436,9 +800,218 @@ func (ml *MacroLiteral) String() string {
 	return out.String()
 }
 func (ml *MacroLiteral) Copy() Node {
+	if ml == nil {
+		return ml
+	}
 	identifiers := []*Identifier{}
 	for _, identifier := range ml.Parameters {
 		identifiers = append(identifiers, identifier.Copy().(*Identifier))
 	}
 	return &MacroLiteral{Token: ml.Token, Parameters: identifiers, Body: ml.Body.Copy().(*BlockStatement)}
 }
+
+type Pattern interface {
+	Node
+	patternNode()
+}
+
+type MatchExpression struct {
+	Token   token.Token
+	Subject Expression
+	Arms    []*MatchArm
+}
+
+func (me *MatchExpression) expressionNode()      {}
+func (me *MatchExpression) TokenLiteral() string { return me.Token.Literal }
+func (me *MatchExpression) String() string {
+	var out bytes.Buffer
+
+	arms := []string{}
+	for _, a := range me.Arms {
+		arms = append(arms, a.String())
+	}
+
+	out.WriteString("match")
+	out.WriteString(me.Subject.String())
+	out.WriteString(" {")
+	out.WriteString(strings.Join(arms, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
+func (me *MatchExpression) Copy() Node {
+	if me == nil {
+		return me
+	}
+	arms := []*MatchArm{}
+	for _, arm := range me.Arms {
+		arms = append(arms, arm.Copy())
+	}
+	return &MatchExpression{Token: me.Token, Subject: copyExpression(me.Subject), Arms: arms}
+}
+
+type MatchArm struct {
+	Pattern Pattern
+	Body    Expression
+}
+
+func (ma *MatchArm) String() string {
+	return ma.Pattern.String() + " => " + ma.Body.String()
+}
+func (ma *MatchArm) Copy() *MatchArm {
+	if ma == nil {
+		return ma
+	}
+	return &MatchArm{Pattern: copyPattern(ma.Pattern), Body: copyExpression(ma.Body)}
+}
+
+type WildcardPattern struct {
+	Token token.Token
+}
+
+func (wp *WildcardPattern) patternNode()         {}
+func (wp *WildcardPattern) TokenLiteral() string { return wp.Token.Literal }
+func (wp *WildcardPattern) String() string       { return wp.Token.Literal }
+func (wp *WildcardPattern) Copy() Node {
+	if wp == nil {
+		return wp
+	}
+	return &WildcardPattern{Token: wp.Token}
+}
+
+type BindingPattern struct {
+	Token token.Token
+	Name  *Identifier
+}
+
+func (bp *BindingPattern) patternNode()         {}
+func (bp *BindingPattern) TokenLiteral() string { return bp.Token.Literal }
+func (bp *BindingPattern) String() string       { return bp.Name.String() }
+func (bp *BindingPattern) Copy() Node {
+	if bp == nil {
+		return bp
+	}
+	return &BindingPattern{Token: bp.Token, Name: bp.Name.Copy().(*Identifier)}
+}
+
+type LiteralPattern struct {
+	Token token.Token
+	Value Expression
+}
+
+func (lp *LiteralPattern) patternNode()         {}
+func (lp *LiteralPattern) TokenLiteral() string { return lp.Token.Literal }
+func (lp *LiteralPattern) String() string       { return lp.Value.String() }
+func (lp *LiteralPattern) Copy() Node {
+	if lp == nil {
+		return lp
+	}
+	return &LiteralPattern{Token: lp.Token, Value: copyExpression(lp.Value)}
+}
+
+type ArrayPattern struct {
+	Token    token.Token
+	Elements []Pattern
+	Rest     *Identifier
+}
+
+func (ap *ArrayPattern) patternNode()         {}
+func (ap *ArrayPattern) TokenLiteral() string { return ap.Token.Literal }
+func (ap *ArrayPattern) String() string {
+	var out bytes.Buffer
+
+	elements := []string{}
+	for _, el := range ap.Elements {
+		elements = append(elements, el.String())
+	}
+	if ap.Rest != nil {
+		elements = append(elements, "..."+ap.Rest.String())
+	}
+
+	out.WriteString("[")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("]")
+
+	return out.String()
+}
+func (ap *ArrayPattern) Copy() Node {
+	if ap == nil {
+		return ap
+	}
+	elements := []Pattern{}
+	for _, el := range ap.Elements {
+		elements = append(elements, copyPattern(el))
+	}
+	return &ArrayPattern{Token: ap.Token, Elements: elements, Rest: ap.Rest.Copy().(*Identifier)}
+}
+
+type HashPatternPair struct {
+	Key   Expression
+	Value Pattern
+}
+
+type HashPattern struct {
+	Token token.Token
+	Pairs []HashPatternPair
+}
+
+func (hp *HashPattern) patternNode()         {}
+func (hp *HashPattern) TokenLiteral() string { return hp.Token.Literal }
+func (hp *HashPattern) String() string {
+	var out bytes.Buffer
+
+	pairs := []string{}
+	for _, pair := range hp.Pairs {
+		pairs = append(pairs, pair.Key.String()+":"+pair.Value.String())
+	}
+
+	out.WriteString("{")
+	out.WriteString(strings.Join(pairs, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
+func (hp *HashPattern) Copy() Node {
+	if hp == nil {
+		return hp
+	}
+	pairs := []HashPatternPair{}
+	for _, pair := range hp.Pairs {
+		pairs = append(pairs, HashPatternPair{Key: copyExpression(pair.Key), Value: copyPattern(pair.Value)})
+	}
+	return &HashPattern{Token: hp.Token, Pairs: pairs}
+}
+
+// copyExpression などは nil のままの子 (構文エラーから回復した木にある) を nil のまま複製する。
+// nil のポインタは各 Copy が受け取った nil をそのまま返す。
+func copyExpression(exp Expression) Expression {
+	if exp == nil {
+		return nil
+	}
+	return exp.Copy().(Expression)
+}
+
+func copyExpressions(exps []Expression) []Expression {
+	if exps == nil {
+		return nil
+	}
+	copied := make([]Expression, len(exps))
+	for i, exp := range exps {
+		copied[i] = copyExpression(exp)
+	}
+	return copied
+}
+
+func copyStatement(stmt Statement) Statement {
+	if stmt == nil {
+		return nil
+	}
+	return stmt.Copy().(Statement)
+}
+
+func copyPattern(pattern Pattern) Pattern {
+	if pattern == nil {
+		return nil
+	}
+	return pattern.Copy().(Pattern)
+}
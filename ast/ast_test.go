@@ -27,3 +27,54 @@ func TestString(t *testing.T) {
 		t.Errorf("program.String() wrong. got=%q", program.String())
 	}
 }
+
+func TestCopyNil(t *testing.T) {
+	var ident *Identifier
+	if copied := ident.Copy(); copied != ident {
+		t.Errorf("nil Identifier copied to %#v", copied)
+	}
+
+	ifx := &IfExpression{
+		Token:       token.Token{Type: token.IF, Literal: "if"},
+		Consequence: &BlockStatement{Token: token.Token{Type: token.LBRACE, Literal: "{"}},
+	}
+	copied := ifx.Copy().(*IfExpression)
+	if copied.Condition != nil || copied.Alternative != nil {
+		t.Errorf("nil fields not preserved: %#v", copied)
+	}
+	if copied.Consequence == ifx.Consequence {
+		t.Errorf("Consequence was not copied")
+	}
+}
+
+// ハッシュリテラルの String と Copy、Modify の順は map の走査順によらない
+func TestHashLiteralOrder(t *testing.T) {
+	str := func(s string) *StringLiteral {
+		return &StringLiteral{Token: token.Token{Type: token.STRING, Literal: s}, Value: s}
+	}
+	hash := &HashLiteral{Pairs: map[Expression]Expression{}}
+	for _, k := range []string{"d", "b", "e", "a", "c"} {
+		hash.Pairs[str(k)] = str(k + k)
+	}
+
+	expected := "{a:aa, b:bb, c:cc, d:dd, e:ee}"
+	for i := 0; i < 20; i++ {
+		if got := hash.String(); got != expected {
+			t.Fatalf("String() wrong. want=%q, got=%q", expected, got)
+		}
+		if got := hash.Copy().String(); got != expected {
+			t.Fatalf("Copy().String() wrong. want=%q, got=%q", expected, got)
+		}
+
+		visited := ""
+		Modify(hash, func(node Node) Node {
+			if s, ok := node.(*StringLiteral); ok {
+				visited += s.Value + " "
+			}
+			return node
+		})
+		if visited != "a aa b bb c cc d dd e ee " {
+			t.Fatalf("Modify visited pairs in the wrong order: %q", visited)
+		}
+	}
+}
@@ -0,0 +1,309 @@
+package ast
+
+import (
+	"encoding/binary"
+	"hash"
+	"hash/fnv"
+)
+
+// Hash は node を根とする木の構造から計算した 64 ビットのハッシュを返す。
+// トークンの位置、ドキュメントコメント、ふつうのコメントは含めないので、書き方が違っても同じ木なら同じ値になる。
+// ハッシュリテラルの組は順序によらない。マクロ展開や構文解析の結果をキャッシュするときのキーに使える。
+// 値が等しくても木が等しいとは限らないので、取り違えが困る場合は String() なども比べること。
+func Hash(node Node) uint64 {
+	h := &hasher{h: fnv.New64a()}
+	h.node(node)
+	return h.h.Sum64()
+}
+
+type hasher struct {
+	h   hash.Hash64
+	buf [8]byte
+}
+
+// ノードの種類を区別するタグ
+const (
+	tagNil byte = iota
+	tagProgram
+	tagLet
+	tagReturn
+	tagDefer
+	tagOperator
+	tagClass
+	tagNew
+	tagExpressionStatement
+	tagBlock
+	tagIdentifier
+	tagInteger
+	tagDecimal
+	tagString
+	tagBoolean
+	tagArray
+	tagHash
+	tagIndex
+	tagMember
+	tagPrefix
+	tagInfix
+	tagIf
+	tagConditional
+	tagPipeline
+	tagFunction
+	tagCall
+	tagMacro
+	tagMatch
+	tagWildcardPattern
+	tagBindingPattern
+	tagLiteralPattern
+	tagArrayPattern
+	tagHashPattern
+	tagBigInt
+	tagUnknown
+)
+
+func (h *hasher) tag(t byte) {
+	h.h.Write([]byte{t})
+}
+
+func (h *hasher) int(n int64) {
+	binary.LittleEndian.PutUint64(h.buf[:], uint64(n))
+	h.h.Write(h.buf[:])
+}
+
+// string は長さを前に付けて書き、隣り合う文字列の境目がずれても同じにならないようにする
+func (h *hasher) string(s string) {
+	h.int(int64(len(s)))
+	h.h.Write([]byte(s))
+}
+
+func (h *hasher) node(node Node) {
+	switch node := node.(type) {
+	case nil:
+		h.tag(tagNil)
+	case *Program:
+		if node == nil {
+			h.tag(tagNil)
+			return
+		}
+		h.tag(tagProgram)
+		h.statements(node.Statements)
+	case *LetStatement:
+		if node == nil {
+			h.tag(tagNil)
+			return
+		}
+		h.tag(tagLet)
+		h.node(node.Name)
+		h.node(node.Value)
+	case *ReturnStatement:
+		if node == nil {
+			h.tag(tagNil)
+			return
+		}
+		h.tag(tagReturn)
+		h.node(node.ReturnValue)
+	case *DeferStatement:
+		if node == nil {
+			h.tag(tagNil)
+			return
+		}
+		h.tag(tagDefer)
+		h.node(node.Call)
+	case *OperatorStatement:
+		if node == nil {
+			h.tag(tagNil)
+			return
+		}
+		h.tag(tagOperator)
+		h.string(node.Operator)
+		h.string(node.Precedence)
+		h.node(node.Function)
+	case *ClassStatement:
+		if node == nil {
+			h.tag(tagNil)
+			return
+		}
+		h.tag(tagClass)
+		h.node(node.Name)
+		h.node(node.Extends)
+		h.int(int64(len(node.Methods)))
+		for _, m := range node.Methods {
+			h.node(m.Name)
+			h.node(m.Function)
+		}
+	case *NewExpression:
+		h.tag(tagNew)
+		h.node(node.Class)
+		h.expressions(node.Arguments)
+	case *ExpressionStatement:
+		if node == nil {
+			h.tag(tagNil)
+			return
+		}
+		h.tag(tagExpressionStatement)
+		h.node(node.Expression)
+	case *BlockStatement:
+		if node == nil {
+			h.tag(tagNil)
+			return
+		}
+		h.tag(tagBlock)
+		h.statements(node.Statements)
+	case *Identifier:
+		if node == nil {
+			h.tag(tagNil)
+			return
+		}
+		h.tag(tagIdentifier)
+		h.string(node.Value)
+	case *IntegerLiteral:
+		h.tag(tagInteger)
+		h.int(node.Value)
+	case *BigIntLiteral:
+		h.tag(tagBigInt)
+		h.string(node.Value.String())
+	case *DecimalLiteral:
+		h.tag(tagDecimal)
+		if node.Value == nil {
+			h.string(node.Token.Literal)
+		} else {
+			h.string(node.Value.RatString())
+		}
+	case *StringLiteral:
+		h.tag(tagString)
+		h.string(node.Value)
+	case *Boolean:
+		h.tag(tagBoolean)
+		if node.Value {
+			h.int(1)
+		} else {
+			h.int(0)
+		}
+	case *ArrayLiteral:
+		h.tag(tagArray)
+		h.expressions(node.Elements)
+	case *HashLiteral:
+		h.tag(tagHash)
+		h.int(int64(len(node.Pairs)))
+		// 組の順序は map の反復順で変わるので、組ごとのハッシュを足し合わせる
+		var sum uint64
+		for key, value := range node.Pairs {
+			pair := &hasher{h: fnv.New64a()}
+			pair.node(key)
+			pair.node(value)
+			sum += pair.h.Sum64()
+		}
+		h.int(int64(sum))
+	case *IndexExpression:
+		h.tag(tagIndex)
+		h.node(node.Left)
+		h.node(node.Index)
+	case *MemberExpression:
+		h.tag(tagMember)
+		h.node(node.Object)
+		h.node(node.Member)
+	case *PrefixExpression:
+		h.tag(tagPrefix)
+		h.string(node.Operator)
+		h.node(node.Right)
+	case *InfixExpression:
+		h.tag(tagInfix)
+		h.string(node.Operator)
+		h.node(node.Left)
+		h.node(node.Right)
+	case *IfExpression:
+		h.tag(tagIf)
+		h.node(node.Condition)
+		h.node(node.Consequence)
+		h.node(node.Alternative)
+	case *ConditionalExpression:
+		h.tag(tagConditional)
+		h.node(node.Condition)
+		h.node(node.Consequence)
+		h.node(node.Alternative)
+	case *PipelineExpression:
+		h.tag(tagPipeline)
+		h.node(node.Source)
+		h.int(int64(len(node.Stages)))
+		for _, stage := range node.Stages {
+			h.node(stage.Name)
+			h.node(stage.Function)
+		}
+	case *FunctionLiteral:
+		if node == nil {
+			h.tag(tagNil)
+			return
+		}
+		h.tag(tagFunction)
+		h.identifiers(node.Parameters)
+		h.expressions(node.Requires)
+		h.expressions(node.Ensures)
+		h.node(node.Body)
+	case *CallExpression:
+		if node == nil {
+			h.tag(tagNil)
+			return
+		}
+		h.tag(tagCall)
+		h.node(node.Function)
+		h.expressions(node.Arguments)
+	case *MacroLiteral:
+		h.tag(tagMacro)
+		h.identifiers(node.Parameters)
+		h.node(node.Body)
+	case *MatchExpression:
+		h.tag(tagMatch)
+		h.node(node.Subject)
+		h.int(int64(len(node.Arms)))
+		for _, arm := range node.Arms {
+			h.node(arm.Pattern)
+			h.node(arm.Body)
+		}
+	case *WildcardPattern:
+		h.tag(tagWildcardPattern)
+	case *BindingPattern:
+		h.tag(tagBindingPattern)
+		h.node(node.Name)
+	case *LiteralPattern:
+		h.tag(tagLiteralPattern)
+		h.node(node.Value)
+	case *ArrayPattern:
+		h.tag(tagArrayPattern)
+		h.int(int64(len(node.Elements)))
+		for _, element := range node.Elements {
+			h.node(element)
+		}
+		h.node(node.Rest)
+	case *HashPattern:
+		h.tag(tagHashPattern)
+		h.int(int64(len(node.Pairs)))
+		for _, pair := range node.Pairs {
+			h.node(pair.Key)
+			h.node(pair.Value)
+		}
+	default:
+		// 知らないノードは文字列表現で区別する
+		h.tag(tagUnknown)
+		h.string(node.String())
+	}
+}
+
+func (h *hasher) statements(stmts []Statement) {
+	h.int(int64(len(stmts)))
+	for _, stmt := range stmts {
+		h.node(stmt)
+	}
+}
+
+func (h *hasher) expressions(exps []Expression) {
+	h.int(int64(len(exps)))
+	for _, exp := range exps {
+		h.node(exp)
+	}
+}
+
+func (h *hasher) identifiers(idents []*Identifier) {
+	h.int(int64(len(idents)))
+	for _, ident := range idents {
+		h.node(ident)
+	}
+}
@@ -0,0 +1,67 @@
+package ast
+
+import (
+	"testing"
+
+	"github.com/al-keio/monkey-go/token"
+)
+
+func TestHashIgnoresPositions(t *testing.T) {
+	ident := func(name string, offset int) *Identifier {
+		return &Identifier{Token: token.Token{Type: token.IDENT, Literal: name, Offset: offset}, Value: name}
+	}
+	let := func(offset int, doc string) *LetStatement {
+		return &LetStatement{
+			Token:  token.Token{Type: token.LET, Literal: "let", Offset: offset},
+			Name:   ident("x", offset+4),
+			Value:  ident("y", offset+8),
+			Doc:    doc,
+			Trivia: Trivia{Trailing: "// " + doc},
+		}
+	}
+
+	a := &Program{Statements: []Statement{let(0, "")}}
+	b := &Program{Statements: []Statement{let(10, "doc")}, EndComments: []string{"// end"}}
+	if Hash(a) != Hash(b) {
+		t.Errorf("hashes differ for programs that differ only in positions and comments")
+	}
+}
+
+func TestHashDistinguishesStructure(t *testing.T) {
+	str := &StringLiteral{Token: token.Token{Type: token.STRING, Literal: "x"}, Value: "x"}
+	ident := &Identifier{Token: token.Token{Type: token.IDENT, Literal: "x"}, Value: "x"}
+	if Hash(str) == Hash(ident) {
+		t.Errorf("string literal and identifier with the same text hash equally")
+	}
+
+	one := &IntegerLiteral{Token: token.Token{Type: token.INT, Literal: "1"}, Value: 1}
+	two := &IntegerLiteral{Token: token.Token{Type: token.INT, Literal: "2"}, Value: 2}
+	minus := func(left, right Expression) *InfixExpression {
+		return &InfixExpression{Token: token.Token{Type: token.MINUS, Literal: "-"}, Left: left, Operator: "-", Right: right}
+	}
+	if Hash(minus(one, two)) == Hash(minus(two, one)) {
+		t.Errorf("1 - 2 and 2 - 1 hash equally")
+	}
+}
+
+func TestHashHashLiteralOrder(t *testing.T) {
+	key := func(s string) *StringLiteral {
+		return &StringLiteral{Token: token.Token{Type: token.STRING, Literal: s}, Value: s}
+	}
+	value := func(n int64) *IntegerLiteral {
+		return &IntegerLiteral{Token: token.Token{Type: token.INT}, Value: n}
+	}
+
+	first := &HashLiteral{Pairs: map[Expression]Expression{key("a"): value(1), key("b"): value(2)}}
+	for i := 0; i < 10; i++ {
+		other := &HashLiteral{Pairs: map[Expression]Expression{key("b"): value(2), key("a"): value(1)}}
+		if Hash(first) != Hash(other) {
+			t.Fatalf("hash of hash literal depends on pair order")
+		}
+	}
+
+	swapped := &HashLiteral{Pairs: map[Expression]Expression{key("a"): value(2), key("b"): value(1)}}
+	if Hash(first) == Hash(swapped) {
+		t.Errorf("hash literals with swapped values hash equally")
+	}
+}
@@ -2,54 +2,254 @@ package ast
 
 type ModifierFunc func(Node) Node
 
+// Modify は node を根とする木の各ノードに帰りがけ順で modifier を適用した木を返す。
+// 元の木は書き換えず、子が置き換わったノードだけを複製する。変更のない部分木は元の木と共有されるので、
+// 呼び出し側は戻り値を使うこと。
 func Modify(node Node, modifier ModifierFunc) Node {
 	switch node := node.(type) {
 	case *Program:
-		for i, statement := range node.Statements {
-			node.Statements[i], _ = Modify(statement, modifier).(Statement)
+		if statements, changed := modifyStatements(node.Statements, modifier); changed {
+			copied := *node
+			copied.Statements = statements
+			return modifier(&copied)
 		}
 	case *ExpressionStatement:
-		node.Expression, _ = Modify(node.Expression, modifier).(Expression)
+		if expression, changed := modifyExpression(node.Expression, modifier); changed {
+			copied := *node
+			copied.Expression = expression
+			return modifier(&copied)
+		}
 	case *BlockStatement:
-		for i := range node.Statements {
-			node.Statements[i], _ = Modify(node.Statements[i], modifier).(Statement)
+		if statements, changed := modifyStatements(node.Statements, modifier); changed {
+			copied := *node
+			copied.Statements = statements
+			return modifier(&copied)
 		}
 	case *ReturnStatement:
-		node.ReturnValue, _ = Modify(node.ReturnValue, modifier).(Expression)
+		if value, changed := modifyExpression(node.ReturnValue, modifier); changed {
+			copied := *node
+			copied.ReturnValue = value
+			return modifier(&copied)
+		}
+	case *DeferStatement:
+		if call, changed := modifyExpression(node.Call, modifier); changed {
+			copied := *node
+			copied.Call = call
+			return modifier(&copied)
+		}
+	case *OperatorStatement:
+		modified, _ := Modify(node.Function, modifier).(*FunctionLiteral)
+		if modified != node.Function {
+			copied := *node
+			copied.Function = modified
+			return modifier(&copied)
+		}
+	case *ClassStatement:
+		extends, extendsChanged := node.Extends, false
+		if node.Extends != nil {
+			extends, extendsChanged = modifyExpression(node.Extends, modifier)
+		}
+		methods, methodsChanged := node.Methods, false
+		for i, m := range node.Methods {
+			modified, _ := Modify(m.Function, modifier).(*FunctionLiteral)
+			if modified == m.Function {
+				continue
+			}
+			if !methodsChanged {
+				methods = append([]*ClassMethod{}, node.Methods...)
+				methodsChanged = true
+			}
+			methods[i] = &ClassMethod{Name: m.Name, Function: modified}
+		}
+		if extendsChanged || methodsChanged {
+			copied := *node
+			copied.Extends, copied.Methods = extends, methods
+			return modifier(&copied)
+		}
+	case *NewExpression:
+		class, classChanged := modifyExpression(node.Class, modifier)
+		arguments, argumentsChanged := modifyExpressions(node.Arguments, modifier)
+		if classChanged || argumentsChanged {
+			copied := *node
+			copied.Class, copied.Arguments = class, arguments
+			return modifier(&copied)
+		}
 	case *LetStatement:
-		node.Value, _ = Modify(node.Value, modifier).(Expression)
+		if value, changed := modifyExpression(node.Value, modifier); changed {
+			copied := *node
+			copied.Value = value
+			return modifier(&copied)
+		}
 	case *PrefixExpression:
-		node.Right, _ = Modify(node.Right, modifier).(Expression)
+		if right, changed := modifyExpression(node.Right, modifier); changed {
+			copied := *node
+			copied.Right = right
+			return modifier(&copied)
+		}
 	case *InfixExpression:
-		node.Left, _ = Modify(node.Left, modifier).(Expression)
-		node.Right, _ = Modify(node.Right, modifier).(Expression)
+		left, leftChanged := modifyExpression(node.Left, modifier)
+		right, rightChanged := modifyExpression(node.Right, modifier)
+		if leftChanged || rightChanged {
+			copied := *node
+			copied.Left, copied.Right = left, right
+			return modifier(&copied)
+		}
 	case *IndexExpression:
-		node.Left, _ = Modify(node.Left, modifier).(Expression)
-		node.Index, _ = Modify(node.Index, modifier).(Expression)
+		left, leftChanged := modifyExpression(node.Left, modifier)
+		index, indexChanged := modifyExpression(node.Index, modifier)
+		if leftChanged || indexChanged {
+			copied := *node
+			copied.Left, copied.Index = left, index
+			return modifier(&copied)
+		}
+	case *MemberExpression:
+		if object, changed := modifyExpression(node.Object, modifier); changed {
+			copied := *node
+			copied.Object = object
+			return modifier(&copied)
+		}
 	case *IfExpression:
-		node.Condition, _ = Modify(node.Condition, modifier).(Expression)
-		node.Consequence, _ = Modify(node.Consequence, modifier).(*BlockStatement)
+		condition, conditionChanged := modifyExpression(node.Condition, modifier)
+		consequence, consequenceChanged := modifyBlock(node.Consequence, modifier)
+		alternative, alternativeChanged := node.Alternative, false
 		if node.Alternative != nil {
-			node.Alternative, _ = Modify(node.Alternative, modifier).(*BlockStatement)
+			alternative, alternativeChanged = modifyBlock(node.Alternative, modifier)
+		}
+		if conditionChanged || consequenceChanged || alternativeChanged {
+			copied := *node
+			copied.Condition, copied.Consequence, copied.Alternative = condition, consequence, alternative
+			return modifier(&copied)
+		}
+	case *ConditionalExpression:
+		condition, conditionChanged := modifyExpression(node.Condition, modifier)
+		consequence, consequenceChanged := modifyExpression(node.Consequence, modifier)
+		alternative, alternativeChanged := modifyExpression(node.Alternative, modifier)
+		if conditionChanged || consequenceChanged || alternativeChanged {
+			copied := *node
+			copied.Condition, copied.Consequence, copied.Alternative = condition, consequence, alternative
+			return modifier(&copied)
 		}
 	case *FunctionLiteral:
-		for i := range node.Parameters {
-			node.Parameters[i], _ = Modify(node.Parameters[i], modifier).(*Identifier)
+		parameters, parametersChanged := modifyIdentifiers(node.Parameters, modifier)
+		requires, requiresChanged := modifyExpressions(node.Requires, modifier)
+		ensures, ensuresChanged := modifyExpressions(node.Ensures, modifier)
+		body, bodyChanged := modifyBlock(node.Body, modifier)
+		if parametersChanged || requiresChanged || ensuresChanged || bodyChanged {
+			copied := *node
+			copied.Parameters, copied.Requires, copied.Ensures, copied.Body = parameters, requires, ensures, body
+			return modifier(&copied)
+		}
+	case *CallExpression:
+		function, functionChanged := modifyExpression(node.Function, modifier)
+		arguments, argumentsChanged := modifyExpressions(node.Arguments, modifier)
+		if functionChanged || argumentsChanged {
+			copied := *node
+			copied.Function, copied.Arguments = function, arguments
+			return modifier(&copied)
 		}
-		node.Body, _ = Modify(node.Body, modifier).(*BlockStatement)
 	case *ArrayLiteral:
-		for i := range node.Elements {
-			node.Elements[i], _ = Modify(node.Elements[i], modifier).(Expression)
+		if elements, changed := modifyExpressions(node.Elements, modifier); changed {
+			copied := *node
+			copied.Elements = elements
+			return modifier(&copied)
 		}
 	case *HashLiteral:
-		newPairs := make(map[Expression]Expression)
-		for key, value := range node.Pairs {
-			newKey, _ := Modify(key, modifier).(Expression)
-			newValue, _ := Modify(value, modifier).(Expression)
+		newPairs := make(map[Expression]Expression, len(node.Pairs))
+		changed := false
+		// マクロの展開のように modifier に副作用があっても毎回同じ順に呼ぶ
+		for _, key := range node.SortedKeys() {
+			newKey, keyChanged := modifyExpression(key, modifier)
+			newValue, valueChanged := modifyExpression(node.Pairs[key], modifier)
 			newPairs[newKey] = newValue
+			changed = changed || keyChanged || valueChanged
+		}
+		if changed {
+			copied := *node
+			copied.Pairs = newPairs
+			return modifier(&copied)
+		}
+	case *MatchExpression:
+		subject, subjectChanged := modifyExpression(node.Subject, modifier)
+		arms, armsChanged := node.Arms, false
+		for i, arm := range node.Arms {
+			body, changed := modifyExpression(arm.Body, modifier)
+			if !changed {
+				continue
+			}
+			if !armsChanged {
+				arms = append([]*MatchArm{}, node.Arms...)
+				armsChanged = true
+			}
+			arms[i] = &MatchArm{Pattern: arm.Pattern, Body: body}
+		}
+		if subjectChanged || armsChanged {
+			copied := *node
+			copied.Subject, copied.Arms = subject, arms
+			return modifier(&copied)
 		}
-		node.Pairs = newPairs
 	}
 
 	return modifier(node)
 }
+
+func modifyExpression(exp Expression, modifier ModifierFunc) (Expression, bool) {
+	modified, _ := Modify(exp, modifier).(Expression)
+	return modified, modified != exp
+}
+
+func modifyBlock(block *BlockStatement, modifier ModifierFunc) (*BlockStatement, bool) {
+	modified, _ := Modify(block, modifier).(*BlockStatement)
+	return modified, modified != block
+}
+
+// modifyExpressions などは要素が一つでも置き換わったときだけ新しいスライスを返す
+func modifyExpressions(exps []Expression, modifier ModifierFunc) ([]Expression, bool) {
+	var result []Expression
+	for i, exp := range exps {
+		modified, changed := modifyExpression(exp, modifier)
+		if changed && result == nil {
+			result = append(make([]Expression, 0, len(exps)), exps[:i]...)
+		}
+		if result != nil {
+			result = append(result, modified)
+		}
+	}
+	if result == nil {
+		return exps, false
+	}
+	return result, true
+}
+
+func modifyStatements(stmts []Statement, modifier ModifierFunc) ([]Statement, bool) {
+	var result []Statement
+	for i, stmt := range stmts {
+		modified, _ := Modify(stmt, modifier).(Statement)
+		if modified != stmt && result == nil {
+			result = append(make([]Statement, 0, len(stmts)), stmts[:i]...)
+		}
+		if result != nil {
+			result = append(result, modified)
+		}
+	}
+	if result == nil {
+		return stmts, false
+	}
+	return result, true
+}
+
+func modifyIdentifiers(idents []*Identifier, modifier ModifierFunc) ([]*Identifier, bool) {
+	var result []*Identifier
+	for i, ident := range idents {
+		modified, _ := Modify(ident, modifier).(*Identifier)
+		if modified != ident && result == nil {
+			result = append(make([]*Identifier, 0, len(idents)), idents[:i]...)
+		}
+		if result != nil {
+			result = append(result, modified)
+		}
+	}
+	if result == nil {
+		return idents, false
+	}
+	return result, true
+}
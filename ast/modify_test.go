@@ -87,6 +87,10 @@ func TestModify(t *testing.T) {
 				},
 			},
 		},
+		{
+			&ConditionalExpression{Condition: one(), Consequence: one(), Alternative: one()},
+			&ConditionalExpression{Condition: two(), Consequence: two(), Alternative: two()},
+		},
 		{
 			&ReturnStatement{ReturnValue: one()},
 			&ReturnStatement{ReturnValue: two()},
@@ -121,6 +125,10 @@ func TestModify(t *testing.T) {
 			&ArrayLiteral{Elements: []Expression{one(), one()}},
 			&ArrayLiteral{Elements: []Expression{two(), two()}},
 		},
+		{
+			&CallExpression{Function: one(), Arguments: []Expression{one(), two()}},
+			&CallExpression{Function: two(), Arguments: []Expression{two(), two()}},
+		},
 	}
 
 	for _, tt := range tests {
@@ -152,3 +160,38 @@ func TestModify(t *testing.T) {
 		}
 	}
 }
+
+func TestModifySharesUnchangedSubtrees(t *testing.T) {
+	untouched := &ArrayLiteral{Elements: []Expression{&IntegerLiteral{Value: 3}}}
+	one := &IntegerLiteral{Value: 1}
+	call := &CallExpression{Function: &Identifier{Value: "f"}, Arguments: []Expression{one, untouched}}
+	program := &Program{Statements: []Statement{&ExpressionStatement{Expression: call}}}
+
+	replaceOne := func(node Node) Node {
+		if node == one {
+			return &IntegerLiteral{Value: 2}
+		}
+		return node
+	}
+
+	modified := Modify(program, replaceOne).(*Program)
+
+	if modified == program {
+		t.Fatalf("Modify returned the original program although a node changed")
+	}
+	if call.Arguments[0] != one {
+		t.Errorf("original tree was modified")
+	}
+
+	newCall := modified.Statements[0].(*ExpressionStatement).Expression.(*CallExpression)
+	if newCall.Arguments[0].(*IntegerLiteral).Value != 2 {
+		t.Errorf("changed node was not replaced")
+	}
+	if newCall.Arguments[1] != untouched || newCall.Function != call.Function {
+		t.Errorf("unchanged subtrees were copied")
+	}
+
+	if Modify(program, func(node Node) Node { return node }) != program {
+		t.Errorf("Modify copied a tree without changes")
+	}
+}
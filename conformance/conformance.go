@@ -0,0 +1,78 @@
+// Package conformance は同じ Monkey のプログラムを複数の実行エンジンで動かし、結果が一致するかを調べる。
+package conformance
+
+import (
+	"sort"
+
+	"github.com/al-keio/monkey-go/internal/evaluator"
+	"github.com/al-keio/monkey-go/internal/lexer"
+	"github.com/al-keio/monkey-go/internal/parser"
+	"github.com/al-keio/monkey-go/object"
+)
+
+// Engine は input を実行し、最後の値の Inspect を返す。構文エラーは "PARSE ERROR" で始まる文字列で返す。
+type Engine func(input string) string
+
+var engines = map[string]Engine{
+	"evaluator": Evaluate,
+}
+
+// RegisterEngine は比較対象のエンジンを追加する
+func RegisterEngine(name string, engine Engine) {
+	if _, dup := engines[name]; dup {
+		panic("conformance: RegisterEngine called twice for engine " + name)
+	}
+	engines[name] = engine
+}
+
+// Engines は登録されているエンジンの名前を順に並べて返す
+func Engines() []string {
+	names := make([]string, 0, len(engines))
+	for name := range engines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Evaluate は木を辿る評価器でプログラムを実行する
+func Evaluate(input string) string {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return "PARSE ERROR: " + p.Errors()[0]
+	}
+
+	macroEnv := object.NewEnvironment()
+	evaluator.DefineMacros(program, macroEnv)
+	expanded := evaluator.ExpandMacros(program, macroEnv)
+
+	result := evaluator.Eval(expanded, object.NewEnvironment())
+	if result == nil {
+		return ""
+	}
+	return result.Inspect()
+}
+
+type Case struct {
+	Name     string
+	Input    string
+	Expected string
+}
+
+type Failure struct {
+	Case   Case
+	Engine string
+	Got    string
+}
+
+// Check は c を登録されているすべてのエンジンで実行し、期待値と異なった結果を返す
+func Check(c Case) []Failure {
+	failures := []Failure{}
+	for _, name := range Engines() {
+		if got := engines[name](c.Input); got != c.Expected {
+			failures = append(failures, Failure{Case: c, Engine: name, Got: got})
+		}
+	}
+	return failures
+}
@@ -0,0 +1,25 @@
+package conformance
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestCorpus(t *testing.T) {
+	for _, c := range Corpus {
+		for _, f := range Check(c) {
+			t.Errorf("%s [%s]: expected %q, got %q", f.Case.Name, f.Engine, f.Case.Expected, f.Got)
+		}
+	}
+}
+
+func TestGeneratedPrograms(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 500; i++ {
+		c := Generate(r, 4)
+		for _, f := range Check(c) {
+			t.Errorf("generated program %d [%s]:\n%s\nexpected %q, got %q", i, f.Engine, f.Case.Input, f.Case.Expected, f.Got)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package conformance
+
+// Corpus はすべてのエンジンが同じ結果を返すべきプログラムの一覧
+var Corpus = []Case{
+	{"integer arithmetic", `(5 + 10 * 2 + 15 / 3) * 2 + -10`, "50"},
+	{"boolean logic", `!(1 < 2) == false`, "true"},
+	{"string concatenation", `"Hello" + " " + "World!"`, "Hello World!"},
+	{"if else", `if (1 > 2) { 10 } else { 20 }`, "20"},
+	{"if without else", `if (false) { 10 }`, "null"},
+	{"nested return", `if (10 > 1) { if (10 > 1) { return 10; } return 1; }`, "10"},
+	{"let bindings", `let a = 5; let b = a * 2; a + b;`, "15"},
+	{"closures", `let adder = fn(x) { fn(y) { x + y } }; adder(2)(3);`, "5"},
+	{"recursion", `let fib = fn(n) { if (n < 2) { n } else { fib(n - 1) + fib(n - 2) } }; fib(15);`, "610"},
+	{"higher order", `let twice = fn(f, x) { f(f(x)) }; twice(fn(x) { x * 3 }, 2);`, "18"},
+	{"arrays", `let a = [1, 2 * 2, 3 + 3]; a[0] + a[1] + a[2];`, "11"},
+	{"array out of range", `[1, 2, 3][3]`, "null"},
+	{"builtins", `len(push(rest([1, 2, 3]), 4))`, "3"},
+	{"map and filter", `map(filter([1, 2, 3, 4, 5], fn(x) { x > 2 }), fn(x) { x * x })`, "[9, 16, 25]"},
+	{"hashes", `let h = {"one": 1, true: 2, 3: 3}; h["one"] + h[true] + h[3];`, "6"},
+	{"missing hash key", `{"a": 1}["b"]`, "null"},
+	{"ternary", `let x = 3; x > 2 ? "big" : "small"`, "big"},
+	{"match", `match ([1, 2, 3]) { [a, ...rest] => len(rest) + a, _ => 0 }`, "3"},
+	{"decimals", `1.5d + 1`, "2.5"},
+	{"macros", `let unless = macro(c, a, b) { quote(if (!(unquote(c))) { unquote(a) } else { unquote(b) }) }; unless(10 > 5, 1, 2);`, "2"},
+	{"type error", `5 + true;`, "ERROR: type mismatch: INTEGER + BOOLEAN"},
+	{"unknown identifier", `foobar`, "ERROR: identifier not found: foobar"},
+	{"division by zero", `1 / 0`, "ERROR: division by zero"},
+	{"parse error", `let = 1;`, "PARSE ERROR: expected next token to be IDENT, got = instead"},
+}
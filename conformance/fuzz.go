@@ -0,0 +1,12 @@
+//go:build gofuzz
+// +build gofuzz
+
+package conformance
+
+// Fuzz は go-fuzz のエントリポイント。不変条件が破れたら panic して go-fuzz に報告する。
+func Fuzz(data []byte) int {
+	if err := CheckInvariants(string(data)); err != nil {
+		panic(err)
+	}
+	return 1
+}
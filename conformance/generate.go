@@ -0,0 +1,144 @@
+package conformance
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// Generate は入れ子の深さが depth までのランダムなプログラムを作り、その期待値とともに返す。
+// 期待値はプログラムを組み立てながら Go で計算するので、どのエンジンの実装にも依存しない。
+func Generate(r *rand.Rand, depth int) Case {
+	g := &generator{r: r}
+
+	statements := []string{}
+	for i := r.Intn(4); i > 0; i-- {
+		name := g.fresh("v")
+		exp, value := g.intExpression(depth)
+		statements = append(statements, fmt.Sprintf("let %s = %s;", name, exp))
+		g.vars = append(g.vars, variable{name, value})
+	}
+
+	exp, value := g.intExpression(depth)
+	statements = append(statements, exp)
+
+	return Case{
+		Name:     "generated",
+		Input:    strings.Join(statements, "\n"),
+		Expected: strconv.FormatInt(value, 10),
+	}
+}
+
+type variable struct {
+	name  string
+	value int64
+}
+
+type generator struct {
+	r     *rand.Rand
+	vars  []variable
+	count int
+}
+
+// fresh は重複しない名前を返す。識別子に数字は使えないので番号は英字で表す
+func (g *generator) fresh(prefix string) string {
+	g.count++
+
+	suffix := ""
+	for n := g.count; n > 0; n /= 26 {
+		suffix = string(rune('a'+n%26)) + suffix
+	}
+	return prefix + suffix
+}
+
+func (g *generator) intExpression(depth int) (string, int64) {
+	if depth <= 0 {
+		if len(g.vars) > 0 && g.r.Intn(2) == 0 {
+			v := g.vars[g.r.Intn(len(g.vars))]
+			return v.name, v.value
+		}
+		n := int64(g.r.Intn(20))
+		return strconv.FormatInt(n, 10), n
+	}
+
+	switch g.r.Intn(7) {
+	case 0:
+		left, l := g.intExpression(depth - 1)
+		right, r := g.intExpression(depth - 1)
+		switch g.r.Intn(3) {
+		case 0:
+			return "(" + left + " + " + right + ")", l + r
+		case 1:
+			return "(" + left + " - " + right + ")", l - r
+		default:
+			return "(" + left + " * " + right + ")", l * r
+		}
+	case 1:
+		left, l := g.intExpression(depth - 1)
+		divisor := int64(g.r.Intn(9) + 1)
+		return fmt.Sprintf("(%s / %d)", left, divisor), l / divisor
+	case 2:
+		right, r := g.intExpression(depth - 1)
+		return "(-" + right + ")", -r
+	case 3:
+		cond, c := g.boolExpression(depth - 1)
+		consequence, a := g.intExpression(depth - 1)
+		alternative, b := g.intExpression(depth - 1)
+		exp := fmt.Sprintf("if (%s) { %s } else { %s }", cond, consequence, alternative)
+		if c {
+			return exp, a
+		}
+		return exp, b
+	case 4:
+		cond, c := g.boolExpression(depth - 1)
+		consequence, a := g.intExpression(depth - 1)
+		alternative, b := g.intExpression(depth - 1)
+		exp := fmt.Sprintf("(%s ? %s : %s)", cond, consequence, alternative)
+		if c {
+			return exp, a
+		}
+		return exp, b
+	case 5:
+		body, b := g.intExpression(depth - 1)
+		arg, a := g.intExpression(depth - 1)
+		param := g.fresh("p")
+		return fmt.Sprintf("fn(%s) { %s + %s }(%s)", param, param, body, arg), a + b
+	default:
+		elements := make([]string, g.r.Intn(3)+1)
+		values := make([]int64, len(elements))
+		for i := range elements {
+			elements[i], values[i] = g.intExpression(depth - 1)
+		}
+		i := g.r.Intn(len(elements))
+		return fmt.Sprintf("[%s][%d]", strings.Join(elements, ", "), i), values[i]
+	}
+}
+
+func (g *generator) boolExpression(depth int) (string, bool) {
+	if depth <= 0 {
+		if g.r.Intn(2) == 0 {
+			return "true", true
+		}
+		return "false", false
+	}
+
+	switch g.r.Intn(4) {
+	case 0:
+		right, r := g.boolExpression(depth - 1)
+		return "(!" + right + ")", !r
+	default:
+		left, l := g.intExpression(depth - 1)
+		right, r := g.intExpression(depth - 1)
+		switch g.r.Intn(4) {
+		case 0:
+			return "(" + left + " < " + right + ")", l < r
+		case 1:
+			return "(" + left + " > " + right + ")", l > r
+		case 2:
+			return "(" + left + " == " + right + ")", l == r
+		default:
+			return "(" + left + " != " + right + ")", l != r
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package conformance
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/al-keio/monkey-go/ast"
+	"github.com/al-keio/monkey-go/internal/lexer"
+	"github.com/al-keio/monkey-go/internal/parser"
+)
+
+// 異なる木が同じハッシュにならないこと、同じ木を書き直しても同じハッシュになることを
+// コーパスと生成したプログラムで確かめる
+func TestASTHashCollisions(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	inputs := []string{}
+	for _, c := range Corpus {
+		inputs = append(inputs, c.Input)
+	}
+	for i := 0; i < 300; i++ {
+		inputs = append(inputs, Generate(r, 4).Input)
+	}
+
+	seen := map[uint64]string{}
+	for _, input := range inputs {
+		p := parser.New(lexer.New(input))
+		program := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			continue
+		}
+
+		h := ast.Hash(program)
+		if other, ok := seen[h]; ok && other != program.String() {
+			t.Errorf("hash collision between %q and %q", other, program.String())
+		}
+		seen[h] = program.String()
+
+		// 前にコメントと空白を足して解析し直した木は位置が違うだけなので同じハッシュになる
+		reparsed := parser.New(lexer.New("// comment\n\n  " + input)).ParseProgram()
+		if got := ast.Hash(reparsed); got != h {
+			t.Errorf("%q: hash changed after reparsing", input)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package conformance
+
+import (
+	"fmt"
+
+	"github.com/al-keio/monkey-go/ast"
+	"github.com/al-keio/monkey-go/internal/evaluator"
+	"github.com/al-keio/monkey-go/internal/lexer"
+	"github.com/al-keio/monkey-go/internal/parser"
+	"github.com/al-keio/monkey-go/object"
+)
+
+// InvariantMaxSteps は CheckInvariants で評価するときのステップ数の上限。ランダムな入力の無限ループで止まらないようにする。
+const InvariantMaxSteps = 100000
+
+// CheckInvariants は input について、実行エンジンが守るべき性質を調べる。
+//   - 字句解析・構文解析・マクロ展開・評価のどこでも panic しない
+//   - 木を Copy してから評価しても、Optimize してから評価しても結果が変わらない
+//   - 登録されているすべてのエンジンの結果が一致する
+//
+// 構文エラーになる入力は panic しないことだけを調べる。
+func CheckInvariants(input string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return nil
+	}
+
+	macroEnv := object.NewEnvironment()
+	evaluator.DefineMacros(program, macroEnv)
+	expanded := evaluator.ExpandMacros(program, macroEnv)
+
+	want := evaluate(expanded)
+	if got := evaluate(expanded.Copy()); got != want {
+		return fmt.Errorf("evaluating a copy gave %q, want %q", got, want)
+	}
+	if got := evaluate(evaluator.Optimize(expanded)); got != want {
+		return fmt.Errorf("evaluating the optimized program gave %q, want %q", got, want)
+	}
+
+	if len(engines) > 1 {
+		results := map[string]string{}
+		for _, name := range Engines() {
+			results[name] = engines[name](input)
+		}
+		for _, name := range Engines() {
+			if results[name] != results[Engines()[0]] {
+				return fmt.Errorf("engines disagree: %q", results)
+			}
+		}
+	}
+
+	return nil
+}
+
+func evaluate(node ast.Node) string {
+	result := evaluator.EvalWithOptions(node, object.NewEnvironment(), evaluator.EvalOptions{MaxSteps: InvariantMaxSteps}).Value
+	if result == nil {
+		return ""
+	}
+	return result.Inspect()
+}
@@ -0,0 +1,49 @@
+package conformance
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestInvariants(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	inputs := []string{}
+	for _, c := range Corpus {
+		inputs = append(inputs, c.Input)
+	}
+	for i := 0; i < 200; i++ {
+		inputs = append(inputs, Generate(r, 3).Input)
+	}
+
+	// 正しいプログラムを少しずつ壊した入力でも panic しないこと
+	for _, input := range inputs[:len(Corpus)] {
+		for i := 0; i < 20; i++ {
+			inputs = append(inputs, mutate(r, input))
+		}
+	}
+
+	for _, input := range inputs {
+		if err := CheckInvariants(input); err != nil {
+			t.Errorf("%q: %s", input, err)
+		}
+	}
+}
+
+func mutate(r *rand.Rand, input string) string {
+	const alphabet = "()[]{},;:.+-*/%!<>=?\"'`dfn0123456789 \n"
+
+	b := []byte(input)
+	for n := 1 + r.Intn(3); n > 0 && len(b) > 0; n-- {
+		i := r.Intn(len(b))
+		switch r.Intn(3) {
+		case 0:
+			b = append(b[:i], b[i+1:]...)
+		case 1:
+			b[i] = alphabet[r.Intn(len(alphabet))]
+		default:
+			b = append(b[:i], append([]byte{alphabet[r.Intn(len(alphabet))]}, b[i:]...)...)
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,94 @@
+// Package diagnostics はエラーメッセージに添えるヒントを作るための道具。
+package diagnostics
+
+import (
+	"sort"
+	"strings"
+)
+
+// MaxSuggestions は Suggest が返す候補の数の上限
+const MaxSuggestions = 3
+
+// Suggest は candidates のうち name の書き間違いらしいものを近い順に返す。
+// 編集距離 (隣り合う二文字の入れ替えも一回と数える) が name の長さの半分 (少なくとも 1、多くとも 3) 以下のものを選ぶ。
+// name 自身と重複は除く。
+func Suggest(name string, candidates []string) []string {
+	limit := (len([]rune(name)) + 1) / 2
+	if limit > 3 {
+		limit = 3
+	}
+
+	type candidate struct {
+		name     string
+		distance int
+	}
+	seen := map[string]bool{name: true}
+	var found []candidate
+	for _, c := range candidates {
+		if seen[c] {
+			continue
+		}
+		seen[c] = true
+		if d := Distance(name, c); d <= limit {
+			found = append(found, candidate{c, d})
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].distance != found[j].distance {
+			return found[i].distance < found[j].distance
+		}
+		return found[i].name < found[j].name
+	})
+
+	var names []string
+	for i := 0; i < len(found) && i < MaxSuggestions; i++ {
+		names = append(names, found[i].name)
+	}
+	return names
+}
+
+// DidYouMean は Suggest の結果を "did you mean: len?" の形にする。候補がなければ空文字列。
+func DidYouMean(name string, candidates []string) string {
+	names := Suggest(name, candidates)
+	if len(names) == 0 {
+		return ""
+	}
+	return "did you mean: " + strings.Join(names, ", ") + "?"
+}
+
+// Distance は a と b の編集距離を文字単位で返す。挿入、削除、置換、隣り合う二文字の入れ替えをそれぞれ一回と数える。
+func Distance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	// d[i][j] は s[:i] と t[:j] の距離
+	d := make([][]int, len(s)+1)
+	for i := range d {
+		d[i] = make([]int, len(t)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(s)][len(t)]
+}
+
+func min(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
@@ -0,0 +1,54 @@
+package diagnostics
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "", 0},
+		{"len", "len", 0},
+		{"lng", "len", 2},
+		{"lenght", "length", 1},
+		{"kitten", "sitting", 3},
+		{"", "abc", 3},
+		{"λx", "λy", 1},
+	}
+
+	for _, tt := range tests {
+		if got := Distance(tt.a, tt.b); got != tt.expected {
+			t.Errorf("Distance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.expected)
+		}
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	candidates := []string{"len", "puts", "first", "rest", "length", "len", "x"}
+
+	tests := []struct {
+		name     string
+		expected []string
+	}{
+		{"lng", []string{"len"}},
+		{"le", []string{"len"}},
+		{"lenght", []string{"length", "len"}},
+		{"len", nil},
+		{"y", []string{"x"}},
+		{"frist", []string{"first", "rest"}},
+		{"zzzzzz", nil},
+	}
+
+	for _, tt := range tests {
+		if got := Suggest(tt.name, candidates); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("Suggest(%q) = %v, want %v", tt.name, got, tt.expected)
+		}
+	}
+
+	if got := DidYouMean("lng", candidates); got != "did you mean: len?" {
+		t.Errorf("DidYouMean wrong. got=%q", got)
+	}
+}
@@ -1,654 +0,0 @@
-package evaluator
-
-import (
-	"reflect"
-	"testing"
-
-	"github.com/al-keio/monkey-go/lexer"
-	"github.com/al-keio/monkey-go/object"
-	"github.com/al-keio/monkey-go/parser"
-)
-
-func TestEvalIntegerExpression(t *testing.T) {
-	tests := []struct {
-		input    string
-		expected int64
-	}{
-		{"5", 5},
-		{"10", 10},
-		{"-5", -5},
-		{"-10", -10},
-		{"5 + 5 + 5 + 5 - 10", 10},
-		{"2 * 2 * 2 * 2 * 2", 32},
-		{"-50 + 100 + -50", 0},
-		{"5 * 2 + 10", 20},
-		{"5 + 2 * 10", 25},
-		{"20 + 2 * -10", 0},
-		{"50 / 2 * 2 + 10", 60},
-		{"2 * (5 + 10)", 30},
-		{"3 * 3 * 3 + 10", 37},
-		{"3 * (3 * 3) + 10", 37},
-		{"(5 + 10 * 2 + 15 / 3) * 2 + -10", 50},
-	}
-
-	for _, tt := range tests {
-		evaluated := testEval(tt.input)
-		testIntegerObject(t, evaluated, tt.expected)
-	}
-}
-
-func TestEvalStringExpression(t *testing.T) {
-	input := `"Hello World!"`
-
-	evaluated := testEval(input)
-	str, ok := evaluated.(*object.String)
-	if !ok {
-		t.Fatalf("object is not String. got=%T (%+v)", evaluated, evaluated)
-	}
-
-	if str.Value != "Hello World!" {
-		t.Errorf("String hash wrong value. got=%q", str.Value)
-	}
-}
-
-func TestStringConcatenation(t *testing.T) {
-	input := `"Hello" + " " + "World!"`
-
-	evaluated := testEval(input)
-	str, ok := evaluated.(*object.String)
-	if !ok {
-		t.Fatalf("object is not String. got=%T (%+v)", evaluated, evaluated)
-	}
-
-	if str.Value != "Hello World!" {
-		t.Errorf("String hash wrong value. got=%q", str.Value)
-	}
-}
-
-func TestEvalBooleanExpression(t *testing.T) {
-	tests := []struct {
-		input    string
-		expected bool
-	}{
-		{"true", true},
-		{"false", false},
-		{"1 < 2", true},
-		{"1 > 2", false},
-		{"1 < 1", false},
-		{"1 > 1", false},
-		{"1 == 1", true},
-		{"1 != 1", false},
-		{"1 == 2", false},
-		{"1 != 2", true},
-		{`"hello" == "hello"`, true},
-		{`"" == ""`, true},
-		{`"hello" == "world"`, false},
-		{`"hello" != "world"`, true},
-		{`"" != "world"`, true},
-		{`"hello" == "hell"`, false},
-		{`"hell" == "hello"`, false},
-		{`"foobar" == "foo" + "bar"`, true},
-		{"true == true", true},
-		{"false == false", true},
-		{"true == false", false},
-		{"false != true", true},
-		{"(1 < 2) == true", true},
-		{"(1 < 2) == false", false},
-		{"(1 > 2) == true", false},
-		{"(1 > 2) == false", true},
-	}
-
-	for _, tt := range tests {
-		evaluated := testEval(tt.input)
-		testBooleanObject(t, evaluated, tt.expected)
-	}
-}
-
-func TestBangOperator(t *testing.T) {
-	tests := []struct {
-		input    string
-		expected bool
-	}{
-		{"!true", false},
-		{"!false", true},
-		{"!5", false},
-		{"!!true", true},
-		{"!!false", false},
-		{"!!5", true},
-	}
-
-	for _, tt := range tests {
-		evaluated := testEval(tt.input)
-		testBooleanObject(t, evaluated, tt.expected)
-	}
-}
-
-func TestIfElseExpressions(t *testing.T) {
-	tests := []struct {
-		input    string
-		expected interface{}
-	}{
-		{"if (true) { 10 }", 10},
-		{"if (false) { 10 }", nil},
-		{"if (1) { 10 }", 10},
-		{"if (1 < 2) { 10 }", 10},
-		{"if (1 > 2) { 10 }", nil},
-		{"if (1 > 2) { 10 } else { 20 }", 20},
-		{"if (1 < 2) { 10 } else { 20 }", 10},
-	}
-
-	for _, tt := range tests {
-		evaluated := testEval(tt.input)
-		integer, ok := tt.expected.(int)
-		if ok {
-			testIntegerObject(t, evaluated, int64(integer))
-		} else {
-			testNullObject(t, evaluated)
-		}
-	}
-}
-
-func TestReturnStatements(t *testing.T) {
-	tests := []struct {
-		input    string
-		expected int64
-	}{
-		{"return 10;", 10},
-		{"return 10; 9;", 10},
-		{"return 2 * 5; 9;", 10},
-		{"9; return 2 * 5; 9;", 10},
-		{`
-if (10 > 1) {
-	if (10 > 1) {
-		return 10;
-	}
-
-	return 1;
-}
-`,
-			10,
-		},
-	}
-
-	for _, tt := range tests {
-		evaluated := testEval(tt.input)
-		testIntegerObject(t, evaluated, tt.expected)
-	}
-}
-
-func TestErrorHandling(t *testing.T) {
-	tests := []struct {
-		input           string
-		expectedMessage string
-	}{
-		{
-			"5 + true;",
-			"type mismatch: INTEGER + BOOLEAN",
-		},
-		{
-			"5 + true; 5;",
-			"type mismatch: INTEGER + BOOLEAN",
-		},
-		{
-			`"Hello" - "World"`,
-			"unknown operator: STRING - STRING",
-		},
-		{
-			"-true",
-			"unknown operator: -BOOLEAN",
-		},
-		{
-			"true + false;",
-			"unknown operator: BOOLEAN + BOOLEAN",
-		},
-		{
-			"5; true + false; 5;",
-			"unknown operator: BOOLEAN + BOOLEAN",
-		},
-		{
-			"if (10 > 1) { true + false; }",
-			"unknown operator: BOOLEAN + BOOLEAN",
-		},
-		{
-			`
-if (10 > 1) {
-	if (10 > 1) {
-		return true + false;
-	}
-	return 1;
-}
-`,
-			"unknown operator: BOOLEAN + BOOLEAN",
-		},
-		{
-			"foobar;",
-			"identifier not found: foobar",
-		},
-	}
-
-	for _, tt := range tests {
-		evaluated := testEval(tt.input)
-
-		errObj, ok := evaluated.(*object.Error)
-		if !ok {
-			t.Errorf("no error object returned. got=%T (%+v)", evaluated, evaluated)
-			continue
-		}
-
-		if errObj.Message != tt.expectedMessage {
-			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
-		}
-	}
-}
-
-func TestLetStatement(t *testing.T) {
-	tests := []struct {
-		input    string
-		expected int64
-	}{
-		{"let a = 5; a;", 5},
-		{"let a = 5 * 5; a;", 25},
-		{"let a = 5; let b = a; b;", 5},
-		{"let a = 5; let b = a; let c = a + b + 5; c", 15},
-	}
-
-	for _, tt := range tests {
-		testIntegerObject(t, testEval(tt.input), tt.expected)
-	}
-}
-
-func TestFunctionObject(t *testing.T) {
-	input := "fn(x) { x + 2; };"
-
-	evaluated := testEval(input)
-	fn, ok := evaluated.(*object.Function)
-	if !ok {
-		t.Fatalf("object is not Function. got=%T (%+v)", evaluated, evaluated)
-	}
-
-	if len(fn.Parameters) != 1 {
-		t.Fatalf("function has wrong parameters. Parameters=%+v", fn.Parameters)
-	}
-
-	if fn.Parameters[0].String() != "x" {
-		t.Fatalf("parameter is not 'x'. got=%q", fn.Parameters[0])
-	}
-
-	expectedBody := "(x + 2)"
-
-	if fn.Body.String() != expectedBody {
-		t.Fatalf("body is not %q. got=%q", expectedBody, fn.Body.String())
-	}
-}
-
-func TestFunctionApplication(t *testing.T) {
-	tests := []struct {
-		input    string
-		expected int64
-	}{
-		{"let identity = fn(x) { x; }; identity(5);", 5},
-		{"let identity = fn(x) { return x; }; identity(5);", 5},
-		{"let double = fn(x) { return x * 2; }; double(5);", 10},
-		{"let add = fn(x, y) { return x + y; }; add(5, 5);", 10},
-		{"let add = fn(x, y) { return x + y; }; add(5 + 5, add(5, 5));", 20},
-		{"fn(x) { x; }(5)", 5},
-	}
-
-	for _, tt := range tests {
-		testIntegerObject(t, testEval(tt.input), tt.expected)
-	}
-}
-
-func TestClosures(t *testing.T) {
-	input := `
-let newAdder = fn(x) {
-	fn(y) { x + y };
-};
-
-let addTwo = newAdder(2);
-addTwo(2);
-`
-	testIntegerObject(t, testEval(input), int64(4))
-}
-
-func TestBuiltinFunctions(t *testing.T) {
-	tests := []struct {
-		input    string
-		expected interface{}
-	}{
-		{`len("")`, 0},
-		{`len("four")`, 4},
-		{`len("hello world")`, 11},
-		{`len(1)`, "argument to `len` not supported, got INTEGER"},
-		{`len("one", "two")`, "wrong number of arguments. got=2, want=1"},
-		{`len([])`, 0},
-		{`len([1, 2, 3])`, 3},
-		{`len([1, 2, false, true])`, 4},
-		{`first(["foo", 2, true])`, "foo"},
-		{`first([2, true, "foo"])`, 2},
-		{`first([true, "foo", 2])`, true},
-		{`first([])`, nil},
-		{`first()`, "wrong number of arguments. got=0, want=1"},
-		{`first(1, 2)`, "wrong number of arguments. got=2, want=1"},
-		{`first(1)`, "argument to `first` must be Array, got INTEGER"},
-		{`last(["foo", 2, true])`, true},
-		{`last([2, true, "foo"])`, "foo"},
-		{`last([true, "foo", 2])`, 2},
-		{`last([])`, nil},
-		{`last()`, "wrong number of arguments. got=0, want=1"},
-		{`last(1, 2)`, "wrong number of arguments. got=2, want=1"},
-		{`last(1)`, "argument to `last` must be Array, got INTEGER"},
-		{`rest(["foo", 2, true])`, `[2, true]`},
-		{`rest([2, true, "foo"])`, `[true, "foo"]`},
-		{`rest([true, "foo", 2])`, `["foo", 2]`},
-		{`rest([1])`, "[]"},
-		{`rest([])`, nil},
-		{`rest()`, "wrong number of arguments. got=0, want=1"},
-		{`rest(1, 2)`, "wrong number of arguments. got=2, want=1"},
-		{`rest(1)`, "argument to `rest` must be Array, got INTEGER"},
-		{`let a = [1, 2]; rest(a);`, "[2]"},
-		{`let a = [1, 2]; rest(a); a;`, "[1, 2]"},
-		{`let a = [1, 2, 3, 4]; rest(rest(rest(rest(a))))`, "[]"},
-		{`let a = [1, 2, 3, 4]; rest(rest(a))`, "[3, 4]"},
-		{`let a = [1, 2]; push(a, 3)`, "[1, 2, 3]"},
-		{`let a = [1, 2]; push(a, 3); a;`, "[1, 2]"},
-		{`let a = [1, 2]; push(a, true)`, "[1, 2, true]"},
-		{`let a = []; push(a, 3)`, "[3]"},
-		{`let a = ["foo", "bar"]; push(a, 3)`, `["foo", "bar", 3]`},
-		{`let a = ["foo", "bar"]; let b = [1, 2]; push(a, b)`, `["foo", "bar", [1, 2]]`},
-	}
-
-	for _, tt := range tests {
-		evaluated := testEval(tt.input)
-
-		switch evaluated := evaluated.(type) {
-		case *object.Integer:
-			if expected, ok := tt.expected.(int); ok {
-				testIntegerObject(t, evaluated, int64(expected))
-			} else {
-				t.Errorf("evaluated must not be object.Integer.")
-			}
-		case *object.String:
-			if expected, ok := tt.expected.(string); ok {
-				testStringObject(t, evaluated, expected)
-			} else {
-				t.Errorf("evaluated must not be object.String.")
-			}
-		case *object.Array:
-			if expected, ok := tt.expected.(string); ok {
-				testArrayObject(t, evaluated, expected)
-			} else {
-				t.Errorf("evaluated must not be object.Array.")
-			}
-		case *object.Error:
-			if expected, ok := tt.expected.(string); ok {
-				testErrorObject(t, evaluated, expected)
-			} else {
-				t.Errorf("evaluated must not be object.Error.")
-			}
-		}
-	}
-}
-
-func TestArrayLiterals(t *testing.T) {
-	input := "[1, 2 * 2, 3 + 3]"
-
-	evaluated := testEval(input)
-	fn, ok := evaluated.(*object.Array)
-	if !ok {
-		t.Fatalf("object is not Array. got=%T (%+v)", evaluated, evaluated)
-	}
-
-	if len(fn.Elements) != 3 {
-		t.Fatalf("function has wrong parameters. Parameters=%+v", fn.Elements)
-	}
-
-	testIntegerObject(t, fn.Elements[0], 1)
-	testIntegerObject(t, fn.Elements[1], 4)
-	testIntegerObject(t, fn.Elements[2], 6)
-}
-
-func TestArrayIndexExpressions(t *testing.T) {
-	tests := []struct {
-		input    string
-		expected interface{}
-	}{
-		{
-			"[1, 2, 3][0]",
-			1,
-		},
-		{
-			"[1, 2, 3][1]",
-			2,
-		},
-		{
-			"[1, 2, 3][2]",
-			3,
-		},
-		{
-			"let i = 0; [1][i];",
-			1,
-		},
-		{
-			"[1, 2, 3][1 + 1]",
-			3,
-		},
-		{
-			"let myArray = [1, 2, 3]; myArray[0] + myArray[1] + myArray[2];",
-			6,
-		},
-		{
-			"let myArray = [1, 2, 3]; let i = myArray[0]; myArray[i]",
-			2,
-		},
-		{
-			"[1, 2, 3][3]",
-			nil,
-		},
-		{
-			"[1, 2, 3][-1]",
-			nil,
-		},
-	}
-
-	for _, tt := range tests {
-		evaluated := testEval(tt.input)
-		integer, ok := tt.expected.(int)
-		if ok {
-			testIntegerObject(t, evaluated, int64(integer))
-		} else {
-			testNullObject(t, evaluated)
-		}
-	}
-}
-
-func TestHashLiterals(t *testing.T) {
-	input := `
-let two = "two";
-{
-	"one": 10 - 9,
-	two: 1 + 1,
-	"thr" + "ee": 6 / 2,
-	4: 4,
-	true: 5,
-	false: 6
-}`
-
-	evaluated := testEval(input)
-	result, ok := evaluated.(*object.Hash)
-	if !ok {
-		t.Fatalf("object is not Hash. got=%T (%+v)", evaluated, evaluated)
-	}
-
-	expected := map[object.HashKey]int64{
-		(&object.String{Value: "one"}).HashKey():   1,
-		(&object.String{Value: "two"}).HashKey():   2,
-		(&object.String{Value: "three"}).HashKey(): 3,
-		(&object.Integer{Value: 4}).HashKey():      4,
-		TRUE.HashKey():                             5,
-		FALSE.HashKey():                            6,
-	}
-
-	if len(result.Pairs) != len(expected) {
-		t.Fatalf("Hash has wrong num fo pairs. got=%d", len(result.Pairs))
-	}
-
-	for expectedKey, expectedValue := range expected {
-		pair, ok := result.Pairs[expectedKey]
-		if !ok {
-			t.Errorf("no pair for given key in Pairs")
-		}
-
-		testIntegerObject(t, pair.Value, expectedValue)
-	}
-
-}
-
-func TestHashIndexExpression(t *testing.T) {
-	tests := []struct {
-		input    string
-		expected interface{}
-	}{
-		{
-			`{"foo": 5}["foo"]`,
-			5,
-		},
-		{
-			`{"foo": 5}["bar"]`,
-			nil,
-		},
-		{
-			`let key = "foo"; {"foo": 5}[key]`,
-			5,
-		},
-		{
-			`let key = "foo"; {key: 5}["foo"]`,
-			5,
-		},
-		{
-			`{}["foo"]`,
-			nil,
-		},
-		{
-			`{5: 5}[5]`,
-			5,
-		},
-		{
-			`{true: 5}[true]`,
-			5,
-		},
-		{
-			`{false: 5}[false]`,
-			5,
-		},
-		{
-			`let a = [1, 2]; {a: 5}[[1, 2]]`,
-			5,
-		},
-		{
-			`let a = {1: 1, 2: 2}; {a: 5}[{1: 1, (3 - 1): 2}]`,
-			5,
-		},
-		{
-			`let a = fn(x) { return x; }; {a: 5}[fn(x) { return x; }]`,
-			5,
-		},
-	}
-	for _, tt := range tests {
-		evaluated := testEval(tt.input)
-		integer, ok := tt.expected.(int)
-		if ok {
-			testIntegerObject(t, evaluated, int64(integer))
-		} else {
-			testNullObject(t, evaluated)
-		}
-	}
-}
-
-func testEval(input string) object.Object {
-	l := lexer.New(input)
-	p := parser.New(l)
-	program := p.ParseProgram()
-	env := object.NewEnvironment()
-
-	return Eval(program, env)
-}
-
-func testIntegerObject(t *testing.T, obj object.Object, expected int64) bool {
-	result, ok := obj.(*object.Integer)
-	if !ok {
-		t.Errorf("object is not Integer. got=%T (%+v)", obj, obj)
-		return false
-	}
-	if result.Value != expected {
-		t.Errorf("object has wrong value. got=%d, want=%d", result.Value, expected)
-		return false
-	}
-
-	return true
-}
-
-func testStringObject(t *testing.T, obj object.Object, expected string) bool {
-	result, ok := obj.(*object.String)
-	if !ok {
-		t.Errorf("object is not String. got=%T (%+v)", obj, obj)
-		return false
-	}
-	if result.Value != expected {
-		t.Errorf("object has wrong value. got=%q, want=%q", result.Value, expected)
-		return false
-	}
-
-	return true
-}
-
-func testBooleanObject(t *testing.T, obj object.Object, expected bool) bool {
-	result, ok := obj.(*object.Boolean)
-	if !ok {
-		t.Errorf("object is not Boolean. got=%T (%+v)", obj, obj)
-		return false
-	}
-	if result.Value != expected {
-		t.Errorf("object has wrong value. got=%t, want=%t", result.Value, expected)
-		return false
-	}
-
-	return true
-}
-
-func testArrayObject(t *testing.T, obj object.Object, expected string) bool {
-	result, ok := obj.(*object.Array)
-	if !ok {
-		t.Errorf("object is not Array. got=%T (%+v)", obj, obj)
-		return false
-	}
-	expectedEval := testEval(expected)
-	expectedArray := expectedEval.(*object.Array)
-	if !reflect.DeepEqual(result.Elements, expectedArray.Elements) {
-		t.Errorf("object has wrong value. got=%#v, want=%#v", result.Elements, expectedArray.Elements)
-		return false
-	}
-
-	return true
-}
-
-func testNullObject(t *testing.T, obj object.Object) bool {
-	if obj != NULL {
-		t.Errorf("object is not NULL. got=%T (%+v)", obj, obj)
-		return false
-	}
-	return true
-}
-
-func testErrorObject(t *testing.T, obj object.Object, expected string) bool {
-	result, ok := obj.(*object.Error)
-	if !ok {
-		t.Errorf("object is not String. got=%T (%+v)", obj, obj)
-		return false
-	}
-	if result.Message != expected {
-		t.Errorf("wrong error message. expected=%q, got=%q", expected, result.Message)
-	}
-
-	return true
-}
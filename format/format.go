@@ -0,0 +1,483 @@
+// Package format は構文木を解析し直せるソースコードに戻す。
+// 括弧は優先順位から必要なものだけを付け、ブロックは二つの空白で字下げする。
+// 文に付いたコメント (ast.Trivia) は残すが、式の途中にあったコメントは失われる。
+package format
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/al-keio/monkey-go/ast"
+)
+
+// Source は node を整形したソースコードを返す。
+// ソースコードで書けない木 (" を含む文字列や構文エラーから回復した nil の子など) ならエラーを返す。
+func Source(node ast.Node) (string, error) {
+	f := &formatter{}
+	switch node := node.(type) {
+	case *ast.Program:
+		f.statements(node.Statements, node.EndComments)
+	case ast.Statement:
+		f.statement(node)
+	case ast.Expression:
+		f.expression(node, lowest)
+	default:
+		return "", fmt.Errorf("cannot format %T", node)
+	}
+	if f.err != nil {
+		return "", f.err
+	}
+	return f.out.String(), nil
+}
+
+// 式の優先順位。parser と同じ順で、括弧が要るかどうかの判断にだけ使う。
+// operator 宣言の演算子は優先順位がわからないので、custom として演算子の両側とも括弧で囲む。
+const (
+	lowest = iota
+	custom
+	ternary
+	equals
+	lessgreater
+	sum
+	product
+	prefix
+	call
+	index
+	atom
+)
+
+var precedences = map[string]int{
+	"==": equals,
+	"!=": equals,
+	"<":  lessgreater,
+	">":  lessgreater,
+	"+":  sum,
+	"-":  sum,
+	"*":  product,
+	"/":  product,
+	"%":  product,
+}
+
+type formatter struct {
+	out    bytes.Buffer
+	indent int
+	err    error
+}
+
+func (f *formatter) fail(format string, a ...interface{}) {
+	if f.err == nil {
+		f.err = fmt.Errorf(format, a...)
+	}
+}
+
+func (f *formatter) write(s string) {
+	f.out.WriteString(s)
+}
+
+func (f *formatter) newline() {
+	f.out.WriteByte('\n')
+	f.write(strings.Repeat("  ", f.indent))
+}
+
+// statements は文を一行ずつ書き、最後にどの文にも付かなかったコメントを書く
+func (f *formatter) statements(stmts []ast.Statement, endComments []string) {
+	for i, stmt := range stmts {
+		if i > 0 {
+			f.newline()
+		}
+		f.statement(stmt)
+	}
+	for i, comment := range endComments {
+		if i > 0 || len(stmts) > 0 {
+			f.newline()
+		}
+		f.write(comment)
+	}
+}
+
+func (f *formatter) statement(stmt ast.Statement) {
+	var trivia *ast.Trivia
+	if commented, ok := stmt.(ast.Commented); ok {
+		trivia = commented.Comments()
+		for _, comment := range trivia.Leading {
+			f.write(comment)
+			f.newline()
+		}
+	}
+
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		f.write("let ")
+		f.identifier(stmt.Name)
+		f.write(" = ")
+		f.expression(stmt.Value, lowest)
+		f.write(";")
+	case *ast.ReturnStatement:
+		f.write("return ")
+		f.expression(stmt.ReturnValue, lowest)
+		f.write(";")
+	case *ast.DeferStatement:
+		f.write("defer ")
+		f.expression(stmt.Call, lowest)
+		f.write(";")
+	case *ast.OperatorStatement:
+		f.write("operator " + stmt.Operator)
+		if stmt.Precedence != "" {
+			f.write(" " + stmt.Precedence)
+		}
+		f.function(stmt.Function)
+	case *ast.ClassStatement:
+		f.class(stmt)
+	case *ast.ExpressionStatement:
+		f.expression(stmt.Expression, lowest)
+		// ブロックで終わる if は ; を付けずに書く
+		if _, ok := stmt.Expression.(*ast.IfExpression); !ok {
+			f.write(";")
+		}
+	case *ast.BlockStatement:
+		f.block(stmt)
+	default:
+		f.fail("cannot format statement %T", stmt)
+	}
+
+	if trivia != nil && trivia.Trailing != "" {
+		f.write(" " + trivia.Trailing)
+	}
+}
+
+func (f *formatter) class(stmt *ast.ClassStatement) {
+	f.write("class ")
+	f.identifier(stmt.Name)
+	if stmt.Extends != nil {
+		f.write(" extends ")
+		f.expression(stmt.Extends, index)
+	}
+	f.write(" {")
+	f.indent++
+	for _, m := range stmt.Methods {
+		f.newline()
+		f.identifier(m.Name)
+		f.function(m.Function)
+	}
+	f.indent--
+	if len(stmt.Methods) > 0 {
+		f.newline()
+	}
+	f.write("}")
+}
+
+// block は { から } までを書く
+func (f *formatter) block(block *ast.BlockStatement) {
+	if block == nil {
+		f.fail("cannot format a missing block")
+		return
+	}
+	if len(block.Statements) == 0 && len(block.EndComments) == 0 {
+		f.write("{}")
+		return
+	}
+	f.write("{")
+	f.indent++
+	f.newline()
+	f.statements(block.Statements, block.EndComments)
+	f.indent--
+	f.newline()
+	f.write("}")
+}
+
+// function は fn の後ろの引数リスト、条件、本体を書く。クラスのメソッドや operator 宣言でも使う。
+func (f *formatter) function(fl *ast.FunctionLiteral) {
+	if fl == nil {
+		f.fail("cannot format a missing function")
+		return
+	}
+	f.write("(")
+	for i, param := range fl.Parameters {
+		if i > 0 {
+			f.write(", ")
+		}
+		f.identifier(param)
+	}
+	f.write(") ")
+	for _, cond := range fl.Requires {
+		f.write("requires ")
+		f.expression(cond, lowest)
+		f.write(" ")
+	}
+	for _, cond := range fl.Ensures {
+		f.write("ensures ")
+		f.expression(cond, lowest)
+		f.write(" ")
+	}
+	f.block(fl.Body)
+}
+
+func (f *formatter) identifier(ident *ast.Identifier) {
+	if ident == nil {
+		f.fail("cannot format a missing identifier")
+		return
+	}
+	f.write(ident.Value)
+}
+
+// integer は 0xFF のように基数の接頭辞を付けて書かれた整数を、値が変わっていなければその書き方のまま書く
+func (f *formatter) integer(lit *ast.IntegerLiteral) {
+	literal := lit.Token.Literal
+	if len(literal) > 2 && literal[0] == '0' && strings.ContainsRune("xXoObB", rune(literal[1])) {
+		if value, err := strconv.ParseInt(literal, 0, 64); err == nil && value == lit.Value {
+			f.write(literal)
+			return
+		}
+	}
+	f.write(fmt.Sprintf("%d", lit.Value))
+}
+
+// expression は exp を書く。exp の優先順位が min より低ければ括弧で囲む。
+func (f *formatter) expression(exp ast.Expression, min int) {
+	if exp == nil {
+		f.fail("cannot format a missing expression")
+		return
+	}
+	if p := precedence(exp); p < min {
+		f.write("(")
+		defer f.write(")")
+	}
+
+	switch exp := exp.(type) {
+	case *ast.Identifier:
+		f.identifier(exp)
+	case *ast.IntegerLiteral:
+		f.integer(exp)
+	case *ast.BigIntLiteral, *ast.DecimalLiteral:
+		f.write(exp.TokenLiteral())
+	case *ast.StringLiteral:
+		f.string(exp.Value)
+	case *ast.Boolean:
+		f.write(fmt.Sprintf("%t", exp.Value))
+	case *ast.ArrayLiteral:
+		f.write("[")
+		f.expressions(exp.Elements)
+		f.write("]")
+	case *ast.HashLiteral:
+		f.hash(exp)
+	case *ast.PrefixExpression:
+		f.write(exp.Operator)
+		f.expression(exp.Right, prefix)
+	case *ast.InfixExpression:
+		left, right := precedence(exp), precedence(exp)+1
+		if left == custom {
+			left, right = prefix, prefix
+		}
+		f.expression(exp.Left, left)
+		f.write(" " + exp.Operator + " ")
+		f.expression(exp.Right, right)
+	case *ast.ConditionalExpression:
+		f.expression(exp.Condition, ternary+1)
+		f.write(" ? ")
+		f.expression(exp.Consequence, ternary+1)
+		f.write(" : ")
+		f.expression(exp.Alternative, lowest)
+	case *ast.IndexExpression:
+		f.expression(exp.Left, call)
+		f.write("[")
+		f.expression(exp.Index, lowest)
+		f.write("]")
+	case *ast.MemberExpression:
+		f.expression(exp.Object, call)
+		f.write(".")
+		f.identifier(exp.Member)
+	case *ast.CallExpression:
+		f.expression(exp.Function, call)
+		f.write("(")
+		f.expressions(exp.Arguments)
+		f.write(")")
+	case *ast.NewExpression:
+		f.write("new ")
+		f.expression(exp.Class, index)
+		f.write("(")
+		f.expressions(exp.Arguments)
+		f.write(")")
+	case *ast.PipelineExpression:
+		f.expression(exp.Original, lowest)
+	case *ast.IfExpression:
+		f.write("if (")
+		f.expression(exp.Condition, lowest)
+		f.write(") ")
+		f.block(exp.Consequence)
+		if exp.Alternative != nil {
+			f.write(" else ")
+			f.block(exp.Alternative)
+		}
+	case *ast.FunctionLiteral:
+		f.write("fn")
+		f.function(exp)
+	case *ast.MacroLiteral:
+		f.write("macro(")
+		for i, param := range exp.Parameters {
+			if i > 0 {
+				f.write(", ")
+			}
+			f.identifier(param)
+		}
+		f.write(") ")
+		f.block(exp.Body)
+	case *ast.MatchExpression:
+		f.match(exp)
+	default:
+		f.fail("cannot format expression %T", exp)
+	}
+}
+
+func precedence(exp ast.Expression) int {
+	switch exp := exp.(type) {
+	case *ast.InfixExpression:
+		if p, ok := precedences[exp.Operator]; ok {
+			return p
+		}
+		return custom
+	case *ast.ConditionalExpression:
+		return ternary
+	case *ast.PrefixExpression:
+		return prefix
+	case *ast.CallExpression:
+		return call
+	case *ast.IndexExpression, *ast.MemberExpression:
+		return index
+	case *ast.PipelineExpression:
+		return precedence(exp.Original)
+	case *ast.NewExpression:
+		// new の後ろのクラスは呼び出しを含まないので、new の式を入れ子にするときは括弧が要る
+		return prefix
+	case *ast.IntegerLiteral:
+		// 負の整数はマクロが作る。-5 は前置の - として解析し直される
+		if exp.Value < 0 {
+			return prefix
+		}
+	case *ast.BigIntLiteral:
+		if exp.Value.Sign() < 0 {
+			return prefix
+		}
+	}
+	return atom
+}
+
+func (f *formatter) expressions(exps []ast.Expression) {
+	for i, exp := range exps {
+		if i > 0 {
+			f.write(", ")
+		}
+		f.expression(exp, lowest)
+	}
+}
+
+// string は文字列リテラルを書く。" と、読み直すとエスケープになってしまう \ だけをエスケープし、
+// 改行やタブ、正規表現の \d などは書かれたとおりに残す。
+func (f *formatter) string(s string) {
+	var out strings.Builder
+	out.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '"':
+			out.WriteString(`\"`)
+		case s[i] == '\\' && (i+1 == len(s) || strings.IndexByte(`nt"\u`, s[i+1]) >= 0):
+			out.WriteString(`\\`)
+		default:
+			out.WriteByte(s[i])
+		}
+	}
+	out.WriteByte('"')
+	f.write(out.String())
+}
+
+// hash はハッシュリテラルを書く。組はキーを整形した文字列の順に並べる。
+func (f *formatter) hash(hash *ast.HashLiteral) {
+	type pair struct{ key, value string }
+	pairs := make([]pair, 0, len(hash.Pairs))
+	for key, value := range hash.Pairs {
+		pairs = append(pairs, pair{f.sub(key), f.sub(value)})
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].key < pairs[j].key })
+
+	f.write("{")
+	for i, pair := range pairs {
+		if i > 0 {
+			f.write(", ")
+		}
+		f.write(pair.key + ": " + pair.value)
+	}
+	f.write("}")
+}
+
+// sub は exp を今の字下げで整形した文字列を返す
+func (f *formatter) sub(exp ast.Expression) string {
+	sub := &formatter{indent: f.indent}
+	sub.expression(exp, lowest)
+	if sub.err != nil {
+		f.fail("%s", sub.err)
+	}
+	return sub.out.String()
+}
+
+func (f *formatter) match(exp *ast.MatchExpression) {
+	f.write("match (")
+	f.expression(exp.Subject, lowest)
+	f.write(") {")
+	f.indent++
+	for i, arm := range exp.Arms {
+		f.newline()
+		f.pattern(arm.Pattern)
+		f.write(" => ")
+		f.expression(arm.Body, lowest)
+		if i < len(exp.Arms)-1 {
+			f.write(",")
+		}
+	}
+	f.indent--
+	if len(exp.Arms) > 0 {
+		f.newline()
+	}
+	f.write("}")
+}
+
+func (f *formatter) pattern(pattern ast.Pattern) {
+	switch pattern := pattern.(type) {
+	case *ast.WildcardPattern:
+		f.write("_")
+	case *ast.BindingPattern:
+		f.identifier(pattern.Name)
+	case *ast.LiteralPattern:
+		f.expression(pattern.Value, lowest)
+	case *ast.ArrayPattern:
+		f.write("[")
+		for i, element := range pattern.Elements {
+			if i > 0 {
+				f.write(", ")
+			}
+			f.pattern(element)
+		}
+		if pattern.Rest != nil {
+			if len(pattern.Elements) > 0 {
+				f.write(", ")
+			}
+			f.write("...")
+			f.identifier(pattern.Rest)
+		}
+		f.write("]")
+	case *ast.HashPattern:
+		f.write("{")
+		for i, pair := range pattern.Pairs {
+			if i > 0 {
+				f.write(", ")
+			}
+			f.expression(pair.Key, lowest)
+			f.write(": ")
+			f.pattern(pair.Value)
+		}
+		f.write("}")
+	default:
+		f.fail("cannot format pattern %T", pattern)
+	}
+}
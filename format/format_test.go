@@ -0,0 +1,134 @@
+package format
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/al-keio/monkey-go/ast"
+	"github.com/al-keio/monkey-go/conformance"
+	"github.com/al-keio/monkey-go/internal/lexer"
+	"github.com/al-keio/monkey-go/internal/parser"
+	"github.com/al-keio/monkey-go/token"
+)
+
+func parse(t *testing.T, input string) *ast.Program {
+	t.Helper()
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors for %q: %v", input, p.Errors())
+	}
+	return program
+}
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x=1+2*3", "let x = 1 + 2 * 3;"},
+		{"(1 + 2) * 3", "(1 + 2) * 3;"},
+		{"1 - (2 - 3)", "1 - (2 - 3);"},
+		{"(1 - 2) - 3", "1 - 2 - 3;"},
+		{"-(a + b)", "-(a + b);"},
+		{"!-a", "!-a;"},
+		{"(a ? b : c) + 1", "(a ? b : c) + 1;"},
+		{"a ? b : c ? d : e", "a ? b : c ? d : e;"},
+		{"(fn(x) { x })(1)[0]", "fn(x) {\n  x;\n}(1)[0];"},
+		{"a.b.c(1)", "a.b.c(1);"},
+		{"new a.B(1, 2)", "new a.B(1, 2);"},
+		{`{"b": 2, "a": [1, 2]}`, `{"a": [1, 2], "b": 2};`},
+		{"1.50d", "1.50d;"},
+		{"0xFF + 0b1010 * 0o755 + 010", "0xFF + 0b1010 * 0o755 + 8;"},
+		{"123456789012345678901234567890n*0xFFn", "123456789012345678901234567890n * 0xFFn;"},
+		{`"say \"hi\"\t\\n" + "\d\u00e9"`, `"say \"hi\"	\\n" + "\dé";`},
+		{
+			"if (x < 1) { return 1 } else { let y = x; y }",
+			"if (x < 1) {\n  return 1;\n} else {\n  let y = x;\n  y;\n}",
+		},
+		{
+			"let f = fn(a, b) requires a > 0 ensures result > 0 { a + b }",
+			"let f = fn(a, b) requires a > 0 ensures result > 0 {\n  a + b;\n};",
+		},
+		{
+			"match (x) { [a, ...rest] => a, {\"k\": v} => v, -1 => 0, _ => x }",
+			"match (x) {\n  [a, ...rest] => a,\n  {\"k\": v} => v,\n  -1 => 0,\n  _ => x\n};",
+		},
+		{
+			"operator <+> product(a, b) { a + b }\n1 <+> 2 + 3",
+			"operator <+> product(a, b) {\n  a + b;\n}\n(1 <+> 2) + 3;",
+		},
+		{
+			"class P extends Base { init(x) { {\"x\": x} } norm() { self.x } }",
+			"class P extends Base {\n  init(x) {\n    {\"x\": x};\n  }\n  norm() {\n    self.x;\n  }\n}",
+		},
+		{
+			"// head\nlet x = 1; // tail\nfn() {\n  // inner\n}\n// end",
+			"// head\nlet x = 1; // tail\nfn() {\n  // inner\n};\n// end",
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := Source(parse(t, tt.input))
+		if err != nil {
+			t.Errorf("Source(%q) failed: %s", tt.input, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("Source(%q) wrong.\nexpected=%q\ngot=%q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestSourceErrors(t *testing.T) {
+	tests := []struct {
+		node     ast.Node
+		expected string
+	}{
+		{&ast.PrefixExpression{Operator: "-"}, "cannot format a missing expression"},
+		{&ast.LetStatement{Token: token.Token{Type: token.LET, Literal: "let"}, Value: &ast.Boolean{Value: true}}, "cannot format a missing identifier"},
+	}
+
+	for _, tt := range tests {
+		_, err := Source(tt.node)
+		if err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("expected error containing %q, got %v", tt.expected, err)
+		}
+	}
+}
+
+// 整形したソースを解析し直すと同じ木になり、もう一度整形しても変わらないこと
+func TestSourceRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	inputs := []string{}
+	for _, c := range conformance.Corpus {
+		inputs = append(inputs, c.Input)
+	}
+	for i := 0; i < 300; i++ {
+		inputs = append(inputs, conformance.Generate(r, 4).Input)
+	}
+
+	for _, input := range inputs {
+		p := parser.New(lexer.New(input))
+		program := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			continue
+		}
+
+		formatted, err := Source(program)
+		if err != nil {
+			t.Errorf("Source(%q) failed: %s", input, err)
+			continue
+		}
+		reparsed := parse(t, formatted)
+		if ast.Hash(reparsed) != ast.Hash(program) {
+			t.Errorf("formatted program differs from %q:\n%s", input, formatted)
+			continue
+		}
+		if again, _ := Source(reparsed); again != formatted {
+			t.Errorf("formatting is not stable for %q:\nfirst=%q\nsecond=%q", input, formatted, again)
+		}
+	}
+}
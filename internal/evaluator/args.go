@@ -0,0 +1,224 @@
+package evaluator
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/al-keio/monkey-go/object"
+)
+
+// argSpec は parse_args の spec の一項目
+type argSpec struct {
+	name  string
+	typ   string // "string", "int", "decimal", "bool" のいずれか
+	value object.Object
+	help  string
+}
+
+func init() {
+	builtins["args"] = &object.Builtin{
+		Doc:          "args(): the command-line arguments passed to the script, as an array of strings",
+		Capabilities: []object.Capability{object.PROC_CAP},
+		ApplyFn: func(applier object.Applier, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=0", len(args))
+			}
+			return &object.Array{Elements: scriptArgs(applier)}
+		},
+	}
+	builtins["parse_args"] = &object.Builtin{
+		Doc:          "parse_args(spec): parse args() as --name value flags described by spec, a hash of name to {\"type\", \"default\", \"help\"}; returns a hash of the values with the remaining arguments under \"_\", or prints usage and returns null on --help",
+		Capabilities: []object.Capability{object.PROC_CAP, object.IO_CAP},
+		ApplyFn: func(applier object.Applier, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+			spec, ok := args[0].(*object.Hash)
+			if !ok {
+				return newError(object.WRONG_ARGUMENT_TYPE_ERR, "argument to `parse_args` must be HASH, got %s", args[0].Type())
+			}
+			specs, err := parseArgSpecs(spec)
+			if err != nil {
+				return err
+			}
+
+			strs := []string{}
+			for _, arg := range scriptArgs(applier) {
+				strs = append(strs, arg.(*object.String).Value)
+			}
+			for _, s := range strs {
+				if s == "--" {
+					break
+				}
+				if s == "--help" || s == "-h" {
+					return writeOutput(applier, usage(specs))
+				}
+			}
+			return parseArgs(specs, strs)
+		},
+	}
+}
+
+func scriptArgs(applier object.Applier) []object.Object {
+	elements := []object.Object{}
+	if ev, ok := applier.(*evaluation); ok {
+		for _, arg := range ev.options.Args {
+			elements = append(elements, &object.String{Value: arg})
+		}
+	}
+	return elements
+}
+
+func parseArgSpecs(spec *object.Hash) ([]*argSpec, *object.Error) {
+	specs := []*argSpec{}
+	for _, pair := range sortedPairs(spec) {
+		name, ok := pair.Key.(*object.String)
+		if !ok {
+			return nil, newError(object.WRONG_ARGUMENT_TYPE_ERR, "flag names passed to `parse_args` must be STRING, got %s", pair.Key.Type())
+		}
+		options, ok := pair.Value.(*object.Hash)
+		if !ok {
+			return nil, newError(object.WRONG_ARGUMENT_TYPE_ERR, "spec of flag %s must be HASH, got %s", name.Value, pair.Value.Type())
+		}
+
+		s := &argSpec{name: name.Value, typ: "string", value: NULL}
+		if p, ok := options.Get(&object.String{Value: "default"}); ok {
+			s.value = p.Value
+			switch p.Value.(type) {
+			case *object.Integer:
+				s.typ = "int"
+			case *object.Decimal:
+				s.typ = "decimal"
+			case *object.Boolean:
+				s.typ = "bool"
+			}
+		}
+		if p, ok := options.Get(&object.String{Value: "type"}); ok {
+			typ, ok := p.Value.(*object.String)
+			if !ok {
+				return nil, newError(object.WRONG_ARGUMENT_TYPE_ERR, "type of flag %s must be STRING, got %s", name.Value, p.Value.Type())
+			}
+			switch typ.Value {
+			case "string", "int", "decimal", "bool":
+				s.typ = typ.Value
+			default:
+				return nil, newError(object.INVALID_VALUE_ERR, "unknown type %q for flag %s", typ.Value, name.Value)
+			}
+		}
+		if s.typ == "bool" && s.value == NULL {
+			s.value = FALSE
+		}
+		if p, ok := options.Get(&object.String{Value: "help"}); ok {
+			s.help = p.Value.Inspect()
+		}
+		specs = append(specs, s)
+	}
+	return specs, nil
+}
+
+// parseArgs は --name value, --name=value, 真偽値なら --name の形のフラグを読む。
+// フラグ以外の引数と -- 以降の引数は "_" に集める。
+func parseArgs(specs []*argSpec, strs []string) object.Object {
+	byName := map[string]*argSpec{}
+	values := map[string]object.Object{}
+	for _, s := range specs {
+		byName[s.name] = s
+		values[s.name] = s.value
+	}
+
+	rest := []object.Object{}
+	for i := 0; i < len(strs); i++ {
+		arg := strs[i]
+		if arg == "--" {
+			for _, s := range strs[i+1:] {
+				rest = append(rest, &object.String{Value: s})
+			}
+			break
+		}
+		if !strings.HasPrefix(arg, "--") {
+			rest = append(rest, &object.String{Value: arg})
+			continue
+		}
+
+		name, text := arg[2:], ""
+		hasValue := false
+		if eq := strings.IndexByte(name, '='); eq >= 0 {
+			name, text, hasValue = name[:eq], name[eq+1:], true
+		}
+		s, ok := byName[name]
+		if !ok {
+			return newError(object.INVALID_VALUE_ERR, "unknown flag --%s", name)
+		}
+		if !hasValue {
+			if s.typ == "bool" {
+				values[name] = TRUE
+				continue
+			}
+			if i+1 == len(strs) {
+				return newError(object.INVALID_VALUE_ERR, "flag --%s needs a value", name)
+			}
+			i++
+			text = strs[i]
+		}
+
+		value, err := parseArgValue(s, text)
+		if err != nil {
+			return err
+		}
+		values[name] = value
+	}
+
+	result := object.NewHash()
+	for name, value := range values {
+		result.Set(&object.String{Value: name}, value)
+	}
+	result.Set(&object.String{Value: "_"}, &object.Array{Elements: rest})
+	return result
+}
+
+func parseArgValue(s *argSpec, text string) (object.Object, *object.Error) {
+	switch s.typ {
+	case "int":
+		n, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return nil, newError(object.INVALID_VALUE_ERR, "invalid value %q for flag --%s: want an integer", text, s.name)
+		}
+		return &object.Integer{Value: n}, nil
+	case "decimal":
+		r, ok := new(big.Rat).SetString(text)
+		if !ok {
+			return nil, newError(object.INVALID_VALUE_ERR, "invalid value %q for flag --%s: want a decimal", text, s.name)
+		}
+		return &object.Decimal{Value: r}, nil
+	case "bool":
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return nil, newError(object.INVALID_VALUE_ERR, "invalid value %q for flag --%s: want true or false", text, s.name)
+		}
+		return nativeBoolToBooleanObject(b), nil
+	default:
+		return &object.String{Value: text}, nil
+	}
+}
+
+// usage は flag パッケージに倣った書式でフラグの一覧を返す
+func usage(specs []*argSpec) string {
+	var out bytes.Buffer
+	out.WriteString("options:\n")
+	for _, s := range specs {
+		fmt.Fprintf(&out, "  --%s", s.name)
+		if s.typ != "bool" {
+			fmt.Fprintf(&out, " %s", s.typ)
+		}
+		out.WriteString("\n    \t")
+		out.WriteString(s.help)
+		if s.value != NULL && s.value != FALSE && s.value.Inspect() != "" {
+			fmt.Fprintf(&out, " (default %s)", s.value.Inspect())
+		}
+		out.WriteString("\n")
+	}
+	return out.String()
+}
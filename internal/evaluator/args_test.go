@@ -0,0 +1,48 @@
+package evaluator
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParseArgs(t *testing.T) {
+	spec := `{"count": {"default": 1, "help": "times to run"}, "name": {"help": "who"}, "verbose": {"type": "bool"}, "rate": {"type": "decimal"}}`
+
+	tests := []struct {
+		args     []string
+		input    string
+		expected string
+	}{
+		{[]string{"a", "b"}, `args()`, "[a, b]"},
+		{nil, `args()`, "[]"},
+		{nil, `parse_args(` + spec + `)`, "{_: [], count: 1, name: null, rate: null, verbose: false}"},
+		{[]string{"--count", "3", "x", "--verbose", "--name=bob", "--rate", "0.5"}, `parse_args(` + spec + `)`,
+			"{_: [x], count: 3, name: bob, rate: 0.5, verbose: true}"},
+		{[]string{"--verbose=false", "--", "--count"}, `parse_args(` + spec + `)["_"]`, "[--count]"},
+		{[]string{"--count", "many"}, `parse_args(` + spec + `)`, `ERROR: invalid value "many" for flag --count: want an integer`},
+		{[]string{"--bogus"}, `parse_args(` + spec + `)`, "ERROR: unknown flag --bogus"},
+		{[]string{"--name"}, `parse_args(` + spec + `)`, "ERROR: flag --name needs a value"},
+		{nil, `parse_args({"x": {"type": "float"}})`, `ERROR: unknown type "float" for flag x`},
+	}
+
+	for _, tt := range tests {
+		result := evalWithOutput(tt.input, EvalOptions{Args: tt.args})
+		if got := result.Inspect(); got != tt.expected {
+			t.Errorf("%v %s: want=%q, got=%q", tt.args, tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestParseArgsHelp(t *testing.T) {
+	var out bytes.Buffer
+	input := `let opts = parse_args({"count": {"default": 1, "help": "times to run"}, "verbose": {"type": "bool", "help": "say more"}}); opts`
+	result := evalWithOutput(input, EvalOptions{Args: []string{"--help"}, Output: &out})
+
+	if result != NULL {
+		t.Errorf("parse_args should return null on --help, got=%s", result.Inspect())
+	}
+	expected := "options:\n  --count int\n    \ttimes to run (default 1)\n  --verbose\n    \tsay more\n"
+	if out.String() != expected {
+		t.Errorf("usage wrong.\nwant=%q\ngot= %q", expected, out.String())
+	}
+}
@@ -0,0 +1,154 @@
+package evaluator
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/al-keio/monkey-go/ast"
+	"github.com/al-keio/monkey-go/object"
+)
+
+func init() {
+	builtins["ast_match"] = &object.Builtin{
+		Doc: "ast_match(q, pattern): match quoted q against a quoted pattern where _name captures any expression and _ matches one; returns a hash of name to captured quote, or null",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=2", len(args))
+			}
+			quotes := make([]*object.Quote, 2)
+			for i, arg := range args {
+				q, ok := arg.(*object.Quote)
+				if !ok {
+					return newError(object.WRONG_ARGUMENT_TYPE_ERR, "argument %d to `ast_match` must be QUOTE, got %s", i+1, arg.Type())
+				}
+				quotes[i] = q
+			}
+
+			captures := map[string]ast.Node{}
+			if !matchAST(quotes[0].Node, quotes[1].Node, captures) {
+				return NULL
+			}
+
+			hash := object.NewHash()
+			for name, node := range captures {
+				hash.Set(&object.String{Value: name}, &object.Quote{Node: node})
+			}
+			return hash
+		},
+	}
+}
+
+// matchAST は node が pattern と同じ形かどうかを調べ、pattern 中の _name に対応する部分木を captures に入れる。
+// 同じ名前が二度現れたら、同じ式でなければ一致しない。
+func matchAST(node, pattern ast.Node, captures map[string]ast.Node) bool {
+	if ident, ok := pattern.(*ast.Identifier); ok && strings.HasPrefix(ident.Value, "_") {
+		if _, ok := node.(ast.Expression); !ok {
+			return false
+		}
+		name := strings.TrimPrefix(ident.Value, "_")
+		if name == "" {
+			return true
+		}
+		if captured, ok := captures[name]; ok {
+			return captured.String() == node.String()
+		}
+		captures[name] = node
+		return true
+	}
+
+	switch pattern := pattern.(type) {
+	case *ast.ExpressionStatement:
+		n, ok := node.(*ast.ExpressionStatement)
+		return ok && matchAST(n.Expression, pattern.Expression, captures)
+	case *ast.LetStatement:
+		n, ok := node.(*ast.LetStatement)
+		return ok && matchAST(n.Name, pattern.Name, captures) && matchAST(n.Value, pattern.Value, captures)
+	case *ast.ReturnStatement:
+		n, ok := node.(*ast.ReturnStatement)
+		return ok && matchAST(n.ReturnValue, pattern.ReturnValue, captures)
+	case *ast.DeferStatement:
+		n, ok := node.(*ast.DeferStatement)
+		return ok && matchAST(n.Call, pattern.Call, captures)
+	case *ast.ClassStatement:
+		n, ok := node.(*ast.ClassStatement)
+		if !ok || !matchAST(n.Name, pattern.Name, captures) || !matchAST(n.Extends, pattern.Extends, captures) || len(n.Methods) != len(pattern.Methods) {
+			return false
+		}
+		for i, m := range pattern.Methods {
+			if !matchAST(n.Methods[i].Name, m.Name, captures) || !matchAST(n.Methods[i].Function, m.Function, captures) {
+				return false
+			}
+		}
+		return true
+	case *ast.NewExpression:
+		n, ok := node.(*ast.NewExpression)
+		return ok && matchAST(n.Class, pattern.Class, captures) && matchASTs(n.Arguments, pattern.Arguments, captures)
+	case *ast.OperatorStatement:
+		n, ok := node.(*ast.OperatorStatement)
+		return ok && n.Operator == pattern.Operator && n.Precedence == pattern.Precedence && matchAST(n.Function, pattern.Function, captures)
+	case *ast.BlockStatement:
+		n, ok := node.(*ast.BlockStatement)
+		if !ok || n == nil || pattern == nil {
+			return ok && (n == nil) == (pattern == nil)
+		}
+		if len(n.Statements) != len(pattern.Statements) {
+			return false
+		}
+		for i := range pattern.Statements {
+			if !matchAST(n.Statements[i], pattern.Statements[i], captures) {
+				return false
+			}
+		}
+		return true
+	case *ast.PrefixExpression:
+		n, ok := node.(*ast.PrefixExpression)
+		return ok && n.Operator == pattern.Operator && matchAST(n.Right, pattern.Right, captures)
+	case *ast.InfixExpression:
+		n, ok := node.(*ast.InfixExpression)
+		return ok && n.Operator == pattern.Operator &&
+			matchAST(n.Left, pattern.Left, captures) && matchAST(n.Right, pattern.Right, captures)
+	case *ast.ConditionalExpression:
+		n, ok := node.(*ast.ConditionalExpression)
+		return ok && matchAST(n.Condition, pattern.Condition, captures) &&
+			matchAST(n.Consequence, pattern.Consequence, captures) && matchAST(n.Alternative, pattern.Alternative, captures)
+	case *ast.IfExpression:
+		n, ok := node.(*ast.IfExpression)
+		return ok && matchAST(n.Condition, pattern.Condition, captures) &&
+			matchAST(n.Consequence, pattern.Consequence, captures) && matchAST(n.Alternative, pattern.Alternative, captures)
+	case *ast.CallExpression:
+		n, ok := node.(*ast.CallExpression)
+		return ok && matchAST(n.Function, pattern.Function, captures) && matchASTs(n.Arguments, pattern.Arguments, captures)
+	case *ast.IndexExpression:
+		n, ok := node.(*ast.IndexExpression)
+		return ok && matchAST(n.Left, pattern.Left, captures) && matchAST(n.Index, pattern.Index, captures)
+	case *ast.MemberExpression:
+		n, ok := node.(*ast.MemberExpression)
+		return ok && matchAST(n.Object, pattern.Object, captures) && matchAST(n.Member, pattern.Member, captures)
+	case *ast.ArrayLiteral:
+		n, ok := node.(*ast.ArrayLiteral)
+		return ok && matchASTs(n.Elements, pattern.Elements, captures)
+	default:
+		// 識別子やリテラル、関数リテラルなどはそのまま書いたものとして比べる
+		return reflect.TypeOf(node) == reflect.TypeOf(pattern) && astString(node) == astString(pattern)
+	}
+}
+
+func matchASTs(nodes, patterns []ast.Expression, captures map[string]ast.Node) bool {
+	if len(nodes) != len(patterns) {
+		return false
+	}
+	for i := range patterns {
+		if !matchAST(nodes[i], patterns[i], captures) {
+			return false
+		}
+	}
+	return true
+}
+
+// astString は nil でも使える String
+func astString(node ast.Node) string {
+	if node == nil || reflect.ValueOf(node).IsNil() {
+		return ""
+	}
+	return node.String()
+}
@@ -0,0 +1,59 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/al-keio/monkey-go/object"
+)
+
+func TestASTMatch(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`ast_match(quote(a == b + 1), quote(_l == _r))`, "{l: QUOTE(a), r: QUOTE((b + 1))}"},
+		{`ast_match(quote(a == b), quote(_l != _r))`, "null"},
+		{`ast_match(quote(f(1, x)), quote(f(_, _arg)))`, "{arg: QUOTE(x)}"},
+		{`ast_match(quote(f(1, x)), quote(g(_, _arg)))`, "null"},
+		{`ast_match(quote(f(1)), quote(f(_, _)))`, "null"},
+		{`ast_match(quote(x * x), quote(_a * _a))`, "{a: QUOTE(x)}"},
+		{`ast_match(quote(x * y), quote(_a * _a))`, "null"},
+		{`ast_match(quote(if (c) { 1 }), quote(if (_cond) { _body }))`, "{body: QUOTE(1), cond: QUOTE(c)}"},
+		{`ast_match(quote(if (c) { 1 } else { 2 }), quote(if (_cond) { _body }))`, "null"},
+		{`ast_match(quote(h.k[0]), quote(_h.k[_i]))`, "{h: QUOTE(h), i: QUOTE(0)}"},
+		{`ast_match(quote("s"), quote("s"))`, "{}"},
+		{`ast_match(quote(1), 1)`, "ERROR: argument 2 to `ast_match` must be QUOTE, got INTEGER"},
+		{`quote(a + 1) == quote(a + 1)`, "true"},
+		{`quote(a + 1) != quote(a + 2)`, "true"},
+		{`quote(a) < quote(b)`, "ERROR: unknown operator: QUOTE < QUOTE"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestASTMatchInMacro(t *testing.T) {
+	input := `
+let check = macro(cond) {
+	let m = ast_match(cond, quote(_left == _right));
+	if (m) {
+		quote(if (unquote(cond)) { "ok" } else { "expected " + unquote(m["left"]) + " == " + unquote(m["right"]) })
+	} else {
+		quote(unquote(cond))
+	}
+};
+[check(1 + 1 == 2), check("a" == "b"), check(true)]
+`
+	program := testParseProgram(input)
+	macroEnv := object.NewEnvironment()
+	DefineMacros(program, macroEnv)
+	expanded := ExpandMacros(program, macroEnv)
+
+	if evaluated := Eval(expanded, object.NewEnvironment()); evaluated.Inspect() != "[ok, expected a == b, true]" {
+		t.Errorf("got %q", evaluated.Inspect())
+	}
+}
@@ -0,0 +1,34 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/al-keio/monkey-go/internal/lexer"
+	"github.com/al-keio/monkey-go/internal/parser"
+	"github.com/al-keio/monkey-go/object"
+)
+
+// 識別子の参照が多いプログラム
+var identifierBenchmarks = map[string]string{
+	"fib": `
+let fib = fn(n) { if (n < 2) { return n; } fib(n - 1) + fib(n - 2); };
+fib(18);
+`,
+	"closures": `
+let alpha = 1; let beta = 2; let gamma = 3; let delta = 4;
+let make = fn(x) { fn(y) { fn(z) { x + y + z + alpha + beta + gamma + delta } } };
+let loop = fn(i, acc) { if (i == 0) { acc } else { loop(i - 1, acc + make(i)(i)(i)) } };
+loop(2000, 0);
+`,
+}
+
+func BenchmarkIdentifierLookup(b *testing.B) {
+	for name, input := range identifierBenchmarks {
+		program := parser.New(lexer.New(input)).ParseProgram()
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				Eval(program, object.NewEnvironment())
+			}
+		})
+	}
+}
@@ -0,0 +1,627 @@
+package evaluator
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/al-keio/monkey-go/ast"
+	"github.com/al-keio/monkey-go/object"
+)
+
+var builtins = map[string]*object.Builtin{
+	"len": &object.Builtin{
+		Doc: "len(x): length of a string, array or deque",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *object.String:
+				return &object.Integer{Value: int64(len(arg.Value))}
+			case *object.Array:
+				return &object.Integer{Value: int64(len(arg.Elements))}
+			case *object.Deque:
+				return &object.Integer{Value: int64(arg.Len())}
+			default:
+				return newError(object.WRONG_ARGUMENT_TYPE_ERR, "argument to `len` not supported, got %s", arg.Type())
+			}
+		},
+	},
+	"first": &object.Builtin{
+		Doc: "first(array): first element of array, or null",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+			if args[0].Type() != object.ARRAY_OBJ {
+				return newError(object.WRONG_ARGUMENT_TYPE_ERR, "argument to `first` must be Array, got %s", args[0].Type())
+			}
+
+			arr := args[0].(*object.Array)
+			if len(arr.Elements) > 0 {
+				return arr.Elements[0]
+			}
+			return NULL
+		},
+	},
+	"last": &object.Builtin{
+		Doc: "last(array): last element of array, or null",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+			if args[0].Type() != object.ARRAY_OBJ {
+				return newError(object.WRONG_ARGUMENT_TYPE_ERR, "argument to `last` must be Array, got %s", args[0].Type())
+			}
+
+			arr := args[0].(*object.Array)
+			if len(arr.Elements) > 0 {
+				return arr.Elements[len(arr.Elements)-1]
+			}
+			return NULL
+		},
+	},
+	"rest": &object.Builtin{
+		Doc: "rest(array): new array without the first element, or null",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+			if args[0].Type() != object.ARRAY_OBJ {
+				return newError(object.WRONG_ARGUMENT_TYPE_ERR, "argument to `rest` must be Array, got %s", args[0].Type())
+			}
+
+			arr := args[0].(*object.Array)
+			length := len(arr.Elements)
+			if length > 0 {
+				newElements := make([]object.Object, length-1, length-1)
+				copy(newElements, arr.Elements[1:length])
+				return &object.Array{Elements: newElements}
+			}
+			return NULL
+		},
+	},
+	"push": &object.Builtin{
+		Doc: "push(array, x): new array with x appended",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=2", len(args))
+			}
+			if args[0].Type() != object.ARRAY_OBJ {
+				return newError(object.WRONG_ARGUMENT_TYPE_ERR, "argument to `push` must be Array, got %s", args[0].Type())
+			}
+
+			arr := args[0].(*object.Array)
+			length := len(arr.Elements)
+
+			newElements := make([]object.Object, length+1, length+1)
+			copy(newElements, arr.Elements)
+			newElements[length] = args[1]
+
+			return &object.Array{Elements: newElements}
+		},
+	},
+	"sort": &object.Builtin{
+		Doc: "sort(array) / sort(array, less): new array sorted in ascending order, or so that less(a, b) is truthy when a comes before b",
+		ApplyFn: func(applier object.Applier, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=1 or 2", len(args))
+			}
+			if args[0].Type() != object.ARRAY_OBJ {
+				return newError(object.WRONG_ARGUMENT_TYPE_ERR, "argument to `sort` must be Array, got %s", args[0].Type())
+			}
+
+			arr := args[0].(*object.Array)
+			length := len(arr.Elements)
+
+			newElements := make([]object.Object, length, length)
+			copy(newElements, arr.Elements)
+
+			var err *object.Error
+			if len(args) == 2 {
+				// 比較関数がエラーになったら、それ以降は呼ばずに並べ替えを終わらせてエラーを返す
+				sort.SliceStable(newElements, func(i, j int) bool {
+					if err != nil {
+						return false
+					}
+					result := applier.Apply(args[1], newElements[i], newElements[j])
+					if e, ok := result.(*object.Error); ok {
+						err = e
+						return false
+					}
+					return isTruthy(result)
+				})
+				if err != nil {
+					return err
+				}
+				return &object.Array{Elements: newElements}
+			}
+
+			sort.SliceStable(newElements, func(i, j int) bool {
+				result, ok := object.Compare(newElements[i], newElements[j])
+				if !ok && err == nil {
+					err = newError(object.NOT_COMPARABLE_ERR, "cannot compare %s and %s", newElements[i].Type(), newElements[j].Type())
+				}
+				return result < 0
+			})
+			if err != nil {
+				return err
+			}
+
+			return &object.Array{Elements: newElements}
+		},
+	},
+	"decimal": &object.Builtin{
+		Doc: "decimal(x): convert an integer (including a big integer) or string to a decimal",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *object.Decimal:
+				return arg
+			case *object.Integer:
+				return &object.Decimal{Value: new(big.Rat).SetInt64(arg.Value)}
+			case *object.BigInt:
+				return &object.Decimal{Value: new(big.Rat).SetInt(arg.Value)}
+			case *object.String:
+				value, ok := new(big.Rat).SetString(arg.Value)
+				if !ok {
+					return newError(object.INVALID_VALUE_ERR, "could not parse %q as decimal", arg.Value)
+				}
+				return &object.Decimal{Value: value}
+			default:
+				return newError(object.WRONG_ARGUMENT_TYPE_ERR, "argument to `decimal` not supported, got %s", arg.Type())
+			}
+		},
+	},
+	"divmod": &object.Builtin{
+		Doc: "divmod(a, b): [a / b, a % b], the quotient truncated toward zero and the remainder with the sign of a; decimals if either is a decimal",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=2", len(args))
+			}
+			for i, arg := range args {
+				if !isInteger(arg) && arg.Type() != object.DECIMAL_OBJ {
+					return newError(object.WRONG_ARGUMENT_TYPE_ERR, "argument %d to `divmod` must be INTEGER or DECIMAL, got %s", i+1, arg.Type())
+				}
+			}
+
+			if a, ok := args[0].(*object.Integer); ok {
+				if b, ok := args[1].(*object.Integer); ok && !(a.Value == math.MinInt64 && b.Value == -1) {
+					if b.Value == 0 {
+						return newError(object.DIVISION_BY_ZERO_ERR, "division by zero")
+					}
+					return &object.Array{Elements: []object.Object{&object.Integer{Value: a.Value / b.Value}, &object.Integer{Value: a.Value % b.Value}}}
+				}
+			}
+			if isInteger(args[0]) && isInteger(args[1]) {
+				quotient := evalBigIntInfixExpression("/", args[0], args[1])
+				if isError(quotient) {
+					return quotient
+				}
+				return &object.Array{Elements: []object.Object{quotient, evalBigIntInfixExpression("%", args[0], args[1])}}
+			}
+
+			a, _ := toRat(args[0])
+			b, _ := toRat(args[1])
+			if b.Sign() == 0 {
+				return newError(object.DIVISION_BY_ZERO_ERR, "division by zero")
+			}
+			quotient, remainder := divmodRat(a, b)
+			return &object.Array{Elements: []object.Object{&object.Decimal{Value: quotient}, &object.Decimal{Value: remainder}}}
+		},
+	},
+	"assert_eq": &object.Builtin{
+		Doc: "assert_eq(expected, actual): error describing the differences, or null",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=2", len(args))
+			}
+
+			diff := object.Diff(args[0], args[1])
+			if len(diff) == 0 {
+				return NULL
+			}
+			return newError(object.ASSERTION_FAILED_ERR, "assert_eq failed:\n\t%s", strings.Join(diff, "\n\t"))
+		},
+	},
+	"map": &object.Builtin{
+		Doc: "map(array, fn): new array of fn applied to each element",
+		ApplyFn: func(applier object.Applier, args ...object.Object) object.Object {
+			arr, err := arrayAndFunctionArgs("map", args)
+			if err != nil {
+				return err
+			}
+
+			newElements := make([]object.Object, len(arr.Elements))
+			for i, element := range arr.Elements {
+				result := applier.Apply(args[1], element)
+				if isError(result) {
+					return result
+				}
+				newElements[i] = result
+			}
+
+			return &object.Array{Elements: newElements}
+		},
+	},
+	"filter": &object.Builtin{
+		Doc: "filter(array, fn): new array of the elements for which fn is truthy",
+		ApplyFn: func(applier object.Applier, args ...object.Object) object.Object {
+			arr, err := arrayAndFunctionArgs("filter", args)
+			if err != nil {
+				return err
+			}
+
+			newElements := []object.Object{}
+			for _, element := range arr.Elements {
+				result := applier.Apply(args[1], element)
+				if isError(result) {
+					return result
+				}
+				if isTruthy(result) {
+					newElements = append(newElements, element)
+				}
+			}
+
+			return &object.Array{Elements: newElements}
+		},
+	},
+	"help": &object.Builtin{
+		Doc: "help(fn): documentation of a builtin or of a function with a /// doc comment, or null",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			var doc string
+			switch arg := args[0].(type) {
+			case *object.Function:
+				doc = arg.Doc
+			case *object.Builtin:
+				doc = arg.Doc
+			default:
+				return newError(object.WRONG_ARGUMENT_TYPE_ERR, "argument to `help` must be FUNCTION, got %s", arg.Type())
+			}
+
+			if doc == "" {
+				return NULL
+			}
+			return &object.String{Value: doc}
+		},
+	},
+	"freeze": &object.Builtin{
+		Doc: "freeze(x): mark an array or hash and everything in it immutable; returns x",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+			if err := checkFreezable(args[0]); err != nil {
+				return err
+			}
+			freeze(args[0])
+			return args[0]
+		},
+	},
+	"is_frozen": &object.Builtin{
+		Doc: "is_frozen(x): whether x is immutable; true for frozen arrays and hashes and for scalars",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+			return nativeBoolToBooleanObject(isFrozen(args[0]))
+		},
+	},
+	"update_in": &object.Builtin{
+		Doc: "update_in(x, path, fn): copy of x with the value at path replaced by fn(old); missing hash keys give null",
+		ApplyFn: func(applier object.Applier, args ...object.Object) object.Object {
+			if len(args) != 3 {
+				return newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=3", len(args))
+			}
+			path, ok := args[1].(*object.Array)
+			if !ok {
+				return newError(object.WRONG_ARGUMENT_TYPE_ERR, "second argument to `update_in` must be Array, got %s", args[1].Type())
+			}
+			return updateIn(args[0], path.Elements, func(old object.Object) object.Object {
+				return applier.Apply(args[2], old)
+			})
+		},
+	},
+	"assoc_in": &object.Builtin{
+		Doc: "assoc_in(x, path, value): copy of x with value at path, creating hashes for missing keys",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 3 {
+				return newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=3", len(args))
+			}
+			path, ok := args[1].(*object.Array)
+			if !ok {
+				return newError(object.WRONG_ARGUMENT_TYPE_ERR, "second argument to `assoc_in` must be Array, got %s", args[1].Type())
+			}
+			return updateIn(args[0], path.Elements, func(object.Object) object.Object {
+				return args[2]
+			})
+		},
+	},
+	"with_recover": &object.Builtin{
+		Doc: `with_recover(fn, handler): fn(), or handler({"code": ..., "message": ...}) if fn() is an error`,
+		ApplyFn: func(applier object.Applier, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=2", len(args))
+			}
+			for i, arg := range args {
+				switch arg.(type) {
+				case *object.Function, *object.Builtin:
+				default:
+					return newError(object.WRONG_ARGUMENT_TYPE_ERR, "argument %d to `with_recover` must be FUNCTION, got %s", i+1, arg.Type())
+				}
+			}
+
+			result := applier.Apply(args[0])
+			err, ok := result.(*object.Error)
+			if !ok {
+				return result
+			}
+			// エラーをそのまま渡すと handler の中で参照した時点で再び伝播してしまうので、ハッシュにして渡す
+			return applier.Apply(args[1], errorToHash(err))
+		},
+	},
+	"is_error_code": &object.Builtin{
+		Doc: `is_error_code(e, code): whether the error e given to a with_recover handler has the given code, such as "TYPE_MISMATCH"; if e is a function, whether e() is such an error`,
+		ApplyFn: func(applier object.Applier, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=2", len(args))
+			}
+			code, ok := args[1].(*object.String)
+			if !ok {
+				return newError(object.WRONG_ARGUMENT_TYPE_ERR, "second argument to `is_error_code` must be STRING, got %s", args[1].Type())
+			}
+
+			switch e := args[0].(type) {
+			case *object.Hash:
+				// with_recover の handler が受け取る {"code": ..., "message": ...}
+				pair, ok := e.Get(&object.String{Value: "code"})
+				if !ok {
+					return newError(object.WRONG_ARGUMENT_TYPE_ERR, "first argument to `is_error_code` must be an error from with_recover, got a HASH without code")
+				}
+				actual, ok := pair.Value.(*object.String)
+				return nativeBoolToBooleanObject(ok && actual.Value == code.Value)
+			case *object.Function, *object.Builtin:
+				// with_recover と同じく、エラーは伝播させずに関数の中で起きたものとして調べる
+				err, ok := applier.Apply(e).(*object.Error)
+				return nativeBoolToBooleanObject(ok && err.Code == code.Value)
+			default:
+				return newError(object.WRONG_ARGUMENT_TYPE_ERR, "first argument to `is_error_code` must be HASH or FUNCTION, got %s", e.Type())
+			}
+		},
+	},
+	"min": &object.Builtin{
+		Doc: "min(array) / min(args...): smallest value, or null for an empty array",
+		Fn: func(args ...object.Object) object.Object {
+			return extremum("min", -1, args)
+		},
+	},
+	"max": &object.Builtin{
+		Doc: "max(array) / max(args...): largest value, or null for an empty array",
+		Fn: func(args ...object.Object) object.Object {
+			return extremum("max", 1, args)
+		},
+	},
+}
+
+// RegisterBuiltin は name という名前で組み込み関数を追加する。
+// 追加の組み込み関数を提供するパッケージやプラグインの init から呼び出すことを想定している。
+// builtin が nil のときや、同じ名前の組み込み関数がすでにあるときはエラーを返し、何も追加しない。
+func RegisterBuiltin(name string, builtin *object.Builtin) error {
+	if builtin == nil || builtin.Fn == nil && builtin.ApplyFn == nil {
+		return fmt.Errorf("builtin %s has no function", name)
+	}
+	if _, dup := builtins[name]; dup {
+		return fmt.Errorf("builtin %s is already registered", name)
+	}
+	builtins[name] = builtin
+	return nil
+}
+
+// Doc は name が指す関数の説明を返す。env で定義された関数はシグネチャとドキュメントコメントを返す。
+func Doc(name string, env *object.Environment) (string, bool) {
+	if obj, ok := env.Get(name); ok {
+		switch obj := obj.(type) {
+		case *object.Function:
+			signature := name + "(" + joinParameters(obj.Parameters) + ")"
+			if obj.Doc != "" {
+				signature += "\n" + obj.Doc
+			}
+			return signature, true
+		case *object.Macro:
+			return "macro " + name + "(" + joinParameters(obj.Parameters) + ")", true
+		case *object.Builtin:
+			return obj.Doc, obj.Doc != ""
+		default:
+			return "", false
+		}
+	}
+
+	if builtin, ok := builtins[name]; ok && builtin.Doc != "" {
+		return builtin.Doc, true
+	}
+	if member, ok := namespaceMember(name); ok && member.Doc != "" {
+		return member.Doc, true
+	}
+	return "", false
+}
+
+func joinParameters(params []*ast.Identifier) string {
+	names := make([]string, len(params))
+	for i, p := range params {
+		names[i] = p.Value
+	}
+	return strings.Join(names, ", ")
+}
+
+func arrayAndFunctionArgs(name string, args []object.Object) (*object.Array, *object.Error) {
+	if len(args) != 2 {
+		return nil, newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=2", len(args))
+	}
+	arr, ok := args[0].(*object.Array)
+	if !ok {
+		return nil, newError(object.WRONG_ARGUMENT_TYPE_ERR, "argument to `%s` must be Array, got %s", name, args[0].Type())
+	}
+	return arr, nil
+}
+
+// checkFreezable は freeze の前に凍結できない値が含まれていないかを調べる。途中まで凍結された状態を残さないため。
+func checkFreezable(obj object.Object) *object.Error {
+	switch obj := obj.(type) {
+	case *object.Array:
+		for _, element := range obj.Elements {
+			if err := checkFreezable(element); err != nil {
+				return err
+			}
+		}
+	case *object.Hash:
+		for _, pair := range obj.PairList() {
+			if err := checkFreezable(pair.Key); err != nil {
+				return err
+			}
+			if err := checkFreezable(pair.Value); err != nil {
+				return err
+			}
+		}
+	default:
+		if !isFrozen(obj) {
+			return newError(object.WRONG_ARGUMENT_TYPE_ERR, "cannot freeze %s", obj.Type())
+		}
+	}
+	return nil
+}
+
+func freeze(obj object.Object) {
+	switch obj := obj.(type) {
+	case *object.Array:
+		obj.Frozen = true
+		for _, element := range obj.Elements {
+			freeze(element)
+		}
+	case *object.Hash:
+		obj.Frozen = true
+		for _, pair := range obj.PairList() {
+			freeze(pair.Key)
+			freeze(pair.Value)
+		}
+	}
+}
+
+func isFrozen(obj object.Object) bool {
+	switch obj := obj.(type) {
+	case *object.Array:
+		return obj.Frozen
+	case *object.Hash:
+		return obj.Frozen
+	case *object.Integer, *object.BigInt, *object.Decimal, *object.String, *object.Boolean, *object.Null:
+		return true
+	default:
+		return false
+	}
+}
+
+// updateIn は path の先の値を update の結果に置き換えた x の複製を返す。
+// 複製するのは path 上の配列とハッシュだけで、それ以外の要素は元の値と共有する。
+func updateIn(x object.Object, path []object.Object, update func(object.Object) object.Object) object.Object {
+	if len(path) == 0 {
+		return update(x)
+	}
+	key := path[0]
+
+	switch x := x.(type) {
+	case *object.Array:
+		index, ok := key.(*object.Integer)
+		if !ok {
+			return newError(object.WRONG_ARGUMENT_TYPE_ERR, "array index in path must be INTEGER, got %s", key.Type())
+		}
+		if index.Value < 0 || index.Value >= int64(len(x.Elements)) {
+			return newError(object.INVALID_VALUE_ERR, "index %d out of range for array of length %d", index.Value, len(x.Elements))
+		}
+
+		updated := updateIn(x.Elements[index.Value], path[1:], update)
+		if isError(updated) {
+			return updated
+		}
+
+		elements := make([]object.Object, len(x.Elements))
+		copy(elements, x.Elements)
+		elements[index.Value] = updated
+		return &object.Array{Elements: elements}
+	case *object.Hash, *object.Null:
+		if part := object.UnhashablePart(key); part != nil {
+			return newError(object.UNUSABLE_HASH_KEY_ERR, "unusable as hash key: %s", part.Type())
+		}
+
+		copied := object.NewHash()
+		if hash, ok := x.(*object.Hash); ok {
+			for _, pair := range hash.PairList() {
+				copied.Set(pair.Key, pair.Value)
+			}
+		}
+
+		var old object.Object = NULL
+		if pair, ok := copied.Get(key); ok {
+			old = pair.Value
+		}
+
+		updated := updateIn(old, path[1:], update)
+		if isError(updated) {
+			return updated
+		}
+
+		copied.Set(key, updated)
+		return copied
+	default:
+		return newError(object.INDEX_NOT_SUPPORTED_ERR, "index operator not supported: %s", x.Type())
+	}
+}
+
+func errorToHash(err *object.Error) *object.Hash {
+	hash := object.NewHash()
+	for _, field := range []struct{ name, value string }{{"code", err.Code}, {"message", err.Message}} {
+		hash.Set(&object.String{Value: field.name}, &object.String{Value: field.value})
+	}
+	return hash
+}
+
+func extremum(name string, want int, args []object.Object) object.Object {
+	if len(args) == 0 {
+		return newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=0, want=1+")
+	}
+
+	candidates := args
+	if len(args) == 1 {
+		arr, ok := args[0].(*object.Array)
+		if !ok {
+			return newError(object.WRONG_ARGUMENT_TYPE_ERR, "argument to `%s` must be Array, got %s", name, args[0].Type())
+		}
+		candidates = arr.Elements
+	}
+
+	if len(candidates) == 0 {
+		return NULL
+	}
+
+	result := candidates[0]
+	for _, candidate := range candidates[1:] {
+		order, ok := object.Compare(candidate, result)
+		if !ok {
+			return newError(object.NOT_COMPARABLE_ERR, "cannot compare %s and %s", candidate.Type(), result.Type())
+		}
+		if order == want {
+			result = candidate
+		}
+	}
+
+	return result
+}
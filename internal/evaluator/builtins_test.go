@@ -0,0 +1,85 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/al-keio/monkey-go/internal/lexer"
+	"github.com/al-keio/monkey-go/internal/parser"
+	"github.com/al-keio/monkey-go/object"
+)
+
+func TestRegisterBuiltin(t *testing.T) {
+	err := RegisterBuiltin("test_double", &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			return &object.Integer{Value: args[0].(*object.Integer).Value * 2}
+		},
+	})
+	if err != nil {
+		t.Fatalf("RegisterBuiltin failed: %s", err)
+	}
+	defer delete(builtins, "test_double")
+
+	testIntegerObject(t, testEval("test_double(21)"), 42)
+
+	tests := []struct {
+		name     string
+		builtin  *object.Builtin
+		expected string
+	}{
+		{"len", builtins["puts"], "builtin len is already registered"},
+		{"test_nil", nil, "builtin test_nil has no function"},
+		{"test_empty", &object.Builtin{Doc: "no function"}, "builtin test_empty has no function"},
+	}
+	for _, tt := range tests {
+		previous := builtins[tt.name]
+		err := RegisterBuiltin(tt.name, tt.builtin)
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("RegisterBuiltin(%q): expected error %q, got %v", tt.name, tt.expected, err)
+		}
+		if builtins[tt.name] != previous {
+			t.Errorf("RegisterBuiltin(%q) changed the builtins after failing", tt.name)
+		}
+	}
+}
+
+func TestDoc(t *testing.T) {
+	env := object.NewEnvironment()
+	program := parser.New(lexer.New("let add = fn(a, b) { a + b }; let len = fn(x) { 0 }; let n = 1;")).ParseProgram()
+	Eval(program, env)
+
+	tests := []struct {
+		name     string
+		expected string
+		ok       bool
+	}{
+		{"add", "add(a, b)", true},
+		{"len", "len(x)", true},
+		{"first", "first(array): first element of array, or null", true},
+		{"is_error_code", `is_error_code(e, code): whether the error e given to a with_recover handler has the given code, such as "TYPE_MISMATCH"; if e is a function, whether e() is such an error`, true},
+		{"run_loop", "run_loop(): run scheduled timers until none are left", true},
+		{"string.split", "string.split(s, sep): array of the substrings of s separated by sep", true},
+		{"n", "", false},
+		{"missing", "", false},
+		{"string.missing", "", false},
+	}
+
+	for _, tt := range tests {
+		doc, ok := Doc(tt.name, env)
+		if doc != tt.expected || ok != tt.ok {
+			t.Errorf("Doc(%q) = (%q, %t), want (%q, %t)", tt.name, doc, ok, tt.expected, tt.ok)
+		}
+	}
+
+	for name, builtin := range builtins {
+		if builtin.Doc == "" {
+			t.Errorf("builtin %s has no Doc", name)
+		}
+	}
+	for _, namespace := range namespaces {
+		for name, builtin := range namespace.Members {
+			if builtin.Doc == "" {
+				t.Errorf("builtin %s.%s has no Doc", namespace.Name, name)
+			}
+		}
+	}
+}
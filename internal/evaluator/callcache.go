@@ -0,0 +1,46 @@
+package evaluator
+
+import (
+	"github.com/al-keio/monkey-go/ast"
+	"github.com/al-keio/monkey-go/object"
+)
+
+// callee は呼び出し node の関数を評価する。obj.name(...) の形なら method で評価する。
+// 関数が名前で、前回その位置で名前を引いたときと外側の環境の列が同じでどれも束縛を変えていなければ、
+// 前回の結果を使って環境を引き直さない。再帰や map に渡した関数のように、同じ関数の本体から
+// 同じ関数や組み込み関数を繰り返し呼ぶ場合に効く。
+func (ev *evaluation) callee(node *ast.CallExpression, env *object.Environment) object.Object {
+	if member, ok := node.Function.(*ast.MemberExpression); ok {
+		return ev.method(member, env)
+	}
+	ident, ok := node.Function.(*ast.Identifier)
+	if !ok {
+		return ev.eval(node.Function, env)
+	}
+	// ev.eval(ident, env) と同じだけステップを数える
+	if err := ev.step(); err != nil {
+		return err
+	}
+
+	if r, ok := ev.calls[node]; ok {
+		if obj, found, valid := r.Lookup(env); valid {
+			ev.stats.CallCacheHits++
+			if found {
+				return obj
+			}
+			return evalUnboundIdentifier(ident, env)
+		}
+	}
+
+	obj, found, r := env.Resolve(ident.ID())
+	if r != nil {
+		if ev.calls == nil {
+			ev.calls = map[*ast.CallExpression]*object.Resolution{}
+		}
+		ev.calls[node] = r
+	}
+	if found {
+		return obj
+	}
+	return evalUnboundIdentifier(ident, env)
+}
@@ -0,0 +1,46 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/al-keio/monkey-go/internal/lexer"
+	"github.com/al-keio/monkey-go/internal/parser"
+	"github.com/al-keio/monkey-go/object"
+)
+
+func TestCallCacheInvalidation(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		// 呼ばれる関数を束縛し直す
+		{`let f = fn() { 1 }; let g = fn() { f() }; let a = g(); let f = fn() { 2 }; [a, g()]`, "[1, 2]"},
+		// 呼び出し位置のある環境だけが名前を束縛する
+		{`let f = fn() { 1 }; let h = fn(c) { if (c) { let f = fn() { 2 }; } f() }; [h(false), h(true), h(false)]`, "[1, 2, 1]"},
+		// 途中の環境に後から同じ名前を束縛する
+		{`let f = fn() { 1 }; let mk = fn() { let inner = fn() { f() }; let r = inner(); let f = fn() { 2 }; [r, inner()] }; mk()`, "[1, 2]"},
+		// 組み込み関数を同じ名前の関数で隠す
+		{`let g = fn() { len([1]) }; let a = g(); let len = fn(x) { 42 }; [a, g()]`, "[1, 42]"},
+		// 別の環境から作った同じ関数リテラルの呼び出し
+		{`let mk = fn(f) { fn() { f() } }; [mk(fn() { 1 })(), mk(fn() { 2 })()]`, "[1, 2]"},
+		{`let g = fn() { nope() }; g(); g()`, "ERROR: identifier not found: nope"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestCallCacheHits(t *testing.T) {
+	input := `let fib = fn(n) { if (n < 2) { return n; } fib(n - 1) + fib(n - 2) }; fib(10)`
+	program := parser.New(lexer.New(input)).ParseProgram()
+
+	cached := EvalWithStats(program, object.NewEnvironment())
+	testIntegerObject(t, cached.Value, 55)
+	if cached.Stats.CallCacheHits == 0 {
+		t.Errorf("expected call cache hits for recursive calls")
+	}
+}
@@ -0,0 +1,58 @@
+package evaluator
+
+import (
+	"github.com/al-keio/monkey-go/analysis"
+	"github.com/al-keio/monkey-go/ast"
+	"github.com/al-keio/monkey-go/object"
+	"github.com/al-keio/monkey-go/symbol"
+)
+
+// closureEnv は関数リテラル fl を env で評価してできる関数が保持する環境を返す。
+// EvalOptions.CaptureFreeVariables が true なら、本体が参照する変数だけを写した環境を作る。
+// まだ束縛されていない名前 (ローカルな再帰関数など) を参照していれば env をそのまま使う。
+func (ev *evaluation) closureEnv(fl *ast.FunctionLiteral, env *object.Environment) *object.Environment {
+	if !ev.options.CaptureFreeVariables {
+		return env
+	}
+
+	ids := ev.freeVariables(fl)
+	for _, id := range ids {
+		// eval に渡す文字列がどの変数を読むかは分からない
+		if id == evalSymbol {
+			return env
+		}
+	}
+
+	captured, unbound := env.Capture(ids)
+	for _, id := range unbound {
+		name := id.String()
+		if _, ok := builtins[name]; ok {
+			continue
+		}
+		if _, ok := namespaces[name]; ok {
+			continue
+		}
+		return env
+	}
+	return captured
+}
+
+var evalSymbol = symbol.Intern("eval")
+
+func (ev *evaluation) freeVariables(fl *ast.FunctionLiteral) []symbol.ID {
+	if ids, ok := ev.captures[fl]; ok {
+		return ids
+	}
+
+	names := analysis.FreeVariables(fl)
+	ids := make([]symbol.ID, len(names))
+	for i, name := range names {
+		ids[i] = symbol.Intern(name)
+	}
+
+	if ev.captures == nil {
+		ev.captures = map[*ast.FunctionLiteral][]symbol.ID{}
+	}
+	ev.captures[fl] = ids
+	return ids
+}
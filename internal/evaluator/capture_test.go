@@ -0,0 +1,55 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/al-keio/monkey-go/internal/lexer"
+	"github.com/al-keio/monkey-go/internal/parser"
+	"github.com/al-keio/monkey-go/object"
+)
+
+func evalCapturing(input string) object.Object {
+	program := parser.New(lexer.New(input)).ParseProgram()
+	return EvalWithOptions(program, object.NewEnvironment(), EvalOptions{CaptureFreeVariables: true}).Value
+}
+
+func TestCaptureFreeVariables(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let adder = fn(x) { fn(y) { x + y } }; adder(2)(3)`, "5"},
+		{`let f = fn() { let fact = fn(n) { if (n < 2) { 1 } else { n * fact(n - 1) } }; fact(5) }; f()`, "120"},
+		{`let f = fn() { let g = fn() { later() }; g() }; let later = fn() { 7 }; f()`, "7"},
+		{`let f = fn(xs) { fn() { len(xs) } }; f([1, 2])()`, "2"},
+		{`let f = fn() { let len = fn(x) { 0 }; fn() { len([1]) } }; f()()`, "0"},
+		{`let f = fn(s) { fn() { string.split(s, ",") } }; f("a,b")()`, `[a, b]`},
+		{`let x = 1; let f = fn() { fn() { x } }; let g = f(); let x = 2; g()`, "2"},
+	}
+
+	for _, tt := range tests {
+		if got := evalCapturing(tt.input).Inspect(); got != tt.expected {
+			t.Errorf("%s: want=%q, got=%q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestCaptureDropsUnreferencedBindings(t *testing.T) {
+	input := `let f = fn() { let big = [1, 2, 3]; let small = 1; fn() { small } }; f()`
+
+	fn, ok := evalCapturing(input).(*object.Function)
+	if !ok {
+		t.Fatalf("object is not Function")
+	}
+	if _, ok := fn.Env.Get("small"); !ok {
+		t.Errorf("referenced binding was not captured")
+	}
+	if _, ok := fn.Env.Get("big"); ok {
+		t.Errorf("unreferenced binding was captured")
+	}
+
+	fn = testEval(input).(*object.Function)
+	if _, ok := fn.Env.Get("big"); !ok {
+		t.Errorf("without CaptureFreeVariables the defining environment should be kept")
+	}
+}
@@ -0,0 +1,24 @@
+package evaluator
+
+import (
+	"github.com/al-keio/monkey-go/ast"
+	"github.com/al-keio/monkey-go/object"
+)
+
+// checkContract は conds を順に env で評価し、偽になったものがあれば CONTRACT_VIOLATION のエラーを返す。
+// kind は "precondition" か "postcondition"。EvalOptions.DisableContracts なら何もしない。
+func (ev *evaluation) checkContract(kind string, conds []ast.Expression, env *object.Environment) object.Object {
+	if ev.options.DisableContracts {
+		return nil
+	}
+	for _, cond := range conds {
+		val := ev.eval(cond, env)
+		if isError(val) {
+			return val
+		}
+		if !isTruthy(val) {
+			return newError(object.CONTRACT_VIOLATION_ERR, "%s failed: %s", kind, cond.String())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/al-keio/monkey-go/object"
+)
+
+func TestContracts(t *testing.T) {
+	define := `let half = fn(x) requires x % 2 == 0 ensures result * 2 == x { x / 2 };
+let wrong = fn(x) ensures result > x { x - 1 };
+`
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{define + "half(4)", "2"},
+		{define + "half(3)", "ERROR: precondition failed: ((x % 2) == 0)"},
+		{define + "wrong(1)", "ERROR: postcondition failed: (result > x)"},
+		{define + "with_recover(fn() { half(3) }, fn(e) { is_error_code(e, \"CONTRACT_VIOLATION\") })", "true"},
+		{"let f = fn(x) requires y { x }; f(1)", "ERROR: identifier not found: y"},
+		{"let f = fn() ensures result == 1 { return 1; 2 }; f()", "1"},
+		{"let f = fn() ensures false { 1 / 0 }; f()", "ERROR: division by zero"},
+	}
+
+	for _, tt := range tests {
+		if got := testEval(tt.input).Inspect(); got != tt.expected {
+			t.Errorf("%s: want=%q, got=%q", tt.input, tt.expected, got)
+		}
+	}
+
+	result := evalWithOutput(define+"half(3)", EvalOptions{DisableContracts: true})
+	testIntegerObject(t, result, 1)
+
+	if err, ok := testEval(define + "wrong(1)").(*object.Error); !ok || err.Code != object.CONTRACT_VIOLATION_ERR {
+		t.Errorf("expected a CONTRACT_VIOLATION error, got=%v", err)
+	}
+}
@@ -0,0 +1,130 @@
+package evaluator
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/al-keio/monkey-go/object"
+)
+
+func init() {
+	namespaces["crypto"] = &object.Namespace{
+		Name: "crypto",
+		Members: map[string]*object.Builtin{
+			"sha256": &object.Builtin{
+				Doc: "crypto.sha256(data): SHA-256 digest of a string or an array of bytes, as lower-case hex",
+				Fn: func(args ...object.Object) object.Object {
+					return digest("crypto.sha256", args, func(data []byte) []byte {
+						sum := sha256.Sum256(data)
+						return sum[:]
+					})
+				},
+			},
+			"md5": &object.Builtin{
+				Doc: "crypto.md5(data): MD5 digest of a string or an array of bytes, as lower-case hex (for checksums, not security)",
+				Fn: func(args ...object.Object) object.Object {
+					return digest("crypto.md5", args, func(data []byte) []byte {
+						sum := md5.Sum(data)
+						return sum[:]
+					})
+				},
+			},
+			"hmac_sha256": &object.Builtin{
+				Doc: "crypto.hmac_sha256(key, message): HMAC-SHA256 of message with key, as lower-case hex",
+				Fn: func(args ...object.Object) object.Object {
+					if len(args) != 2 {
+						return newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=2", len(args))
+					}
+					key, err := bytesArg("crypto.hmac_sha256", 1, args[0])
+					if err != nil {
+						return err
+					}
+					message, err := bytesArg("crypto.hmac_sha256", 2, args[1])
+					if err != nil {
+						return err
+					}
+					mac := hmac.New(sha256.New, key)
+					mac.Write(message)
+					return &object.String{Value: hex.EncodeToString(mac.Sum(nil))}
+				},
+			},
+			"base64_encode": &object.Builtin{
+				Doc: "crypto.base64_encode(data): standard base64 encoding of a string or an array of bytes",
+				Fn: func(args ...object.Object) object.Object {
+					if len(args) != 1 {
+						return newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=1", len(args))
+					}
+					data, err := bytesArg("crypto.base64_encode", 1, args[0])
+					if err != nil {
+						return err
+					}
+					return &object.String{Value: base64.StdEncoding.EncodeToString(data)}
+				},
+			},
+			"base64_decode": &object.Builtin{
+				Doc: "crypto.base64_decode(s): the string decoded from standard base64",
+				Fn: func(args ...object.Object) object.Object {
+					strs, err := stringArgs("crypto.base64_decode", 1, args)
+					if err != nil {
+						return err
+					}
+					data, decodeErr := base64.StdEncoding.DecodeString(strs[0])
+					if decodeErr != nil {
+						return newError(object.INVALID_VALUE_ERR, "crypto.base64_decode: %s", decodeErr)
+					}
+					return &object.String{Value: string(data)}
+				},
+			},
+			"uuid": &object.Builtin{
+				Doc: "crypto.uuid(): a random (version 4) UUID string",
+				Fn: func(args ...object.Object) object.Object {
+					if len(args) != 0 {
+						return newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=0", len(args))
+					}
+					var u [16]byte
+					if _, err := rand.Read(u[:]); err != nil {
+						return newError(object.HOST_ERR, "crypto.uuid: %s", err)
+					}
+					u[6] = u[6]&0x0f | 0x40
+					u[8] = u[8]&0x3f | 0x80
+					return &object.String{Value: fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16])}
+				},
+			},
+		},
+	}
+}
+
+func digest(name string, args []object.Object, sum func([]byte) []byte) object.Object {
+	if len(args) != 1 {
+		return newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=1", len(args))
+	}
+	data, err := bytesArg(name, 1, args[0])
+	if err != nil {
+		return err
+	}
+	return &object.String{Value: hex.EncodeToString(sum(data))}
+}
+
+// bytesArg は文字列をそのバイト列として、整数の配列を 0 から 255 のバイトの並びとして読む
+func bytesArg(name string, position int, arg object.Object) ([]byte, *object.Error) {
+	switch arg := arg.(type) {
+	case *object.String:
+		return []byte(arg.Value), nil
+	case *object.Array:
+		data := make([]byte, len(arg.Elements))
+		for i, element := range arg.Elements {
+			n, ok := element.(*object.Integer)
+			if !ok || n.Value < 0 || n.Value > 255 {
+				return nil, newError(object.INVALID_VALUE_ERR, "argument %d to `%s` must be an array of bytes (0 to 255), got %s at index %d", position, name, element.Inspect(), i)
+			}
+			data[i] = byte(n.Value)
+		}
+		return data, nil
+	}
+	return nil, newError(object.WRONG_ARGUMENT_TYPE_ERR, "argument %d to `%s` must be STRING or ARRAY, got %s", position, name, arg.Type())
+}
@@ -0,0 +1,38 @@
+package evaluator
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestCrypto(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`crypto.sha256("abc")`, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{`crypto.sha256([0, 255])`, "06eb7d6a69ee19e5fbdf749018d3d2abfa04bcbd1365db312eb86dc7169389b8"},
+		{`crypto.md5("monkey")`, "d0763edaa9d9bd2a9516280e9044d885"},
+		{`crypto.hmac_sha256("key", "The quick brown fox jumps over the lazy dog")`, "f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"},
+		{`crypto.base64_encode("monkey")`, "bW9ua2V5"},
+		{`crypto.base64_encode([0, 255, 1])`, "AP8B"},
+		{`crypto.base64_decode(crypto.base64_encode("monkey"))`, "monkey"},
+		{`crypto.uuid() == crypto.uuid()`, "false"},
+		{`crypto.sha256(1)`, "ERROR: argument 1 to `crypto.sha256` must be STRING or ARRAY, got INTEGER"},
+		{`crypto.md5([1, 256])`, "ERROR: argument 1 to `crypto.md5` must be an array of bytes (0 to 255), got 256 at index 1"},
+		{`crypto.base64_decode("***")`, "ERROR: crypto.base64_decode: illegal base64 data at input byte 0"},
+		{`crypto.hmac_sha256("key")`, "ERROR: wrong number of arguments. got=1, want=2"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+
+	uuid := testEval(`crypto.uuid()`).Inspect()
+	if !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(uuid) {
+		t.Errorf("crypto.uuid() is not a version 4 UUID: %q", uuid)
+	}
+}
@@ -0,0 +1,250 @@
+package evaluator
+
+import (
+	"bytes"
+	"encoding/csv"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/al-keio/monkey-go/object"
+)
+
+func init() {
+	builtins["csv_parse"] = &object.Builtin{
+		Doc: "csv_parse(str[, opts]): parse CSV text; with a header row returns an array of hashes keyed by the header, otherwise an array of arrays. " +
+			"opts: \"header\" (true/false, detected when omitted), \"separator\" (default \",\"), \"numbers\" (convert numeric fields, default false)",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=1 or 2", len(args))
+			}
+			text, ok := args[0].(*object.String)
+			if !ok {
+				return newError(object.WRONG_ARGUMENT_TYPE_ERR, "argument to `csv_parse` must be STRING, got %s", args[0].Type())
+			}
+			opts, err := csvOptions("csv_parse", args[1:])
+			if err != nil {
+				return err
+			}
+			return parseCSV(text.Value, opts)
+		},
+	}
+	builtins["csv_stringify"] = &object.Builtin{
+		Doc: "csv_stringify(rows[, opts]): CSV text of an array of arrays, or of an array of hashes with a header row. " +
+			"opts: \"columns\" (column order for hashes, sorted keys by default), \"header\" (default true), \"separator\" (default \",\")",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=1 or 2", len(args))
+			}
+			rows, ok := args[0].(*object.Array)
+			if !ok {
+				return newError(object.WRONG_ARGUMENT_TYPE_ERR, "argument to `csv_stringify` must be ARRAY, got %s", args[0].Type())
+			}
+			opts, err := csvOptions("csv_stringify", args[1:])
+			if err != nil {
+				return err
+			}
+			return stringifyCSV(rows.Elements, opts)
+		},
+	}
+}
+
+type csvOpts struct {
+	header    object.Object // TRUE, FALSE または未指定の nil
+	separator rune
+	numbers   bool
+	columns   []string
+}
+
+func csvOptions(name string, args []object.Object) (*csvOpts, *object.Error) {
+	opts := &csvOpts{separator: ','}
+	if len(args) == 0 {
+		return opts, nil
+	}
+	hash, ok := args[0].(*object.Hash)
+	if !ok {
+		return nil, newError(object.WRONG_ARGUMENT_TYPE_ERR, "options for `%s` must be HASH, got %s", name, args[0].Type())
+	}
+
+	for _, pair := range sortedPairs(hash) {
+		key, ok := pair.Key.(*object.String)
+		if !ok {
+			return nil, newError(object.WRONG_ARGUMENT_TYPE_ERR, "option names for `%s` must be STRING, got %s", name, pair.Key.Type())
+		}
+		switch key.Value {
+		case "header", "numbers":
+			b, ok := pair.Value.(*object.Boolean)
+			if !ok {
+				return nil, newError(object.WRONG_ARGUMENT_TYPE_ERR, "option %s for `%s` must be BOOLEAN, got %s", key.Value, name, pair.Value.Type())
+			}
+			if key.Value == "header" {
+				opts.header = b
+			} else {
+				opts.numbers = b.Value
+			}
+		case "separator":
+			s, ok := pair.Value.(*object.String)
+			if !ok || utf8.RuneCountInString(s.Value) != 1 {
+				return nil, newError(object.INVALID_VALUE_ERR, "option separator for `%s` must be a one-character string, got %s", name, pair.Value.Inspect())
+			}
+			opts.separator, _ = utf8.DecodeRuneInString(s.Value)
+		case "columns":
+			columns, ok := pair.Value.(*object.Array)
+			if !ok {
+				return nil, newError(object.WRONG_ARGUMENT_TYPE_ERR, "option columns for `%s` must be ARRAY, got %s", name, pair.Value.Type())
+			}
+			for _, column := range columns.Elements {
+				s, ok := column.(*object.String)
+				if !ok {
+					return nil, newError(object.WRONG_ARGUMENT_TYPE_ERR, "columns for `%s` must be STRING, got %s", name, column.Type())
+				}
+				opts.columns = append(opts.columns, s.Value)
+			}
+		default:
+			return nil, newError(object.INVALID_VALUE_ERR, "unknown option %q for `%s`", key.Value, name)
+		}
+	}
+	return opts, nil
+}
+
+func parseCSV(text string, opts *csvOpts) object.Object {
+	r := csv.NewReader(strings.NewReader(text))
+	r.Comma = opts.separator
+	records, e := r.ReadAll()
+	if e != nil {
+		return newError(object.INVALID_VALUE_ERR, "csv_parse: %s", e)
+	}
+
+	header := opts.header == TRUE || opts.header == nil && looksLikeHeader(records)
+	rows := []object.Object{}
+	if !header {
+		for _, record := range records {
+			fields := make([]object.Object, len(record))
+			for i, field := range record {
+				fields[i] = csvField(field, opts.numbers)
+			}
+			rows = append(rows, &object.Array{Elements: fields})
+		}
+		return &object.Array{Elements: rows}
+	}
+
+	if len(records) == 0 {
+		return &object.Array{Elements: rows}
+	}
+	for _, record := range records[1:] {
+		row := object.NewHash()
+		for i, name := range records[0] {
+			row.Set(&object.String{Value: name}, csvField(record[i], opts.numbers))
+		}
+		rows = append(rows, row)
+	}
+	return &object.Array{Elements: rows}
+}
+
+// looksLikeHeader は先頭の行が空でなく重複もない数値でない欄だけからなれば見出しとみなす。
+// すべて文字列の表は見出しがあるとみなすので、見出しのない表には header: false を渡すこと。
+func looksLikeHeader(records [][]string) bool {
+	if len(records) == 0 {
+		return false
+	}
+	seen := map[string]bool{}
+	for _, field := range records[0] {
+		if field == "" || seen[field] || isNumeric(field) {
+			return false
+		}
+		seen[field] = true
+	}
+	return true
+}
+
+func isNumeric(s string) bool {
+	if _, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return true
+	}
+	_, ok := new(big.Rat).SetString(s)
+	return ok && !strings.ContainsAny(s, "/eE")
+}
+
+func csvField(field string, numbers bool) object.Object {
+	if !numbers {
+		return &object.String{Value: field}
+	}
+	if n, err := strconv.ParseInt(field, 10, 64); err == nil {
+		return &object.Integer{Value: n}
+	}
+	if isNumeric(field) {
+		r, _ := new(big.Rat).SetString(field)
+		return &object.Decimal{Value: r}
+	}
+	return &object.String{Value: field}
+}
+
+func stringifyCSV(rows []object.Object, opts *csvOpts) object.Object {
+	var out bytes.Buffer
+	w := csv.NewWriter(&out)
+	w.Comma = opts.separator
+
+	columns := opts.columns
+	if columns == nil {
+		columns = hashColumns(rows)
+	}
+	if len(columns) != 0 && opts.header != FALSE {
+		w.Write(columns)
+	}
+
+	for i, row := range rows {
+		var record []string
+		switch row := row.(type) {
+		case *object.Array:
+			for _, field := range row.Elements {
+				record = append(record, csvText(field))
+			}
+		case *object.Hash:
+			for _, column := range columns {
+				var field object.Object = NULL
+				if pair, ok := row.Get(&object.String{Value: column}); ok {
+					field = pair.Value
+				}
+				record = append(record, csvText(field))
+			}
+		default:
+			return newError(object.WRONG_ARGUMENT_TYPE_ERR, "row %d passed to `csv_stringify` must be ARRAY or HASH, got %s", i, row.Type())
+		}
+		w.Write(record)
+	}
+	w.Flush()
+	return &object.String{Value: out.String()}
+}
+
+// hashColumns はハッシュの行にある文字列のキーをすべて集め、並べて返す
+func hashColumns(rows []object.Object) []string {
+	seen := map[string]bool{}
+	columns := []string{}
+	for _, row := range rows {
+		hash, ok := row.(*object.Hash)
+		if !ok {
+			continue
+		}
+		for _, pair := range hash.PairList() {
+			if name, ok := pair.Key.(*object.String); ok && !seen[name.Value] {
+				seen[name.Value] = true
+				columns = append(columns, name.Value)
+			}
+		}
+	}
+	sort.Strings(columns)
+	return columns
+}
+
+func csvText(obj object.Object) string {
+	switch obj := obj.(type) {
+	case *object.String:
+		return obj.Value
+	case *object.Null:
+		return ""
+	default:
+		return obj.Inspect()
+	}
+}
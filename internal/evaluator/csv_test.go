@@ -0,0 +1,63 @@
+package evaluator
+
+import "testing"
+
+func TestCSVParse(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`csv_parse("name,age
+alice,30
+bob,25")`, "[{age: 30, name: alice}, {age: 25, name: bob}]"},
+		{`csv_parse("name,age
+alice,30", {"numbers": true})[0]["age"] + 1`, "31"},
+		{`csv_parse("1,2
+3,4")`, "[[1, 2], [3, 4]]"},
+		{`csv_parse("1,2.5,x", {"numbers": true})[0][1] + 1`, "3.5"},
+		{`csv_parse("a,b
+c,d", {"header": false})`, "[[a, b], [c, d]]"},
+		{`csv_parse("x,x
+1,2")`, "[[x, x], [1, 2]]"},
+		{`csv_parse("a;b
+1;2,3", {"separator": ";"})`, "[{a: 1, b: 2,3}]"},
+		{`csv_parse("")`, "[]"},
+		{`csv_parse("a,b
+1")`, "ERROR: csv_parse: record on line 2: wrong number of fields"},
+		{`csv_parse("a", {"separator": ";;"})`, "ERROR: option separator for `csv_parse` must be a one-character string, got ;;"},
+		{`csv_parse("a", {"headers": true})`, "ERROR: unknown option \"headers\" for `csv_parse`"},
+		{`csv_parse(1)`, "ERROR: argument to `csv_parse` must be STRING, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestCSVStringify(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`csv_stringify([[1, "a"], [2, "b,c"]])`, "1,a\n2,\"b,c\"\n"},
+		{`csv_stringify([{"name": "alice", "age": 30}, {"name": "bob"}])`, "age,name\n30,alice\n,bob\n"},
+		{`csv_stringify([{"a": 1, "b": 2}], {"columns": ["b", "a"]})`, "b,a\n2,1\n"},
+		{`csv_stringify([{"a": "x|y", "b": 1}], {"header": false, "separator": "|"})`, "\"x|y\"|1\n"},
+		{`csv_stringify([])`, ""},
+		{`csv_stringify([1])`, "ERROR: row 0 passed to `csv_stringify` must be ARRAY or HASH, got INTEGER"},
+		{`let text = "x,y
+1,2
+3,4"; csv_stringify(csv_parse(text)) == text + "
+"`, "true"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
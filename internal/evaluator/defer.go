@@ -0,0 +1,41 @@
+package evaluator
+
+import (
+	"github.com/al-keio/monkey-go/ast"
+	"github.com/al-keio/monkey-go/object"
+)
+
+// deferred は defer 文で登録した式と、それを評価する環境
+type deferred struct {
+	call ast.Expression
+	env  *object.Environment
+}
+
+// pushFrame は関数呼び出し (トップレベルではプログラム全体) の defer を溜める枠を積む
+func (ev *evaluation) pushFrame() {
+	ev.frames = append(ev.frames, nil)
+}
+
+// popFrame は一番上の枠を降ろし、そこに登録された式を後から登録したものから順に評価する。
+// result がエラーでなく、defer した式がエラーになれば、最初のエラーを結果にする。
+func (ev *evaluation) popFrame(result object.Object) object.Object {
+	frame := ev.frames[len(ev.frames)-1]
+	ev.frames = ev.frames[:len(ev.frames)-1]
+
+	for i := len(frame) - 1; i >= 0; i-- {
+		val := ev.eval(frame[i].call, frame[i].env)
+		if isError(val) && !isError(result) {
+			result = val
+		}
+	}
+	return result
+}
+
+func (ev *evaluation) evalDeferStatement(node *ast.DeferStatement, env *object.Environment) object.Object {
+	if len(ev.frames) == 0 {
+		return newError(object.INVALID_VALUE_ERR, "defer outside of a function")
+	}
+	top := len(ev.frames) - 1
+	ev.frames[top] = append(ev.frames[top], deferred{call: node.Call, env: env})
+	return nil
+}
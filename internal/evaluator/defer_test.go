@@ -0,0 +1,32 @@
+package evaluator
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDeferStatements(t *testing.T) {
+	tests := []struct {
+		input          string
+		expected       string
+		expectedOutput string
+	}{
+		{`let f = fn() { defer puts(1); defer puts(2); puts(3); 4 }; f()`, "4", "3\n2\n1\n"},
+		{`let f = fn(x) { defer puts(x); if (x > 0) { return x * 2; } puts("no") }; f(1) + f(0)`, "ERROR: type mismatch: INTEGER + NULL", "1\nno\n0\n"},
+		{`let f = fn() { defer puts("done"); 1 / 0 }; f()`, "ERROR: division by zero", "done\n"},
+		{`let f = fn() { defer 1 / 0; 1 }; f()`, "ERROR: division by zero", ""},
+		{`let f = fn() { defer puts("f"); 1 }; defer puts("top"); f(); puts("end")`, "null", "f\nend\ntop\n"},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		result := evalWithOutput(tt.input, EvalOptions{Output: &out})
+
+		if got := result.Inspect(); got != tt.expected {
+			t.Errorf("%s: result wrong. want=%q, got=%q", tt.input, tt.expected, got)
+		}
+		if got := out.String(); got != tt.expectedOutput {
+			t.Errorf("%s: output wrong. want=%q, got=%q", tt.input, tt.expectedOutput, got)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package evaluator
+
+import "github.com/al-keio/monkey-go/object"
+
+func init() {
+	builtins["queue"] = &object.Builtin{
+		Doc: "queue([array]): new deque holding the elements of array; grows and shrinks at both ends in O(1), unlike arrays",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) > 1 {
+				return newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=0 or 1", len(args))
+			}
+			d := &object.Deque{}
+			if len(args) == 1 {
+				arr, ok := args[0].(*object.Array)
+				if !ok {
+					return newError(object.WRONG_ARGUMENT_TYPE_ERR, "argument to `queue` must be ARRAY, got %s", args[0].Type())
+				}
+				for _, element := range arr.Elements {
+					d.PushBack(element)
+				}
+			}
+			return d
+		},
+	}
+	builtins["push_front"] = dequeBuiltin("push_front", 2, "push_front(deque, x): add x before the first element of deque; returns deque",
+		func(d *object.Deque, args []object.Object) object.Object {
+			d.PushFront(args[0])
+			return d
+		})
+	builtins["push_back"] = dequeBuiltin("push_back", 2, "push_back(deque, x): add x after the last element of deque; returns deque",
+		func(d *object.Deque, args []object.Object) object.Object {
+			d.PushBack(args[0])
+			return d
+		})
+	builtins["pop_front"] = dequeBuiltin("pop_front", 1, "pop_front(deque): remove and return the first element of deque, or null if it is empty",
+		func(d *object.Deque, args []object.Object) object.Object {
+			if obj, ok := d.PopFront(); ok {
+				return obj
+			}
+			return NULL
+		})
+	builtins["pop_back"] = dequeBuiltin("pop_back", 1, "pop_back(deque): remove and return the last element of deque, or null if it is empty",
+		func(d *object.Deque, args []object.Object) object.Object {
+			if obj, ok := d.PopBack(); ok {
+				return obj
+			}
+			return NULL
+		})
+}
+
+// dequeBuiltin は最初の引数に deque を取る組み込み関数を作る。fn には残りの引数を渡す。
+func dequeBuiltin(name string, argc int, doc string, fn func(d *object.Deque, args []object.Object) object.Object) *object.Builtin {
+	return &object.Builtin{
+		Doc: doc,
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != argc {
+				return newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=%d", len(args), argc)
+			}
+			d, ok := args[0].(*object.Deque)
+			if !ok {
+				return newError(object.WRONG_ARGUMENT_TYPE_ERR, "argument 1 to `%s` must be DEQUE, got %s", name, args[0].Type())
+			}
+			return fn(d, args[1:])
+		},
+	}
+}
@@ -0,0 +1,37 @@
+package evaluator
+
+import "testing"
+
+func TestDequeBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`queue()`, "deque[]"},
+		{`queue([1, 2, 3])`, "deque[1, 2, 3]"},
+		{`let q = queue([2]); push_front(q, 1); push_back(q, 3); q`, "deque[1, 2, 3]"},
+		{`push_back(push_back(queue(), "a"), "b")`, "deque[a, b]"},
+		{`let q = queue([1, 2, 3]); [pop_front(q), pop_back(q), len(q), q]`, "[1, 3, 1, deque[2]]"},
+		{`let q = queue(); [pop_front(q), pop_back(q), len(q)]`, "[null, null, 0]"},
+		{`let arr = [1, 2]; let q = queue(arr); pop_front(q); arr`, "[1, 2]"},
+		{`let drain = fn(q, acc) { if (len(q) == 0) { acc } else { drain(q, acc + pop_front(q)) } }; drain(queue([1, 2, 3]), 0)`, "6"},
+		{`queue(1)`, "ERROR: argument to `queue` must be ARRAY, got INTEGER"},
+		{`queue([], [])`, "ERROR: wrong number of arguments. got=2, want=0 or 1"},
+		{`push_back([1], 2)`, "ERROR: argument 1 to `push_back` must be DEQUE, got ARRAY"},
+		{`pop_front(queue(), 1)`, "ERROR: wrong number of arguments. got=2, want=1"},
+		// deque は中身が変わるので、入れ子の中にあってもハッシュのキーにできない
+		{`{queue(): 1}`, "ERROR: unusable as hash key DEQUE"},
+		{`{[1, queue()]: 1}`, "ERROR: unusable as hash key DEQUE"},
+		{`{{"a": [queue()]}: 1}`, "ERROR: unusable as hash key DEQUE"},
+		{`{"a": 1}[[queue()]]`, "ERROR: unusable as hash key: DEQUE"},
+		{`assoc_in({}, [[queue()]], 1)`, "ERROR: unusable as hash key: DEQUE"},
+		{`{[1, [2]]: "ok"}[[1, [2]]]`, "ok"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package evaluator
+
+import (
+	"strings"
+
+	"github.com/al-keio/monkey-go/ast"
+	"github.com/al-keio/monkey-go/internal/lexer"
+	"github.com/al-keio/monkey-go/internal/parser"
+	"github.com/al-keio/monkey-go/object"
+)
+
+func init() {
+	// 呼び出しは evalEval が扱う。ここでは help や :doc のために説明だけを登録する
+	builtins["eval"] = &object.Builtin{
+		Doc: "eval(source[, fresh]): parse, expand and evaluate the string source in the calling environment, or in a new one if fresh is true; parse errors become PARSE errors",
+		Fn: func(args ...object.Object) object.Object {
+			return newError(object.NOT_A_FUNCTION_ERR, "eval must be called directly as eval(source)")
+		},
+	}
+}
+
+// eval(source[, fresh]) は呼び出した環境で source を評価する。同じ評価の中で実行するので、
+// EvalOptions のステップ数の上限や Context の取り消し、出力の制限はそのまま及ぶ。
+func (ev *evaluation) evalEval(node *ast.CallExpression, env *object.Environment) object.Object {
+	if len(node.Arguments) != 1 && len(node.Arguments) != 2 {
+		return newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=1 or 2", len(node.Arguments))
+	}
+
+	args := ev.evalExpressions(node.Arguments, env)
+	if len(args) == 1 && isError(args[0]) {
+		return args[0]
+	}
+	source, ok := args[0].(*object.String)
+	if !ok {
+		return newError(object.WRONG_ARGUMENT_TYPE_ERR, "argument 1 to `eval` must be STRING, got %s", args[0].Type())
+	}
+	if len(args) == 2 {
+		fresh, ok := args[1].(*object.Boolean)
+		if !ok {
+			return newError(object.WRONG_ARGUMENT_TYPE_ERR, "argument 2 to `eval` must be BOOLEAN, got %s", args[1].Type())
+		}
+		if fresh.Value {
+			env = object.NewEnvironment()
+		}
+	}
+
+	p := parser.New(lexer.New(source.Value))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return newError(object.PARSE_ERR, "parse errors in eval: %s", strings.Join(p.Errors(), "; "))
+	}
+
+	macroEnv := object.NewEnvironment()
+	DefineMacros(program, macroEnv)
+	// 展開中のマクロも呼び出し元と同じ上限の下で評価する
+	expanded, err := ev.expandMacros(program, macroEnv)
+	if err != nil {
+		return err
+	}
+
+	result := ev.eval(expanded, env)
+	if result == nil {
+		return NULL
+	}
+	return result
+}
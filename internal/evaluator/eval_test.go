@@ -0,0 +1,47 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/al-keio/monkey-go/object"
+)
+
+func TestEvalBuiltin(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`eval("1 + 2")`, "3"},
+		{`let x = 10; eval("x * 2")`, "20"},
+		{`eval("let y = 5;"); y`, "5"},
+		{`let f = fn(a) { eval("a + 1") }; f(1)`, "2"},
+		{`let x = 1; eval("x", true)`, "ERROR: identifier not found: x"},
+		{`eval("let z = 1", true); z`, "ERROR: identifier not found: z"},
+		{`eval("let m = macro(a) { quote(unquote(a) * 2) }; m(4)")`, "8"},
+		{`eval("")`, "null"},
+		{`eval("return 3; 4")`, "3"},
+		{`eval(1)`, "ERROR: argument 1 to `eval` must be STRING, got INTEGER"},
+		{`let f = eval; f("1")`, "ERROR: eval must be called directly as eval(source)"},
+	}
+
+	for _, tt := range tests {
+		if got := testEval(tt.input).Inspect(); got != tt.expected {
+			t.Errorf("%s: want=%q, got=%q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestEvalBuiltinErrors(t *testing.T) {
+	err, ok := testEval(`eval("let = 1")`).(*object.Error)
+	if !ok || err.Code != object.PARSE_ERR {
+		t.Fatalf("expected a PARSE error, got=%v", err)
+	}
+
+	result := evalWithOutput(`eval("let f = fn() { f() }; f()")`, EvalOptions{MaxSteps: 1000})
+	if err, ok := result.(*object.Error); !ok || err.Code != object.STEP_LIMIT_ERR {
+		t.Errorf("step limit should apply inside eval, got=%s", result.Inspect())
+	}
+
+	result = evalWithOutput(`let f = fn() { let secret = 1; fn() { eval("secret") } }; f()()`, EvalOptions{CaptureFreeVariables: true})
+	testIntegerObject(t, result, 1)
+}
@@ -0,0 +1,753 @@
+package evaluator
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"runtime/debug"
+
+	"github.com/al-keio/monkey-go/ast"
+	"github.com/al-keio/monkey-go/object"
+	"github.com/al-keio/monkey-go/symbol"
+	"github.com/al-keio/monkey-go/token"
+)
+
+var (
+	NULL  = &object.Null{}
+	TRUE  = &object.Boolean{Value: true}
+	FALSE = &object.Boolean{Value: false}
+)
+
+func Eval(node ast.Node, env *object.Environment) object.Object {
+	ev := &evaluation{}
+	return ev.run(node, env)
+}
+
+// run は評価の入口。インタプリタのバグで panic しても埋め込み先ごと落ちないよう、
+// INTERNAL のエラーに変換して返す。
+func (ev *evaluation) run(node ast.Node, env *object.Environment) object.Object {
+	return ev.guard(func() object.Object { return ev.eval(node, env) })
+}
+
+// guard は f を一番外側のフレームで実行し、panic を INTERNAL のエラーにする
+func (ev *evaluation) guard(f func() object.Object) (result object.Object) {
+	defer func() {
+		if r := recover(); r != nil {
+			err := newError(object.INTERNAL_ERR, "internal error: %v", r)
+			err.GoStack = string(debug.Stack())
+			result = err
+		}
+	}()
+	defer ev.flushOutput()
+	defer ev.closeTrace()
+	if ev.options.Trace != nil {
+		// トレースの時刻は評価の始まりから数える
+		ev.tracer()
+	}
+
+	ev.pushFrame()
+	return ev.popFrame(f())
+}
+
+// evaluation は一回の評価の間だけ有効な状態を保持する
+type evaluation struct {
+	options   EvalOptions
+	stats     Stats
+	depth     int
+	timers    *timerQueue
+	cancelled bool
+	out       *output
+	frames    [][]deferred
+	captures  map[*ast.FunctionLiteral][]symbol.ID
+	limit     *object.Error                              // 最初に超えた上限のエラー
+	unquoted  *object.Error                              // 評価中の quote の中で unquote の引数が返した最初のエラー
+	calls     map[*ast.CallExpression]*object.Resolution // 呼び出し位置ごとに関数の名前を引いた結果
+	trace     *tracer
+}
+
+func (ev *evaluation) eval(node ast.Node, env *object.Environment) object.Object {
+	if err := ev.step(); err != nil {
+		return err
+	}
+	result := ev.evalNode(node, env)
+	ev.countAllocation(node, result)
+	return result
+}
+
+func (ev *evaluation) evalNode(node ast.Node, env *object.Environment) object.Object {
+	switch node := node.(type) {
+	case *ast.Program:
+		return ev.evalProgram(node, env)
+	case *ast.ExpressionStatement:
+		return ev.eval(node.Expression, env)
+	case *ast.BlockStatement:
+		return ev.evalBlockStatement(node, env)
+	case *ast.ReturnStatement:
+		val := ev.eval(node.ReturnValue, env)
+		if isError(val) {
+			return val
+		}
+		return &object.ReturnValue{Value: val}
+	case *ast.DeferStatement:
+		return ev.evalDeferStatement(node, env)
+	case *ast.ClassStatement:
+		return ev.evalClassStatement(node, env)
+	case *ast.NewExpression:
+		return ev.evalNewExpression(node, env)
+	case *ast.OperatorStatement:
+		fn := ev.eval(node.Function, env)
+		if isError(fn) {
+			return fn
+		}
+		env.Set(node.Operator, fn)
+	case *ast.LetStatement:
+		val := ev.eval(node.Value, env)
+		if isError(val) {
+			return val
+		}
+		env.SetSymbol(node.Name.ID(), val)
+
+	case *ast.PrefixExpression:
+		right := ev.eval(node.Right, env)
+		if isError(right) {
+			return right
+		}
+		return evalPrefixExpression(node.Operator, right)
+	case *ast.InfixExpression:
+		left := ev.eval(node.Left, env)
+		if isError(left) {
+			return left
+		}
+		right := ev.eval(node.Right, env)
+		if isError(right) {
+			return right
+		}
+		if node.Token.Type == token.CUSTOM_INFIX {
+			return ev.evalCustomInfixExpression(node, left, right, env)
+		}
+		return evalInfixExpression(node.Operator, left, right)
+	case *ast.IfExpression:
+		return ev.evalIfExpression(node, env)
+	case *ast.ConditionalExpression:
+		return ev.evalConditionalExpression(node, env)
+	case *ast.MatchExpression:
+		return ev.evalMatchExpression(node, env)
+	case *ast.Identifier:
+		return evalIdentifier(node, env)
+	case *ast.FunctionLiteral:
+		params := node.Parameters
+		body := node.Body
+		return &object.Function{Parameters: params, Body: body, Env: ev.closureEnv(node, env), Doc: node.Doc,
+			Requires: node.Requires, Ensures: node.Ensures}
+	case *ast.CallExpression:
+		if node.Function.TokenLiteral() == "quote" {
+			return ev.quote(node.Arguments[0], env)
+		}
+		if node.Function.TokenLiteral() == "eval" {
+			return ev.evalEval(node, env)
+		}
+		function := ev.callee(node, env)
+		if isError(function) {
+			return function
+		}
+		args := ev.evalExpressions(node.Arguments, env)
+		if len(args) == 1 && isError(args[0]) {
+			return args[0]
+		}
+		return ev.call(calleeName(node.Function), node.Token.Offset, function, args)
+	case *ast.PipelineExpression:
+		return ev.evalPipelineExpression(node, env)
+	case *ast.MemberExpression:
+		obj := ev.eval(node.Object, env)
+		if isError(obj) {
+			return obj
+		}
+		return evalIndexExpression(obj, &object.String{Value: node.Member.Value})
+	case *ast.IndexExpression:
+		left := ev.eval(node.Left, env)
+		if isError(left) {
+			return left
+		}
+		index := ev.eval(node.Index, env)
+		if isError(index) {
+			return index
+		}
+		return evalIndexExpression(left, index)
+
+	case *ast.IntegerLiteral:
+		return &object.Integer{Value: node.Value}
+	case *ast.BigIntLiteral:
+		return object.NewInteger(node.Value)
+	case *ast.DecimalLiteral:
+		return &object.Decimal{Value: node.Value}
+	case *ast.StringLiteral:
+		return &object.String{Value: node.Value}
+	case *ast.Boolean:
+		return nativeBoolToBooleanObject(node.Value)
+	case *ast.ArrayLiteral:
+		elements := ev.evalExpressions(node.Elements, env)
+		if len(elements) == 1 && isError(elements[0]) {
+			return elements[0]
+		}
+		return &object.Array{Elements: elements}
+	case *ast.HashLiteral:
+		return ev.evalHashLiteral(node, env)
+	case *ast.MacroLiteral:
+		// マクロはトップレベルの let で定義したものだけが DefineMacros で取り除かれる
+		return newError(object.INVALID_VALUE_ERR, "macro can only be defined by a top-level let statement")
+	}
+	return nil
+}
+
+func (ev *evaluation) evalProgram(program *ast.Program, env *object.Environment) object.Object {
+	var result object.Object
+
+	for _, statement := range program.Statements {
+		result = ev.eval(statement, env)
+
+		switch result := result.(type) {
+		case *object.ReturnValue:
+			return result.Value
+		case *object.Error:
+			return result
+		}
+	}
+
+	return result
+}
+
+func (ev *evaluation) evalBlockStatement(block *ast.BlockStatement, env *object.Environment) object.Object {
+	var result object.Object
+
+	for _, statement := range block.Statements {
+		result = ev.eval(statement, env)
+
+		if result != nil {
+			rt := result.Type()
+			if rt == object.RETURN_VALUE_OBJ || rt == object.ERROR_OBJ {
+				return result
+			}
+		}
+	}
+
+	// 空のブロックや let で終わるブロックは値を持たないので、式の値としては null にする
+	if result == nil {
+		return NULL
+	}
+	return result
+}
+
+func (ev *evaluation) evalExpressions(exps []ast.Expression, env *object.Environment) []object.Object {
+	result := []object.Object{}
+
+	for _, e := range exps {
+		evaluated := ev.eval(e, env)
+		if isError(evaluated) {
+			return []object.Object{evaluated}
+		}
+		result = append(result, evaluated)
+	}
+
+	return result
+}
+
+func evalPrefixExpression(operator string, right object.Object) object.Object {
+	switch operator {
+	case "!":
+		return evalBangOperatorExpression(right)
+	case "-":
+		return evalMinusPrefixOperatorExpression(right)
+	default:
+		return newError(object.UNKNOWN_OPERATOR_ERR, "unknown operator: %s%s", operator, right.Type())
+	}
+}
+
+func evalBangOperatorExpression(right object.Object) object.Object {
+	switch right {
+	case TRUE:
+		return FALSE
+	case FALSE:
+		return TRUE
+	case NULL:
+		return TRUE
+	default:
+		return FALSE
+	}
+}
+
+func evalMinusPrefixOperatorExpression(right object.Object) object.Object {
+	if decimal, ok := right.(*object.Decimal); ok {
+		return &object.Decimal{Value: new(big.Rat).Neg(decimal.Value)}
+	}
+	if bigInt, ok := right.(*object.BigInt); ok {
+		return object.NewInteger(new(big.Int).Neg(bigInt.Value))
+	}
+	if right.Type() != object.INTEGER_OBJ {
+		return newError(object.UNKNOWN_OPERATOR_ERR, "unknown operator: -%s", right.Type())
+	}
+	value := right.(*object.Integer).Value
+	if value == math.MinInt64 {
+		return object.NewInteger(new(big.Int).Neg(big.NewInt(value)))
+	}
+	return &object.Integer{Value: -value}
+}
+
+func evalInfixExpression(operator string, left object.Object, right object.Object) object.Object {
+	switch {
+	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
+		return evalIntegerInfixExpression(operator, left, right)
+	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
+		return evalStringInfixExpression(operator, left, right)
+	case left.Type() == object.DECIMAL_OBJ || right.Type() == object.DECIMAL_OBJ:
+		return evalDecimalInfixExpression(operator, left, right)
+	case isInteger(left) && isInteger(right):
+		return evalBigIntInfixExpression(operator, left, right)
+	case left.Type() == object.QUOTE_OBJ && right.Type() == object.QUOTE_OBJ:
+		return evalQuoteInfixExpression(operator, left, right)
+	case operator == "+" && left.Type() == object.ARRAY_OBJ && right.Type() == object.ARRAY_OBJ:
+		return concatArrays(left.(*object.Array), right.(*object.Array))
+	case operator == "+" && left.Type() == object.HASH_OBJ && right.Type() == object.HASH_OBJ:
+		return mergeHashes(left.(*object.Hash), right.(*object.Hash))
+	case operator == "==":
+		return nativeBoolToBooleanObject(left == right)
+	case operator == "!=":
+		return nativeBoolToBooleanObject(left != right)
+	case left.Type() != right.Type():
+		return newError(object.TYPE_MISMATCH_ERR, "type mismatch: %s %s %s", left.Type(), operator, right.Type())
+	default:
+		return newError(object.UNKNOWN_OPERATOR_ERR, "unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+// concatArrays は left と right の要素をつなげた新しい配列を返す。凍結はされていない。
+func concatArrays(left, right *object.Array) *object.Array {
+	elements := make([]object.Object, 0, len(left.Elements)+len(right.Elements))
+	elements = append(elements, left.Elements...)
+	elements = append(elements, right.Elements...)
+	return &object.Array{Elements: elements}
+}
+
+// mergeHashes は left に right を重ねた新しいハッシュを返す。同じキーは right の値になる。
+func mergeHashes(left, right *object.Hash) *object.Hash {
+	merged := object.NewHash()
+	for _, pair := range left.PairList() {
+		merged.Set(pair.Key, pair.Value)
+	}
+	for _, pair := range right.PairList() {
+		merged.Set(pair.Key, pair.Value)
+	}
+	return merged
+}
+
+// クォート同士は同じ式を表していれば等しい
+func evalQuoteInfixExpression(operator string, left object.Object, right object.Object) object.Object {
+	equal := astString(left.(*object.Quote).Node) == astString(right.(*object.Quote).Node)
+	switch operator {
+	case "==":
+		return nativeBoolToBooleanObject(equal)
+	case "!=":
+		return nativeBoolToBooleanObject(!equal)
+	default:
+		return newError(object.UNKNOWN_OPERATOR_ERR, "unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+// 整数の演算が int64 からあふれるときは BigInt にして計算し直す
+func evalIntegerInfixExpression(operator string, left object.Object, right object.Object) object.Object {
+	leftVal := left.(*object.Integer).Value
+	rightVal := right.(*object.Integer).Value
+	switch operator {
+	case "+":
+		sum := leftVal + rightVal
+		if (sum > leftVal) != (rightVal > 0) {
+			return evalBigIntInfixExpression(operator, left, right)
+		}
+		return &object.Integer{Value: sum}
+	case "-":
+		difference := leftVal - rightVal
+		if (difference < leftVal) != (rightVal > 0) {
+			return evalBigIntInfixExpression(operator, left, right)
+		}
+		return &object.Integer{Value: difference}
+	case "*":
+		product := leftVal * rightVal
+		if leftVal != 0 && (product/leftVal != rightVal || leftVal == -1 && rightVal == math.MinInt64) {
+			return evalBigIntInfixExpression(operator, left, right)
+		}
+		return &object.Integer{Value: product}
+	case "/":
+		if rightVal == 0 {
+			return newError(object.DIVISION_BY_ZERO_ERR, "division by zero")
+		}
+		if leftVal == math.MinInt64 && rightVal == -1 {
+			return evalBigIntInfixExpression(operator, left, right)
+		}
+		return &object.Integer{Value: leftVal / rightVal}
+	case "%":
+		if rightVal == 0 {
+			return newError(object.DIVISION_BY_ZERO_ERR, "division by zero")
+		}
+		return &object.Integer{Value: leftVal % rightVal}
+	case "<":
+		return nativeBoolToBooleanObject(leftVal < rightVal)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal > rightVal)
+	case "==":
+		return nativeBoolToBooleanObject(leftVal == rightVal)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal != rightVal)
+	default:
+		return NULL
+	}
+}
+
+// evalBigIntInfixExpression は多倍長で計算し、結果が int64 に収まれば Integer に戻す。
+// / と % は Integer と同じく 0 方向に切り捨てる。
+func evalBigIntInfixExpression(operator string, left object.Object, right object.Object) object.Object {
+	leftVal := toBigInt(left)
+	rightVal := toBigInt(right)
+	switch operator {
+	case "+":
+		return object.NewInteger(new(big.Int).Add(leftVal, rightVal))
+	case "-":
+		return object.NewInteger(new(big.Int).Sub(leftVal, rightVal))
+	case "*":
+		return object.NewInteger(new(big.Int).Mul(leftVal, rightVal))
+	case "/":
+		if rightVal.Sign() == 0 {
+			return newError(object.DIVISION_BY_ZERO_ERR, "division by zero")
+		}
+		return object.NewInteger(new(big.Int).Quo(leftVal, rightVal))
+	case "%":
+		if rightVal.Sign() == 0 {
+			return newError(object.DIVISION_BY_ZERO_ERR, "division by zero")
+		}
+		return object.NewInteger(new(big.Int).Rem(leftVal, rightVal))
+	case "<":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) < 0)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) > 0)
+	case "==":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) == 0)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) != 0)
+	default:
+		return newError(object.UNKNOWN_OPERATOR_ERR, "unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+func isInteger(obj object.Object) bool {
+	return obj.Type() == object.INTEGER_OBJ || obj.Type() == object.BIGINT_OBJ
+}
+
+func toBigInt(obj object.Object) *big.Int {
+	if obj, ok := obj.(*object.Integer); ok {
+		return big.NewInt(obj.Value)
+	}
+	return obj.(*object.BigInt).Value
+}
+
+func evalDecimalInfixExpression(operator string, left object.Object, right object.Object) object.Object {
+	leftVal, ok := toRat(left)
+	if !ok {
+		return newError(object.TYPE_MISMATCH_ERR, "type mismatch: %s %s %s", left.Type(), operator, right.Type())
+	}
+	rightVal, ok := toRat(right)
+	if !ok {
+		return newError(object.TYPE_MISMATCH_ERR, "type mismatch: %s %s %s", left.Type(), operator, right.Type())
+	}
+
+	switch operator {
+	case "+":
+		return &object.Decimal{Value: new(big.Rat).Add(leftVal, rightVal)}
+	case "-":
+		return &object.Decimal{Value: new(big.Rat).Sub(leftVal, rightVal)}
+	case "*":
+		return &object.Decimal{Value: new(big.Rat).Mul(leftVal, rightVal)}
+	case "/":
+		if rightVal.Sign() == 0 {
+			return newError(object.DIVISION_BY_ZERO_ERR, "division by zero")
+		}
+		return &object.Decimal{Value: new(big.Rat).Quo(leftVal, rightVal)}
+	case "%":
+		if rightVal.Sign() == 0 {
+			return newError(object.DIVISION_BY_ZERO_ERR, "division by zero")
+		}
+		_, remainder := divmodRat(leftVal, rightVal)
+		return &object.Decimal{Value: remainder}
+	case "<":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) < 0)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) > 0)
+	case "==":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) == 0)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) != 0)
+	default:
+		return newError(object.UNKNOWN_OPERATOR_ERR, "unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+// divmodRat は整数の / と % に合わせて、0 方向に切り捨てた商とその余り (符号は a と同じ) を返す。b は 0 でないこと。
+func divmodRat(a, b *big.Rat) (*big.Rat, *big.Rat) {
+	q := new(big.Rat).Quo(a, b)
+	quotient := new(big.Rat).SetInt(new(big.Int).Quo(q.Num(), q.Denom()))
+	remainder := new(big.Rat).Sub(a, new(big.Rat).Mul(b, quotient))
+	return quotient, remainder
+}
+
+func toRat(obj object.Object) (*big.Rat, bool) {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return new(big.Rat).SetInt64(obj.Value), true
+	case *object.BigInt:
+		return new(big.Rat).SetInt(obj.Value), true
+	case *object.Decimal:
+		return obj.Value, true
+	default:
+		return nil, false
+	}
+}
+
+func evalStringInfixExpression(operator string, left object.Object, right object.Object) object.Object {
+	leftVal := left.(*object.String).Value
+	rightVal := right.(*object.String).Value
+	switch operator {
+	case "+":
+		return &object.String{Value: leftVal + rightVal}
+	case "<", ">":
+		order, _ := object.Compare(left, right)
+		if operator == "<" {
+			return nativeBoolToBooleanObject(order < 0)
+		}
+		return nativeBoolToBooleanObject(order > 0)
+	case "==":
+		return nativeBoolToBooleanObject(leftVal == rightVal)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal != rightVal)
+	default:
+		return newError(object.UNKNOWN_OPERATOR_ERR, "unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+func (ev *evaluation) evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Object {
+	condition := ev.eval(ie.Condition, env)
+
+	if isError(condition) {
+		return condition
+	}
+
+	if isTruthy(condition) {
+		return ev.eval(ie.Consequence, env)
+	} else if ie.Alternative != nil {
+		return ev.eval(ie.Alternative, env)
+	} else {
+		return NULL
+	}
+}
+
+func (ev *evaluation) evalConditionalExpression(ce *ast.ConditionalExpression, env *object.Environment) object.Object {
+	condition := ev.eval(ce.Condition, env)
+
+	if isError(condition) {
+		return condition
+	}
+
+	if isTruthy(condition) {
+		return ev.eval(ce.Consequence, env)
+	}
+	return ev.eval(ce.Alternative, env)
+}
+
+func evalIndexExpression(left, index object.Object) object.Object {
+	switch {
+	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
+		return evalArrayIndexExpression(left, index)
+	case left.Type() == object.HASH_OBJ:
+		return evalHashIndexExpression(left, index)
+	case left.Type() == object.PROXY_OBJ:
+		return evalProxyIndexExpression(left.(*object.Proxy), index)
+	case left.Type() == object.NAMESPACE_OBJ:
+		return evalNamespaceIndexExpression(left.(*object.Namespace), index)
+	default:
+		return newError(object.INDEX_NOT_SUPPORTED_ERR, "index operator not supported: %s", left.Type())
+	}
+}
+
+func evalArrayIndexExpression(array, index object.Object) object.Object {
+	arrayObject := array.(*object.Array)
+	idx := index.(*object.Integer).Value
+	max := int64(len(arrayObject.Elements) - 1)
+
+	if idx < 0 || idx > max {
+		return NULL
+	}
+
+	return arrayObject.Elements[idx]
+}
+
+func evalHashIndexExpression(array, index object.Object) object.Object {
+	hashObject := array.(*object.Hash)
+
+	if part := object.UnhashablePart(index); part != nil {
+		return newError(object.UNUSABLE_HASH_KEY_ERR, "unusable as hash key: %s", part.Type())
+	}
+
+	pair, ok := hashObject.Lookup(index)
+	if !ok {
+		return NULL
+	}
+
+	return pair.Value
+}
+
+func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object {
+	if val, ok := env.GetSymbol(node.ID()); ok {
+		return val
+	}
+	return evalUnboundIdentifier(node, env)
+}
+
+// evalUnboundIdentifier は環境に束縛のない名前を組み込み関数か名前空間として評価する
+func evalUnboundIdentifier(node *ast.Identifier, env *object.Environment) object.Object {
+	if builtin, ok := builtins[node.Value]; ok {
+		return builtin
+	}
+
+	if namespace, ok := namespaces[node.Value]; ok {
+		return namespace
+	}
+	err := newError(object.UNKNOWN_IDENTIFIER_ERR, "identifier not found: %s", node.Value)
+	err.Hint = identifierHint(node.Value, env)
+	return err
+}
+
+func (ev *evaluation) evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Object {
+	hash := object.NewHash()
+
+	// map の走査順によってどのエラーが返るかが変わらないよう、キーの文字列表現の順に評価する
+	for _, keyNode := range node.SortedKeys() {
+		valueNode := node.Pairs[keyNode]
+		key := ev.eval(keyNode, env)
+		if isError(key) {
+			return key
+		}
+
+		if part := object.UnhashablePart(key); part != nil {
+			return newError(object.UNUSABLE_HASH_KEY_ERR, "unusable as hash key %s", part.Type())
+		}
+
+		value := ev.eval(valueNode, env)
+		if isError(value) {
+			return value
+		}
+
+		hash.Set(key, value)
+	}
+
+	return hash
+}
+
+// applyFunction は fn を呼び出す。label は関数の本体を評価する環境に付ける。
+func (ev *evaluation) applyFunction(fn object.Object, args []object.Object, label string) object.Object {
+	switch fn := fn.(type) {
+	case *object.Function:
+		if len(args) < len(fn.Parameters) {
+			return newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=%d", len(args), len(fn.Parameters))
+		}
+		extendedEnv := extendFunctionEnv(fn, args)
+		extendedEnv.SetLabel(label)
+		ev.stats.Allocations++
+
+		if err := ev.checkContract("precondition", fn.Requires, extendedEnv); err != nil {
+			return err
+		}
+
+		if err := ev.enterCallChecked(); err != nil {
+			return err
+		}
+		ev.pushFrame()
+		evaluated := unwrapReturnValue(ev.popFrame(ev.eval(fn.Body, extendedEnv)))
+		ev.leaveCall()
+
+		if len(fn.Ensures) > 0 && !isError(evaluated) {
+			resultEnv := object.NewEnclosedEnvironment(extendedEnv)
+			resultEnv.Set("result", evaluated)
+			if err := ev.checkContract("postcondition", fn.Ensures, resultEnv); err != nil {
+				return err
+			}
+		}
+		return evaluated
+	case *object.Builtin:
+		if err := ev.checkCapabilities(fn); err != nil {
+			return err
+		}
+		var result object.Object
+		if fn.ApplyFn != nil {
+			result = fn.ApplyFn(ev, args...)
+		} else {
+			result = fn.Fn(args...)
+		}
+		ev.countAllocation(nil, result)
+		return result
+	default:
+		return newError(object.NOT_A_FUNCTION_ERR, "not a function: %s", fn.Type())
+	}
+}
+
+// Apply は組み込み関数から呼ばれ、同じ評価の中で fn を呼び出す。
+// 呼び出し自体も一ステップとして数えるので、ネイティブのループから関数を呼んでも EvalOptions.MaxSteps は回避できない。
+func (ev *evaluation) Apply(fn object.Object, args ...object.Object) object.Object {
+	if err := ev.step(); err != nil {
+		return err
+	}
+	return ev.call(calleeName(nil), -1, fn, args)
+}
+
+func extendFunctionEnv(fn *object.Function, args []object.Object) *object.Environment {
+	env := object.NewEnclosedEnvironment(fn.Env)
+
+	for paramIdx, param := range fn.Parameters {
+		env.SetSymbol(param.ID(), args[paramIdx])
+	}
+
+	return env
+}
+
+func unwrapReturnValue(obj object.Object) object.Object {
+	if returnValue, ok := obj.(*object.ReturnValue); ok {
+		return returnValue.Value
+	}
+	return obj
+}
+
+func isTruthy(obj object.Object) bool {
+	switch obj {
+	case NULL:
+		return false
+	case TRUE:
+		return true
+	case FALSE:
+		return false
+	default:
+		return true
+	}
+}
+
+func nativeBoolToBooleanObject(input bool) object.Object {
+	if input {
+		return TRUE
+	}
+	return FALSE
+}
+
+func newError(code string, format string, a ...interface{}) *object.Error {
+	return &object.Error{Code: code, Message: fmt.Sprintf(format, a...)}
+}
+
+func isError(obj object.Object) bool {
+	if obj != nil {
+		return obj.Type() == object.ERROR_OBJ
+	}
+	return false
+}
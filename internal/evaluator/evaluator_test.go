@@ -0,0 +1,1062 @@
+package evaluator
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/al-keio/monkey-go/internal/lexer"
+	"github.com/al-keio/monkey-go/internal/parser"
+	"github.com/al-keio/monkey-go/object"
+)
+
+func TestEvalIntegerExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"5", 5},
+		{"10", 10},
+		{"-5", -5},
+		{"-10", -10},
+		{"0xFF", 255},
+		{"0o755", 493},
+		{"0b1010 + 0B1", 11},
+		{"-0x10", -16},
+		{"5 + 5 + 5 + 5 - 10", 10},
+		{"2 * 2 * 2 * 2 * 2", 32},
+		{"-50 + 100 + -50", 0},
+		{"5 * 2 + 10", 20},
+		{"5 + 2 * 10", 25},
+		{"20 + 2 * -10", 0},
+		{"50 / 2 * 2 + 10", 60},
+		{"2 * (5 + 10)", 30},
+		{"3 * 3 * 3 + 10", 37},
+		{"3 * (3 * 3) + 10", 37},
+		{"(5 + 10 * 2 + 15 / 3) * 2 + -10", 50},
+		{"7 / 2", 3},
+		{"-7 / 2", -3},
+		{"7 % 3", 1},
+		{"-7 % 3", -1},
+		{"7 % -3", 1},
+		{"1 + 7 % 3 * 2", 3},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testIntegerObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestEvalBigIntExpression(t *testing.T) {
+	tests := []struct {
+		input        string
+		expectedType object.ObjectType
+		expected     string
+	}{
+		{"123456789012345678901234567890n", object.BIGINT_OBJ, "123456789012345678901234567890"},
+		{"5n", object.INTEGER_OBJ, "5"},
+		{"9223372036854775807 + 1", object.BIGINT_OBJ, "9223372036854775808"},
+		{"-9223372036854775807 - 2", object.BIGINT_OBJ, "-9223372036854775809"},
+		{"4294967296 * 4294967296", object.BIGINT_OBJ, "18446744073709551616"},
+		{"-(-9223372036854775807 - 1)", object.BIGINT_OBJ, "9223372036854775808"},
+		{"(-9223372036854775807 - 1) / -1", object.BIGINT_OBJ, "9223372036854775808"},
+		{"(9223372036854775807 + 1) - 1", object.INTEGER_OBJ, "9223372036854775807"},
+		{"100000000000000000000n / 10000000000n", object.INTEGER_OBJ, "10000000000"},
+		{"-100000000000000000007n % 10", object.INTEGER_OBJ, "-7"},
+		{"0x10000000000000000n", object.BIGINT_OBJ, "18446744073709551616"},
+		{"100000000000000000000n + 0.5d", object.DECIMAL_OBJ, "100000000000000000000.5"},
+		{"math.pow(2, 100)", object.BIGINT_OBJ, "1267650600228229401496703205376"},
+		{"math.abs(-100000000000000000000n)", object.BIGINT_OBJ, "100000000000000000000"},
+		{"divmod(100000000000000000000n, 3)", object.ARRAY_OBJ, "[33333333333333333333, 1]"},
+		{"decimal(100000000000000000000n)", object.DECIMAL_OBJ, "100000000000000000000"},
+		{`{100000000000000000000n: "a"}[10000000000n * 10000000000]`, object.STRING_OBJ, "a"},
+		{"match (10000000000n * 10000000000) { 100000000000000000000n => 1, _ => 2 }", object.INTEGER_OBJ, "1"},
+		{"100000000000000000000n > 9223372036854775807", object.BOOLEAN_OBJ, "true"},
+		{"100000000000000000000n == 10000000000 * 10000000000", object.BOOLEAN_OBJ, "true"},
+		{"100000000000000000000n == 1", object.BOOLEAN_OBJ, "false"},
+		{"100000000000000000000n / 0", object.ERROR_OBJ, "ERROR: division by zero"},
+		{`100000000000000000000n + "a"`, object.ERROR_OBJ, "ERROR: type mismatch: BIGINT + STRING"},
+		{"math.pow(3, 10000000)", object.ERROR_OBJ, "ERROR: result of `math.pow` is too large: 3 to the power of 10000000"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Type() != tt.expectedType || evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %s %q, got %s %q", tt.input, tt.expectedType, tt.expected, evaluated.Type(), evaluated.Inspect())
+		}
+	}
+}
+
+func TestEvalDecimalExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"12.34d", "12.34"},
+		{"0.1d + 0.2d", "0.3"},
+		{"-1.5d", "-1.5"},
+		{"1.10d * 3", "3.3"},
+		{"10d / 4", "2.5"},
+		{"1d / 3 * 3", "1"},
+		{"1 - 0.01d", "0.99"},
+		{`decimal("19.99") * 3`, "59.97"},
+		{"decimal(7)", "7"},
+		{"decimal(1.5d)", "1.5"},
+		{"7.5d % 2", "1.5"},
+		{"-7.5d % 2", "-1.5"},
+		{"0.1 + 0.2", "0.3"},
+		{"3.14 * 2", "6.28"},
+		{"1e-3 * 1000", "1"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		decimal, ok := evaluated.(*object.Decimal)
+		if !ok {
+			t.Errorf("object is not Decimal for %q. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if decimal.Inspect() != tt.expected {
+			t.Errorf("Decimal has wrong value for %q. expected=%q, got=%q", tt.input, tt.expected, decimal.Inspect())
+		}
+	}
+
+	booleans := []struct {
+		input    string
+		expected bool
+	}{
+		{"0.1d + 0.2d == 0.3d", true},
+		{"1.50d == 1.5d", true},
+		{"1d == 1", true},
+		{"0.5d < 1", true},
+		{"2 > 2.5d", false},
+		{"1.5d != 1.5d", false},
+	}
+
+	for _, tt := range booleans {
+		testBooleanObject(t, testEval(tt.input), tt.expected)
+	}
+
+	errors := []struct {
+		input    string
+		expected string
+	}{
+		{"1d / 0", "division by zero"},
+		{"1.5d % 0", "division by zero"},
+		{"1 % 0", "division by zero"},
+		{`1d + "a"`, "type mismatch: DECIMAL + STRING"},
+		{`decimal("abc")`, `could not parse "abc" as decimal`},
+		{`decimal(true)`, "argument to `decimal` not supported, got BOOLEAN"},
+	}
+
+	for _, tt := range errors {
+		testErrorObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestEvalStringExpression(t *testing.T) {
+	input := `"Hello World!"`
+
+	evaluated := testEval(input)
+	str, ok := evaluated.(*object.String)
+	if !ok {
+		t.Fatalf("object is not String. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	if str.Value != "Hello World!" {
+		t.Errorf("String hash wrong value. got=%q", str.Value)
+	}
+}
+
+func TestStringConcatenation(t *testing.T) {
+	input := `"Hello" + " " + "World!"`
+
+	evaluated := testEval(input)
+	str, ok := evaluated.(*object.String)
+	if !ok {
+		t.Fatalf("object is not String. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	if str.Value != "Hello World!" {
+		t.Errorf("String hash wrong value. got=%q", str.Value)
+	}
+}
+
+func TestEvalBooleanExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"true", true},
+		{"false", false},
+		{"1 < 2", true},
+		{"1 > 2", false},
+		{"1 < 1", false},
+		{"1 > 1", false},
+		{"1 == 1", true},
+		{"1 != 1", false},
+		{"1 == 2", false},
+		{"1 != 2", true},
+		{`"hello" == "hello"`, true},
+		{`"" == ""`, true},
+		{`"hello" == "world"`, false},
+		{`"hello" != "world"`, true},
+		{`"" != "world"`, true},
+		{`"hello" == "hell"`, false},
+		{`"hell" == "hello"`, false},
+		{`"foobar" == "foo" + "bar"`, true},
+		{`"a" < "b"`, true},
+		{`"b" < "a"`, false},
+		{`"a" > "b"`, false},
+		{`"ab" > "a"`, true},
+		{`"a" < "a"`, false},
+		{"true == true", true},
+		{"false == false", true},
+		{"true == false", false},
+		{"false != true", true},
+		{"(1 < 2) == true", true},
+		{"(1 < 2) == false", false},
+		{"(1 > 2) == true", false},
+		{"(1 > 2) == false", true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testBooleanObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestBangOperator(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"!true", false},
+		{"!false", true},
+		{"!5", false},
+		{"!!true", true},
+		{"!!false", false},
+		{"!!5", true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testBooleanObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestIfElseExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"if (true) { 10 }", 10},
+		{"if (false) { 10 }", nil},
+		{"if (1) { 10 }", 10},
+		{"if (1 < 2) { 10 }", 10},
+		{"if (1 > 2) { 10 }", nil},
+		{"if (1 > 2) { 10 } else { 20 }", 20},
+		{"if (1 < 2) { 10 } else { 20 }", 10},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		integer, ok := tt.expected.(int)
+		if ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestConditionalExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"true ? 10 : 20", 10},
+		{"false ? 10 : 20", 20},
+		{"1 < 2 ? 10 : 20", 10},
+		{"1 > 2 ? 10 : 20", 20},
+		{"1 ? 10 : 20", 10},
+		{"let x = 3; x > 5 ? 1 : x > 2 ? 2 : 3", 2},
+		{"false ? foobar : 20", 20},
+		{"true ? 10 : foobar", 10},
+		{"let f = fn(n) { n < 2 ? n : f(n - 1) + f(n - 2) }; f(10)", 55},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		integer, ok := tt.expected.(int)
+		if ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestReturnStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"return 10;", 10},
+		{"return 10; 9;", 10},
+		{"return 2 * 5; 9;", 10},
+		{"9; return 2 * 5; 9;", 10},
+		{`
+if (10 > 1) {
+	if (10 > 1) {
+		return 10;
+	}
+
+	return 1;
+}
+`,
+			10,
+		},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testIntegerObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestErrorHandling(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{
+			"5 + true;",
+			"type mismatch: INTEGER + BOOLEAN",
+		},
+		{
+			"5 + true; 5;",
+			"type mismatch: INTEGER + BOOLEAN",
+		},
+		{
+			`"Hello" - "World"`,
+			"unknown operator: STRING - STRING",
+		},
+		{
+			"-true",
+			"unknown operator: -BOOLEAN",
+		},
+		{
+			"if (true) { } + 1",
+			"type mismatch: NULL + INTEGER",
+		},
+		{
+			"fn() { let x = 1; }() + 1",
+			"type mismatch: NULL + INTEGER",
+		},
+		{
+			"-macro(x) { x }",
+			"macro can only be defined by a top-level let statement",
+		},
+		{
+			"let f = fn(x, y) { x }; f(1)",
+			"wrong number of arguments. got=1, want=2",
+		},
+		{
+			"true + false;",
+			"unknown operator: BOOLEAN + BOOLEAN",
+		},
+		{
+			"5; true + false; 5;",
+			"unknown operator: BOOLEAN + BOOLEAN",
+		},
+		{
+			"if (10 > 1) { true + false; }",
+			"unknown operator: BOOLEAN + BOOLEAN",
+		},
+		{
+			`
+if (10 > 1) {
+	if (10 > 1) {
+		return true + false;
+	}
+	return 1;
+}
+`,
+			"unknown operator: BOOLEAN + BOOLEAN",
+		},
+		{
+			"foobar;",
+			"identifier not found: foobar",
+		},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T (%+v)", evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestErrorCodes(t *testing.T) {
+	tests := []struct {
+		input        string
+		expectedCode string
+	}{
+		{"foobar", object.UNKNOWN_IDENTIFIER_ERR},
+		{"5 + true", object.TYPE_MISMATCH_ERR},
+		{"-true", object.UNKNOWN_OPERATOR_ERR},
+		{`"a" - "b"`, object.UNKNOWN_OPERATOR_ERR},
+		{"len(1, 2)", object.WRONG_ARGUMENT_COUNT_ERR},
+		{"len(1)", object.WRONG_ARGUMENT_TYPE_ERR},
+		{"1(2)", object.NOT_A_FUNCTION_ERR},
+		{`{"a": 1}[len]`, object.UNUSABLE_HASH_KEY_ERR},
+		{"1[0]", object.INDEX_NOT_SUPPORTED_ERR},
+		{"1 / 0", object.DIVISION_BY_ZERO_ERR},
+		{`decimal("x")`, object.INVALID_VALUE_ERR},
+		{`sort([1, "a"])`, object.NOT_COMPARABLE_ERR},
+		{"assert_eq(1, 2)", object.ASSERTION_FAILED_ERR},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Code != tt.expectedCode {
+			t.Errorf("wrong error code for %q. expected=%q, got=%q", tt.input, tt.expectedCode, errObj.Code)
+		}
+	}
+}
+
+func TestIsErrorCode(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`with_recover(fn() { 1 + true }, fn(e) { is_error_code(e, "TYPE_MISMATCH") })`, true},
+		{`with_recover(fn() { 1 + true }, fn(e) { is_error_code(e, "UNKNOWN_IDENTIFIER") })`, false},
+		{`with_recover(fn() { missing }, fn(e) { is_error_code(e, "UNKNOWN_IDENTIFIER") ? "handled" : "other" })`, "handled"},
+		{`is_error_code({"code": "TYPE_MISMATCH", "message": "x"}, "TYPE_MISMATCH")`, true},
+		{`is_error_code({"message": "x"}, "TYPE_MISMATCH")`, "first argument to `is_error_code` must be an error from with_recover, got a HASH without code"},
+		{`is_error_code(fn() { 1 + true }, "TYPE_MISMATCH")`, true},
+		{`is_error_code(fn() { 1 + true }, "UNKNOWN_IDENTIFIER")`, false},
+		{`is_error_code(fn() { 1 + 1 }, "TYPE_MISMATCH")`, false},
+		{`is_error_code(fn() { len(1) }, "WRONG_ARGUMENT_TYPE")`, true},
+		{`is_error_code(fn() { missing }, "UNKNOWN_IDENTIFIER") ? "handled" : "other"`, "handled"},
+		// ほかの組み込み関数と同じく値として扱える
+		{`let check = is_error_code; check(fn() { 1 / 0 }, "DIVISION_BY_ZERO")`, true},
+		{`map([fn() { 1 / 0 }, fn() { 1 }], fn(f) { is_error_code(f, "DIVISION_BY_ZERO") })`, "[true, false]"},
+		{`is_error_code(1 + true, "TYPE_MISMATCH")`, "type mismatch: INTEGER + BOOLEAN"},
+		{`is_error_code(1, "TYPE_MISMATCH")`, "first argument to `is_error_code` must be HASH or FUNCTION, got INTEGER"},
+		{`is_error_code(fn() { 1 }, 2)`, "second argument to `is_error_code` must be STRING, got INTEGER"},
+		{`is_error_code(fn() { 1 })`, "wrong number of arguments. got=1, want=2"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			if _, ok := evaluated.(*object.Error); ok {
+				testErrorObject(t, evaluated, expected)
+			} else if evaluated.Inspect() != expected {
+				t.Errorf("%s: expected %q, got %q", tt.input, expected, evaluated.Inspect())
+			}
+		}
+	}
+}
+
+func TestLetStatement(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let a = 5; a;", 5},
+		{"let a = 5 * 5; a;", 25},
+		{"let a = 5; let b = a; b;", 5},
+		{"let a = 5; let b = a; let c = a + b + 5; c", 15},
+		{"let 合計 = 5; 合計;", 5},
+		{"let ｘ = 5; x;", 5},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestFunctionObject(t *testing.T) {
+	input := "fn(x) { x + 2; };"
+
+	evaluated := testEval(input)
+	fn, ok := evaluated.(*object.Function)
+	if !ok {
+		t.Fatalf("object is not Function. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	if len(fn.Parameters) != 1 {
+		t.Fatalf("function has wrong parameters. Parameters=%+v", fn.Parameters)
+	}
+
+	if fn.Parameters[0].String() != "x" {
+		t.Fatalf("parameter is not 'x'. got=%q", fn.Parameters[0])
+	}
+
+	expectedBody := "(x + 2)"
+
+	if fn.Body.String() != expectedBody {
+		t.Fatalf("body is not %q. got=%q", expectedBody, fn.Body.String())
+	}
+}
+
+func TestFunctionApplication(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let identity = fn(x) { x; }; identity(5);", 5},
+		{"let identity = fn(x) { return x; }; identity(5);", 5},
+		{"let double = fn(x) { return x * 2; }; double(5);", 10},
+		{"let add = fn(x, y) { return x + y; }; add(5, 5);", 10},
+		{"let add = fn(x, y) { return x + y; }; add(5 + 5, add(5, 5));", 20},
+		{"fn(x) { x; }(5)", 5},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestClosures(t *testing.T) {
+	input := `
+let newAdder = fn(x) {
+	fn(y) { x + y };
+};
+
+let addTwo = newAdder(2);
+addTwo(2);
+`
+	testIntegerObject(t, testEval(input), int64(4))
+}
+
+func TestBuiltinFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`len("")`, 0},
+		{`len("four")`, 4},
+		{`len("hello world")`, 11},
+		{`len(1)`, "argument to `len` not supported, got INTEGER"},
+		{`len("one", "two")`, "wrong number of arguments. got=2, want=1"},
+		{`len([])`, 0},
+		{`len([1, 2, 3])`, 3},
+		{`len([1, 2, false, true])`, 4},
+		{`first(["foo", 2, true])`, "foo"},
+		{`first([2, true, "foo"])`, 2},
+		{`first([true, "foo", 2])`, true},
+		{`first([])`, nil},
+		{`first()`, "wrong number of arguments. got=0, want=1"},
+		{`first(1, 2)`, "wrong number of arguments. got=2, want=1"},
+		{`first(1)`, "argument to `first` must be Array, got INTEGER"},
+		{`last(["foo", 2, true])`, true},
+		{`last([2, true, "foo"])`, "foo"},
+		{`last([true, "foo", 2])`, 2},
+		{`last([])`, nil},
+		{`last()`, "wrong number of arguments. got=0, want=1"},
+		{`last(1, 2)`, "wrong number of arguments. got=2, want=1"},
+		{`last(1)`, "argument to `last` must be Array, got INTEGER"},
+		{`rest(["foo", 2, true])`, `[2, true]`},
+		{`rest([2, true, "foo"])`, `[true, "foo"]`},
+		{`rest([true, "foo", 2])`, `["foo", 2]`},
+		{`rest([1])`, "[]"},
+		{`rest([])`, nil},
+		{`rest()`, "wrong number of arguments. got=0, want=1"},
+		{`rest(1, 2)`, "wrong number of arguments. got=2, want=1"},
+		{`rest(1)`, "argument to `rest` must be Array, got INTEGER"},
+		{`let a = [1, 2]; rest(a);`, "[2]"},
+		{`let a = [1, 2]; rest(a); a;`, "[1, 2]"},
+		{`let a = [1, 2, 3, 4]; rest(rest(rest(rest(a))))`, "[]"},
+		{`let a = [1, 2, 3, 4]; rest(rest(a))`, "[3, 4]"},
+		{`let a = [1, 2]; push(a, 3)`, "[1, 2, 3]"},
+		{`let a = [1, 2]; push(a, 3); a;`, "[1, 2]"},
+		{`let a = [1, 2]; push(a, true)`, "[1, 2, true]"},
+		{`let a = []; push(a, 3)`, "[3]"},
+		{`let a = ["foo", "bar"]; push(a, 3)`, `["foo", "bar", 3]`},
+		{`let a = ["foo", "bar"]; let b = [1, 2]; push(a, b)`, `["foo", "bar", [1, 2]]`},
+		{`sort([3, 1, 2])`, "[1, 2, 3]"},
+		{`sort(["b", "c", "a"])`, `["a", "b", "c"]`},
+		{`sort([[2, 1], [1, 2], [1]])`, "[[1], [1, 2], [2, 1]]"},
+		{`sort([])`, "[]"},
+		{`let a = [2, 1]; sort(a); a`, "[2, 1]"},
+		{`sort([1, "a"])`, "cannot compare STRING and INTEGER"},
+		{`sort(1)`, "argument to `sort` must be Array, got INTEGER"},
+		{`sort([1, 3, 2], fn(a, b) { a > b })`, "[3, 2, 1]"},
+		{`sort([[2, "b"], [1, "a"]], fn(a, b) { a[0] < b[0] })`, `[[1, "a"], [2, "b"]]`},
+		{`sort([1, 2], fn(a, b) { a + "x" })`, "type mismatch: INTEGER + STRING"},
+		{`sort([1, 2], fn(a, b, c) { a })`, "wrong number of arguments. got=2, want=3"},
+		{`sort([1, 2], 1, 2)`, "wrong number of arguments. got=3, want=1 or 2"},
+		{`map([1, 2], fn(a, b) { a })`, "wrong number of arguments. got=1, want=2"},
+		{`divmod(7, 2)`, "[3, 1]"},
+		{`divmod(-7, 2)`, "[-3, -1]"},
+		{`divmod(7.5d, 2)`, "[3d, 1.5d]"},
+		{`divmod(-7.5d, 2)`, "[-3d, -1.5d]"},
+		{`divmod(1, 0)`, "division by zero"},
+		{`divmod(1, "a")`, "argument 2 to `divmod` must be INTEGER or DECIMAL, got STRING"},
+		{`min([3, 1, 2])`, 1},
+		{`min(3, 1, 2)`, 1},
+		{`max([3, 1, 2])`, 3},
+		{`max("a", "c", "b")`, "c"},
+		{`max([[1, 2], [1, 3]])`, "[1, 3]"},
+		{`min([])`, nil},
+		{`min()`, "wrong number of arguments. got=0, want=1+"},
+		{`min(1)`, "argument to `min` must be Array, got INTEGER"},
+		{`max(1, "a")`, "cannot compare STRING and INTEGER"},
+		{`assert_eq([1, 2], [1, 2])`, nil},
+		{`assert_eq(1, 2)`, "assert_eq failed:\n\texpected 1, got 2"},
+		{`assert_eq({"a": [1, 2], "b": 1}, {"a": [1, 3], "b": 1})`, "assert_eq failed:\n\t[\"a\"][1]: expected 2, got 3"},
+		{`assert_eq([1], [1, 2, 3])`, "assert_eq failed:\n\t[1]: unexpected 2\n\t[2]: unexpected 3"},
+		{`assert_eq(1)`, "wrong number of arguments. got=1, want=2"},
+		{`map([1, 2, 3], fn(x) { x * 2 })`, "[2, 4, 6]"},
+		{`map([], fn(x) { x })`, "[]"},
+		{`map([1], len)`, "argument to `len` not supported, got INTEGER"},
+		{`map(1, len)`, "argument to `map` must be Array, got INTEGER"},
+		{`filter([1, 2, 3, 4], fn(x) { x > 2 })`, "[3, 4]"},
+		{`filter([1, 2], fn(x) { false })`, "[]"},
+		{`filter([1, 2])`, "wrong number of arguments. got=1, want=2"},
+		{"/// doubles x\nlet double = fn(x) { x * 2 }; help(double)", "doubles x"},
+		{`let f = fn() { 1 }; help(f)`, nil},
+		{`help(len)`, "len(x): length of a string, array or deque"},
+		{`help(1)`, "argument to `help` must be FUNCTION, got INTEGER"},
+		{`freeze([1, fn(x) { x }])`, "cannot freeze FUNCTION"},
+		{`freeze()`, "wrong number of arguments. got=0, want=1"},
+		{`update_in([1, [2, 3]], [1, 0], fn(x) { x * 10 })`, "[1, [20, 3]]"},
+		{`update_in({"a": {"b": [1]}}, ["a", "b", 0], fn(x) { x + 1 })["a"]["b"][0]`, 2},
+		{`update_in({}, ["a"], fn(x) { x })["a"]`, nil},
+		{`update_in(5, [], fn(x) { x + 1 })`, 6},
+		{`let h = {"a": 1}; update_in(h, ["a"], fn(x) { x + 1 }); h["a"]`, 1},
+		{`update_in([1], [1], fn(x) { x })`, "index 1 out of range for array of length 1"},
+		{`update_in([1], ["a"], fn(x) { x })`, "array index in path must be INTEGER, got STRING"},
+		{`update_in([[1]], [0, 0], fn(x) { x + "a" })`, "type mismatch: INTEGER + STRING"},
+		{`update_in(1, [0], fn(x) { x })`, "index operator not supported: INTEGER"},
+		{`update_in([1], 0, fn(x) { x })`, "second argument to `update_in` must be Array, got INTEGER"},
+		{`assoc_in({}, ["a", "b"], 1)["a"]["b"]`, 1},
+		{`assoc_in([1, {"x": 2}], [1, "x"], 3)[1]["x"]`, 3},
+		{`assoc_in({"a": 1}, [], [2])`, "[2]"},
+		{`assoc_in({"a": 1}, [len], 1)`, "unusable as hash key: BUILTIN"},
+		{`with_recover(fn() { 1 + 1 }, fn(e) { 0 })`, 2},
+		{`with_recover(fn() { 1 / 0 }, fn(e) { e["code"] })`, "DIVISION_BY_ZERO"},
+		{`with_recover(fn() { foo }, fn(e) { e["message"] })`, "identifier not found: foo"},
+		{`with_recover(fn() { return 1; 2 }, fn(e) { 0 })`, 1},
+		{`with_recover(fn() { 1 / 0 }, fn(e) { e["x"] + 1 })`, "type mismatch: NULL + INTEGER"},
+		{`with_recover(1, fn(e) { 0 })`, "argument 1 to `with_recover` must be FUNCTION, got INTEGER"},
+		{`with_recover(fn() { 1 })`, "wrong number of arguments. got=1, want=2"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch evaluated := evaluated.(type) {
+		case *object.Integer:
+			if expected, ok := tt.expected.(int); ok {
+				testIntegerObject(t, evaluated, int64(expected))
+			} else {
+				t.Errorf("evaluated must not be object.Integer.")
+			}
+		case *object.String:
+			if expected, ok := tt.expected.(string); ok {
+				testStringObject(t, evaluated, expected)
+			} else {
+				t.Errorf("evaluated must not be object.String.")
+			}
+		case *object.Array:
+			if expected, ok := tt.expected.(string); ok {
+				testArrayObject(t, evaluated, expected)
+			} else {
+				t.Errorf("evaluated must not be object.Array.")
+			}
+		case *object.Error:
+			if expected, ok := tt.expected.(string); ok {
+				testErrorObject(t, evaluated, expected)
+			} else {
+				t.Errorf("evaluated must not be object.Error.")
+			}
+		}
+	}
+}
+
+func TestFreeze(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`is_frozen([1])`, false},
+		{`is_frozen(freeze([1]))`, true},
+		{`freeze([1, [2]]) == [1, [2]]`, false},
+		{`let a = [1, [2]]; freeze(a) == a`, true},
+		{`let a = [1]; freeze(a); is_frozen(a)`, true},
+		{`let h = {"a": [1]}; freeze(h); is_frozen(h["a"])`, true},
+		{`let a = [1, [2]]; freeze(a); is_frozen(a[1])`, true},
+		{`let a = [[1], len]; with_recover(fn() { freeze(a) }, fn(e) { if (is_error_code(e, "WRONG_ARGUMENT_TYPE")) { is_frozen(a[0]) } })`, false},
+		{`is_frozen(push(freeze([1]), 2))`, false},
+		{`is_frozen(1)`, true},
+		{`is_frozen("a")`, true},
+		{`is_frozen(len)`, false},
+		{`let k = freeze([1, 2]); {k: "pair"}[[1, 2]] == "pair"`, true},
+	}
+
+	for _, tt := range tests {
+		testBooleanObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestArrayLiterals(t *testing.T) {
+	input := "[1, 2 * 2, 3 + 3]"
+
+	evaluated := testEval(input)
+	fn, ok := evaluated.(*object.Array)
+	if !ok {
+		t.Fatalf("object is not Array. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	if len(fn.Elements) != 3 {
+		t.Fatalf("function has wrong parameters. Parameters=%+v", fn.Elements)
+	}
+
+	testIntegerObject(t, fn.Elements[0], 1)
+	testIntegerObject(t, fn.Elements[1], 4)
+	testIntegerObject(t, fn.Elements[2], 6)
+}
+
+func TestArrayIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{
+			"[1, 2, 3][0]",
+			1,
+		},
+		{
+			"[1, 2, 3][1]",
+			2,
+		},
+		{
+			"[1, 2, 3][2]",
+			3,
+		},
+		{
+			"let i = 0; [1][i];",
+			1,
+		},
+		{
+			"[1, 2, 3][1 + 1]",
+			3,
+		},
+		{
+			"let myArray = [1, 2, 3]; myArray[0] + myArray[1] + myArray[2];",
+			6,
+		},
+		{
+			"let myArray = [1, 2, 3]; let i = myArray[0]; myArray[i]",
+			2,
+		},
+		{
+			"[1, 2, 3][3]",
+			nil,
+		},
+		{
+			"[1, 2, 3][-1]",
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		integer, ok := tt.expected.(int)
+		if ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestHashLiterals(t *testing.T) {
+	input := `
+let two = "two";
+{
+	"one": 10 - 9,
+	two: 1 + 1,
+	"thr" + "ee": 6 / 2,
+	4: 4,
+	true: 5,
+	false: 6
+}`
+
+	evaluated := testEval(input)
+	result, ok := evaluated.(*object.Hash)
+	if !ok {
+		t.Fatalf("object is not Hash. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	expected := []struct {
+		key   object.Object
+		value int64
+	}{
+		{&object.String{Value: "one"}, 1},
+		{&object.String{Value: "two"}, 2},
+		{&object.String{Value: "three"}, 3},
+		{&object.Integer{Value: 4}, 4},
+		{TRUE, 5},
+		{FALSE, 6},
+	}
+
+	if result.Len() != len(expected) {
+		t.Fatalf("Hash has wrong num fo pairs. got=%d", result.Len())
+	}
+
+	for _, tt := range expected {
+		pair, ok := result.Get(tt.key)
+		if !ok {
+			t.Errorf("no pair for given key in Pairs")
+			continue
+		}
+
+		testIntegerObject(t, pair.Value, tt.value)
+	}
+
+}
+
+func TestCollectionConcatenation(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`[1, 2] + [3]`, "[1, 2, 3]"},
+		{`[] + []`, "[]"},
+		{`let a = [1]; a + a; a`, "[1]"},
+		{`{"a": 1, "b": 2} + {"b": 3}`, "{a: 1, b: 3}"},
+		{`let h = {"a": 1}; h + {"a": 2}; h["a"]`, "1"},
+		{`{} + {}`, "{}"},
+		{`[1] + 1`, "ERROR: type mismatch: ARRAY + INTEGER"},
+		{`[1] - [1]`, "ERROR: unknown operator: ARRAY - ARRAY"},
+		{`{} + []`, "ERROR: type mismatch: HASH + ARRAY"},
+	}
+
+	for _, tt := range tests {
+		if got := testEval(tt.input).Inspect(); got != tt.expected {
+			t.Errorf("%s: want=%q, got=%q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestHashIndexExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{
+			`{"foo": 5}["foo"]`,
+			5,
+		},
+		{
+			`{"foo": 5}["bar"]`,
+			nil,
+		},
+		{
+			`let key = "foo"; {"foo": 5}[key]`,
+			5,
+		},
+		{
+			`let key = "foo"; {key: 5}["foo"]`,
+			5,
+		},
+		{
+			`{}["foo"]`,
+			nil,
+		},
+		{
+			`{5: 5}[5]`,
+			5,
+		},
+		{
+			`{true: 5}[true]`,
+			5,
+		},
+		{
+			`{false: 5}[false]`,
+			5,
+		},
+		{
+			`let a = [1, 2]; {a: 5}[[1, 2]]`,
+			5,
+		},
+		// 2 == 2.0 なので、数値のキーは型によらず値で引く
+		{
+			`{2: 5}[2.0]`,
+			5,
+		},
+		{
+			`{1.5: 5}[3 / 2.0]`,
+			5,
+		},
+		{
+			`{[1, 2]: 5}[[1.0, 2.0]]`,
+			5,
+		},
+		{
+			`{2: 1, 2.0: 5}[2]`,
+			5,
+		},
+		{
+			`let a = {1: 1, 2: 2}; {a: 5}[{1: 1, (3 - 1): 2}]`,
+			5,
+		},
+		{
+			`let a = fn(x) { return x; }; {a: 5}[fn(x) { return x; }]`,
+			5,
+		},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		integer, ok := tt.expected.(int)
+		if ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func testEval(input string) object.Object {
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	env := object.NewEnvironment()
+
+	return Eval(program, env)
+}
+
+func testIntegerObject(t *testing.T, obj object.Object, expected int64) bool {
+	result, ok := obj.(*object.Integer)
+	if !ok {
+		t.Errorf("object is not Integer. got=%T (%+v)", obj, obj)
+		return false
+	}
+	if result.Value != expected {
+		t.Errorf("object has wrong value. got=%d, want=%d", result.Value, expected)
+		return false
+	}
+
+	return true
+}
+
+func testStringObject(t *testing.T, obj object.Object, expected string) bool {
+	result, ok := obj.(*object.String)
+	if !ok {
+		t.Errorf("object is not String. got=%T (%+v)", obj, obj)
+		return false
+	}
+	if result.Value != expected {
+		t.Errorf("object has wrong value. got=%q, want=%q", result.Value, expected)
+		return false
+	}
+
+	return true
+}
+
+func testBooleanObject(t *testing.T, obj object.Object, expected bool) bool {
+	result, ok := obj.(*object.Boolean)
+	if !ok {
+		t.Errorf("object is not Boolean. got=%T (%+v)", obj, obj)
+		return false
+	}
+	if result.Value != expected {
+		t.Errorf("object has wrong value. got=%t, want=%t", result.Value, expected)
+		return false
+	}
+
+	return true
+}
+
+func testArrayObject(t *testing.T, obj object.Object, expected string) bool {
+	result, ok := obj.(*object.Array)
+	if !ok {
+		t.Errorf("object is not Array. got=%T (%+v)", obj, obj)
+		return false
+	}
+	expectedEval := testEval(expected)
+	expectedArray := expectedEval.(*object.Array)
+	if !reflect.DeepEqual(result.Elements, expectedArray.Elements) {
+		t.Errorf("object has wrong value. got=%#v, want=%#v", result.Elements, expectedArray.Elements)
+		return false
+	}
+
+	return true
+}
+
+func testNullObject(t *testing.T, obj object.Object) bool {
+	if obj != NULL {
+		t.Errorf("object is not NULL. got=%T (%+v)", obj, obj)
+		return false
+	}
+	return true
+}
+
+func testErrorObject(t *testing.T, obj object.Object, expected string) bool {
+	result, ok := obj.(*object.Error)
+	if !ok {
+		t.Errorf("object is not String. got=%T (%+v)", obj, obj)
+		return false
+	}
+	if result.Message != expected {
+		t.Errorf("wrong error message. expected=%q, got=%q", expected, result.Message)
+	}
+
+	return true
+}
+
+func TestPanicBecomesInternalError(t *testing.T) {
+	RegisterBuiltin("test_unchecked", &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			return args[0].(*object.Integer)
+		},
+	})
+	defer delete(builtins, "test_unchecked")
+
+	program := parser.New(lexer.New(`let f = fn() { test_unchecked("x") }; f()`)).ParseProgram()
+	for _, evaluated := range []object.Object{
+		Eval(program, object.NewEnvironment()),
+		EvalWithStats(program, object.NewEnvironment()).Value,
+	} {
+		err, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Fatalf("expected an error, got %T (%+v)", evaluated, evaluated)
+		}
+		if err.Code != object.INTERNAL_ERR {
+			t.Errorf("wrong code. expected=%q, got=%q", object.INTERNAL_ERR, err.Code)
+		}
+		if !strings.HasPrefix(err.Message, "internal error: interface conversion") {
+			t.Errorf("wrong message: %q", err.Message)
+		}
+		if !strings.Contains(err.GoStack, "TestPanicBecomesInternalError") {
+			t.Errorf("Go stack was not captured:\n%s", err.GoStack)
+		}
+	}
+}
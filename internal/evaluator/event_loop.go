@@ -0,0 +1,146 @@
+package evaluator
+
+import (
+	"sort"
+	"time"
+
+	"github.com/al-keio/monkey-go/object"
+)
+
+type timer struct {
+	id       int64
+	fn       object.Object
+	due      time.Time
+	interval time.Duration
+	repeat   bool
+	ticks    int64
+}
+
+// timerQueue は一回の評価の中で set_timeout / set_interval により登録されたタイマーを保持する
+type timerQueue struct {
+	nextID int64
+	timers []*timer
+}
+
+func (q *timerQueue) add(t *timer) {
+	q.nextID++
+	t.id = q.nextID
+	q.push(t)
+}
+
+func (q *timerQueue) push(t *timer) {
+	q.timers = append(q.timers, t)
+	sort.SliceStable(q.timers, func(i, j int) bool {
+		return q.timers[i].due.Before(q.timers[j].due)
+	})
+}
+
+func (q *timerQueue) pop() *timer {
+	t := q.timers[0]
+	q.timers = q.timers[1:]
+	return t
+}
+
+func (q *timerQueue) remove(id int64) bool {
+	for i, t := range q.timers {
+		if t.id == id {
+			q.timers = append(q.timers[:i], q.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (ev *evaluation) timerQueue() *timerQueue {
+	if ev.timers == nil {
+		ev.timers = &timerQueue{}
+	}
+	return ev.timers
+}
+
+// runLoop は登録されたタイマーがなくなるまで、期限の早い順にコールバックを呼び出す。
+// コールバックがエラーを返した場合はそこでループを止めてエラーを返す。
+func (ev *evaluation) runLoop() object.Object {
+	q := ev.timerQueue()
+
+	for len(q.timers) > 0 {
+		t := q.pop()
+		if wait := time.Until(t.due); wait > 0 {
+			if err := ev.sleep(wait); err != nil {
+				return err
+			}
+		}
+
+		t.ticks++
+		if t.repeat {
+			t.due = t.due.Add(t.interval)
+			q.push(t)
+		}
+
+		result := ev.applyFunction(t.fn, []object.Object{&object.Integer{Value: t.ticks}}, scopeLabel(calleeName(nil), ""))
+		if isError(result) {
+			return result
+		}
+	}
+
+	return NULL
+}
+
+func scheduleTimer(name string, repeat bool, doc string) *object.Builtin {
+	return &object.Builtin{
+		Doc:          doc,
+		Capabilities: []object.Capability{object.TIME_CAP},
+		ApplyFn: func(applier object.Applier, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=2", len(args))
+			}
+			switch args[0].(type) {
+			case *object.Function, *object.Builtin:
+			default:
+				return newError(object.WRONG_ARGUMENT_TYPE_ERR, "first argument to `%s` must be FUNCTION, got %s", name, args[0].Type())
+			}
+			ms, ok := args[1].(*object.Integer)
+			if !ok {
+				return newError(object.WRONG_ARGUMENT_TYPE_ERR, "second argument to `%s` must be INTEGER, got %s", name, args[1].Type())
+			}
+
+			interval := time.Duration(ms.Value) * time.Millisecond
+			if interval < 0 {
+				interval = 0
+			}
+
+			t := &timer{fn: args[0], due: time.Now().Add(interval), interval: interval, repeat: repeat}
+			applier.(*evaluation).timerQueue().add(t)
+
+			return &object.Integer{Value: t.id}
+		},
+	}
+}
+
+func init() {
+	builtins["set_timeout"] = scheduleTimer("set_timeout", false, "set_timeout(fn, ms): call fn once after ms milliseconds of run_loop; returns a timer id")
+	builtins["set_interval"] = scheduleTimer("set_interval", true, "set_interval(fn, ms): call fn(tick) every ms milliseconds of run_loop; returns a timer id")
+	builtins["clear_timeout"] = &object.Builtin{
+		Doc: "clear_timeout(id): cancel a timer; returns whether it was pending",
+		ApplyFn: func(applier object.Applier, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+			id, ok := args[0].(*object.Integer)
+			if !ok {
+				return newError(object.WRONG_ARGUMENT_TYPE_ERR, "argument to `clear_timeout` must be INTEGER, got %s", args[0].Type())
+			}
+			return nativeBoolToBooleanObject(applier.(*evaluation).timerQueue().remove(id.Value))
+		},
+	}
+	builtins["run_loop"] = &object.Builtin{
+		Doc:          "run_loop(): run scheduled timers until none are left",
+		Capabilities: []object.Capability{object.TIME_CAP},
+		ApplyFn: func(applier object.Applier, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=0", len(args))
+			}
+			return applier.(*evaluation).runLoop()
+		},
+	}
+}
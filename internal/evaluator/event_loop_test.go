@@ -0,0 +1,73 @@
+package evaluator
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/al-keio/monkey-go/object"
+)
+
+func TestEventLoop(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{
+			`set_timeout(fn() { record("b") }, 20); set_timeout(fn() { record("a") }, 1); run_loop();`,
+			[]string{"a", "b"},
+		},
+		{
+			`set_timeout(fn() { record("a"); set_timeout(fn() { record("c") }, 1) }, 1);
+			 set_timeout(fn() { record("b") }, 1);
+			 run_loop();`,
+			[]string{"a", "b", "c"},
+		},
+		{
+			`let id = set_interval(fn(n) { record(n); if (n == 3) { clear_timeout(id) } }, 1); run_loop();`,
+			[]string{"1", "2", "3"},
+		},
+		{
+			`let id = set_timeout(fn() { record("never") }, 1); clear_timeout(id); run_loop();`,
+			[]string{},
+		},
+		{
+			`run_loop();`,
+			[]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		recorded := []string{}
+		RegisterBuiltin("record", &object.Builtin{
+			Fn: func(args ...object.Object) object.Object {
+				recorded = append(recorded, args[0].Inspect())
+				return NULL
+			},
+		})
+
+		evaluated := testEval(tt.input)
+		delete(builtins, "record")
+
+		testNullObject(t, evaluated)
+		if !reflect.DeepEqual(recorded, tt.expected) {
+			t.Errorf("callbacks ran in wrong order. want=%q, got=%q", tt.expected, recorded)
+		}
+	}
+}
+
+func TestEventLoopErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`set_timeout(fn() { foobar }, 1); run_loop();`, "identifier not found: foobar"},
+		{`set_timeout(1, 1)`, "first argument to `set_timeout` must be FUNCTION, got INTEGER"},
+		{`set_interval(fn() {}, "1")`, "second argument to `set_interval` must be INTEGER, got STRING"},
+		{`clear_timeout("1")`, "argument to `clear_timeout` must be INTEGER, got STRING"},
+		{`run_loop(1)`, "wrong number of arguments. got=1, want=0"},
+	}
+
+	for _, tt := range tests {
+		testErrorObject(t, testEval(tt.input), tt.expected)
+	}
+}
@@ -0,0 +1,158 @@
+package evaluator
+
+import (
+	"path"
+	"regexp"
+	"sort"
+
+	"github.com/al-keio/monkey-go/object"
+)
+
+func init() {
+	builtins["map_values"] = &object.Builtin{
+		Doc: "map_values(hash, fn): new hash with the same keys and fn(value) as values",
+		ApplyFn: func(applier object.Applier, args ...object.Object) object.Object {
+			hash, err := hashAndFunctionArgs("map_values", 2, args)
+			if err != nil {
+				return err
+			}
+
+			mapped := object.NewHash()
+			for _, pair := range sortedPairs(hash) {
+				value := applier.Apply(args[1], pair.Value)
+				if isError(value) {
+					return value
+				}
+				mapped.Set(pair.Key, value)
+			}
+			return mapped
+		},
+	}
+	builtins["filter_keys"] = &object.Builtin{
+		Doc: "filter_keys(hash, fn): new hash of the pairs for which fn(key) is truthy",
+		ApplyFn: func(applier object.Applier, args ...object.Object) object.Object {
+			hash, err := hashAndFunctionArgs("filter_keys", 2, args)
+			if err != nil {
+				return err
+			}
+
+			filtered := object.NewHash()
+			for _, pair := range sortedPairs(hash) {
+				keep := applier.Apply(args[1], pair.Key)
+				if isError(keep) {
+					return keep
+				}
+				if isTruthy(keep) {
+					filtered.Set(pair.Key, pair.Value)
+				}
+			}
+			return filtered
+		},
+	}
+	builtins["reduce_pairs"] = &object.Builtin{
+		Doc: "reduce_pairs(hash, fn, initial): fold fn(acc, key, value) over the pairs in key order, starting from initial",
+		ApplyFn: func(applier object.Applier, args ...object.Object) object.Object {
+			hash, err := hashAndFunctionArgs("reduce_pairs", 3, args)
+			if err != nil {
+				return err
+			}
+
+			acc := args[2]
+			for _, pair := range sortedPairs(hash) {
+				acc = applier.Apply(args[1], acc, pair.Key, pair.Value)
+				if isError(acc) {
+					return acc
+				}
+			}
+			return acc
+		},
+	}
+	builtins["match_keys"] = &object.Builtin{
+		Doc: "match_keys(hash, pattern): new hash of the pairs whose string key matches the glob pattern (*, ?, [a-z]; * does not match /)",
+		ApplyFn: func(applier object.Applier, args ...object.Object) object.Object {
+			hash, pattern, err := hashAndPatternArgs("match_keys", args)
+			if err != nil {
+				return err
+			}
+			if _, e := path.Match(pattern, ""); e != nil {
+				return newError(object.INVALID_VALUE_ERR, "invalid pattern for `match_keys`: %q", pattern)
+			}
+			return selectKeys(hash, func(key string) bool {
+				matched, _ := path.Match(pattern, key)
+				return matched
+			})
+		},
+	}
+	builtins["match_keys_regex"] = &object.Builtin{
+		Doc: "match_keys_regex(hash, regex): new hash of the pairs whose string key contains a match of regex",
+		ApplyFn: func(applier object.Applier, args ...object.Object) object.Object {
+			hash, pattern, err := hashAndPatternArgs("match_keys_regex", args)
+			if err != nil {
+				return err
+			}
+			re, e := regexp.Compile(pattern)
+			if e != nil {
+				return newError(object.INVALID_VALUE_ERR, "invalid regex for `match_keys_regex`: %s", e)
+			}
+			return selectKeys(hash, re.MatchString)
+		},
+	}
+}
+
+// selectKeys は文字列のキーのうち match が真になる組だけを持つハッシュを返す。文字列でないキーは含めない。
+func selectKeys(hash *object.Hash, match func(string) bool) *object.Hash {
+	selected := object.NewHash()
+	for _, pair := range sortedPairs(hash) {
+		if key, ok := pair.Key.(*object.String); ok && match(key.Value) {
+			selected.Set(pair.Key, pair.Value)
+		}
+	}
+	return selected
+}
+
+func hashAndPatternArgs(name string, args []object.Object) (*object.Hash, string, *object.Error) {
+	if len(args) != 2 {
+		return nil, "", newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=2", len(args))
+	}
+	hash, ok := args[0].(*object.Hash)
+	if !ok {
+		return nil, "", newError(object.WRONG_ARGUMENT_TYPE_ERR, "argument to `%s` must be HASH, got %s", name, args[0].Type())
+	}
+	pattern, ok := args[1].(*object.String)
+	if !ok {
+		return nil, "", newError(object.WRONG_ARGUMENT_TYPE_ERR, "pattern for `%s` must be STRING, got %s", name, args[1].Type())
+	}
+	return hash, pattern.Value, nil
+}
+
+func hashAndFunctionArgs(name string, want int, args []object.Object) (*object.Hash, *object.Error) {
+	if len(args) != want {
+		return nil, newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=%d", len(args), want)
+	}
+	hash, ok := args[0].(*object.Hash)
+	if !ok {
+		return nil, newError(object.WRONG_ARGUMENT_TYPE_ERR, "argument to `%s` must be HASH, got %s", name, args[0].Type())
+	}
+	return hash, nil
+}
+
+// sortedPairs はハッシュを決まった順に辿るための組の列を返す。
+// 比較できるキー同士は値の順、そうでなければ型名の順に並べる。ハッシュに順序が入るまではこれで代用する。
+func sortedPairs(hash *object.Hash) []object.HashPair {
+	pairs := hash.PairList()
+
+	sort.Slice(pairs, func(i, j int) bool {
+		a, b := pairs[i].Key, pairs[j].Key
+		if result, ok := object.Compare(a, b); ok {
+			return result < 0
+		}
+		if a.Type() != b.Type() {
+			return a.Type() < b.Type()
+		}
+		if ha, hb := a.(object.Hashable).HashKey().Value, b.(object.Hashable).HashKey().Value; ha != hb {
+			return ha < hb
+		}
+		return a.Inspect() < b.Inspect()
+	})
+	return pairs
+}
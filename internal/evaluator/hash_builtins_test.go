@@ -0,0 +1,41 @@
+package evaluator
+
+import "testing"
+
+func TestHashBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`map_values({"a": 1, "b": 2}, fn(v) { v * 10 })`, "{a: 10, b: 20}"},
+		{`map_values({}, fn(v) { v })`, "{}"},
+		{`let h = {"a": 1}; map_values(h, fn(v) { v + 1 }); h`, "{a: 1}"},
+		{`filter_keys({"a": 1, "b": 2, "c": 3}, fn(k) { k != "b" })`, "{a: 1, c: 3}"},
+		{`filter_keys({1: "x", 2: "y"}, fn(k) { k > 5 })`, "{}"},
+		{`reduce_pairs({"b": 2, "a": 1}, fn(acc, k, v) { acc + k }, "")`, "ab"},
+		{`reduce_pairs({"a": 1, "b": 2}, fn(acc, k, v) { acc + v }, 0)`, "3"},
+		{`reduce_pairs({3: 1, 1: 2, 2: 3}, fn(acc, k, v) { push(acc, k) }, [])`, "[1, 2, 3]"},
+		{`reduce_pairs({}, fn(acc, k, v) { acc + v }, 0)`, "0"},
+		{`reduce_pairs({true: 1, 2: 1, "s": 1}, fn(acc, k, v) { push(acc, k) }, [])`, "[true, 2, s]"},
+		{`map_values({"a": 1}, fn(v) { v + "x" })`, "ERROR: type mismatch: INTEGER + STRING"},
+		{`filter_keys([1], fn(k) { true })`, "ERROR: argument to `filter_keys` must be HASH, got ARRAY"},
+		{`reduce_pairs({}, fn(acc, k, v) { acc })`, "ERROR: wrong number of arguments. got=2, want=3"},
+		{`match_keys({"db.host": "h", "db.port": 1, "log.level": "info"}, "db.*")`, "{db.host: h, db.port: 1}"},
+		{`match_keys({"a.b.c": 1, "a.x": 2, "b": 3}, "a.?")`, "{a.x: 2}"},
+		{`match_keys({"a/b": 1, "a.b": 2}, "a*")`, "{a.b: 2}"},
+		{`match_keys({"k1": 1, 2: 2, "k3": 3}, "k[0-2]")`, "{k1: 1}"},
+		{`match_keys({"a": 1}, "[")`, `ERROR: invalid pattern for ` + "`match_keys`" + `: "["`},
+		{`match_keys([1], "*")`, "ERROR: argument to `match_keys` must be HASH, got ARRAY"},
+		{`match_keys({}, 1)`, "ERROR: pattern for `match_keys` must be STRING, got INTEGER"},
+		{`match_keys_regex({"db.host": 1, "db.port": 2, "dbx": 3}, "^db\.(host|user)$")`, "{db.host: 1}"},
+		{`match_keys_regex({"user_id": 1, "id": 2, "name": 3}, "id")`, "{id: 2, user_id: 1}"},
+		{`match_keys_regex({}, "(")`, "ERROR: invalid regex for `match_keys_regex`: error parsing regexp: missing closing ): `(`"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package evaluator
+
+import (
+	"github.com/al-keio/monkey-go/diagnostics"
+	"github.com/al-keio/monkey-go/object"
+	"github.com/al-keio/monkey-go/token"
+)
+
+// identifierHint は見つからなかった名前 name に近い名前を、env で見える束縛、組み込み関数、名前空間から探す。
+// 見つからなければ retrun のようなキーワードの書き間違いとみなしてキーワードから探す。
+func identifierHint(name string, env *object.Environment) string {
+	candidates := env.Names()
+	for builtin := range builtins {
+		candidates = append(candidates, builtin)
+	}
+	for namespace := range namespaces {
+		candidates = append(candidates, namespace)
+	}
+	if hint := diagnostics.DidYouMean(name, candidates); hint != "" {
+		return hint
+	}
+
+	keywords := []string{}
+	for keyword := range token.DefaultKeywords {
+		keywords = append(keywords, keyword)
+	}
+	return diagnostics.DidYouMean(name, keywords)
+}
+
+func memberHint(name string, namespace *object.Namespace) string {
+	members := []string{}
+	for member := range namespace.Members {
+		members = append(members, member)
+	}
+	return diagnostics.DidYouMean(name, members)
+}
@@ -0,0 +1,32 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/al-keio/monkey-go/object"
+)
+
+func TestErrorHints(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"lng([1, 2])", "did you mean: len?"},
+		{"let counter = 1; countr", "did you mean: counter?"},
+		{"let f = fn(total) { totl }; f(1)", "did you mean: total?"},
+		{"retrun", "did you mean: return, true?"},
+		{`string.splt("a b", " ")`, "did you mean: split?"},
+		{"qwertyuiop", ""},
+	}
+
+	for _, tt := range tests {
+		err, ok := testEval(tt.input).(*object.Error)
+		if !ok {
+			t.Errorf("%q: expected an error", tt.input)
+			continue
+		}
+		if err.Hint != tt.expected {
+			t.Errorf("%q: hint wrong. expected=%q, got=%q", tt.input, tt.expected, err.Hint)
+		}
+	}
+}
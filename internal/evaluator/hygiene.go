@@ -0,0 +1,58 @@
+package evaluator
+
+import (
+	"github.com/al-keio/monkey-go/ast"
+	"github.com/al-keio/monkey-go/object"
+)
+
+type IdentifierOrigin struct {
+	Name      string
+	FromMacro bool // マクロ定義側で導入された識別子
+	Captures  bool // 呼び出し側に同名の識別子があり、意図せず捕捉しうる
+}
+
+type HygieneReport struct {
+	Expanded    ast.Node
+	Identifiers []IdentifierOrigin
+}
+
+// TraceMacroHygiene は program のマクロを展開し、展開後に現れる各識別子が
+// 呼び出し側で書かれたものか、マクロ定義側で導入されたものかを報告する。
+// 呼び出し側の引数もマクロ本体もノードを共有したまま展開結果に埋め込まれ、マクロ定義は
+// DefineMacros で program から取り除かれているため、ノードの同一性で出自を判別できる。
+func TraceMacroHygiene(program ast.Node, env *object.Environment) *HygieneReport {
+	callSite := map[*ast.Identifier]bool{}
+	callSiteNames := map[string]bool{}
+	walkIdentifiers(program, func(ident *ast.Identifier) {
+		callSite[ident] = true
+		callSiteNames[ident.Value] = true
+	})
+
+	expanded := ExpandMacros(program, env)
+
+	report := &HygieneReport{Expanded: expanded, Identifiers: []IdentifierOrigin{}}
+	seen := map[IdentifierOrigin]bool{}
+	walkIdentifiers(expanded, func(ident *ast.Identifier) {
+		origin := IdentifierOrigin{Name: ident.Value, FromMacro: !callSite[ident]}
+		origin.Captures = origin.FromMacro && callSiteNames[ident.Value]
+
+		if !seen[origin] {
+			seen[origin] = true
+			report.Identifiers = append(report.Identifiers, origin)
+		}
+	})
+
+	return report
+}
+
+func walkIdentifiers(node ast.Node, fn func(*ast.Identifier)) {
+	ast.Modify(node, func(node ast.Node) ast.Node {
+		switch node := node.(type) {
+		case *ast.Identifier:
+			fn(node)
+		case *ast.LetStatement:
+			fn(node.Name)
+		}
+		return node
+	})
+}
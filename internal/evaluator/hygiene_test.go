@@ -0,0 +1,35 @@
+package evaluator
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/al-keio/monkey-go/object"
+)
+
+func TestTraceMacroHygiene(t *testing.T) {
+	input := `
+let addOne = macro(x) { quote(fn(tmp) { tmp + unquote(x) }(1)); };
+let tmp = 10;
+addOne(tmp + y);
+`
+	program := testParseProgram(input)
+	env := object.NewEnvironment()
+	DefineMacros(program, env)
+
+	report := TraceMacroHygiene(program, env)
+
+	expected := []IdentifierOrigin{
+		{Name: "tmp", FromMacro: false},
+		{Name: "tmp", FromMacro: true, Captures: true},
+		{Name: "y", FromMacro: false},
+	}
+
+	if !reflect.DeepEqual(report.Identifiers, expected) {
+		t.Errorf("identifiers wrong.\nwant=%+v\ngot= %+v", expected, report.Identifiers)
+	}
+
+	if report.Expanded.String() != "let tmp = 10;fn(tmp) (tmp + (tmp + y))(1)" {
+		t.Errorf("expanded wrong. got=%q", report.Expanded.String())
+	}
+}
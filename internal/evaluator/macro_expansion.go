@@ -0,0 +1,167 @@
+package evaluator
+
+import (
+	"runtime/debug"
+
+	"github.com/al-keio/monkey-go/ast"
+	"github.com/al-keio/monkey-go/object"
+)
+
+func DefineMacros(program *ast.Program, env *object.Environment) {
+	definitions := []int{}
+
+	for i, statement := range program.Statements {
+		if isMacroDefinition(statement) {
+			addMacro(statement, env)
+			definitions = append(definitions, i)
+		}
+	}
+
+	for i := len(definitions) - 1; i >= 0; i-- {
+		definitionIndex := definitions[i]
+		program.Statements = append(program.Statements[:definitionIndex], program.Statements[definitionIndex+1:]...)
+	}
+}
+
+func isMacroDefinition(node ast.Statement) bool {
+	letStatement, ok := node.(*ast.LetStatement)
+	if !ok {
+		return false
+	}
+
+	if _, ok := letStatement.Value.(*ast.MacroLiteral); !ok {
+		return false
+	}
+
+	return true
+}
+
+func addMacro(stmt ast.Statement, env *object.Environment) {
+	letStatement, _ := stmt.(*ast.LetStatement)
+	macroLiteral, _ := letStatement.Value.(*ast.MacroLiteral)
+
+	macro := &object.Macro{
+		Parameters: macroLiteral.Parameters,
+		Env:        env,
+		Body:       macroLiteral.Body,
+	}
+	env.Set(letStatement.Name.Value, macro)
+}
+
+// ExpandMacros はマクロ呼び出しを展開した木を返す。引数が足りない呼び出しや、quote 以外
+// (エラーを含む) を返したマクロの呼び出しは展開せずに残すので、評価したときにエラーになる。
+// マクロが返したエラーそのものを知りたければ ExpandMacrosWithOptions を使う。
+// マクロ本体の評価は制限しないので、信頼できないプログラムには ExpandMacrosWithOptions を使うこと。
+func ExpandMacros(program ast.Node, env *object.Environment) ast.Node {
+	expanded, _ := ExpandMacrosWithOptions(program, env, EvalOptions{})
+	return expanded
+}
+
+// ExpandMacrosWithOptions は ExpandMacros と同じくマクロ呼び出しを展開する。マクロ本体と unquote の引数の評価には、
+// 評価と同じく options のステップ数と呼び出しの深さの上限、Context の取り消しを課す。
+// 上限に達したらそれ以降のマクロ呼び出しは展開せずに残し、STEP_LIMIT、CALL_DEPTH、CANCELLED のエラーを返す。
+// マクロがエラーを返したとき (unquote の引数のエラーを含む) も同じく、それ以降を展開せずにそのエラーを返す。
+// ステップ数は展開全体で数える。マクロ本体の puts の出力は options.Output に書く。
+func ExpandMacrosWithOptions(program ast.Node, env *object.Environment, options EvalOptions) (expanded ast.Node, err *object.Error) {
+	ev := &evaluation{options: options}
+	defer func() {
+		if r := recover(); r != nil {
+			err = newError(object.INTERNAL_ERR, "internal error: %v", r)
+			err.GoStack = string(debug.Stack())
+			expanded = program
+		}
+	}()
+	defer ev.flushOutput()
+
+	return ev.expandMacros(program, env)
+}
+
+// expandMacros は ev の上限の下でマクロを展開し、展開中に上限に達したかマクロがエラーを返したらそのエラーも返す
+func (ev *evaluation) expandMacros(program ast.Node, env *object.Environment) (ast.Node, *object.Error) {
+	var failed *object.Error
+	// with_recover で回復した CALL_DEPTH のように、展開の前に達した上限では展開をやめない
+	outer := ev.limit
+	ev.limit = nil
+	defer func() {
+		if ev.limit == nil {
+			ev.limit = outer
+		}
+	}()
+
+	expanded := ast.Modify(program, func(node ast.Node) ast.Node {
+		callExpression, ok := node.(*ast.CallExpression)
+		if !ok || ev.limit != nil || failed != nil {
+			return node
+		}
+
+		macro, ok := isMacroCall(callExpression, env)
+		if !ok || len(callExpression.Arguments) < len(macro.Parameters) {
+			return node
+		}
+		// step は Context を時々しか調べないので、短いマクロばかりでも取り消しに気づけるようにする
+		if err := ev.checkContext(); err != nil {
+			ev.limited(err)
+			return node
+		}
+
+		args := quoteArgs(callExpression)
+		evalEnv := extendMacroEnv(macro, args)
+
+		// quote は木を書き換えないので、マクロ本体は複製せずに展開ごとに共有する
+		evaluated := ev.eval(macro.Body, evalEnv)
+
+		if err, ok := evaluated.(*object.Error); ok {
+			failed = err
+			return node
+		}
+		// with_recover で回復したエラーのように、quote が返っても上限に達していれば展開しない
+		quote, ok := evaluated.(*object.Quote)
+		if !ok || ev.limit != nil {
+			return node
+		}
+		return quote.Node
+	})
+	if ev.limit != nil {
+		return expanded, ev.limit
+	}
+	return expanded, failed
+}
+
+func isMacroCall(exp *ast.CallExpression, env *object.Environment) (*object.Macro, bool) {
+	identifier, ok := exp.Function.(*ast.Identifier)
+	if !ok {
+		return nil, false
+	}
+
+	obj, ok := env.Get(identifier.Value)
+	if !ok {
+		return nil, false
+	}
+
+	macro, ok := obj.(*object.Macro)
+	if !ok {
+		return nil, false
+	}
+
+	return macro, true
+}
+
+func quoteArgs(exp *ast.CallExpression) []*object.Quote {
+	args := []*object.Quote{}
+
+	for _, a := range exp.Arguments {
+		args = append(args, &object.Quote{Node: a})
+	}
+
+	return args
+}
+
+func extendMacroEnv(macro *object.Macro, args []*object.Quote) *object.Environment {
+	extended := object.NewEnclosedEnvironment(macro.Env)
+
+	for paramIdx, param := range macro.Parameters {
+		extended.Set(param.Value, args[paramIdx])
+	}
+
+	return extended
+}
@@ -0,0 +1,228 @@
+package evaluator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/al-keio/monkey-go/ast"
+	"github.com/al-keio/monkey-go/internal/lexer"
+	"github.com/al-keio/monkey-go/internal/parser"
+	"github.com/al-keio/monkey-go/object"
+)
+
+func TestDefineMacros(t *testing.T) {
+	input := `
+let number = 1;
+let function = fn(x, y) { x + y; };
+let mymacro = macro(x, y) { x + y; };
+`
+	env := object.NewEnvironment()
+	program := testParseProgram(input)
+
+	DefineMacros(program, env)
+
+	if len(program.Statements) != 2 {
+		t.Fatalf("wrong number of statements. got=%d", len(program.Statements))
+	}
+
+	if _, ok := env.Get("number"); ok {
+		t.Fatalf("number should not be defined")
+	}
+
+	if _, ok := env.Get("function"); ok {
+		t.Fatalf("function should not be defined")
+	}
+
+	obj, ok := env.Get("mymacro")
+	if !ok {
+		t.Fatalf("mymacro should be defined")
+	}
+
+	macro, ok := obj.(*object.Macro)
+	if !ok {
+		t.Fatalf("object is not Macro. got=%T (%+v)", obj, obj)
+	}
+
+	if len(macro.Parameters) != 2 {
+		t.Fatalf("wrong number of macro parameters. got=%d", len(macro.Parameters))
+	}
+
+	if macro.Parameters[0].String() != "x" {
+		t.Fatalf("parameter is not 'x'. got=%q", macro.Parameters[0].String())
+	}
+	if macro.Parameters[1].String() != "y" {
+		t.Fatalf("parameter is not 'y'. got=%q", macro.Parameters[0].String())
+	}
+
+	expectedBody := "(x + y)"
+
+	if macro.Body.String() != expectedBody {
+		t.Fatalf("body is not %q, got=%q.", expectedBody, macro.Body.String())
+	}
+}
+
+func TestExpandMacros(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`let infixExpression = macro() { quote(1 + 2); }; infixExpression()`,
+			`(1 + 2)`,
+		},
+		{
+			`let reverse = macro(a, b) { quote(unquote(b) - unquote(a)); }; reverse(2 + 2, 10 - 5);`,
+			`(10 - 5) - (2 + 2)`,
+		},
+		{
+			`
+let unless = macro(condition, consequence, alternative) {
+	quote(
+		if (!(unquote(condition))) {
+			unquote(consequence);
+		} else {
+			unquote(alternative);
+		}
+	);
+};
+
+unless((10 > 5), puts("not greater"), puts("greater"));
+`,
+			`if (!(10 > 5)) { puts("not greater") } else { puts("greater") };`,
+		},
+		{
+			`let notQuote = macro() { 1 }; notQuote()`,
+			`notQuote()`,
+		},
+		{
+			`let one = macro(x) { quote(unquote(x)) }; one()`,
+			`one()`,
+		},
+		{
+			`let call = macro(f, args) { quote(unquote(f)(unquote_splice(args))); }; call(add, [1, 2, 3]);`,
+			`add(1, 2, 3)`,
+		},
+	}
+
+	for _, tt := range tests {
+		expected := testParseProgram(tt.expected)
+		program := testParseProgram(tt.input)
+
+		env := object.NewEnvironment()
+		DefineMacros(program, env)
+		expanded := ExpandMacros(program, env)
+		t.Logf("%+v", expanded)
+
+		if expanded.String() != expected.String() {
+			t.Errorf("not equal. want=%q, got=%q.", expected.String(), expanded.String())
+		}
+	}
+}
+
+func TestExpandMacrosSharesMacroBody(t *testing.T) {
+	input := `
+let wrap = macro(x) { quote([0, unquote_splice(x), unquote(x)]); };
+wrap([1, 2]);
+wrap([3]);
+`
+	program := testParseProgram(input)
+
+	env := object.NewEnvironment()
+	DefineMacros(program, env)
+
+	obj, _ := env.Get("wrap")
+	body := obj.(*object.Macro).Body
+	before := body.String()
+
+	expanded := ExpandMacros(program, env)
+
+	expected := "[0, 1, 2, [1, 2]][0, 3, [3]]"
+	if expanded.String() != expected {
+		t.Errorf("not equal. want=%q, got=%q.", expected, expanded.String())
+	}
+	if body.String() != before {
+		t.Errorf("macro body was modified by expansion. before=%q, after=%q", before, body.String())
+	}
+}
+
+func TestExpandMacrosWithOptions(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		input    string
+		options  EvalOptions
+		code     string
+		expected string
+	}{
+		{
+			"let twice = macro(x) { quote(unquote(x) * 2) }; twice(3)",
+			EvalOptions{MaxSteps: 100, MaxCallDepth: 10},
+			"",
+			"(3 * 2)",
+		},
+		{
+			"let m = macro() { let loop = fn(n) { loop(n + 1) }; quote(unquote(loop(0))) }; m(); 1",
+			EvalOptions{MaxSteps: 1000},
+			object.STEP_LIMIT_ERR,
+			"m()1",
+		},
+		{
+			"let m = macro() { quote(unquote(fn(f) { f(f) }(fn(f) { f(f) }))) }; m()",
+			EvalOptions{MaxCallDepth: 50},
+			object.CALL_DEPTH_ERR,
+			"m()",
+		},
+		{
+			"let m = macro(x) { quote([unquote_splice(missing)]) }; m(1); m(2)",
+			EvalOptions{},
+			object.UNKNOWN_IDENTIFIER_ERR,
+			"m(1)m(2)",
+		},
+		{
+			`let m = macro(x) { quote(unquote(len(io.read_file("/etc/hostname")))) }; m(1)`,
+			EvalOptions{Sandbox: true},
+			object.CAPABILITY_DENIED_ERR,
+			"m(1)",
+		},
+		{
+			"let m = macro() { quote(1) }; m()",
+			EvalOptions{Context: cancelled},
+			object.CANCELLED_ERR,
+			"m()",
+		},
+	}
+
+	for _, tt := range tests {
+		program := testParseProgram(tt.input)
+		env := object.NewEnvironment()
+		DefineMacros(program, env)
+
+		expanded, err := ExpandMacrosWithOptions(program, env, tt.options)
+		switch {
+		case tt.code == "" && err != nil:
+			t.Errorf("%s: unexpected error %s", tt.input, err.Inspect())
+		case tt.code != "" && (err == nil || err.Code != tt.code):
+			t.Errorf("%s: expected %s error, got %v", tt.input, tt.code, err)
+		}
+		if expanded.String() != tt.expected {
+			t.Errorf("%s: expanded wrong. want=%q, got=%q", tt.input, tt.expected, expanded.String())
+		}
+	}
+}
+
+// スクリプトから eval で展開するマクロも、呼び出し元の評価と同じ上限を受ける
+func TestEvalExpandsMacrosWithinLimits(t *testing.T) {
+	input := `eval("let m = macro() { let loop = fn(n) { loop(n + 1) }; quote(unquote(loop(0))) }; m()")`
+	result := EvalWithOptions(testParseProgram(input), object.NewEnvironment(), EvalOptions{MaxSteps: 1000})
+
+	if err, ok := result.Value.(*object.Error); !ok || err.Code != object.STEP_LIMIT_ERR {
+		t.Errorf("expected STEP_LIMIT, got %s", result.Value.Inspect())
+	}
+}
+
+func testParseProgram(input string) *ast.Program {
+	l := lexer.New(input)
+	p := parser.New(l)
+	return p.ParseProgram()
+}
@@ -0,0 +1,116 @@
+package evaluator
+
+import (
+	"github.com/al-keio/monkey-go/ast"
+	"github.com/al-keio/monkey-go/object"
+)
+
+func (ev *evaluation) evalMatchExpression(me *ast.MatchExpression, env *object.Environment) object.Object {
+	subject := ev.eval(me.Subject, env)
+	if isError(subject) {
+		return subject
+	}
+
+	for _, arm := range me.Arms {
+		armEnv := object.NewEnclosedEnvironment(env)
+
+		matched, err := ev.matchPattern(arm.Pattern, subject, armEnv)
+		if err != nil {
+			return err
+		}
+		if matched {
+			return ev.eval(arm.Body, armEnv)
+		}
+	}
+
+	return NULL
+}
+
+func (ev *evaluation) matchPattern(pattern ast.Pattern, value object.Object, env *object.Environment) (bool, *object.Error) {
+	switch pattern := pattern.(type) {
+	case *ast.WildcardPattern:
+		return true, nil
+	case *ast.BindingPattern:
+		env.SetSymbol(pattern.Name.ID(), value)
+		return true, nil
+	case *ast.LiteralPattern:
+		literal := ev.eval(pattern.Value, env)
+		if err, ok := literal.(*object.Error); ok {
+			return false, err
+		}
+		return objectsEqual(literal, value), nil
+	case *ast.ArrayPattern:
+		return ev.matchArrayPattern(pattern, value, env)
+	case *ast.HashPattern:
+		return ev.matchHashPattern(pattern, value, env)
+	default:
+		return false, newError(object.INTERNAL_ERR, "unknown pattern: %T", pattern)
+	}
+}
+
+func (ev *evaluation) matchArrayPattern(pattern *ast.ArrayPattern, value object.Object, env *object.Environment) (bool, *object.Error) {
+	array, ok := value.(*object.Array)
+	if !ok {
+		return false, nil
+	}
+
+	length := len(pattern.Elements)
+	if len(array.Elements) < length || (pattern.Rest == nil && len(array.Elements) != length) {
+		return false, nil
+	}
+
+	for i, element := range pattern.Elements {
+		matched, err := ev.matchPattern(element, array.Elements[i], env)
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+
+	if pattern.Rest != nil {
+		rest := make([]object.Object, len(array.Elements)-length)
+		copy(rest, array.Elements[length:])
+		env.SetSymbol(pattern.Rest.ID(), &object.Array{Elements: rest})
+	}
+
+	return true, nil
+}
+
+func (ev *evaluation) matchHashPattern(pattern *ast.HashPattern, value object.Object, env *object.Environment) (bool, *object.Error) {
+	hash, ok := value.(*object.Hash)
+	if !ok {
+		return false, nil
+	}
+
+	for _, pair := range pattern.Pairs {
+		key := ev.eval(pair.Key, env)
+		if err, ok := key.(*object.Error); ok {
+			return false, err
+		}
+
+		if part := object.UnhashablePart(key); part != nil {
+			return false, newError(object.UNUSABLE_HASH_KEY_ERR, "unusable as hash key: %s", part.Type())
+		}
+
+		found, ok := hash.Get(key)
+		if !ok {
+			return false, nil
+		}
+
+		matched, err := ev.matchPattern(pair.Value, found.Value, env)
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+func objectsEqual(left, right object.Object) bool {
+	switch left.(type) {
+	case *object.Integer, *object.BigInt, *object.Decimal, *object.String:
+		// 数値は == やハッシュのキーと同じく、型が違っても値が等しければ一致とする
+		return object.KeysEqual(left, right)
+	default:
+		return left == right
+	}
+}
@@ -0,0 +1,65 @@
+package evaluator
+
+import "testing"
+
+func TestMatchExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`match (1) { 1 => 10, _ => 20 }`, 10},
+		{`match (2) { 1 => 10, _ => 20 }`, 20},
+		{`match (-1) { -1 => 10, _ => 20 }`, 10},
+		{`match (1.5d) { 1.5d => 10, _ => 20 }`, 10},
+		{`match (3d / 2d) { 1.5d => 10, _ => 20 }`, 10},
+		{`match (2.5d) { 1.5d => 10, _ => 20 }`, 20},
+		{`match (1.5) { 1.5 => 10, _ => 20 }`, 10},
+		{`match (2) { 2.0 => 10, _ => 20 }`, 10},
+		{`match (2.0) { 2 => 10, _ => 20 }`, 10},
+		{`match (1e3) { 1000 => 10, _ => 20 }`, 10},
+		{`match ("2") { 2 => 10, _ => 20 }`, 20},
+		{`match ("a") { "a" => 1, "b" => 2 }`, 1},
+		{`match (true) { false => 1, true => 2 }`, 2},
+		{`match (5) { x => x * 2 }`, 10},
+		{`match (3) { 1 => 10 }`, nil},
+		{`match ([1, 2, 3]) { [first, ...rest] => first }`, 1},
+		{`match ([1, 2, 3]) { [first, ...rest] => len(rest) }`, 2},
+		{`match ([1]) { [first, ...rest] => len(rest) }`, 0},
+		{`match ([]) { [first, ...rest] => 1, [] => 2 }`, 2},
+		{`match ([1, 2]) { [a] => a, [a, b] => a + b }`, 3},
+		{`match ([1, [2, 3]]) { [a, [b, c]] => a + b + c }`, 6},
+		{`match ([1, 2]) { [1, x] => x, _ => 0 }`, 2},
+		{`match ([3, 2]) { [1, x] => x, _ => 0 }`, 0},
+		{`match (1) { [x] => x, _ => 0 }`, 0},
+		{`match ({"type": "user", "id": 7}) { {"type": "admin"} => 0, {"type": "user", "id": id} => id }`, 7},
+		{`match ({"type": "user"}) { {"type": "user", "id": id} => id, _ => -1 }`, -1},
+		{`match ([1, 2]) { 1 => 0 }`, nil},
+		{`let x = 5; match (1) { x => x }; x`, 5},
+		{`let f = fn(arr) { match (arr) { [] => 0, [head, ...tail] => head + f(tail) } }; f([1, 2, 3, 4])`, 10},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		integer, ok := tt.expected.(int)
+		if ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestMatchExpressionErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`match (foobar) { _ => 1 }`, "identifier not found: foobar"},
+		{`match (1) { 1 => foobar }`, "identifier not found: foobar"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testErrorObject(t, evaluated, tt.expected)
+	}
+}
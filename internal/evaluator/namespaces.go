@@ -0,0 +1,285 @@
+package evaluator
+
+import (
+	"io/ioutil"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/al-keio/monkey-go/object"
+)
+
+// namespaces は string.split(s, ",") のようにメンバーアクセスで呼ぶ組み込み関数。
+// builtins と同じく、環境に同じ名前の束縛があればそちらが優先される。
+var namespaces = map[string]*object.Namespace{
+	"string": &object.Namespace{
+		Name: "string",
+		Members: map[string]*object.Builtin{
+			"split": &object.Builtin{
+				Doc: "string.split(s, sep): array of the substrings of s separated by sep",
+				Fn: func(args ...object.Object) object.Object {
+					strs, err := stringArgs("string.split", 2, args)
+					if err != nil {
+						return err
+					}
+					parts := strings.Split(strs[0], strs[1])
+					elements := make([]object.Object, len(parts))
+					for i, part := range parts {
+						elements[i] = &object.String{Value: part}
+					}
+					return &object.Array{Elements: elements}
+				},
+			},
+			"join": &object.Builtin{
+				Doc: "string.join(array, sep): concatenate the strings in array with sep between them",
+				Fn: func(args ...object.Object) object.Object {
+					if len(args) != 2 {
+						return newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=2", len(args))
+					}
+					arr, ok := args[0].(*object.Array)
+					if !ok {
+						return newError(object.WRONG_ARGUMENT_TYPE_ERR, "argument 1 to `string.join` must be ARRAY, got %s", args[0].Type())
+					}
+					sep, ok := args[1].(*object.String)
+					if !ok {
+						return newError(object.WRONG_ARGUMENT_TYPE_ERR, "argument 2 to `string.join` must be STRING, got %s", args[1].Type())
+					}
+
+					parts := make([]string, len(arr.Elements))
+					for i, element := range arr.Elements {
+						s, ok := element.(*object.String)
+						if !ok {
+							return newError(object.WRONG_ARGUMENT_TYPE_ERR, "elements passed to `string.join` must be STRING, got %s", element.Type())
+						}
+						parts[i] = s.Value
+					}
+					return &object.String{Value: strings.Join(parts, sep.Value)}
+				},
+			},
+			"upper": &object.Builtin{
+				Doc: "string.upper(s): s converted to upper case",
+				Fn: func(args ...object.Object) object.Object {
+					strs, err := stringArgs("string.upper", 1, args)
+					if err != nil {
+						return err
+					}
+					return &object.String{Value: strings.ToUpper(strs[0])}
+				},
+			},
+			"lower": &object.Builtin{
+				Doc: "string.lower(s): s converted to lower case",
+				Fn: func(args ...object.Object) object.Object {
+					strs, err := stringArgs("string.lower", 1, args)
+					if err != nil {
+						return err
+					}
+					return &object.String{Value: strings.ToLower(strs[0])}
+				},
+			},
+			"trim": &object.Builtin{
+				Doc: "string.trim(s): s without leading and trailing white space",
+				Fn: func(args ...object.Object) object.Object {
+					strs, err := stringArgs("string.trim", 1, args)
+					if err != nil {
+						return err
+					}
+					return &object.String{Value: strings.TrimSpace(strs[0])}
+				},
+			},
+			"contains": &object.Builtin{
+				Doc: "string.contains(s, sub): whether sub occurs in s",
+				Fn: func(args ...object.Object) object.Object {
+					strs, err := stringArgs("string.contains", 2, args)
+					if err != nil {
+						return err
+					}
+					return nativeBoolToBooleanObject(strings.Contains(strs[0], strs[1]))
+				},
+			},
+		},
+	},
+	"math": &object.Namespace{
+		Name: "math",
+		Members: map[string]*object.Builtin{
+			"abs": &object.Builtin{
+				Doc: "math.abs(x): absolute value of an integer (including a big integer) or decimal",
+				Fn: func(args ...object.Object) object.Object {
+					if len(args) != 1 {
+						return newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=1", len(args))
+					}
+					switch arg := args[0].(type) {
+					case *object.Integer:
+						if arg.Value < 0 {
+							return evalMinusPrefixOperatorExpression(arg)
+						}
+						return arg
+					case *object.BigInt:
+						return &object.BigInt{Value: new(big.Int).Abs(arg.Value)}
+					case *object.Decimal:
+						return &object.Decimal{Value: new(big.Rat).Abs(arg.Value)}
+					default:
+						return newError(object.WRONG_ARGUMENT_TYPE_ERR, "argument to `math.abs` must be INTEGER or DECIMAL, got %s", arg.Type())
+					}
+				},
+			},
+			"sqrt": &object.Builtin{
+				Doc: "math.sqrt(x): square root of x as a decimal (computed in floating point)",
+				Fn: func(args ...object.Object) object.Object {
+					if len(args) != 1 {
+						return newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=1", len(args))
+					}
+					x, err := floatArg("math.sqrt", args[0])
+					if err != nil {
+						return err
+					}
+					if x < 0 {
+						return newError(object.INVALID_VALUE_ERR, "math.sqrt of negative number: %s", args[0].Inspect())
+					}
+					return floatDecimal("math.sqrt", math.Sqrt(x))
+				},
+			},
+			"pow": &object.Builtin{
+				Doc: "math.pow(x, n): x raised to the non-negative integer power n",
+				Fn: func(args ...object.Object) object.Object {
+					if len(args) != 2 {
+						return newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=2", len(args))
+					}
+					n, ok := args[1].(*object.Integer)
+					if !ok || n.Value < 0 {
+						return newError(object.WRONG_ARGUMENT_TYPE_ERR, "exponent to `math.pow` must be a non-negative INTEGER, got %s", args[1].Inspect())
+					}
+
+					switch x := args[0].(type) {
+					case *object.Integer, *object.BigInt:
+						base := toBigInt(x)
+						// 桁数の大きすぎる値を作らないよう、結果のビット数に上限を設ける
+						if base.CmpAbs(big.NewInt(1)) > 0 && int64(base.BitLen()-1)*n.Value > maxPowBits {
+							return newError(object.INVALID_VALUE_ERR, "result of `math.pow` is too large: %s to the power of %d", x.Inspect(), n.Value)
+						}
+						return object.NewInteger(new(big.Int).Exp(base, big.NewInt(n.Value), nil))
+					case *object.Decimal:
+						result := big.NewRat(1, 1)
+						for i := int64(0); i < n.Value; i++ {
+							result.Mul(result, x.Value)
+						}
+						return &object.Decimal{Value: result}
+					default:
+						return newError(object.WRONG_ARGUMENT_TYPE_ERR, "argument 1 to `math.pow` must be INTEGER or DECIMAL, got %s", x.Type())
+					}
+				},
+			},
+		},
+	},
+	"io": &object.Namespace{
+		Name: "io",
+		Members: map[string]*object.Builtin{
+			"read_file": &object.Builtin{
+				Doc:          "io.read_file(path): contents of the file at path as a string",
+				Capabilities: []object.Capability{object.IO_CAP},
+				Fn: func(args ...object.Object) object.Object {
+					strs, err := stringArgs("io.read_file", 1, args)
+					if err != nil {
+						return err
+					}
+					content, readErr := ioutil.ReadFile(strs[0])
+					if readErr != nil {
+						return newError(object.HOST_ERR, "io.read_file: %s", readErr)
+					}
+					return &object.String{Value: string(content)}
+				},
+			},
+		},
+	},
+}
+
+// RegisterNamespaceMember は組み込み関数を namespace.name として登録する。namespace がなければ作る。
+func RegisterNamespaceMember(namespace, name string, builtin *object.Builtin) {
+	if builtin == nil {
+		panic("evaluator: RegisterNamespaceMember builtin is nil")
+	}
+	ns, ok := namespaces[namespace]
+	if !ok {
+		ns = &object.Namespace{Name: namespace, Members: map[string]*object.Builtin{}}
+		namespaces[namespace] = ns
+	}
+	if _, dup := ns.Members[name]; dup {
+		panic("evaluator: RegisterNamespaceMember called twice for " + namespace + "." + name)
+	}
+	ns.Members[name] = builtin
+}
+
+func evalNamespaceIndexExpression(namespace *object.Namespace, index object.Object) object.Object {
+	name, ok := index.(*object.String)
+	if !ok {
+		return newError(object.WRONG_ARGUMENT_TYPE_ERR, "namespace member must be STRING, got %s", index.Type())
+	}
+
+	if member, ok := namespace.Members[name.Value]; ok {
+		return member
+	}
+	err := newError(object.UNKNOWN_MEMBER_ERR, "unknown member %s of %s", name.Value, namespace.Inspect())
+	err.Hint = memberHint(name.Value, namespace)
+	return err
+}
+
+// namespaceMember は "string.split" のような名前から組み込み関数を引く
+func namespaceMember(name string) (*object.Builtin, bool) {
+	i := strings.Index(name, ".")
+	if i < 0 {
+		return nil, false
+	}
+	namespace, ok := namespaces[name[:i]]
+	if !ok {
+		return nil, false
+	}
+	member, ok := namespace.Members[name[i+1:]]
+	return member, ok
+}
+
+func stringArgs(name string, want int, args []object.Object) ([]string, *object.Error) {
+	if len(args) != want {
+		return nil, newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=%d", len(args), want)
+	}
+	strs := make([]string, len(args))
+	for i, arg := range args {
+		s, ok := arg.(*object.String)
+		if !ok {
+			return nil, newError(object.WRONG_ARGUMENT_TYPE_ERR, "argument %d to `%s` must be STRING, got %s", i+1, name, arg.Type())
+		}
+		strs[i] = s.Value
+	}
+	return strs, nil
+}
+
+// maxPowBits は math.pow が整数の結果に許すビット数
+const maxPowBits = 1 << 20
+
+func floatArg(name string, arg object.Object) (float64, *object.Error) {
+	var f float64
+	switch arg := arg.(type) {
+	case *object.Integer:
+		f = float64(arg.Value)
+	case *object.BigInt:
+		f, _ = new(big.Float).SetInt(arg.Value).Float64()
+	case *object.Decimal:
+		f, _ = arg.Value.Float64()
+	default:
+		return 0, newError(object.WRONG_ARGUMENT_TYPE_ERR, "argument to `%s` must be INTEGER or DECIMAL, got %s", name, arg.Type())
+	}
+	// float64 に収まらない値は Inf になってしまう
+	if math.IsInf(f, 0) {
+		return 0, newError(object.INVALID_VALUE_ERR, "argument to `%s` is too large: %s", name, arg.Inspect())
+	}
+	return f, nil
+}
+
+// floatDecimal は浮動小数点数で求めた結果を Decimal にする。二進の展開をそのまま持たないよう、
+// f を表す最短の十進表記から作る (math.sqrt(2) は 1.4142135623730951)。
+func floatDecimal(name string, f float64) object.Object {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return newError(object.INVALID_VALUE_ERR, "result of `%s` is not a finite number", name)
+	}
+	value, _ := new(big.Rat).SetString(strconv.FormatFloat(f, 'g', -1, 64))
+	return &object.Decimal{Value: value}
+}
@@ -0,0 +1,75 @@
+package evaluator
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/al-keio/monkey-go/object"
+)
+
+func TestNamespaces(t *testing.T) {
+	file, err := ioutil.TempFile("", "monkey")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString("hello")
+	file.Close()
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`string.split("a,b,c", ",")`, "[a, b, c]"},
+		{`string.join(["a", "b"], "-")`, "a-b"},
+		{`string.upper("abc")`, "ABC"},
+		{`string.lower("ABC")`, "abc"},
+		{`string.trim("  x ")`, "x"},
+		{`string.contains("monkey", "key")`, "true"},
+		{`math.abs(-3)`, "3"},
+		{`math.abs(-1.5d)`, "1.5"},
+		{`math.sqrt(16)`, "4"},
+		{`math.pow(2, 10)`, "1024"},
+		{`math.pow(0.5d, 2)`, "0.25"},
+		{`io.read_file("` + file.Name() + `")`, "hello"},
+		{`let split = string.split; split("a b", " ")`, "[a, b]"},
+		{`let string = {"split": 1}; string.split`, "1"},
+		{`let h = {"a": {"b": 2}}; h.a.b`, "2"},
+		{`string`, "namespace string"},
+		{`string.missing`, "ERROR: unknown member missing of namespace string"},
+		{`string.upper(1)`, "ERROR: argument 1 to `string.upper` must be STRING, got INTEGER"},
+		{`math.sqrt(-1)`, "ERROR: math.sqrt of negative number: -1"},
+		{`math.sqrt(2)`, "1.4142135623730951"},
+		{`math.sqrt(1e300)`, "1" + strings.Repeat("0", 150)},
+		{`math.sqrt(1e400)`, "ERROR: argument to `math.sqrt` is too large: 1" + strings.Repeat("0", 400)},
+		{`math.sqrt(math.pow(10n, 400))`, "ERROR: argument to `math.sqrt` is too large: 1" + strings.Repeat("0", 400)},
+		{`1.a`, "ERROR: index operator not supported: INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestRegisterNamespaceMember(t *testing.T) {
+	RegisterNamespaceMember("test", "double", &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			return &object.Integer{Value: args[0].(*object.Integer).Value * 2}
+		},
+	})
+	defer delete(namespaces, "test")
+
+	testIntegerObject(t, testEval("test.double(21)"), 42)
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("registering a duplicate member did not panic")
+		}
+	}()
+	RegisterNamespaceMember("string", "split", &object.Builtin{})
+}
@@ -0,0 +1,96 @@
+package evaluator
+
+import (
+	"math/big"
+	"regexp"
+	"strconv"
+
+	"github.com/al-keio/monkey-go/object"
+)
+
+// decimalPattern は parse_float が受け付ける書き方。big.Rat の 1/3 のような分数は受け付けない。
+var decimalPattern = regexp.MustCompile(`^[+-]?(\d+(\.\d*)?|\.\d+)([eE]([+-]?\d+))?$`)
+
+// 巨大な指数で桁数の大きすぎる値を作らないよう、parse_float の指数と to_fixed の桁数に上限を設ける
+const (
+	maxDecimalExponent = 1000
+	maxFixedDigits     = 100
+)
+
+func init() {
+	builtins["parse_int"] = &object.Builtin{
+		Doc: "parse_int(str[, base]): the integer written in str in base 2 to 36 (default 10), with an optional sign; an error if str is not such an integer or does not fit",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=1 or 2", len(args))
+			}
+			str, ok := args[0].(*object.String)
+			if !ok {
+				return newError(object.WRONG_ARGUMENT_TYPE_ERR, "argument 1 to `parse_int` must be STRING, got %s", args[0].Type())
+			}
+			base := int64(10)
+			if len(args) == 2 {
+				b, ok := args[1].(*object.Integer)
+				if !ok {
+					return newError(object.WRONG_ARGUMENT_TYPE_ERR, "argument 2 to `parse_int` must be INTEGER, got %s", args[1].Type())
+				}
+				if b.Value < 2 || b.Value > 36 {
+					return newError(object.INVALID_VALUE_ERR, "base for `parse_int` must be between 2 and 36, got %d", b.Value)
+				}
+				base = b.Value
+			}
+
+			// strconv は base が 0 のときだけ 0x などの接頭辞を読むので、ここでは数字だけを受け付ける
+			value, err := strconv.ParseInt(str.Value, int(base), 64)
+			if err != nil {
+				if err.(*strconv.NumError).Err == strconv.ErrRange {
+					return newError(object.INVALID_VALUE_ERR, "%q is out of range for an integer", str.Value)
+				}
+				return newError(object.INVALID_VALUE_ERR, "could not parse %q as an integer in base %d", str.Value, base)
+			}
+			return &object.Integer{Value: value}
+		},
+	}
+	builtins["parse_float"] = &object.Builtin{
+		Doc: "parse_float(str): the decimal written in str such as \"-1.25\", \".5\" or \"6.02e23\", exactly; an error if str is not such a number",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+			str, ok := args[0].(*object.String)
+			if !ok {
+				return newError(object.WRONG_ARGUMENT_TYPE_ERR, "argument to `parse_float` must be STRING, got %s", args[0].Type())
+			}
+
+			m := decimalPattern.FindStringSubmatch(str.Value)
+			if m == nil {
+				return newError(object.INVALID_VALUE_ERR, "could not parse %q as a number", str.Value)
+			}
+			if exponent, err := strconv.Atoi(m[4]); m[4] != "" && (err != nil || exponent > maxDecimalExponent || exponent < -maxDecimalExponent) {
+				return newError(object.INVALID_VALUE_ERR, "exponent of %q is out of range (at most %d)", str.Value, maxDecimalExponent)
+			}
+			value, _ := new(big.Rat).SetString(str.Value)
+			return &object.Decimal{Value: value}
+		},
+	}
+	builtins["to_fixed"] = &object.Builtin{
+		Doc: "to_fixed(x, digits): x as a string with exactly digits digits after the decimal point, rounding halves away from zero",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=2", len(args))
+			}
+			x, ok := toRat(args[0])
+			if !ok {
+				return newError(object.WRONG_ARGUMENT_TYPE_ERR, "argument 1 to `to_fixed` must be INTEGER or DECIMAL, got %s", args[0].Type())
+			}
+			digits, ok := args[1].(*object.Integer)
+			if !ok {
+				return newError(object.WRONG_ARGUMENT_TYPE_ERR, "argument 2 to `to_fixed` must be INTEGER, got %s", args[1].Type())
+			}
+			if digits.Value < 0 || digits.Value > maxFixedDigits {
+				return newError(object.INVALID_VALUE_ERR, "digits for `to_fixed` must be between 0 and %d, got %d", maxFixedDigits, digits.Value)
+			}
+			return &object.String{Value: x.FloatString(int(digits.Value))}
+		},
+	}
+}
@@ -0,0 +1,45 @@
+package evaluator
+
+import "testing"
+
+func TestNumberConversions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`parse_int("42")`, "42"},
+		{`parse_int("-17")`, "-17"},
+		{`parse_int("ff", 16) + 1`, "256"},
+		{`parse_int("-101", 2)`, "-5"},
+		{`parse_int("zz", 36)`, "1295"},
+		{`parse_int("9223372036854775807")`, "9223372036854775807"},
+		{`parse_int("9223372036854775808")`, `ERROR: "9223372036854775808" is out of range for an integer`},
+		{`parse_int("0x10")`, `ERROR: could not parse "0x10" as an integer in base 10`},
+		{`parse_int(" 1")`, `ERROR: could not parse " 1" as an integer in base 10`},
+		{`parse_int("")`, `ERROR: could not parse "" as an integer in base 10`},
+		{`parse_int("12", 1)`, "ERROR: base for `parse_int` must be between 2 and 36, got 1"},
+		{`parse_int(12)`, "ERROR: argument 1 to `parse_int` must be STRING, got INTEGER"},
+		{`parse_float("1.25") + 1`, "2.25"},
+		{`parse_float("-.5")`, "-0.5"},
+		{`parse_float("6.02e3")`, "6020"},
+		{`parse_float("1e-3")`, "0.001"},
+		{`parse_float("0.1") + parse_float("0.2") == parse_float("0.3")`, "true"},
+		{`parse_float("1/3")`, `ERROR: could not parse "1/3" as a number`},
+		{`parse_float("abc")`, `ERROR: could not parse "abc" as a number`},
+		{`parse_float("1e100000")`, `ERROR: exponent of "1e100000" is out of range (at most 1000)`},
+		{`to_fixed(parse_float("3.14159"), 2)`, "3.14"},
+		{`to_fixed(parse_float("2.5"), 0)`, "3"},
+		{`to_fixed(parse_float("-2.5"), 0)`, "-3"},
+		{`to_fixed(parse_float("1.005"), 2)`, "1.01"},
+		{`to_fixed(7, 3)`, "7.000"},
+		{`to_fixed(1, -1)`, "ERROR: digits for `to_fixed` must be between 0 and 100, got -1"},
+		{`to_fixed("1", 2)`, "ERROR: argument 1 to `to_fixed` must be INTEGER or DECIMAL, got STRING"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
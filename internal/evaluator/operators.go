@@ -0,0 +1,15 @@
+package evaluator
+
+import (
+	"github.com/al-keio/monkey-go/ast"
+	"github.com/al-keio/monkey-go/object"
+)
+
+// evalCustomInfixExpression は operator 宣言で定義した演算子を、その記号の名前で束縛された関数の呼び出しとして評価する
+func (ev *evaluation) evalCustomInfixExpression(node *ast.InfixExpression, left, right object.Object, env *object.Environment) object.Object {
+	fn, ok := env.Get(node.Operator)
+	if !ok {
+		return newError(object.UNKNOWN_OPERATOR_ERR, "operator %s is not defined", node.Operator)
+	}
+	return ev.call(node.Operator, node.Token.Offset, fn, []object.Object{left, right})
+}
@@ -0,0 +1,27 @@
+package evaluator
+
+import "testing"
+
+func TestCustomOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`operator <+> (a, b) { a * 10 + b }; 1 <+> 2`, "12"},
+		{`operator <+> product (a, b) { a * 10 + b }; 1 <+> 2 + 3`, "15"},
+		{`operator <+> (a, b) { a * 10 + b }; 1 <+> 2 <+> 3`, "123"},
+		{`operator |> equals (x, f) { f(x) }; 5 |> fn(x) { x * 2 }`, "10"},
+		{`operator ++ (a, b) { a + b }; [1] ++ [2] ++ [3]`, "[1, 2, 3]"},
+		{`let f = fn() { operator <> (a, b) { a != b }; 1 <> 2 }; f()`, "true"},
+		{`let f = fn() { operator <> (a, b) { a != b }; 1 }; f(); 1 <> 2`, "ERROR: operator <> is not defined"},
+		{`operator ~> (a, b) { a + b }; 1 ~> true`, "ERROR: type mismatch: INTEGER + BOOLEAN"},
+		{`operator ** product (a, b) { if (b == 0) { 1 } else { a * (a ** (b - 1)) } }; 2 ** 10`, "1024"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
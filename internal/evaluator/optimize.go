@@ -0,0 +1,92 @@
+package evaluator
+
+import (
+	"github.com/al-keio/monkey-go/ast"
+	"github.com/al-keio/monkey-go/object"
+)
+
+// Optimize は map(filter(arr, f), g) のような map と filter の呼び出しの連鎖を
+// 途中の配列を作らずに一度の走査で評価する PipelineExpression に置き換える。
+// 連鎖の各段の関数は要素ごとに順に呼ばれるので、f と g の呼び出しの順序は最適化前と異なり、
+// 副作用の順序や、複数の段が失敗するときに返るエラーも変わりうる。そのため呼び出し側が選んだときだけ使う。
+func Optimize(node ast.Node) ast.Node {
+	return ast.Modify(node, func(node ast.Node) ast.Node {
+		call, ok := node.(*ast.CallExpression)
+		if !ok {
+			return node
+		}
+
+		source, stages := pipelineStages(call)
+		if len(stages) < 2 {
+			return node
+		}
+
+		return &ast.PipelineExpression{Token: call.Token, Source: source, Stages: stages, Original: call}
+	})
+}
+
+func pipelineStages(exp ast.Expression) (ast.Expression, []*ast.PipelineStage) {
+	switch exp := exp.(type) {
+	case *ast.PipelineExpression:
+		return exp.Source, exp.Stages
+	case *ast.CallExpression:
+		name, ok := exp.Function.(*ast.Identifier)
+		if !ok || len(exp.Arguments) != 2 || (name.Value != "map" && name.Value != "filter") {
+			return exp, nil
+		}
+
+		source, stages := pipelineStages(exp.Arguments[0])
+		stage := &ast.PipelineStage{Name: name, Function: exp.Arguments[1]}
+		return source, append(stages[:len(stages):len(stages)], stage)
+	default:
+		return exp, nil
+	}
+}
+
+func (ev *evaluation) evalPipelineExpression(pe *ast.PipelineExpression, env *object.Environment) object.Object {
+	for _, stage := range pe.Stages {
+		if _, shadowed := env.Get(stage.Name.Value); shadowed {
+			return ev.eval(pe.Original, env)
+		}
+	}
+
+	source := ev.eval(pe.Source, env)
+	if isError(source) {
+		return source
+	}
+	arr, ok := source.(*object.Array)
+	if !ok {
+		return newError(object.WRONG_ARGUMENT_TYPE_ERR, "argument to `%s` must be Array, got %s", pe.Stages[0].Name.Value, source.Type())
+	}
+
+	fns := make([]object.Object, len(pe.Stages))
+	for i, stage := range pe.Stages {
+		fns[i] = ev.eval(stage.Function, env)
+		if isError(fns[i]) {
+			return fns[i]
+		}
+	}
+
+	newElements := []object.Object{}
+elements:
+	for _, element := range arr.Elements {
+		value := element
+		for i, stage := range pe.Stages {
+			result := ev.applyFunction(fns[i], []object.Object{value}, scopeLabel(calleeName(nil), ""))
+			if isError(result) {
+				return result
+			}
+
+			if stage.Name.Value == "filter" {
+				if !isTruthy(result) {
+					continue elements
+				}
+			} else {
+				value = result
+			}
+		}
+		newElements = append(newElements, value)
+	}
+
+	return &object.Array{Elements: newElements}
+}
@@ -0,0 +1,87 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/al-keio/monkey-go/ast"
+	"github.com/al-keio/monkey-go/internal/lexer"
+	"github.com/al-keio/monkey-go/internal/parser"
+	"github.com/al-keio/monkey-go/object"
+)
+
+func TestOptimizeFusesPipelines(t *testing.T) {
+	tests := []struct {
+		input  string
+		stages []string
+	}{
+		{`map(filter(a, f), g)`, []string{"filter", "map"}},
+		{`filter(map(map(a, f), g), h)`, []string{"map", "map", "filter"}},
+		{`map(a, f)`, nil},
+		{`map(filter(a, f))`, nil},
+		{`len(filter(a, f))`, nil},
+	}
+
+	for _, tt := range tests {
+		program := parser.New(lexer.New(tt.input)).ParseProgram()
+		original := program.String()
+
+		optimized := Optimize(program).(*ast.Program)
+		if optimized.String() != original {
+			t.Errorf("String() changed. want=%q, got=%q", original, optimized.String())
+		}
+
+		exp := optimized.Statements[0].(*ast.ExpressionStatement).Expression
+		pipeline, ok := exp.(*ast.PipelineExpression)
+		if tt.stages == nil {
+			if ok {
+				t.Errorf("%s should not be fused", tt.input)
+			}
+			continue
+		}
+		if !ok {
+			t.Errorf("%s was not fused. got=%T", tt.input, exp)
+			continue
+		}
+
+		if len(pipeline.Stages) != len(tt.stages) {
+			t.Fatalf("wrong number of stages for %s. want=%d, got=%d", tt.input, len(tt.stages), len(pipeline.Stages))
+		}
+		for i, name := range tt.stages {
+			if pipeline.Stages[i].Name.Value != name {
+				t.Errorf("stage %d wrong. want=%s, got=%s", i, name, pipeline.Stages[i].Name.Value)
+			}
+		}
+		if pipeline.Source.String() != "a" {
+			t.Errorf("source wrong. got=%s", pipeline.Source.String())
+		}
+	}
+}
+
+func TestEvalPipelineExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`map(filter([1, 2, 3, 4], fn(x) { x > 2 }), fn(x) { x * 10 })`, "[30, 40]"},
+		{`filter(map([1, 2, 3], fn(x) { x * 2 }), fn(x) { x > 2 })`, "[4, 6]"},
+		{`map(map([], fn(x) { x }), fn(x) { x })`, "[]"},
+		{`map(filter(1, len), len)`, "ERROR: argument to `filter` must be Array, got INTEGER"},
+		{`map(filter([1], fn(x) { true }), len)`, "ERROR: argument to `len` not supported, got INTEGER"},
+		{`let map = fn(a, f) { "shadowed" }; map(filter([1], fn(x) { true }), fn(x) { x })`, `shadowed`},
+	}
+
+	for _, tt := range tests {
+		program := parser.New(lexer.New(tt.input)).ParseProgram()
+		unoptimized := Eval(program, object.NewEnvironment())
+
+		program = parser.New(lexer.New(tt.input)).ParseProgram()
+		optimized := Eval(Optimize(program), object.NewEnvironment())
+
+		if optimized.Inspect() != tt.expected {
+			t.Errorf("%s: want=%q, got=%q", tt.input, tt.expected, optimized.Inspect())
+		}
+		if unoptimized.Inspect() != optimized.Inspect() {
+			t.Errorf("%s: optimized result differs. unoptimized=%q, optimized=%q", tt.input, unoptimized.Inspect(), optimized.Inspect())
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package evaluator
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/al-keio/monkey-go/ast"
+	"github.com/al-keio/monkey-go/object"
+)
+
+// EvalOptions は評価に課す制限。ゼロ値は無制限。
+type EvalOptions struct {
+	// MaxSteps は評価できるノードの数の上限。組み込み関数から Applier.Apply で関数を呼ぶごとにも一つ数える。
+	MaxSteps int64
+
+	// MaxCallDepth は関数呼び出しの入れ子の深さの上限。超えた呼び出しは CALL_DEPTH のエラーになる。
+	// 深い再帰で Go のスタックを使い果たしてプロセスごと落ちるのを防ぐ。
+	MaxCallDepth int
+
+	// Context が取り消されるか期限を過ぎると、評価は CANCELLED のエラーで終わる。
+	// スクリプトは deadline() で残り時間を調べられる。
+	Context context.Context
+
+	// StackArguments が true なら、エラーのスタックトレースの各フレームに引数の値を含める。
+	// 値は ArgumentWidth 文字 (0 なら DefaultArgumentWidth) に切り詰める。
+	StackArguments bool
+	ArgumentWidth  int
+
+	// Output は puts や print の出力先。nil なら os.Stdout。
+	// LineBuffered が true なら改行までを溜めてから書き、残りは評価の終わりに書く。
+	// MaxOutputBytes が正なら、出力がこれを超えたところで切り詰め、OUTPUT_LIMIT のエラーにする。
+	Output         io.Writer
+	LineBuffered   bool
+	MaxOutputBytes int64
+
+	// CaptureFreeVariables が true なら、関数は定義した環境全体ではなく本体が参照する変数の値だけを保持する。
+	// 長く生きるクロージャが外側の関数の大きな値を抱え込まなくなる。大域環境の束縛は共有するが、
+	// それ以外の外側の変数を関数を作った後に let で束縛し直しても、その関数からは見えない。
+	CaptureFreeVariables bool
+
+	// Args はスクリプトに渡すコマンドライン引数。args() と parse_args で読める。
+	Args []string
+
+	// Source と Filename を与えると、スタックトレースと関数呼び出しの環境のラベルに呼び出し位置を
+	// "main.monkey:10" の形で含める。Source は評価する木を解析した入力そのもの。
+	Source   string
+	Filename string
+
+	// Trace が nil でなければ、関数と組み込み関数の呼び出しごとに Chrome のトレースイベント形式の JSON を書く。
+	// 書いたものは chrome://tracing や Perfetto で開ける。評価の終わりに配列を閉じる。
+	Trace io.Writer
+
+	// DisableContracts が true なら、関数の requires と ensures の条件を評価しない。
+	DisableContracts bool
+
+	// Sandbox が true なら、組み込み関数は必要とする作用 (object.Builtin の Capabilities) が
+	// すべて Allow に含まれるときだけ呼べる。それ以外の呼び出しは CAPABILITY_DENIED のエラーになる。
+	Sandbox bool
+	Allow   []object.Capability
+
+	// OutputFormat は puts や print、テンプレートが数値を表示する書式で、set_output_format が書き換える。
+	// REPL のように評価をまたいで書式を保つには、呼び出し側が持つ値を渡す。nil なら評価ごとに既定の書式から始める。
+	OutputFormat *object.OutputFormat
+}
+
+const DefaultArgumentWidth = 40
+
+// EvalWithOptions は options の制限の下で node を評価し、結果とともに実行統計を返す。
+// 上限を超えると STEP_LIMIT (Context の取り消しなら CANCELLED) のエラーになり、それ以降の評価もすべて
+// 同じエラーになるので、with_recover などでスクリプトから回復することはできない。
+func EvalWithOptions(node ast.Node, env *object.Environment, options EvalOptions) *EvalResult {
+	ev := &evaluation{options: options}
+
+	start := time.Now()
+	value := ev.run(node, env)
+	ev.stats.WallTime = time.Since(start)
+
+	return &EvalResult{Value: value, Stats: ev.stats}
+}
+
+// ApplyWithOptions は options の制限の下で関数 fn を args で呼び出す。
+// Go のプログラムから、スクリプトが返した関数や環境に束縛された関数を呼ぶときに使う。
+func ApplyWithOptions(fn object.Object, args []object.Object, options EvalOptions) *EvalResult {
+	ev := &evaluation{options: options}
+
+	start := time.Now()
+	value := ev.guard(func() object.Object { return ev.Apply(fn, args...) })
+	ev.stats.WallTime = time.Since(start)
+
+	return &EvalResult{Value: value, Stats: ev.stats}
+}
+
+// Context の取り消しはこのステップ数ごとに調べる
+const contextCheckInterval = 256
+
+// step はステップを一つ数え、上限を超えていればエラーを返す
+func (ev *evaluation) step() *object.Error {
+	ev.stats.Steps++
+	if ev.options.MaxSteps > 0 && ev.stats.Steps > ev.options.MaxSteps {
+		return ev.limited(newError(object.STEP_LIMIT_ERR, "step limit of %d exceeded", ev.options.MaxSteps))
+	}
+	if ev.cancelled || ev.options.Context != nil && ev.stats.Steps%contextCheckInterval == 0 {
+		if err := ev.checkContext(); err != nil {
+			return ev.limited(err)
+		}
+	}
+	return nil
+}
+
+// enterCallChecked は呼び出しの深さを一つ増やす。MaxCallDepth を超えるならエラーを返して増やさない。
+func (ev *evaluation) enterCallChecked() *object.Error {
+	if ev.options.MaxCallDepth > 0 && ev.depth >= ev.options.MaxCallDepth {
+		return ev.limited(newError(object.CALL_DEPTH_ERR, "call depth limit of %d exceeded", ev.options.MaxCallDepth))
+	}
+	ev.enterCall()
+	return nil
+}
+
+// limited は上限を超えたエラーのうち最初のものを覚えておく。unquote のように途中のエラーを
+// 捨ててしまう評価のあとでも、上限に達したことを呼び出し元に伝えられる。
+func (ev *evaluation) limited(err *object.Error) *object.Error {
+	if ev.limit == nil {
+		ev.limit = err
+	}
+	return err
+}
+
+// checkCapabilities は Sandbox のとき、builtin が許可されていない作用を必要とするならエラーを返す
+func (ev *evaluation) checkCapabilities(builtin *object.Builtin) *object.Error {
+	if !ev.options.Sandbox {
+		return nil
+	}
+	for _, capability := range builtin.Capabilities {
+		if !ev.allowed(capability) {
+			return newError(object.CAPABILITY_DENIED_ERR, "%s needs the %s capability, which the sandbox does not allow", builtinName(builtin), capability)
+		}
+	}
+	return nil
+}
+
+// builtinName はエラーメッセージのために builtin が登録されている名前を名前空間も含めて探す
+func builtinName(builtin *object.Builtin) string {
+	for name, b := range builtins {
+		if b == builtin {
+			return "`" + name + "`"
+		}
+	}
+	for _, namespace := range namespaces {
+		for name, b := range namespace.Members {
+			if b == builtin {
+				return "`" + namespace.Name + "." + name + "`"
+			}
+		}
+	}
+	return "builtin function"
+}
+
+func (ev *evaluation) allowed(capability object.Capability) bool {
+	for _, c := range ev.options.Allow {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+func (ev *evaluation) checkContext() *object.Error {
+	if ev.options.Context == nil {
+		return nil
+	}
+	if err := ev.options.Context.Err(); err != nil {
+		ev.cancelled = true
+		return newError(object.CANCELLED_ERR, "evaluation cancelled: %s", err)
+	}
+	return nil
+}
+
+// sleep は d だけ待つ。その間に Context が取り消されたらエラーを返す。
+func (ev *evaluation) sleep(d time.Duration) *object.Error {
+	if ev.options.Context == nil {
+		time.Sleep(d)
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ev.options.Context.Done():
+		return ev.checkContext()
+	}
+}
+
+// remaining は Context の期限までの残り時間を返す。期限がなければ ok は false。
+func (ev *evaluation) remaining() (d time.Duration, ok bool) {
+	if ev.options.Context == nil {
+		return 0, false
+	}
+	deadline, ok := ev.options.Context.Deadline()
+	if !ok {
+		return 0, false
+	}
+	if d = time.Until(deadline); d < 0 {
+		d = 0
+	}
+	return d, true
+}
+
+func init() {
+	builtins["deadline"] = &object.Builtin{
+		Doc:          "deadline(): milliseconds left before the host cancels evaluation, or null when there is no deadline",
+		Capabilities: []object.Capability{object.TIME_CAP},
+		ApplyFn: func(applier object.Applier, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=0", len(args))
+			}
+			ev, ok := applier.(*evaluation)
+			if !ok {
+				return NULL
+			}
+			d, ok := ev.remaining()
+			if !ok {
+				return NULL
+			}
+			return &object.Integer{Value: int64(d / time.Millisecond)}
+		},
+	}
+}
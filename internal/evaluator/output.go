@@ -0,0 +1,115 @@
+package evaluator
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/al-keio/monkey-go/object"
+)
+
+// output は puts などの出力先。EvalOptions の Output, LineBuffered, MaxOutputBytes に従う。
+type output struct {
+	w            io.Writer
+	lineBuffered bool
+	limit        int64
+	written      int64
+	pending      []byte // 行単位で書くとき、まだ改行の来ていない部分
+}
+
+func (ev *evaluation) output() *output {
+	if ev.out == nil {
+		w := ev.options.Output
+		if w == nil {
+			w = os.Stdout
+		}
+		ev.out = &output{w: w, lineBuffered: ev.options.LineBuffered, limit: ev.options.MaxOutputBytes}
+	}
+	return ev.out
+}
+
+// write は s を書く。上限を超える分は書かずに OUTPUT_LIMIT のエラーを返す。
+func (o *output) write(s string) *object.Error {
+	data := []byte(s)
+	var limitErr *object.Error
+	if o.limit > 0 && o.written+int64(len(data)) > o.limit {
+		data = data[:o.limit-o.written]
+		limitErr = newError(object.OUTPUT_LIMIT_ERR, "output limit of %d bytes exceeded", o.limit)
+	}
+	o.written += int64(len(data))
+
+	if o.lineBuffered {
+		o.pending = append(o.pending, data...)
+		i := bytes.LastIndexByte(o.pending, '\n')
+		if i < 0 {
+			return limitErr
+		}
+		data = o.pending[:i+1]
+		o.pending = append([]byte{}, o.pending[i+1:]...)
+	}
+
+	if _, err := o.w.Write(data); err != nil {
+		return newError(object.HOST_ERR, "write failed: %s", err)
+	}
+	return limitErr
+}
+
+// flush は改行の来ていない残りを書く。評価の終わりに呼ぶ。
+func (o *output) flush() {
+	if len(o.pending) > 0 {
+		// 評価はもう終わっているので、書き込みの失敗は報告する先がない
+		o.w.Write(o.pending)
+		o.pending = nil
+	}
+}
+
+func (ev *evaluation) flushOutput() {
+	if ev.out != nil {
+		ev.out.flush()
+	}
+}
+
+// writeOutput は applier が評価器なら EvalOptions の出力先に、そうでなければ標準出力に書く
+func writeOutput(applier object.Applier, s string) object.Object {
+	ev, ok := applier.(*evaluation)
+	if !ok {
+		fmt.Fprint(os.Stdout, s)
+		return NULL
+	}
+	if err := ev.output().write(s); err != nil {
+		return err
+	}
+	return NULL
+}
+
+func init() {
+	builtins["puts"] = &object.Builtin{
+		Doc:          "puts(args...): print each argument on its own line",
+		Capabilities: []object.Capability{object.IO_CAP},
+		ApplyFn: func(applier object.Applier, args ...object.Object) object.Object {
+			format := outputFormatOf(applier)
+			var out bytes.Buffer
+			for _, arg := range args {
+				out.WriteString(format.Inspect(arg))
+				out.WriteString("\n")
+			}
+			return writeOutput(applier, out.String())
+		},
+	}
+	builtins["print"] = &object.Builtin{
+		Doc:          "print(args...): print the arguments separated by spaces, without a trailing newline",
+		Capabilities: []object.Capability{object.IO_CAP},
+		ApplyFn: func(applier object.Applier, args ...object.Object) object.Object {
+			format := outputFormatOf(applier)
+			var out bytes.Buffer
+			for i, arg := range args {
+				if i > 0 {
+					out.WriteString(" ")
+				}
+				out.WriteString(format.Inspect(arg))
+			}
+			return writeOutput(applier, out.String())
+		},
+	}
+}
@@ -0,0 +1,102 @@
+package evaluator
+
+import (
+	"github.com/al-keio/monkey-go/object"
+)
+
+func init() {
+	builtins["set_output_format"] = &object.Builtin{
+		Doc: `set_output_format(options): set how numbers are printed, e.g. {"thousands": ",", "precision": 2, "hex": false}; returns the previous options`,
+		ApplyFn: func(applier object.Applier, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+			options, ok := args[0].(*object.Hash)
+			if !ok {
+				return newError(object.WRONG_ARGUMENT_TYPE_ERR, "argument to `set_output_format` must be HASH, got %s", args[0].Type())
+			}
+
+			format, err := outputFormatFromHash(options)
+			if err != nil {
+				return err
+			}
+			current := outputFormatOf(applier)
+			previous := *current
+			*current = format
+			return outputFormatToHash(previous)
+		},
+	}
+}
+
+// outputFormatOf は applier が評価器ならその評価の書式を返す。そうでなければ既定の書式の複製を返す。
+func outputFormatOf(applier object.Applier) *object.OutputFormat {
+	if ev, ok := applier.(*evaluation); ok {
+		return ev.outputFormat()
+	}
+	format := object.DefaultOutputFormat
+	return &format
+}
+
+func (ev *evaluation) outputFormat() *object.OutputFormat {
+	if ev.options.OutputFormat == nil {
+		format := object.DefaultOutputFormat
+		ev.options.OutputFormat = &format
+	}
+	return ev.options.OutputFormat
+}
+
+// outputFormatFromHash は指定のない項目を既定値とした書式を返す
+func outputFormatFromHash(options *object.Hash) (object.OutputFormat, *object.Error) {
+	format := object.DefaultOutputFormat
+
+	for _, pair := range options.PairList() {
+		key, ok := pair.Key.(*object.String)
+		if !ok {
+			return format, newError(object.INVALID_VALUE_ERR, "unknown output format option %s", pair.Key.Inspect())
+		}
+
+		switch value := pair.Value.(type) {
+		case *object.String:
+			if key.Value != "thousands" {
+				return format, invalidOutputFormatOption(key.Value, value)
+			}
+			format.ThousandsSeparator = value.Value
+		case *object.Integer:
+			if key.Value != "precision" {
+				return format, invalidOutputFormatOption(key.Value, value)
+			}
+			format.Precision = int(value.Value)
+		case *object.Boolean:
+			if key.Value != "hex" {
+				return format, invalidOutputFormatOption(key.Value, value)
+			}
+			format.Hex = value.Value
+		default:
+			return format, invalidOutputFormatOption(key.Value, value)
+		}
+	}
+
+	return format, nil
+}
+
+func invalidOutputFormatOption(name string, value object.Object) *object.Error {
+	switch name {
+	case "thousands", "precision", "hex":
+		return newError(object.INVALID_VALUE_ERR, "invalid value for output format option %s: %s", name, value.Type())
+	default:
+		return newError(object.INVALID_VALUE_ERR, "unknown output format option %s", name)
+	}
+}
+
+func outputFormatToHash(format object.OutputFormat) *object.Hash {
+	hash := object.NewHash()
+	set := func(name string, value object.Object) {
+		hash.Set(&object.String{Value: name}, value)
+	}
+
+	set("thousands", &object.String{Value: format.ThousandsSeparator})
+	set("precision", &object.Integer{Value: int64(format.Precision)})
+	set("hex", nativeBoolToBooleanObject(format.Hex))
+
+	return hash
+}
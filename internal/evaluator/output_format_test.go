@@ -0,0 +1,56 @@
+package evaluator
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/al-keio/monkey-go/internal/lexer"
+	"github.com/al-keio/monkey-go/internal/parser"
+	"github.com/al-keio/monkey-go/object"
+)
+
+func TestSetOutputFormat(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`set_output_format({"thousands": ","}); [1234567, 1000.5d]`, "[1,234,567, 1,000.5]"},
+		{`set_output_format({"precision": 2}); 10d / 3d`, "3.33"},
+		{`set_output_format({"hex": true}); 255`, "0xff"},
+		{`set_output_format({"hex": true}); set_output_format({})`, "{hex: true, precision: -1, thousands: }"},
+		{`set_output_format({"thousands": ","}); let h = {[1000]: "k"}; set_output_format({}); h[[1000]]`, "k"},
+		{`set_output_format({"digits": 2})`, "ERROR: unknown output format option digits"},
+		{`set_output_format({"hex": 1})`, "ERROR: invalid value for output format option hex: INTEGER"},
+		{`set_output_format(1)`, "ERROR: argument to `set_output_format` must be HASH, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		format := object.DefaultOutputFormat
+		program := parser.New(lexer.New(tt.input)).ParseProgram()
+		evaluated := EvalWithOptions(program, object.NewEnvironment(), EvalOptions{OutputFormat: &format}).Value
+		if got := format.Inspect(evaluated); got != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestOutputFormatIsPerEvaluation(t *testing.T) {
+	var out bytes.Buffer
+	run := func(input string, format *object.OutputFormat) {
+		program := parser.New(lexer.New(input)).ParseProgram()
+		EvalWithOptions(program, object.NewEnvironment(), EvalOptions{Output: &out, OutputFormat: format})
+	}
+
+	var a, b object.OutputFormat = object.DefaultOutputFormat, object.DefaultOutputFormat
+	run(`set_output_format({"hex": true}); puts(255)`, &a)
+	run(`puts(255)`, &b)
+	run(`puts(255)`, &a)
+	run(`puts(255)`, nil)
+
+	if out.String() != "0xff\n255\n0xff\n255\n" {
+		t.Errorf("output wrong. got=%q", out.String())
+	}
+	if (&object.Integer{Value: 255}).Inspect() != "255" {
+		t.Errorf("set_output_format changed Inspect")
+	}
+}
@@ -0,0 +1,66 @@
+package evaluator
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/al-keio/monkey-go/internal/lexer"
+	"github.com/al-keio/monkey-go/internal/parser"
+	"github.com/al-keio/monkey-go/object"
+)
+
+// lineWriter は Write ごとに書かれた内容を記録する
+type lineWriter struct {
+	writes []string
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.writes = append(w.writes, string(p))
+	return len(p), nil
+}
+
+func evalWithOutput(input string, options EvalOptions) object.Object {
+	program := parser.New(lexer.New(input)).ParseProgram()
+	return EvalWithOptions(program, object.NewEnvironment(), options).Value
+}
+
+func TestOutput(t *testing.T) {
+	var out bytes.Buffer
+	result := evalWithOutput(`puts(1, "a"); print("b", 2); print("c"); 3`, EvalOptions{Output: &out})
+
+	testIntegerObject(t, result, 3)
+	if got := out.String(); got != "1\na\nb 2c" {
+		t.Errorf("output wrong. got=%q", got)
+	}
+}
+
+func TestOutputLineBuffered(t *testing.T) {
+	w := &lineWriter{}
+	evalWithOutput(`print("a"); print("b"); puts("c"); print("d")`, EvalOptions{Output: w, LineBuffered: true})
+
+	expected := []string{"abc\n", "d"}
+	if len(w.writes) != len(expected) {
+		t.Fatalf("writes wrong. want=%q, got=%q", expected, w.writes)
+	}
+	for i, s := range expected {
+		if w.writes[i] != s {
+			t.Errorf("writes[%d] wrong. want=%q, got=%q", i, s, w.writes[i])
+		}
+	}
+}
+
+func TestOutputLimit(t *testing.T) {
+	var out bytes.Buffer
+	result := evalWithOutput(`puts("abc"); puts("defg"); puts("never")`, EvalOptions{Output: &out, MaxOutputBytes: 6})
+
+	err, ok := result.(*object.Error)
+	if !ok {
+		t.Fatalf("object is not Error. got=%T (%+v)", result, result)
+	}
+	if err.Code != object.OUTPUT_LIMIT_ERR {
+		t.Errorf("wrong error code. want=%s, got=%s", object.OUTPUT_LIMIT_ERR, err.Code)
+	}
+	if got := out.String(); got != "abc\nde" {
+		t.Errorf("output wrong. got=%q", got)
+	}
+}
@@ -0,0 +1,162 @@
+package evaluator
+
+import (
+	"github.com/al-keio/monkey-go/ast"
+	"github.com/al-keio/monkey-go/object"
+	"github.com/al-keio/monkey-go/symbol"
+)
+
+func init() {
+	builtins["with_proto"] = &object.Builtin{
+		Doc: "with_proto(hash, proto): copy of hash whose missing keys are looked up in the hash proto (and its prototypes); proto may be null to drop the prototype",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=2", len(args))
+			}
+			hash, ok := args[0].(*object.Hash)
+			if !ok {
+				return newError(object.WRONG_ARGUMENT_TYPE_ERR, "argument to `with_proto` must be HASH, got %s", args[0].Type())
+			}
+			var proto *object.Hash
+			switch p := args[1].(type) {
+			case *object.Hash:
+				proto = p
+			case *object.Null:
+			default:
+				return newError(object.WRONG_ARGUMENT_TYPE_ERR, "prototype passed to `with_proto` must be HASH or NULL, got %s", args[1].Type())
+			}
+
+			copied := object.NewHash()
+			for _, pair := range hash.PairList() {
+				copied.Set(pair.Key, pair.Value)
+			}
+			copied.Proto = proto
+			return copied
+		},
+	}
+	builtins["proto"] = &object.Builtin{
+		Doc: "proto(hash): the prototype of hash set by with_proto, or null",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=1", len(args))
+			}
+			hash, ok := args[0].(*object.Hash)
+			if !ok {
+				return newError(object.WRONG_ARGUMENT_TYPE_ERR, "argument to `proto` must be HASH, got %s", args[0].Type())
+			}
+			if hash.Proto == nil {
+				return NULL
+			}
+			return hash.Proto
+		},
+	}
+}
+
+// method は obj.name(...) の形の呼び出しの関数を評価する。obj がハッシュで、見つけた値 (プロトタイプから
+// 見つけたものも含む) が関数なら、本体から obj を self として参照できるようにした関数を返す。
+func (ev *evaluation) method(member *ast.MemberExpression, env *object.Environment) object.Object {
+	// ev.eval(member, env) と同じだけステップを数える
+	if err := ev.step(); err != nil {
+		return err
+	}
+	receiver := ev.eval(member.Object, env)
+	if isError(receiver) {
+		return receiver
+	}
+
+	value := evalIndexExpression(receiver, &object.String{Value: member.Member.Value})
+	fn, ok := value.(*object.Function)
+	if _, isHash := receiver.(*object.Hash); !ok || !isHash {
+		return value
+	}
+	return bindSelf(fn, receiver)
+}
+
+var selfSymbol = symbol.Intern("self")
+
+func bindSelf(fn *object.Function, self object.Object) *object.Function {
+	env := object.NewEnclosedEnvironment(fn.Env)
+	env.SetSymbol(selfSymbol, self)
+
+	bound := *fn
+	bound.Env = env
+	return &bound
+}
+
+// evalClassStatement はメソッドを値に持つハッシュを作って名前に束縛する。extends があれば基底クラスをそのプロトタイプにする。
+func (ev *evaluation) evalClassStatement(node *ast.ClassStatement, env *object.Environment) object.Object {
+	class := object.NewHash()
+	if node.Extends != nil {
+		base := ev.eval(node.Extends, env)
+		if isError(base) {
+			return base
+		}
+		hash, ok := base.(*object.Hash)
+		if !ok {
+			return newError(object.TYPE_MISMATCH_ERR, "class %s cannot extend %s", node.Name.Value, base.Type())
+		}
+		class.Proto = hash
+	}
+
+	for _, m := range node.Methods {
+		fn := ev.eval(m.Function, env)
+		if isError(fn) {
+			return fn
+		}
+		class.Set(&object.String{Value: m.Name.Value}, fn)
+	}
+
+	env.SetSymbol(node.Name.ID(), class)
+	return nil
+}
+
+var initKey = &object.String{Value: "init"}
+
+// evalNewExpression はクラスをプロトタイプに持つインスタンスを作る。ハッシュは変更できないので、
+// init はインスタンスのフィールドのハッシュを返し、それにプロトタイプを付けたものが new の値になる。
+// init の中の self はフィールドのないインスタンスで、メソッドは呼べる。
+func (ev *evaluation) evalNewExpression(node *ast.NewExpression, env *object.Environment) object.Object {
+	value := ev.eval(node.Class, env)
+	if isError(value) {
+		return value
+	}
+	class, ok := value.(*object.Hash)
+	if !ok {
+		return newError(object.TYPE_MISMATCH_ERR, "cannot instantiate %s", value.Type())
+	}
+
+	args := ev.evalExpressions(node.Arguments, env)
+	if len(args) == 1 && isError(args[0]) {
+		return args[0]
+	}
+
+	instance := object.NewHash()
+	instance.Proto = class
+
+	pair, ok := class.Lookup(initKey)
+	if !ok {
+		if len(args) != 0 {
+			return newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=0", len(args))
+		}
+		return instance
+	}
+
+	init := pair.Value
+	if fn, ok := init.(*object.Function); ok {
+		init = bindSelf(fn, instance)
+	}
+	fields := ev.call("init", node.Token.Offset, init, args)
+	switch fields := fields.(type) {
+	case *object.Error:
+		return fields
+	case *object.Null:
+		return instance
+	case *object.Hash:
+		for _, pair := range fields.PairList() {
+			instance.Set(pair.Key, pair.Value)
+		}
+		return instance
+	default:
+		return newError(object.TYPE_MISMATCH_ERR, "init must return HASH or NULL, got %s", fields.Type())
+	}
+}
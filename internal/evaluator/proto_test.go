@@ -0,0 +1,82 @@
+package evaluator
+
+import "testing"
+
+func TestPrototypes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let base = {"greet": "hi"}; let obj = with_proto({"name": "x"}, base); [obj.name, obj.greet, obj["greet"], obj.missing]`, "[x, hi, hi, null]"},
+		{`let a = {"v": 1}; let b = with_proto({}, a); let c = with_proto({}, b); c.v`, "1"},
+		{`let base = {"v": 1}; with_proto({"v": 2}, base).v`, "2"},
+		{`let base = {"v": 1}; let obj = with_proto({}, base); proto(obj) == base`, "true"},
+		{`proto({})`, "null"},
+		{`let obj = with_proto({"a": 1}, {"b": 2}); obj`, "{a: 1}"},
+		{`with_proto(with_proto({}, {"b": 2}), proto({})).b`, "null"},
+		{`with_proto([], {})`, "ERROR: argument to `with_proto` must be HASH, got ARRAY"},
+		{`with_proto({}, 1)`, "ERROR: prototype passed to `with_proto` must be HASH or NULL, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestMethodCalls(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let counter = {"n": 3, "get": fn() { self.n }}; counter.get()`, "3"},
+		{`let point = {"norm": fn() { self.x * self.x + self.y * self.y }}; with_proto({"x": 3, "y": 4}, point).norm()`, "25"},
+		{`let animal = {"speak": fn() { self.name + " says " + self.sound() }};
+		  let dog = with_proto({"sound": fn() { "woof" }}, animal);
+		  with_proto({"name": "rex"}, dog).speak()`, "rex says woof"},
+		{`let obj = {"add": fn(a, b) { a + b + self.base }, "base": 10}; obj.add(1, 2)`, "13"},
+		// 添字で取り出して呼ぶと self は束縛しない
+		{`let obj = {"get": fn() { self }}; obj["get"]()`, "ERROR: identifier not found: self"},
+		{`let self = 1; let f = fn() { self }; let obj = {"f": f}; [f(), obj.f() == obj]`, "[1, true]"},
+		{`let obj = {"f": len}; obj.f([1, 2])`, "2"},
+		{`let obj = {}; obj.nope()`, "ERROR: not a function: NULL"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestClasses(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`class Point { init(x, y) { {"x": x, "y": y} } norm() { self.x * self.x + self.y * self.y } }
+		  let p = new Point(3, 4); [p, p.norm(), proto(p) == Point]`, "[{x: 3, y: 4}, 25, true]"},
+		{`class Counter { get() { 0 } }; new Counter().get()`, "0"},
+		{`class Animal { init(name) { {"name": name} } speak() { self.name + " says " + self.sound() } sound() { "..." } }
+		  class Dog extends Animal { sound() { "woof" } }
+		  [new Dog("rex").speak(), new Animal("cat").speak()]`, "[rex says woof, cat says ...]"},
+		{`class A { init(x) { {"double": self.twice(x)} } twice(x) { x * 2 } }; new A(4).double`, "8"},
+		{`class A { init() { if (false) { 1 } } }; new A()`, "{}"},
+		{`class A { init() { 1 } }; new A()`, "ERROR: init must return HASH or NULL, got INTEGER"},
+		{`class A {}; new A(1)`, "ERROR: wrong number of arguments. got=1, want=0"},
+		{`class A { init(x) { {} } }; new A()`, "ERROR: wrong number of arguments. got=0, want=1"},
+		{`let B = 1; class A extends B {}`, "ERROR: class A cannot extend INTEGER"},
+		{`new len()`, "ERROR: cannot instantiate BUILTIN"},
+		{`class A { init(x) requires x > 0 { {"x": x} } }; new A(0)`, "ERROR: precondition failed: (x > 0)"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
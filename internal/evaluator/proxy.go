@@ -0,0 +1,338 @@
+package evaluator
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+
+	"github.com/al-keio/monkey-go/object"
+)
+
+var (
+	objectType = reflect.TypeOf((*object.Object)(nil)).Elem()
+	errorType  = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+func evalProxyIndexExpression(proxy *object.Proxy, index object.Object) object.Object {
+	name, ok := index.(*object.String)
+	if !ok {
+		return newError(object.WRONG_ARGUMENT_TYPE_ERR, "proxy member must be STRING, got %s", index.Type())
+	}
+
+	if proxy.Value.IsValid() && proxy.Allows(name.Value) {
+		if method := proxy.Value.MethodByName(name.Value); method.IsValid() {
+			return proxyMethod(proxy, name.Value, method)
+		}
+
+		if field, ok := proxyField(proxy.Value, name.Value); ok {
+			return toObject(proxy, field)
+		}
+	}
+
+	return newError(object.UNKNOWN_MEMBER_ERR, "unknown member %s of %s", name.Value, proxy.Inspect())
+}
+
+func proxyField(v reflect.Value, name string) (reflect.Value, bool) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}, false
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return reflect.Value{}, false
+	}
+
+	field, ok := v.Type().FieldByName(name)
+	if !ok || field.PkgPath != "" {
+		return reflect.Value{}, false
+	}
+	return v.FieldByIndex(field.Index), true
+}
+
+func proxyMethod(proxy *object.Proxy, name string, method reflect.Value) *object.Builtin {
+	t := method.Type()
+
+	return &object.Builtin{
+		Doc: fmt.Sprintf("%s%s", name, t.String()[len("func"):]),
+		Fn: func(args ...object.Object) (result object.Object) {
+			if t.IsVariadic() && len(args) < t.NumIn()-1 || !t.IsVariadic() && len(args) != t.NumIn() {
+				return newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=%d", len(args), t.NumIn())
+			}
+
+			// ホスト側の panic でインタプリタごと落ちないようにする。引数の変換も reflect を使うので含める
+			defer func() {
+				if r := recover(); r != nil {
+					result = newError(object.HOST_ERR, "%s: panic: %v", name, r)
+				}
+			}()
+
+			in := make([]reflect.Value, len(args))
+			for i, arg := range args {
+				var pt reflect.Type
+				if t.IsVariadic() && i >= t.NumIn()-1 {
+					pt = t.In(t.NumIn() - 1).Elem()
+				} else {
+					pt = t.In(i)
+				}
+
+				v, ok := fromObject(arg, pt)
+				if !ok {
+					return newError(object.WRONG_ARGUMENT_TYPE_ERR, "argument %d to `%s` must be %s, got %s", i+1, name, pt, arg.Type())
+				}
+				in[i] = v
+			}
+
+			return fromResults(proxy, name, method.Call(in))
+		},
+	}
+}
+
+// fromResults は Go の戻り値を変換する。最後の戻り値が error なら、nil でなければエラーとして返す。
+func fromResults(proxy *object.Proxy, name string, out []reflect.Value) object.Object {
+	if len(out) > 0 && out[len(out)-1].Type() == errorType {
+		if err := out[len(out)-1]; !err.IsNil() {
+			return newError(object.HOST_ERR, "%s: %s", name, err.Interface().(error).Error())
+		}
+		out = out[:len(out)-1]
+	}
+
+	switch len(out) {
+	case 0:
+		return NULL
+	case 1:
+		return toObject(proxy, out[0])
+	default:
+		elements := make([]object.Object, len(out))
+		for i, v := range out {
+			elements[i] = toObject(proxy, v)
+		}
+		return &object.Array{Elements: elements}
+	}
+}
+
+// toObject は proxy のメンバーから得た Go の値を変換する。
+// Proxy で包む値には proxy と同じメンバーの制限をかける。
+func toObject(proxy *object.Proxy, v reflect.Value) object.Object {
+	if !v.IsValid() {
+		return NULL
+	}
+
+	if v.Type().Implements(objectType) {
+		if isNil(v) {
+			return NULL
+		}
+		return v.Interface().(object.Object)
+	}
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &object.Integer{Value: v.Int()}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return object.NewInteger(new(big.Int).SetUint64(v.Uint()))
+	case reflect.Float32, reflect.Float64:
+		value := new(big.Rat).SetFloat64(v.Float())
+		if value == nil {
+			return newError(object.INVALID_VALUE_ERR, "cannot represent %v as decimal", v.Float())
+		}
+		return &object.Decimal{Value: value}
+	case reflect.String:
+		return &object.String{Value: v.String()}
+	case reflect.Bool:
+		return nativeBoolToBooleanObject(v.Bool())
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return NULL
+		}
+		elements := make([]object.Object, v.Len())
+		for i := range elements {
+			elements[i] = toObject(proxy, v.Index(i))
+		}
+		return &object.Array{Elements: elements}
+	case reflect.Map:
+		if v.IsNil() {
+			return NULL
+		}
+		hash := object.NewHash()
+		for _, k := range v.MapKeys() {
+			key := toObject(proxy, k)
+			if object.UnhashablePart(key) != nil {
+				return proxy.Nested(v.Interface())
+			}
+			hash.Set(key, toObject(proxy, v.MapIndex(k)))
+		}
+		return hash
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return NULL
+		}
+		if v.Kind() == reflect.Interface {
+			return toObject(proxy, v.Elem())
+		}
+	}
+
+	// 構造体やポインタなどは Proxy で包む。メンバーの制限は proxy から引き継ぐので、
+	// 入れ子の値に別の制限をかけたい場合はホスト側で object.NewProxy を使って作った Proxy を返せばよい。
+	return proxy.Nested(v.Interface())
+}
+
+func fromObject(obj object.Object, t reflect.Type) (reflect.Value, bool) {
+	if proxy, ok := obj.(*object.Proxy); ok {
+		if proxy.Value.IsValid() && proxy.Value.Type().AssignableTo(t) {
+			return proxy.Value, true
+		}
+		return reflect.Value{}, false
+	}
+
+	if obj == NULL {
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map, reflect.Func, reflect.Chan:
+			return reflect.Zero(t), true
+		}
+		return reflect.Value{}, false
+	}
+
+	// object.Object などを受け取るホストのメソッドにはそのまま渡す
+	if t.Kind() != reflect.Interface || t.NumMethod() > 0 {
+		if reflect.TypeOf(obj).AssignableTo(t) {
+			return reflect.ValueOf(obj), true
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, ok := obj.(*object.Integer)
+		if !ok {
+			return reflect.Value{}, false
+		}
+		v := reflect.New(t).Elem()
+		if v.OverflowInt(i.Value) {
+			return reflect.Value{}, false
+		}
+		v.SetInt(i.Value)
+		return v, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		var u uint64
+		switch obj := obj.(type) {
+		case *object.Integer:
+			if obj.Value < 0 {
+				return reflect.Value{}, false
+			}
+			u = uint64(obj.Value)
+		case *object.BigInt:
+			// int64 に収まらない uint64 の値は BigInt になっている
+			if !obj.Value.IsUint64() {
+				return reflect.Value{}, false
+			}
+			u = obj.Value.Uint64()
+		default:
+			return reflect.Value{}, false
+		}
+		v := reflect.New(t).Elem()
+		if v.OverflowUint(u) {
+			return reflect.Value{}, false
+		}
+		v.SetUint(u)
+		return v, true
+	case reflect.Float32, reflect.Float64:
+		v := reflect.New(t).Elem()
+		switch obj := obj.(type) {
+		case *object.Integer:
+			v.SetFloat(float64(obj.Value))
+		case *object.Decimal:
+			f, _ := obj.Value.Float64()
+			v.SetFloat(f)
+		default:
+			return reflect.Value{}, false
+		}
+		return v, true
+	case reflect.String:
+		s, ok := obj.(*object.String)
+		if !ok {
+			return reflect.Value{}, false
+		}
+		return reflect.ValueOf(s.Value).Convert(t), true
+	case reflect.Bool:
+		b, ok := obj.(*object.Boolean)
+		if !ok {
+			return reflect.Value{}, false
+		}
+		return reflect.ValueOf(b.Value).Convert(t), true
+	case reflect.Slice:
+		arr, ok := obj.(*object.Array)
+		if !ok {
+			return reflect.Value{}, false
+		}
+		v := reflect.MakeSlice(t, len(arr.Elements), len(arr.Elements))
+		for i, element := range arr.Elements {
+			e, ok := fromObject(element, t.Elem())
+			if !ok {
+				return reflect.Value{}, false
+			}
+			v.Index(i).Set(e)
+		}
+		return v, true
+	case reflect.Map:
+		hash, ok := obj.(*object.Hash)
+		if !ok {
+			return reflect.Value{}, false
+		}
+		v := reflect.MakeMapWithSize(t, hash.Len())
+		for _, pair := range hash.PairList() {
+			key, ok := fromObject(pair.Key, t.Key())
+			// 配列のキーは []interface{} になり、Go の map のキーにはできない
+			if !ok || !mapKey(key) {
+				return reflect.Value{}, false
+			}
+			value, ok := fromObject(pair.Value, t.Elem())
+			if !ok {
+				return reflect.Value{}, false
+			}
+			v.SetMapIndex(key, value)
+		}
+		return v, true
+	case reflect.Interface:
+		if t.NumMethod() > 0 {
+			return reflect.Value{}, false
+		}
+		return fromObject(obj, nativeType(obj))
+	}
+
+	return reflect.Value{}, false
+}
+
+// nativeType は interface{} の引数に渡すときの Go の型を返す。
+func nativeType(obj object.Object) reflect.Type {
+	switch obj.(type) {
+	case *object.Integer:
+		return reflect.TypeOf(int64(0))
+	case *object.Decimal:
+		return reflect.TypeOf(float64(0))
+	case *object.String:
+		return reflect.TypeOf("")
+	case *object.Boolean:
+		return reflect.TypeOf(false)
+	case *object.Array:
+		return reflect.TypeOf([]interface{}{})
+	case *object.Hash:
+		return reflect.TypeOf(map[interface{}]interface{}{})
+	default:
+		return objectType
+	}
+}
+
+// mapKey は v を Go の map のキーにできるかどうかを返す。interface の値は中身の型で調べる。
+func mapKey(v reflect.Value) bool {
+	if v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
+	return v.Type().Comparable()
+}
+
+func isNil(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map, reflect.Func, reflect.Chan:
+		return v.IsNil()
+	}
+	return false
+}
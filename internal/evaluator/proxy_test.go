@@ -0,0 +1,128 @@
+package evaluator
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/al-keio/monkey-go/internal/lexer"
+	"github.com/al-keio/monkey-go/internal/parser"
+	"github.com/al-keio/monkey-go/object"
+)
+
+type testService struct {
+	Name    string
+	secret  string
+	Entries map[string]int
+}
+
+func (s *testService) Greet(name string) string { return s.Greet2(name, 1) }
+func (s *testService) Greet2(name string, times int) string {
+	return strings.Repeat("hello "+name+"!", times)
+}
+func (s *testService) Sum(values ...int) int {
+	total := 0
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+func (s *testService) Lookup(key string) (int, error) {
+	v, ok := s.Entries[key]
+	if !ok {
+		return 0, errors.New("no entry " + key)
+	}
+	return v, nil
+}
+func (s *testService) Child() *testService { return &testService{Name: "child"} }
+func (s *testService) Describe(v interface{}) string {
+	switch v := v.(type) {
+	case int64:
+		return "int"
+	case []interface{}:
+		return "array of " + string(rune('0'+len(v)))
+	default:
+		return "other"
+	}
+}
+func (s *testService) Crash()               { panic("boom") }
+func (s *testService) Max() uint64          { return math.MaxUint64 }
+func (s *testService) Half(v uint64) uint64 { return v / 2 }
+
+func TestProxy(t *testing.T) {
+	service := &testService{Name: "svc", secret: "s", Entries: map[string]int{"a": 1}}
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`svc["Greet"]("monkey")`, "hello monkey!"},
+		{`svc["Greet2"]("x", 2)`, "hello x!hello x!"},
+		{`svc["Sum"]()`, 0},
+		{`svc["Sum"](1, 2, 3)`, 6},
+		{`svc["Lookup"]("a")`, 1},
+		{`svc["Lookup"]("b")`, "ERROR: Lookup: no entry b"},
+		{`svc["Name"]`, "svc"},
+		{`svc["Entries"]["a"]`, 1},
+		{`svc["Child"]()["Name"]`, "child"},
+		{`svc["Describe"](1)`, "int"},
+		{`svc["Describe"]([1, "a"])`, "array of 2"},
+		{`svc["Describe"]({"a": 1})`, "other"},
+		{`svc["Describe"]({[1]: 2})`, "ERROR: argument 1 to `Describe` must be interface {}, got HASH"},
+		{`svc["Crash"]()`, "ERROR: Crash: panic: boom"},
+		{`svc["Max"]()`, "18446744073709551615"},
+		{`svc["Half"](svc["Max"]())`, 9223372036854775807},
+		{`svc["Half"](-1)`, "ERROR: argument 1 to `Half` must be uint64, got INTEGER"},
+		{`svc["secret"]`, "ERROR: unknown member secret of proxy(*evaluator.testService)"},
+		{`svc["Missing"]`, "ERROR: unknown member Missing of proxy(*evaluator.testService)"},
+		{`svc["Greet"](1)`, "ERROR: argument 1 to `Greet` must be string, got INTEGER"},
+		{`svc["Greet"]()`, "ERROR: wrong number of arguments. got=0, want=1"},
+		{`svc[1]`, "ERROR: proxy member must be STRING, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		env := object.NewEnvironment()
+		env.Set("svc", object.NewProxy(service))
+
+		evaluated := Eval(parser.New(lexer.New(tt.input)).ParseProgram(), env)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			if evaluated.Inspect() != expected {
+				t.Errorf("%s: expected %q, got %q", tt.input, expected, evaluated.Inspect())
+			}
+		}
+	}
+}
+
+func TestProxyAllowlist(t *testing.T) {
+	env := object.NewEnvironment()
+	env.Set("svc", object.NewProxy(&testService{Name: "svc"}, "Greet"))
+
+	evaluated := Eval(parser.New(lexer.New(`svc["Greet"]("a")`)).ParseProgram(), env)
+	if evaluated.Inspect() != "hello a!" {
+		t.Errorf("allowed method: got %q", evaluated.Inspect())
+	}
+
+	for _, member := range []string{"Name", "Crash"} {
+		evaluated := Eval(parser.New(lexer.New(`svc["`+member+`"]`)).ParseProgram(), env)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok || errObj.Code != object.UNKNOWN_MEMBER_ERR {
+			t.Errorf("member %s should not be accessible, got %s", member, evaluated.Inspect())
+		}
+	}
+
+	// メソッドが返した構造体にも同じ制限がかかる
+	env.Set("svc", object.NewProxy(&testService{Name: "svc"}, "Child", "Greet"))
+	evaluated = Eval(parser.New(lexer.New(`svc["Child"]()["Greet"]("b")`)).ParseProgram(), env)
+	if evaluated.Inspect() != "hello b!" {
+		t.Errorf("allowed method of a nested proxy: got %q", evaluated.Inspect())
+	}
+	evaluated = Eval(parser.New(lexer.New(`svc["Child"]()["Name"]`)).ParseProgram(), env)
+	if errObj, ok := evaluated.(*object.Error); !ok || errObj.Code != object.UNKNOWN_MEMBER_ERR {
+		t.Errorf("member Name of a nested proxy should not be accessible, got %s", evaluated.Inspect())
+	}
+}
@@ -0,0 +1,240 @@
+package evaluator
+
+import (
+	"fmt"
+
+	"github.com/al-keio/monkey-go/ast"
+	"github.com/al-keio/monkey-go/object"
+	"github.com/al-keio/monkey-go/token"
+)
+
+// quote は unquote と unquote_splice を評価した木を返す。それらの引数がエラーになれば、木の代わりにそのエラーを返す。
+func (ev *evaluation) quote(node ast.Node, env *object.Environment) object.Object {
+	outer := ev.unquoted
+	ev.unquoted = nil
+	node = ev.evalUnquoteCalls(node, env)
+	err := ev.unquoted
+	ev.unquoted = outer
+	if err != nil {
+		return err
+	}
+	return &object.Quote{Node: node}
+}
+
+// unquoteError は unquote の引数を評価した結果がエラーなら、quote が返せるように覚えておく
+func (ev *evaluation) unquoteError(obj object.Object) bool {
+	err, ok := obj.(*object.Error)
+	if ok && ev.unquoted == nil {
+		ev.unquoted = err
+	}
+	return ok
+}
+
+func (ev *evaluation) evalUnquoteCalls(quoted ast.Node, env *object.Environment) ast.Node {
+	return ast.Modify(quoted, func(node ast.Node) ast.Node {
+		node = ev.spliceNode(node, env)
+
+		if !isUnquoteCalls(node) {
+			return node
+		}
+
+		call, ok := node.(*ast.CallExpression)
+		if !ok {
+			return node
+		}
+
+		if len(call.Arguments) != 1 {
+			return node
+		}
+
+		// AST に変換できない値 (関数など) は unquote の呼び出しをそのまま残し、評価時のエラーにする
+		unquoted := ev.eval(call.Arguments[0], env)
+		if ev.unquoteError(unquoted) {
+			return node
+		}
+		if converted := convertObjectToASTNode(unquoted); converted != nil {
+			return converted
+		}
+		return node
+	})
+}
+
+// spliceNode は unquote_splice を展開したノードを返す。quote の中身はマクロ本体と共有されているので、
+// 元のノードは書き換えずに複製する。
+func (ev *evaluation) spliceNode(node ast.Node, env *object.Environment) ast.Node {
+	switch node := node.(type) {
+	case *ast.ArrayLiteral:
+		if hasUnquoteSpliceCall(node.Elements) {
+			copied := *node
+			copied.Elements = ev.spliceExpressions(node.Elements, env)
+			return &copied
+		}
+	case *ast.CallExpression:
+		if hasUnquoteSpliceCall(node.Arguments) {
+			copied := *node
+			copied.Arguments = ev.spliceExpressions(node.Arguments, env)
+			return &copied
+		}
+	case *ast.BlockStatement:
+		for _, stmt := range node.Statements {
+			if exprStmt, ok := stmt.(*ast.ExpressionStatement); ok {
+				if _, ok := isUnquoteSpliceCall(exprStmt.Expression); ok {
+					copied := *node
+					copied.Statements = ev.spliceStatements(node.Statements, env)
+					return &copied
+				}
+			}
+		}
+	}
+	return node
+}
+
+func hasUnquoteSpliceCall(exps []ast.Expression) bool {
+	for _, exp := range exps {
+		if _, ok := isUnquoteSpliceCall(exp); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func isUnquoteCalls(node ast.Node) bool {
+	callExpression, ok := node.(*ast.CallExpression)
+	if !ok {
+		return false
+	}
+
+	return callExpression.Function.TokenLiteral() == "unquote"
+}
+
+func isUnquoteSpliceCall(node ast.Node) (*ast.CallExpression, bool) {
+	callExpression, ok := node.(*ast.CallExpression)
+	if !ok {
+		return nil, false
+	}
+
+	if callExpression.Function.TokenLiteral() != "unquote_splice" || len(callExpression.Arguments) != 1 {
+		return nil, false
+	}
+
+	return callExpression, true
+}
+
+func (ev *evaluation) spliceExpressions(exps []ast.Expression, env *object.Environment) []ast.Expression {
+	result := []ast.Expression{}
+
+	for _, exp := range exps {
+		call, ok := isUnquoteSpliceCall(exp)
+		if !ok {
+			result = append(result, exp)
+			continue
+		}
+
+		for _, node := range ev.evalUnquoteSplice(call, env) {
+			if exp, ok := node.(ast.Expression); ok {
+				result = append(result, exp)
+			}
+		}
+	}
+
+	return result
+}
+
+func (ev *evaluation) spliceStatements(stmts []ast.Statement, env *object.Environment) []ast.Statement {
+	result := []ast.Statement{}
+
+	for _, stmt := range stmts {
+		exprStmt, ok := stmt.(*ast.ExpressionStatement)
+		if !ok {
+			result = append(result, stmt)
+			continue
+		}
+
+		call, ok := isUnquoteSpliceCall(exprStmt.Expression)
+		if !ok {
+			result = append(result, stmt)
+			continue
+		}
+
+		for _, node := range ev.evalUnquoteSplice(call, env) {
+			switch node := node.(type) {
+			case ast.Statement:
+				result = append(result, node)
+			case ast.Expression:
+				result = append(result, &ast.ExpressionStatement{Token: exprStmt.Token, Expression: node})
+			}
+		}
+	}
+
+	return result
+}
+
+func (ev *evaluation) evalUnquoteSplice(call *ast.CallExpression, env *object.Environment) []ast.Node {
+	spliced := ev.eval(call.Arguments[0], env)
+	if ev.unquoteError(spliced) {
+		return []ast.Node{}
+	}
+
+	switch spliced := spliced.(type) {
+	case *object.Array:
+		nodes := []ast.Node{}
+		for _, el := range spliced.Elements {
+			if ev.unquoteError(el) {
+				return []ast.Node{}
+			}
+			if node := convertObjectToASTNode(el); node != nil {
+				nodes = append(nodes, node)
+			}
+		}
+		return nodes
+	case *object.Quote:
+		if array, ok := spliced.Node.(*ast.ArrayLiteral); ok {
+			nodes := []ast.Node{}
+			for _, el := range array.Elements {
+				nodes = append(nodes, el)
+			}
+			return nodes
+		}
+		return []ast.Node{spliced.Node}
+	default:
+		if node := convertObjectToASTNode(spliced); node != nil {
+			return []ast.Node{node}
+		}
+		return []ast.Node{}
+	}
+}
+
+func convertObjectToASTNode(obj object.Object) ast.Node {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		t := token.Token{
+			Type:    token.INT,
+			Literal: fmt.Sprintf("%d", obj.Value),
+		}
+		return &ast.IntegerLiteral{Token: t, Value: obj.Value}
+	case *object.BigInt:
+		t := token.Token{
+			Type:    token.BIGINT,
+			Literal: obj.Value.String() + "n",
+		}
+		return &ast.BigIntLiteral{Token: t, Value: obj.Value}
+	case *object.Decimal:
+		t := token.Token{
+			Type:    token.DECIMAL,
+			Literal: object.DefaultOutputFormat.FormatDecimal(obj.Value) + "d",
+		}
+		return &ast.DecimalLiteral{Token: t, Value: obj.Value}
+	case *object.Boolean:
+		var t token.Token
+		if obj.Value {
+			t = token.Token{Type: token.TRUE, Literal: "true"}
+		} else {
+			t = token.Token{Type: token.FALSE, Literal: "false"}
+		}
+		return &ast.Boolean{Token: t, Value: obj.Value}
+	case *object.Quote:
+		return obj.Node
+	default:
+		return nil
+	}
+}
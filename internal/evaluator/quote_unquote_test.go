@@ -0,0 +1,179 @@
+package evaluator
+
+import (
+	"github.com/al-keio/monkey-go/object"
+	"testing"
+)
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`quote(5)`,
+			`5`,
+		},
+		{
+			`quote(5 + 8)`,
+			`(5 + 8)`,
+		},
+		{
+			`quote(foobar)`,
+			`foobar`,
+		},
+		{
+			`quote(foobar + barfoo)`,
+			`(foobar + barfoo)`,
+		},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		quote, ok := evaluated.(*object.Quote)
+		if !ok {
+			t.Fatalf("evaluated is not object.Quote. got=%T (%+v)", evaluated, evaluated)
+		}
+
+		if quote.Node == nil {
+			t.Fatalf("quote.Node is nil")
+		}
+
+		if quote.Node.String() != tt.expected {
+			t.Errorf("not equal. got=%q, want=%q", quote.Node.String(), tt.expected)
+		}
+	}
+}
+
+func TestQuoteUnquote(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`quote(unquote(4))`,
+			`4`,
+		},
+		{
+			`quote(unquote(4 + 4))`,
+			`8`,
+		},
+		{
+			`quote(1 + unquote(fn(x) { x }))`,
+			`(1 + unquote(fn(x) x))`,
+		},
+		{
+			`quote(8 + unquote(4 + 4))`,
+			`(8 + 8)`,
+		},
+		{
+			`quote(unquote(4 + 4) + 8)`,
+			`(8 + 8)`,
+		},
+		{
+			`quote(unquote(true))`,
+			`true`,
+		},
+		{
+			`quote(unquote(true == false))`,
+			`false`,
+		},
+		{
+			`quote(unquote(quote(4 + 4)))`,
+			`(4 + 4)`,
+		},
+		{
+			`let quotedInfixExpression = quote(4 + 4); quote(unquote(4 + 4) + unquote(quotedInfixExpression))`,
+			`(8 + (4 + 4))`,
+		},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		quote, ok := evaluated.(*object.Quote)
+		if !ok {
+			t.Fatalf("evaluated is not object.Quote. got=%T (%+v)", evaluated, evaluated)
+		}
+
+		if quote.Node == nil {
+			t.Fatalf("quote.Node is nil")
+		}
+
+		if quote.Node.String() != tt.expected {
+			t.Errorf("not equal. got=%q, want=%q", quote.Node.String(), tt.expected)
+		}
+	}
+}
+
+func TestQuoteUnquoteSplice(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`quote([0, unquote_splice([1, 2]), 3])`,
+			`[0, 1, 2, 3]`,
+		},
+		{
+			`quote([unquote_splice([])])`,
+			`[]`,
+		},
+		{
+			`let args = [quote(a), quote(b + c)]; quote(f(unquote_splice(args)))`,
+			`f(a, (b + c))`,
+		},
+		{
+			`quote(f(x, unquote_splice(quote([y, z]))))`,
+			`f(x, y, z)`,
+		},
+		{
+			`let stmts = [quote(puts(1)), quote(puts(2))]; quote(fn() { unquote_splice(stmts); 3 })`,
+			`fn() puts(1)puts(2)3`,
+		},
+		{
+			`quote(f(unquote(1 + 1)))`,
+			`f(2)`,
+		},
+		{
+			`quote([unquote_splice(true)])`,
+			`[true]`,
+		},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		quote, ok := evaluated.(*object.Quote)
+		if !ok {
+			t.Fatalf("evaluated is not object.Quote. got=%T (%+v)", evaluated, evaluated)
+		}
+
+		if quote.Node == nil {
+			t.Fatalf("quote.Node is nil")
+		}
+
+		if quote.Node.String() != tt.expected {
+			t.Errorf("not equal. got=%q, want=%q", quote.Node.String(), tt.expected)
+		}
+	}
+}
+
+// unquote と unquote_splice の引数のエラーは捨てずに quote の結果にする
+func TestQuoteUnquoteErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`quote(1 + unquote(missing))`, "ERROR: identifier not found: missing"},
+		{`quote([0, unquote_splice(missing)])`, "ERROR: identifier not found: missing"},
+		{`quote(f(unquote_splice([1, 2] + 1)))`, "ERROR: type mismatch: ARRAY + INTEGER"},
+		{`quote(fn() { unquote_splice(1 / 0) })`, "ERROR: division by zero"},
+		{`quote(unquote(quote(unquote(missing))) + 1)`, "ERROR: identifier not found: missing"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package evaluator
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/al-keio/monkey-go/ast"
+	"github.com/al-keio/monkey-go/object"
+)
+
+// call は offset の位置から fn を呼び出し、エラーが返ってきたらそのスタックトレースにこの呼び出しを加える。
+// 位置が分からなければ offset は -1。
+func (ev *evaluation) call(name string, offset int, fn object.Object, args []object.Object) object.Object {
+	site := ev.position(offset)
+	var begin time.Time
+	if ev.options.Trace != nil {
+		begin = time.Now()
+	}
+	result := ev.applyFunction(fn, args, scopeLabel(name, site))
+	if ev.options.Trace != nil {
+		ev.traceCall(name, site, fn, begin, result)
+	}
+	if err, ok := result.(*object.Error); ok {
+		err.Stack = append(err.Stack, ev.frame(name, site, args))
+	}
+	return result
+}
+
+// position は EvalOptions.Source の offset の位置を "file:line" の形で返す。Source がなければ空文字列。
+func (ev *evaluation) position(offset int) string {
+	if ev.options.Source == "" || offset < 0 || offset > len(ev.options.Source) {
+		return ""
+	}
+	line := strings.Count(ev.options.Source[:offset], "\n") + 1
+	if ev.options.Filename == "" {
+		return fmt.Sprintf("line %d", line)
+	}
+	return fmt.Sprintf("%s:%d", ev.options.Filename, line)
+}
+
+// scopeLabel は関数呼び出しの環境に付けるラベル
+func scopeLabel(name, site string) string {
+	if site == "" {
+		return name + "()"
+	}
+	return name + "() called at " + site
+}
+
+func (ev *evaluation) frame(name, site string, args []object.Object) object.Frame {
+	frame := object.Frame{Function: name, CallSite: site}
+	if !ev.options.StackArguments {
+		return frame
+	}
+
+	width := ev.options.ArgumentWidth
+	if width <= 0 {
+		width = DefaultArgumentWidth
+	}
+	frame.Arguments = make([]string, len(args))
+	for i, arg := range args {
+		frame.Arguments[i] = truncate(arg.Inspect(), width)
+	}
+	return frame
+}
+
+// calleeName はトレースに表示する関数名。名前で呼ばれていない関数 (組み込み関数から呼ばれたものを含む) は <anonymous> とする。
+func calleeName(node ast.Expression) string {
+	switch node := node.(type) {
+	case *ast.Identifier:
+		return node.Value
+	case *ast.MemberExpression:
+		return calleeName(node.Object) + "." + node.Member.Value
+	default:
+		return "<anonymous>"
+	}
+}
+
+// truncate は s を一行にして、width 文字を超える分を ... で省略する
+func truncate(s string, width int) string {
+	s = strings.NewReplacer("\n", " ", "\t", " ").Replace(s)
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	if width <= 3 {
+		return string(runes[:width])
+	}
+	return string(runes[:width-3]) + "..."
+}
@@ -0,0 +1,96 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/al-keio/monkey-go/internal/lexer"
+	"github.com/al-keio/monkey-go/internal/parser"
+	"github.com/al-keio/monkey-go/object"
+)
+
+func TestStackTrace(t *testing.T) {
+	tests := []struct {
+		input    string
+		options  EvalOptions
+		expected []string
+	}{
+		{
+			"let f = fn(n) { if (n == 0) { 1 / 0 } else { f(n - 1) } }; f(2)",
+			EvalOptions{},
+			[]string{"f()", "f()", "f()"},
+		},
+		{
+			"let f = fn(n) { if (n == 0) { 1 / 0 } else { f(n - 1) } }; f(2)",
+			EvalOptions{StackArguments: true},
+			[]string{"f(0)", "f(1)", "f(2)"},
+		},
+		{
+			`map([1, 2], fn(x) { len(x) })`,
+			EvalOptions{StackArguments: true, ArgumentWidth: 12},
+			[]string{"len(1)", "<anonymous>(1)", "map([1, 2], fn(x) { l...)"},
+		},
+		{
+			`string.upper(1)`,
+			EvalOptions{StackArguments: true},
+			[]string{"string.upper(1)"},
+		},
+		{
+			"let f = fn(n) { 1 / n }\nf(0)",
+			EvalOptions{Source: "let f = fn(n) { 1 / n }\nf(0)", Filename: "main.monkey"},
+			[]string{"f() (main.monkey:2)"},
+		},
+		{
+			"let f = fn(n) {\n  1 / n\n}\nlet g = fn() { f(0) }\ng()",
+			EvalOptions{Source: "let f = fn(n) {\n  1 / n\n}\nlet g = fn() { f(0) }\ng()"},
+			[]string{"f() (line 4)", "g() (line 5)"},
+		},
+		{"1 / 0", EvalOptions{}, nil},
+	}
+
+	for _, tt := range tests {
+		program := parser.New(lexer.New(tt.input)).ParseProgram()
+		err, ok := EvalWithOptions(program, object.NewEnvironment(), tt.options).Value.(*object.Error)
+		if !ok {
+			t.Errorf("%s: expected an error", tt.input)
+			continue
+		}
+
+		frames := []string{}
+		for _, frame := range err.Stack {
+			frames = append(frames, frame.String())
+		}
+		if len(frames) != len(tt.expected) {
+			t.Errorf("%s: frames = %q, want %q", tt.input, frames, tt.expected)
+			continue
+		}
+		for i := range frames {
+			if frames[i] != tt.expected[i] {
+				t.Errorf("%s: frames = %q, want %q", tt.input, frames, tt.expected)
+				break
+			}
+		}
+	}
+}
+
+func TestScopeLabels(t *testing.T) {
+	input := "let outer = fn() {\n  let inner = fn() { fn() { 1 } }\n  inner()\n}\nouter()"
+	program := parser.New(lexer.New(input)).ParseProgram()
+	options := EvalOptions{Source: input, Filename: "main.monkey"}
+
+	fn, ok := EvalWithOptions(program, object.NewEnvironment(), options).Value.(*object.Function)
+	if !ok {
+		t.Fatalf("object is not Function")
+	}
+
+	expected := []string{"inner() called at main.monkey:3", "outer() called at main.monkey:5", "<global>"}
+	scopes := fn.Env.Scopes()
+	if len(scopes) != len(expected) {
+		t.Fatalf("scopes = %q, want %q", scopes, expected)
+	}
+	for i := range expected {
+		if scopes[i] != expected[i] {
+			t.Errorf("scopes = %q, want %q", scopes, expected)
+			break
+		}
+	}
+}
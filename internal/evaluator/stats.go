@@ -0,0 +1,61 @@
+package evaluator
+
+import (
+	"time"
+
+	"github.com/al-keio/monkey-go/ast"
+	"github.com/al-keio/monkey-go/object"
+)
+
+// Stats は一回の評価で計測した実行統計
+type Stats struct {
+	Steps        int64         // 評価したノードの数
+	Allocations  int64         // 新たに生成したオブジェクトと環境の数 (概算)
+	MaxCallDepth int           // 関数呼び出しの最大の深さ
+	WallTime     time.Duration // 評価にかかった実時間
+
+	CallCacheHits int64 // 呼び出し位置のキャッシュで関数の名前を引き直さずに済んだ回数
+}
+
+type EvalResult struct {
+	Value object.Object
+	Stats Stats
+}
+
+// EvalWithStats は Eval と同じく node を評価し、結果とともに実行統計を返す
+func EvalWithStats(node ast.Node, env *object.Environment) *EvalResult {
+	ev := &evaluation{}
+
+	start := time.Now()
+	value := ev.run(node, env)
+	ev.stats.WallTime = time.Since(start)
+
+	return &EvalResult{Value: value, Stats: ev.stats}
+}
+
+func (ev *evaluation) enterCall() {
+	ev.depth++
+	if ev.depth > ev.stats.MaxCallDepth {
+		ev.stats.MaxCallDepth = ev.depth
+	}
+}
+
+func (ev *evaluation) leaveCall() {
+	ev.depth--
+}
+
+func (ev *evaluation) countAllocation(node ast.Node, result object.Object) {
+	switch node.(type) {
+	case nil, *ast.IntegerLiteral, *ast.BigIntLiteral, *ast.DecimalLiteral, *ast.StringLiteral, *ast.ArrayLiteral, *ast.HashLiteral,
+		*ast.FunctionLiteral, *ast.PrefixExpression, *ast.InfixExpression, *ast.ReturnStatement:
+	default:
+		return
+	}
+
+	switch result {
+	case nil, NULL, TRUE, FALSE:
+		return
+	}
+
+	ev.stats.Allocations++
+}
@@ -0,0 +1,221 @@
+package evaluator
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/al-keio/monkey-go/internal/lexer"
+	"github.com/al-keio/monkey-go/internal/parser"
+	"github.com/al-keio/monkey-go/object"
+)
+
+func TestEvalWithStats(t *testing.T) {
+	input := `
+let fib = fn(n) { if (n < 2) { return n; } fib(n - 1) + fib(n - 2); };
+fib(5);
+`
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	env := object.NewEnvironment()
+
+	result := EvalWithStats(program, env)
+
+	testIntegerObject(t, result.Value, 5)
+
+	if result.Stats.MaxCallDepth != 5 {
+		t.Errorf("MaxCallDepth wrong. want=5, got=%d", result.Stats.MaxCallDepth)
+	}
+	if result.Stats.Steps == 0 {
+		t.Errorf("Steps was not counted")
+	}
+	if result.Stats.Allocations == 0 {
+		t.Errorf("Allocations was not counted")
+	}
+	if result.Stats.WallTime <= 0 {
+		t.Errorf("WallTime was not measured")
+	}
+}
+
+func TestEvalWithStatsCountsSteps(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedSteps int64
+	}{
+		// Program, ExpressionStatement, IntegerLiteral
+		{"5", 3},
+		// Program, ExpressionStatement, InfixExpression, IntegerLiteral x2
+		{"1 + 2", 5},
+		// Program, LetStatement, IntegerLiteral, ExpressionStatement, Identifier
+		{"let a = 1; a", 5},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := parser.New(l)
+		program := p.ParseProgram()
+
+		result := EvalWithStats(program, object.NewEnvironment())
+		if result.Stats.Steps != tt.expectedSteps {
+			t.Errorf("Steps wrong for %q. want=%d, got=%d", tt.input, tt.expectedSteps, result.Stats.Steps)
+		}
+		if result.Stats.MaxCallDepth != 0 {
+			t.Errorf("MaxCallDepth wrong for %q. want=0, got=%d", tt.input, result.Stats.MaxCallDepth)
+		}
+	}
+}
+
+func TestEvalWithOptionsStepLimit(t *testing.T) {
+	tests := []struct {
+		input   string
+		limited bool
+	}{
+		{"1 + 2", false},
+		{"let loop = fn(n) { loop(n + 1) }; loop(0)", true},
+		// ネイティブのループから呼ばれる関数も数える
+		{"map([1, 2, 3, 4, 5, 6, 7, 8, 9, 10], fn(x) { x * x })", true},
+		{"sort([5, 4, 3, 2, 1, 0], fn(a, b) { a < b })", true},
+		// 上限を超えた後は with_recover や is_error_code でも回復できない
+		{"with_recover(fn() { map([1, 2, 3, 4, 5, 6, 7, 8, 9, 10], fn(x) { x }) }, fn(e) { 0 })", true},
+		{`with_recover(fn() { map([1, 2, 3, 4, 5, 6, 7, 8, 9, 10], fn(x) { x }) }, fn(e) { if (is_error_code(e, "STEP_LIMIT")) { 0 } })`, true},
+	}
+
+	for _, tt := range tests {
+		program := parser.New(lexer.New(tt.input)).ParseProgram()
+		result := EvalWithOptions(program, object.NewEnvironment(), EvalOptions{MaxSteps: 30})
+
+		err, isErr := result.Value.(*object.Error)
+		limited := isErr && err.Code == object.STEP_LIMIT_ERR
+		if limited != tt.limited {
+			t.Errorf("%s: expected limited=%t, got %s", tt.input, tt.limited, result.Value.Inspect())
+		}
+		if limited && result.Stats.Steps <= 30 {
+			t.Errorf("%s: Steps = %d, want > 30", tt.input, result.Stats.Steps)
+		}
+	}
+
+	unlimited := EvalWithOptions(parser.New(lexer.New("map([1, 2, 3, 4, 5, 6, 7, 8, 9, 10], fn(x) { x })")).ParseProgram(), object.NewEnvironment(), EvalOptions{})
+	if isError(unlimited.Value) {
+		t.Errorf("zero EvalOptions must not limit evaluation, got %s", unlimited.Value.Inspect())
+	}
+}
+
+func TestEvalWithOptionsCallDepth(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let down = fn(n) { if (n == 0) { 0 } else { down(n - 1) } }; down(9)", "0"},
+		{"let down = fn(n) { if (n == 0) { 0 } else { down(n - 1) } }; down(10)", "ERROR: call depth limit of 10 exceeded"},
+		// 深さの上限はステップ数と違い、呼び出しから戻れば回復できる
+		{"let deep = fn() { deep() }; with_recover(deep, fn(e) { e[\"code\"] })", "CALL_DEPTH"},
+	}
+
+	for _, tt := range tests {
+		result := EvalWithOptions(parser.New(lexer.New(tt.input)).ParseProgram(), object.NewEnvironment(), EvalOptions{MaxCallDepth: 10})
+		if result.Value.Inspect() != tt.expected {
+			t.Errorf("%s: want=%s, got=%s", tt.input, tt.expected, result.Value.Inspect())
+		}
+	}
+}
+
+func TestEvalWithOptionsSandbox(t *testing.T) {
+	allowIO := EvalOptions{Sandbox: true, Allow: []object.Capability{object.IO_CAP}, Output: &bytes.Buffer{}}
+	tests := []struct {
+		input    string
+		options  EvalOptions
+		expected string
+	}{
+		{"puts(1); len(\"ab\")", EvalOptions{Output: &bytes.Buffer{}}, "2"},
+		{"len(\"ab\")", EvalOptions{Sandbox: true}, "2"},
+		{"puts(1)", EvalOptions{Sandbox: true}, "ERROR: `puts` needs the io capability, which the sandbox does not allow"},
+		{"puts(1)", allowIO, "null"},
+		{"args()", allowIO, "ERROR: `args` needs the proc capability, which the sandbox does not allow"},
+		{"io.read_file(\"x\")", EvalOptions{Sandbox: true}, "ERROR: `io.read_file` needs the io capability, which the sandbox does not allow"},
+		{"set_timeout(fn() { 1 }, 0)", allowIO, "ERROR: `set_timeout` needs the time capability, which the sandbox does not allow"},
+		// 関数の値として渡しても、eval の中から呼んでも同じように調べる
+		{"map([1], puts)", allowIO, "[null]"},
+		{"map([1], fn(x) { deadline() })", allowIO, "ERROR: `deadline` needs the time capability, which the sandbox does not allow"},
+		{"eval(\"args()\")", allowIO, "ERROR: `args` needs the proc capability, which the sandbox does not allow"},
+		{"with_recover(fn() { args() }, fn(e) { e[\"code\"] })", allowIO, "CAPABILITY_DENIED"},
+	}
+
+	for _, tt := range tests {
+		result := EvalWithOptions(parser.New(lexer.New(tt.input)).ParseProgram(), object.NewEnvironment(), tt.options)
+		if result.Value.Inspect() != tt.expected {
+			t.Errorf("%s: want=%s, got=%s", tt.input, tt.expected, result.Value.Inspect())
+		}
+	}
+}
+
+func TestApplyWithOptions(t *testing.T) {
+	env := object.NewEnvironment()
+	Eval(parser.New(lexer.New("let add = fn(a, b) { a + b }; let loop = fn() { loop() };")).ParseProgram(), env)
+
+	add, _ := env.Get("add")
+	result := ApplyWithOptions(add, []object.Object{&object.Integer{Value: 1}, &object.Integer{Value: 2}}, EvalOptions{})
+	if result.Value.Inspect() != "3" {
+		t.Errorf("add(1, 2) wrong. got=%s", result.Value.Inspect())
+	}
+
+	loop, _ := env.Get("loop")
+	result = ApplyWithOptions(loop, nil, EvalOptions{MaxSteps: 30})
+	if err, ok := result.Value.(*object.Error); !ok || err.Code != object.STEP_LIMIT_ERR {
+		t.Errorf("expected STEP_LIMIT, got %s", result.Value.Inspect())
+	}
+
+	result = ApplyWithOptions(&object.Integer{Value: 1}, nil, EvalOptions{})
+	if err, ok := result.Value.(*object.Error); !ok || err.Code != object.NOT_A_FUNCTION_ERR {
+		t.Errorf("expected NOT_A_FUNCTION, got %s", result.Value.Inspect())
+	}
+}
+
+func TestEvalWithOptionsContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	input := `
+let loop = fn(n) { if (n == 0) { 0 } else { loop(n - 1) } };
+let spin = fn() { loop(10); spin() };
+spin()
+`
+	program := parser.New(lexer.New(input)).ParseProgram()
+	result := EvalWithOptions(program, object.NewEnvironment(), EvalOptions{Context: ctx})
+	if err, ok := result.Value.(*object.Error); !ok || err.Code != object.CANCELLED_ERR {
+		t.Fatalf("expected CANCELLED error, got %s", result.Value.Inspect())
+	}
+
+	// タイマーの待ち時間も取り消せる
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	program = parser.New(lexer.New("set_timeout(fn(t) { 1 }, 10000); run_loop()")).ParseProgram()
+	start := time.Now()
+	result = EvalWithOptions(program, object.NewEnvironment(), EvalOptions{Context: ctx})
+	if err, ok := result.Value.(*object.Error); !ok || err.Code != object.CANCELLED_ERR {
+		t.Errorf("expected CANCELLED error from run_loop, got %s", result.Value.Inspect())
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("run_loop kept sleeping after the context was cancelled")
+	}
+}
+
+func TestDeadline(t *testing.T) {
+	program := parser.New(lexer.New("deadline()")).ParseProgram()
+
+	if result := EvalWithOptions(program, object.NewEnvironment(), EvalOptions{}); result.Value != NULL {
+		t.Errorf("deadline() without a context = %s, want null", result.Value.Inspect())
+	}
+	if result := EvalWithOptions(program, object.NewEnvironment(), EvalOptions{Context: context.Background()}); result.Value != NULL {
+		t.Errorf("deadline() without a deadline = %s, want null", result.Value.Inspect())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	result := EvalWithOptions(program, object.NewEnvironment(), EvalOptions{Context: ctx})
+	remaining, ok := result.Value.(*object.Integer)
+	if !ok || remaining.Value <= 0 || remaining.Value > 60000 {
+		t.Errorf("deadline() = %s, want between 0 and 60000", result.Value.Inspect())
+	}
+}
@@ -0,0 +1,115 @@
+package evaluator
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/al-keio/monkey-go/object"
+)
+
+// 位置や幅は len と同じくバイト単位で数える
+func init() {
+	RegisterNamespaceMember("string", "starts_with", &object.Builtin{
+		Doc: "string.starts_with(s, prefix): whether s begins with prefix",
+		Fn: func(args ...object.Object) object.Object {
+			strs, err := stringArgs("string.starts_with", 2, args)
+			if err != nil {
+				return err
+			}
+			return nativeBoolToBooleanObject(strings.HasPrefix(strs[0], strs[1]))
+		},
+	})
+	RegisterNamespaceMember("string", "ends_with", &object.Builtin{
+		Doc: "string.ends_with(s, suffix): whether s ends with suffix",
+		Fn: func(args ...object.Object) object.Object {
+			strs, err := stringArgs("string.ends_with", 2, args)
+			if err != nil {
+				return err
+			}
+			return nativeBoolToBooleanObject(strings.HasSuffix(strs[0], strs[1]))
+		},
+	})
+	RegisterNamespaceMember("string", "trim_left", &object.Builtin{
+		Doc: "string.trim_left(s): s without leading white space",
+		Fn: func(args ...object.Object) object.Object {
+			strs, err := stringArgs("string.trim_left", 1, args)
+			if err != nil {
+				return err
+			}
+			return &object.String{Value: strings.TrimLeftFunc(strs[0], unicode.IsSpace)}
+		},
+	})
+	RegisterNamespaceMember("string", "trim_right", &object.Builtin{
+		Doc: "string.trim_right(s): s without trailing white space",
+		Fn: func(args ...object.Object) object.Object {
+			strs, err := stringArgs("string.trim_right", 1, args)
+			if err != nil {
+				return err
+			}
+			return &object.String{Value: strings.TrimRightFunc(strs[0], unicode.IsSpace)}
+		},
+	})
+	RegisterNamespaceMember("string", "pad_left", &object.Builtin{
+		Doc: "string.pad_left(s, width[, pad]): s preceded by copies of pad (default \" \") up to width bytes",
+		Fn: func(args ...object.Object) object.Object {
+			return pad("string.pad_left", args, func(s, padding string) string { return padding + s })
+		},
+	})
+	RegisterNamespaceMember("string", "pad_right", &object.Builtin{
+		Doc: "string.pad_right(s, width[, pad]): s followed by copies of pad (default \" \") up to width bytes",
+		Fn: func(args ...object.Object) object.Object {
+			return pad("string.pad_right", args, func(s, padding string) string { return s + padding })
+		},
+	})
+	RegisterNamespaceMember("string", "index_of", &object.Builtin{
+		Doc: "string.index_of(s, sub): byte index of the first sub in s, or -1 if there is none",
+		Fn: func(args ...object.Object) object.Object {
+			strs, err := stringArgs("string.index_of", 2, args)
+			if err != nil {
+				return err
+			}
+			return &object.Integer{Value: int64(strings.Index(strs[0], strs[1]))}
+		},
+	})
+}
+
+// maxPadWidth は string.pad_left と string.pad_right が許す幅 (バイト数)
+const maxPadWidth = 1 << 20
+
+// pad は s が width バイトになるまで pad を繰り返した文字列を join で s につなげる。
+// pad が割り切れなければ最後の繰り返しを切り詰める。s がすでに width 以上ならそのまま返す。
+func pad(name string, args []object.Object, join func(s, padding string) string) object.Object {
+	if len(args) != 2 && len(args) != 3 {
+		return newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=2 or 3", len(args))
+	}
+	s, ok := args[0].(*object.String)
+	if !ok {
+		return newError(object.WRONG_ARGUMENT_TYPE_ERR, "argument 1 to `%s` must be STRING, got %s", name, args[0].Type())
+	}
+	width, ok := args[1].(*object.Integer)
+	if !ok {
+		return newError(object.WRONG_ARGUMENT_TYPE_ERR, "argument 2 to `%s` must be INTEGER, got %s", name, args[1].Type())
+	}
+	// メモリを使い果たしてプロセスごと落ちないよう、幅に上限を設ける
+	if width.Value > maxPadWidth {
+		return newError(object.INVALID_VALUE_ERR, "width passed to `%s` is too large: %d (at most %d)", name, width.Value, maxPadWidth)
+	}
+	padding := " "
+	if len(args) == 3 {
+		p, ok := args[2].(*object.String)
+		if !ok {
+			return newError(object.WRONG_ARGUMENT_TYPE_ERR, "argument 3 to `%s` must be STRING, got %s", name, args[2].Type())
+		}
+		if p.Value == "" {
+			return newError(object.INVALID_VALUE_ERR, "padding passed to `%s` must not be empty", name)
+		}
+		padding = p.Value
+	}
+
+	missing := int(width.Value) - len(s.Value)
+	if missing <= 0 {
+		return s
+	}
+	filled := strings.Repeat(padding, missing/len(padding)+1)[:missing]
+	return &object.String{Value: join(s.Value, filled)}
+}
@@ -0,0 +1,37 @@
+package evaluator
+
+import "testing"
+
+func TestStringBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`string.starts_with("monkey", "mon")`, "true"},
+		{`string.starts_with("monkey", "key")`, "false"},
+		{`string.ends_with("monkey", "key")`, "true"},
+		{`string.ends_with("monkey", "")`, "true"},
+		{`"[" + string.trim_left("  x  ") + "]"`, "[x  ]"},
+		{`"[" + string.trim_right("  x  ") + "]"`, "[  x]"},
+		{`string.pad_left("7", 3, "0")`, "007"},
+		{`"[" + string.pad_right("ab", 4) + "]"`, "[ab  ]"},
+		{`string.pad_left("x", 6, "ab")`, "ababax"},
+		{`string.pad_left("long", 2)`, "long"},
+		{`string.index_of("monkey", "key")`, "3"},
+		{`string.index_of("monkey", "z")`, "-1"},
+		{`string.pad_left("x", 3, "")`, "ERROR: padding passed to `string.pad_left` must not be empty"},
+		{`len(string.pad_left("x", 1048576))`, "1048576"},
+		{`string.pad_left("a", 1000000000000)`, "ERROR: width passed to `string.pad_left` is too large: 1000000000000 (at most 1048576)"},
+		{`string.pad_right("a", 9223372036854775807)`, "ERROR: width passed to `string.pad_right` is too large: 9223372036854775807 (at most 1048576)"},
+		{`string.pad_right("x", "3")`, "ERROR: argument 2 to `string.pad_right` must be INTEGER, got STRING"},
+		{`string.pad_left("x")`, "ERROR: wrong number of arguments. got=1, want=2 or 3"},
+		{`string.starts_with("x", 1)`, "ERROR: argument 2 to `string.starts_with` must be STRING, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
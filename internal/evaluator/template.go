@@ -0,0 +1,322 @@
+package evaluator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/al-keio/monkey-go/ast"
+	"github.com/al-keio/monkey-go/internal/parser"
+	"github.com/al-keio/monkey-go/object"
+)
+
+func init() {
+	builtins["render"] = &object.Builtin{
+		Doc: "render(template[, data]): fill in template with {{ expr }}, {% for x in expr %}...{% endfor %} (or for k, v in a hash or for i, x in an array) " +
+			"and {% if expr %}...{% else %}...{% endif %}; expressions see the keys of the hash data as variables",
+		ApplyFn: func(applier object.Applier, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError(object.WRONG_ARGUMENT_COUNT_ERR, "wrong number of arguments. got=%d, want=1 or 2", len(args))
+			}
+			source, ok := args[0].(*object.String)
+			if !ok {
+				return newError(object.WRONG_ARGUMENT_TYPE_ERR, "argument 1 to `render` must be STRING, got %s", args[0].Type())
+			}
+
+			// テンプレートの式は呼び出し元の変数ではなく data のキーだけを見る
+			env := object.NewEnvironment()
+			if len(args) == 2 {
+				data, ok := args[1].(*object.Hash)
+				if !ok {
+					return newError(object.WRONG_ARGUMENT_TYPE_ERR, "argument 2 to `render` must be HASH, got %s", args[1].Type())
+				}
+				for _, pair := range data.PairList() {
+					key, ok := pair.Key.(*object.String)
+					if !ok {
+						return newError(object.WRONG_ARGUMENT_TYPE_ERR, "keys of the data passed to `render` must be STRING, got %s", pair.Key.Type())
+					}
+					env.Set(key.Value, pair.Value)
+				}
+			}
+
+			nodes, err := parseTemplate(source.Value)
+			if err != nil {
+				return err
+			}
+			var out strings.Builder
+			if err := applier.(*evaluation).renderTemplate(&out, nodes, env); err != nil {
+				return err
+			}
+			return &object.String{Value: out.String()}
+		},
+	}
+}
+
+// テンプレートは文字列、式、for と if のブロックの列に解析してから評価する
+type templateNode interface{}
+
+type templateText string
+
+type templateExpression struct {
+	expression ast.Expression
+}
+
+// templateFor の key は for x in ... なら空で、value に要素を束縛する
+type templateFor struct {
+	key, value string
+	iterable   ast.Expression
+	body       []templateNode
+}
+
+type templateIf struct {
+	condition   ast.Expression
+	consequence []templateNode
+	alternative []templateNode
+}
+
+var templateForTag = regexp.MustCompile(`^for\s+([\pL_]+)(?:\s*,\s*([\pL_]+))?\s+in\s+(.+)$`)
+
+type templateParser struct {
+	source string
+	pos    int
+}
+
+func parseTemplate(source string) ([]templateNode, *object.Error) {
+	tp := &templateParser{source: source}
+	nodes, end, endPos, err := tp.parse()
+	if err != nil {
+		return nil, err
+	}
+	if end != "" {
+		return nil, tp.error(endPos, "unexpected {%% %s %%}", end)
+	}
+	return nodes, nil
+}
+
+// parse は入力の終わりか else、endfor、endif のタグまでを読み、読んだ終わりのタグとその位置を返す
+func (tp *templateParser) parse() (nodes []templateNode, end string, endPos int, err *object.Error) {
+	for {
+		rest := tp.source[tp.pos:]
+		i := nextTemplateTag(rest)
+		if i < 0 {
+			if rest != "" {
+				nodes = append(nodes, templateText(rest))
+			}
+			return nodes, "", 0, nil
+		}
+		if i > 0 {
+			nodes = append(nodes, templateText(rest[:i]))
+		}
+
+		start := tp.pos + i
+		closing := "}}"
+		if strings.HasPrefix(rest[i:], "{%") {
+			closing = "%}"
+		}
+		length := strings.Index(tp.source[start+2:], closing)
+		if length < 0 {
+			return nil, "", 0, tp.error(start, "%s is not closed with %s", tp.source[start:start+2], closing)
+		}
+		inner := strings.TrimSpace(tp.source[start+2 : start+2+length])
+		tp.pos = start + 2 + length + 2
+
+		if closing == "}}" {
+			expression, err := tp.expression(start, inner)
+			if err != nil {
+				return nil, "", 0, err
+			}
+			nodes = append(nodes, &templateExpression{expression: expression})
+			continue
+		}
+
+		keyword := inner
+		if i := strings.IndexFunc(inner, unicode.IsSpace); i >= 0 {
+			keyword = inner[:i]
+		}
+		switch keyword {
+		case "for":
+			node, err := tp.parseFor(start, inner)
+			if err != nil {
+				return nil, "", 0, err
+			}
+			nodes = append(nodes, node)
+		case "if":
+			node, err := tp.parseIf(start, strings.TrimSpace(strings.TrimPrefix(inner, "if")))
+			if err != nil {
+				return nil, "", 0, err
+			}
+			nodes = append(nodes, node)
+		case "else", "endfor", "endif":
+			if inner != keyword {
+				return nil, "", 0, tp.error(start, "unexpected text after %s: %s", keyword, inner)
+			}
+			return nodes, keyword, start, nil
+		default:
+			return nil, "", 0, tp.error(start, "unknown tag {%% %s %%}", inner)
+		}
+	}
+}
+
+// nextTemplateTag は s の中で最初の {{ か {% の位置を返す
+func nextTemplateTag(s string) int {
+	i, j := strings.Index(s, "{{"), strings.Index(s, "{%")
+	if i < 0 || j >= 0 && j < i {
+		return j
+	}
+	return i
+}
+
+func (tp *templateParser) parseFor(start int, tag string) (templateNode, *object.Error) {
+	m := templateForTag.FindStringSubmatch(tag)
+	if m == nil {
+		return nil, tp.error(start, "malformed for tag, want {%% for x in expr %%}: %s", tag)
+	}
+	node := &templateFor{value: m[1]}
+	if m[2] != "" {
+		node.key, node.value = m[1], m[2]
+	}
+	iterable, err := tp.expression(start, m[3])
+	if err != nil {
+		return nil, err
+	}
+	node.iterable = iterable
+
+	body, end, endPos, err := tp.parse()
+	if err != nil {
+		return nil, err
+	}
+	if end != "endfor" {
+		return nil, tp.unclosed(start, "for", "endfor", end, endPos)
+	}
+	node.body = body
+	return node, nil
+}
+
+func (tp *templateParser) parseIf(start int, condition string) (templateNode, *object.Error) {
+	expression, err := tp.expression(start, condition)
+	if err != nil {
+		return nil, err
+	}
+	node := &templateIf{condition: expression}
+
+	consequence, end, endPos, err := tp.parse()
+	if err != nil {
+		return nil, err
+	}
+	node.consequence = consequence
+	if end == "else" {
+		node.alternative, end, endPos, err = tp.parse()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if end != "endif" {
+		return nil, tp.unclosed(start, "if", "endif", end, endPos)
+	}
+	return node, nil
+}
+
+func (tp *templateParser) expression(start int, source string) (ast.Expression, *object.Error) {
+	expression, errors := parser.ParseExpression(source)
+	if errors != nil {
+		return nil, tp.error(start, "%s", strings.Join(errors, "; "))
+	}
+	return expression, nil
+}
+
+// unclosed はブロックが end で閉じられずに別のタグか入力の終わりに達したときのエラーを返す
+func (tp *templateParser) unclosed(start int, block, want, got string, gotPos int) *object.Error {
+	if got == "" {
+		return tp.error(start, "{%% %s %%} is not closed with {%% %s %%}", block, want)
+	}
+	return tp.error(gotPos, "expected {%% %s %%}, got {%% %s %%}", want, got)
+}
+
+func (tp *templateParser) error(pos int, format string, a ...interface{}) *object.Error {
+	line := strings.Count(tp.source[:pos], "\n") + 1
+	return newError(object.PARSE_ERR, "render: line %d: %s", line, fmt.Sprintf(format, a...))
+}
+
+// renderTemplate は nodes を env で評価して out に書く。評価のエラーがあればそれを返す。
+func (ev *evaluation) renderTemplate(out *strings.Builder, nodes []templateNode, env *object.Environment) object.Object {
+	for _, node := range nodes {
+		switch node := node.(type) {
+		case templateText:
+			out.WriteString(string(node))
+		case *templateExpression:
+			value := ev.eval(node.expression, env)
+			if isError(value) {
+				return value
+			}
+			out.WriteString(templateString(value, ev.outputFormat()))
+		case *templateFor:
+			if err := ev.renderFor(out, node, env); err != nil {
+				return err
+			}
+		case *templateIf:
+			condition := ev.eval(node.condition, env)
+			if isError(condition) {
+				return condition
+			}
+			body := node.alternative
+			if isTruthy(condition) {
+				body = node.consequence
+			}
+			if err := ev.renderTemplate(out, body, env); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// renderFor は配列なら添字と要素、ハッシュならキーと値を束縛して本体を繰り返す。変数が一つなら要素かキーを束縛する。
+func (ev *evaluation) renderFor(out *strings.Builder, node *templateFor, env *object.Environment) object.Object {
+	iterable := ev.eval(node.iterable, env)
+	if isError(iterable) {
+		return iterable
+	}
+
+	var keys, values []object.Object
+	switch iterable := iterable.(type) {
+	case *object.Array:
+		values = iterable.Elements
+		for i := range iterable.Elements {
+			keys = append(keys, &object.Integer{Value: int64(i)})
+		}
+	case *object.Hash:
+		for _, pair := range sortedPairs(iterable) {
+			keys = append(keys, pair.Key)
+			values = append(values, pair.Value)
+		}
+		if node.key == "" {
+			values = keys
+		}
+	default:
+		return newError(object.WRONG_ARGUMENT_TYPE_ERR, "render: for needs ARRAY or HASH, got %s", iterable.Type())
+	}
+
+	for i := range values {
+		loopEnv := object.NewEnclosedEnvironment(env)
+		if node.key != "" {
+			loopEnv.Set(node.key, keys[i])
+		}
+		loopEnv.Set(node.value, values[i])
+		if err := ev.renderTemplate(out, node.body, loopEnv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// templateString は埋め込む値の文字列。文字列は引用符なしでそのまま、null は空にし、数値は format の書式で表す。
+func templateString(value object.Object, format *object.OutputFormat) string {
+	switch value := value.(type) {
+	case nil, *object.Null:
+		return ""
+	case *object.String:
+		return value.Value
+	}
+	return format.Inspect(value)
+}
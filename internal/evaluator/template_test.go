@@ -0,0 +1,37 @@
+package evaluator
+
+import "testing"
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`render("Hello, {{ name }}!", {"name": "monkey"})`, "Hello, monkey!"},
+		{`render("{{ a + b * 2 }} {{ [a, b] }} {{ first([]) }}", {"a": 1, "b": 2})`, "5 [1, 2] "},
+		{`render("{{ len(xs) }}:{% for x in xs %} <{{ x }}>{% endfor %}", {"xs": ["a", "b"]})`, "2: <a> <b>"},
+		{`render("{% for i, x in xs %}{{ i }}={{ x }};{% endfor %}", {"xs": [5, 6]})`, "0=5;1=6;"},
+		{`render("{% for k, v in h %}{{ k }}:{{ v }} {% endfor %}{% for k in h %}{{ k }}{% endfor %}", {"h": {"b": 2, "a": 1}})`, "a:1 b:2 ab"},
+		{`render("{% if n > 1 %}many{% else %}one{% endif %}/{% if null_value %}x{% endif %}", {"n": 3, "null_value": first([])})`, "many/"},
+		{`render("{% for row in rows %}{% if row[1] %}{{ row[0] }}{% endif %}{% endfor %}", {"rows": [["a", true], ["b", false], ["c", 1]]})`, "ac"},
+		{`render("{{ f(2) }}", {"f": fn(x) { x * x }})`, "4"},
+		{`render("no tags { here }")`, "no tags { here }"},
+		{`let secret = 1; render("{{ secret }}")`, "ERROR: identifier not found: secret"},
+		{`render("a
+{{ 1 + }}")`, "ERROR: render: line 2: no prefix parse function for EOF found"},
+		{`render("{% for x in [1] %}{{ x }}")`, "ERROR: render: line 1: {% for %} is not closed with {% endfor %}"},
+		{`render("{% if true %}{% endfor %}")`, "ERROR: render: line 1: expected {% endif %}, got {% endfor %}"},
+		{`render("{% endif %}")`, "ERROR: render: line 1: unexpected {% endif %}"},
+		{`render("{% each x %}")`, "ERROR: render: line 1: unknown tag {% each x %}"},
+		{`render("{{ x")`, "ERROR: render: line 1: {{ is not closed with }}"},
+		{`render("{% for x in 1 %}{% endfor %}")`, "ERROR: render: for needs ARRAY or HASH, got INTEGER"},
+		{`render("x", {1: 2})`, "ERROR: keys of the data passed to `render` must be STRING, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
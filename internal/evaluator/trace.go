@@ -0,0 +1,102 @@
+package evaluator
+
+import (
+	"bufio"
+	"encoding/json"
+	"time"
+
+	"github.com/al-keio/monkey-go/object"
+)
+
+// traceEvent は Chrome のトレースイベント形式の完了イベント (ph が "X")。
+// 時刻はトレースを始めてからのマイクロ秒。
+type traceEvent struct {
+	Name     string            `json:"name"`
+	Category string            `json:"cat"`
+	Phase    string            `json:"ph"`
+	Start    float64           `json:"ts"`
+	Duration float64           `json:"dur"`
+	Pid      int               `json:"pid"`
+	Tid      int               `json:"tid"`
+	Args     map[string]string `json:"args,omitempty"`
+}
+
+// tracer は EvalOptions.Trace にイベントを一つずつ JSON の配列の要素として書く
+type tracer struct {
+	w      *bufio.Writer
+	start  time.Time
+	events int
+	failed bool
+}
+
+func (ev *evaluation) tracer() *tracer {
+	if ev.trace == nil {
+		ev.trace = &tracer{w: bufio.NewWriter(ev.options.Trace), start: time.Now()}
+	}
+	return ev.trace
+}
+
+// traceCall は begin に始まった fn の呼び出しを一つのイベントとして書く
+func (ev *evaluation) traceCall(name, site string, fn object.Object, begin time.Time, result object.Object) {
+	t := ev.tracer()
+	event := traceEvent{
+		Name:     name,
+		Category: "function",
+		Phase:    "X",
+		Start:    microseconds(begin.Sub(t.start)),
+		Duration: microseconds(time.Since(begin)),
+		Pid:      1,
+		Tid:      1,
+	}
+	if _, ok := fn.(*object.Builtin); ok {
+		event.Category = "builtin"
+	}
+	if site != "" || isError(result) {
+		event.Args = map[string]string{}
+		if site != "" {
+			event.Args["call_site"] = site
+		}
+		if err, ok := result.(*object.Error); ok {
+			event.Args["error"] = err.Code
+		}
+	}
+	t.write(event)
+}
+
+func microseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Microsecond)
+}
+
+func (t *tracer) write(event traceEvent) {
+	if t.failed {
+		return
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		t.failed = true
+		return
+	}
+	separator := ",\n"
+	if t.events == 0 {
+		separator = "[\n"
+	}
+	t.events++
+	t.w.WriteString(separator)
+	if _, err := t.w.Write(data); err != nil {
+		// トレースは評価の結果に影響させず、書けなくなったらそれ以降のイベントを捨てる
+		t.failed = true
+	}
+}
+
+// closeTrace は配列を閉じて書き出す。評価の終わりに呼ぶ。
+func (ev *evaluation) closeTrace() {
+	if ev.options.Trace == nil {
+		return
+	}
+	t := ev.tracer()
+	if t.events == 0 {
+		t.w.WriteString("[")
+	}
+	t.w.WriteString("\n]\n")
+	t.w.Flush()
+}
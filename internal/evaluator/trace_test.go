@@ -0,0 +1,58 @@
+package evaluator
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/al-keio/monkey-go/internal/lexer"
+	"github.com/al-keio/monkey-go/internal/parser"
+	"github.com/al-keio/monkey-go/object"
+)
+
+func TestTrace(t *testing.T) {
+	input := `let fib = fn(n) { if (n < 2) { n } else { fib(n - 1) + fib(n - 2) } };
+fib(3) + len("ab");
+let fail = fn() { 1 + true };
+fail()`
+
+	var trace bytes.Buffer
+	program := parser.New(lexer.New(input)).ParseProgram()
+	EvalWithOptions(program, object.NewEnvironment(), EvalOptions{Trace: &trace, Source: input, Filename: "t.monkey"})
+
+	var events []traceEvent
+	if err := json.Unmarshal(trace.Bytes(), &events); err != nil {
+		t.Fatalf("trace is not a JSON array: %s\n%s", err, trace.String())
+	}
+
+	counts := map[string]int{}
+	for _, event := range events {
+		counts[event.Name+"/"+event.Category]++
+		if event.Phase != "X" || event.Start < 0 || event.Duration < 0 {
+			t.Errorf("bad event %+v", event)
+		}
+	}
+	if counts["fib/function"] != 5 || counts["len/builtin"] != 1 || counts["fail/function"] != 1 {
+		t.Errorf("wrong events: %v", counts)
+	}
+
+	// 内側の呼び出しが先に終わるので先に書かれ、外側の呼び出しの区間に収まる
+	outer := events[4]
+	if outer.Name != "fib" || outer.Args["call_site"] != "t.monkey:2" {
+		t.Fatalf("events[4] is not the outermost fib call: %+v", outer)
+	}
+	for _, inner := range events[:4] {
+		if inner.Start < outer.Start || inner.Start+inner.Duration > outer.Start+outer.Duration {
+			t.Errorf("inner call %+v is outside of %+v", inner, outer)
+		}
+	}
+	if last := events[len(events)-1]; last.Name != "fail" || last.Args["error"] != object.TYPE_MISMATCH_ERR {
+		t.Errorf("failed call not recorded with its error: %+v", last)
+	}
+
+	trace.Reset()
+	EvalWithOptions(parser.New(lexer.New("1")).ParseProgram(), object.NewEnvironment(), EvalOptions{Trace: &trace})
+	if trace.String() != "[\n]\n" {
+		t.Errorf("empty trace wrong. got=%q", trace.String())
+	}
+}
@@ -0,0 +1,560 @@
+package lexer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/al-keio/monkey-go/token"
+)
+
+type Lexer struct {
+	input        string
+	position     int  // 入力中における現在の位置(現在の文字を指し示す)
+	readPosition int  // これから読み込む位置(現在の文字の次)
+	ch           byte // 現在検査中の文字
+	commentStart int  // 読み飛ばし中のブロックコメントの開始位置
+	lastType     token.TokenType
+	comments     []token.Comment
+
+	// 行と桁はトークンを返すたびに前のトークンの位置から数え進める
+	counted      int
+	line, column int
+}
+
+func New(input string) *Lexer {
+	l := &Lexer{input: input, line: 1, column: 1}
+	l.readChar()
+	l.skipShebang()
+	return l
+}
+
+// skipShebang は実行可能なスクリプトの先頭の #! 行を読み飛ばす
+func (l *Lexer) skipShebang() {
+	if strings.HasPrefix(l.input, "#!") {
+		l.readLine()
+	}
+}
+
+func (l *Lexer) readChar() {
+	if l.readPosition >= len(l.input) {
+		l.ch = 0
+	} else {
+		l.ch = l.input[l.readPosition]
+	}
+	l.position = l.readPosition
+	l.readPosition += 1
+}
+
+func (l *Lexer) peekChar() byte {
+	if l.readPosition >= len(l.input) {
+		return 0
+	} else {
+		return l.input[l.readPosition]
+	}
+}
+
+func (l *Lexer) NextToken() token.Token {
+	doc, ok := l.skipComments()
+	if !ok {
+		line, column := l.lineColumn(l.commentStart)
+		return token.Token{Type: token.ILLEGAL, Literal: "/*", Offset: l.commentStart, Line: line, Column: column}
+	}
+
+	offset := l.position
+	var tok token.Token
+	if l.ch == '\n' {
+		// 行末のドキュメントコメントは次の行のものではない
+		doc = ""
+		tok = newToken(token.NEWLINE, l.ch)
+		l.readChar()
+	} else {
+		tok = l.nextToken()
+	}
+	tok.Doc = doc
+	tok.Offset = offset
+	tok.Line, tok.Column = l.lineColumn(offset)
+	l.lastType = tok.Type
+	return tok
+}
+
+// lineColumn は入力中のバイト位置 offset の行と桁を返す
+func (l *Lexer) lineColumn(offset int) (line, column int) {
+	if offset > len(l.input) {
+		offset = len(l.input)
+	}
+	if offset < l.counted {
+		l.counted, l.line, l.column = 0, 1, 1
+	}
+	for ; l.counted < offset; l.counted++ {
+		switch ch := l.input[l.counted]; {
+		case ch == '\n':
+			l.line++
+			l.column = 1
+		case ch&0xC0 != 0x80: // UTF-8 の継続バイトは数えない
+			l.column++
+		}
+	}
+	return l.line, l.column
+}
+
+// endsStatement は Go と同じく、改行の直前にあれば文を終えるトークンかどうかを返す
+func endsStatement(t token.TokenType) bool {
+	switch t {
+	case token.IDENT, token.INT, token.BIGINT, token.DECIMAL, token.FLOAT, token.STRING, token.TRUE, token.FALSE,
+		token.RPAREN, token.RBRACKET, token.RBRACE:
+		return true
+	default:
+		return false
+	}
+}
+
+// skipComments は空白とコメントを読み飛ばし、次のトークンの直前に続いていたドキュメントコメントを返す。
+// 閉じられていないブロックコメントがあれば false を返す。
+func (l *Lexer) skipComments() (string, bool) {
+	docLines := []string{}
+
+	for {
+		l.skipWhitespace()
+		if l.ch != '/' {
+			return strings.Join(docLines, "\n"), true
+		}
+
+		rest := l.input[l.position:]
+		offset := l.position
+		switch {
+		case strings.HasPrefix(rest, "///"):
+			text := l.readLine()
+			l.comments = append(l.comments, token.Comment{Text: text, Offset: offset})
+			docLines = append(docLines, strings.TrimPrefix(strings.TrimPrefix(text, "///"), " "))
+		case strings.HasPrefix(rest, "//"):
+			l.comments = append(l.comments, token.Comment{Text: l.readLine(), Offset: offset})
+			docLines = docLines[:0]
+		case strings.HasPrefix(rest, "/*"):
+			l.commentStart = l.position
+			end := strings.Index(rest[2:], "*/")
+			if end < 0 {
+				for l.ch != 0 {
+					l.readChar()
+				}
+				return "", false
+			}
+			for i := 0; i < end+4; i++ {
+				l.readChar()
+			}
+			l.comments = append(l.comments, token.Comment{Text: rest[:end+4], Offset: offset})
+			docLines = docLines[:0]
+		default:
+			return strings.Join(docLines, "\n"), true
+		}
+	}
+}
+
+// Comments はこれまでに読み飛ばしたコメントを現れた順に返す
+func (l *Lexer) Comments() []token.Comment {
+	return l.comments
+}
+
+// readLine は行末までを読み、改行を除いて返す
+func (l *Lexer) readLine() string {
+	position := l.position
+	for l.ch != '\n' && l.ch != 0 {
+		l.readChar()
+	}
+	return strings.TrimSuffix(l.input[position:l.position], "\r")
+}
+
+func (l *Lexer) nextToken() token.Token {
+	var tok token.Token
+
+	switch l.ch {
+	case '=':
+		if l.peekChar() == '=' {
+			ch := l.ch
+			l.readChar()
+			literal := string(ch) + string(l.ch)
+			tok = token.Token{Type: token.EQ, Literal: literal}
+		} else if l.peekChar() == '>' {
+			ch := l.ch
+			l.readChar()
+			literal := string(ch) + string(l.ch)
+			tok = token.Token{Type: token.ARROW, Literal: literal}
+		} else {
+			tok = newToken(token.ASSIGN, l.ch)
+		}
+	case '+':
+		tok = newToken(token.PLUS, l.ch)
+	case '-':
+		tok = newToken(token.MINUS, l.ch)
+	case '!':
+		if l.peekChar() == '=' {
+			ch := l.ch
+			l.readChar()
+			literal := string(ch) + string(l.ch)
+			tok = token.Token{Type: token.NOT_EQ, Literal: literal}
+		} else {
+			tok = newToken(token.BANG, l.ch)
+		}
+	case '*':
+		tok = newToken(token.ASTERISK, l.ch)
+	case '<':
+		tok = newToken(token.LT, l.ch)
+	case '>':
+		tok = newToken(token.GT, l.ch)
+	case '/':
+		tok = newToken(token.SLASH, l.ch)
+	case '%':
+		tok = newToken(token.PERCENT, l.ch)
+	case '.':
+		if strings.HasPrefix(l.input[l.position:], "...") {
+			l.readChar()
+			l.readChar()
+			tok = token.Token{Type: token.ELLIPSIS, Literal: "..."}
+		} else {
+			tok = newToken(token.DOT, l.ch)
+		}
+	case '?':
+		tok = newToken(token.QUESTION, l.ch)
+	case ',':
+		tok = newToken(token.COMMA, l.ch)
+	case ';':
+		tok = newToken(token.SEMICOLON, l.ch)
+	case ':':
+		tok = newToken(token.COLON, l.ch)
+	case '(':
+		tok = newToken(token.LPAREN, l.ch)
+	case ')':
+		tok = newToken(token.RPAREN, l.ch)
+	case '{':
+		tok = newToken(token.LBRACE, l.ch)
+	case '}':
+		tok = newToken(token.RBRACE, l.ch)
+	case '[':
+		tok = newToken(token.LBRACKET, l.ch)
+	case ']':
+		tok = newToken(token.RBRACKET, l.ch)
+	case '"':
+		tok.Type = token.STRING
+		tok.Literal = l.readString()
+	case 0:
+		tok = token.Token{Type: token.EOF, Literal: ""}
+	default:
+		if r, _ := l.currentRune(); isLetter(r) {
+			return l.readIdentifierToken()
+		} else if isDigit(l.ch) {
+			return l.readNumberToken()
+		} else if l.ch >= utf8.RuneSelf {
+			r, _ := l.currentRune()
+			l.readRune()
+			return token.Token{Type: token.ILLEGAL, Literal: string(r)}
+		} else {
+			tok = newToken(token.ILLEGAL, l.ch)
+		}
+	}
+
+	l.readChar()
+	return tok
+}
+
+func newToken(tokenType token.TokenType, ch byte) token.Token {
+	return token.Token{Type: tokenType, Literal: string(ch)}
+}
+
+// currentRune は現在位置の文字を UTF-8 として解釈し、そのバイト長とともに返す
+func (l *Lexer) currentRune() (rune, int) {
+	if l.ch < utf8.RuneSelf {
+		return rune(l.ch), 1
+	}
+	return utf8.DecodeRuneInString(l.input[l.position:])
+}
+
+func (l *Lexer) readRune() {
+	_, size := l.currentRune()
+	l.readPosition = l.position + size
+	l.readChar()
+}
+
+func (l *Lexer) readIdentifierToken() token.Token {
+	raw := l.readIdentifier()
+	literal := normalizeIdentifier(raw)
+
+	tokenType := token.LookupIdent(literal)
+	if literal != raw && tokenType != token.IDENT {
+		// 全角で書かれたキーワードは受け付けない (キーワードは ASCII のみ)
+		return token.Token{Type: token.ILLEGAL, Literal: raw}
+	}
+	if !isSingleScript(literal) {
+		return token.Token{Type: token.ILLEGAL, Literal: raw}
+	}
+
+	return token.Token{Type: tokenType, Literal: literal}
+}
+
+// readIdentifier は文字で始まり、文字と数字が続く識別子を読む
+func (l *Lexer) readIdentifier() string {
+	position := l.position
+	for r, _ := l.currentRune(); isLetter(r) || isDigit(l.ch); r, _ = l.currentRune() {
+		l.readRune()
+	}
+	return l.input[position:l.position]
+}
+
+func isLetter(r rune) bool {
+	if r < utf8.RuneSelf {
+		return 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || r == '_'
+	}
+	return unicode.IsLetter(r)
+}
+
+// normalizeIdentifier は全角英字を半角に畳み込み、見た目が同じ識別子が別物にならないようにする
+func normalizeIdentifier(ident string) string {
+	return strings.Map(func(r rune) rune {
+		if 0xFF01 <= r && r <= 0xFF5E {
+			return r - 0xFEE0
+		}
+		return r
+	}, ident)
+}
+
+// 見分けのつかない文字を持つ用字系 (ラテン・ギリシャ・キリル) の混在を禁止する
+var confusableScripts = []*unicode.RangeTable{unicode.Latin, unicode.Greek, unicode.Cyrillic}
+
+func isSingleScript(ident string) bool {
+	var script *unicode.RangeTable
+
+	for _, r := range ident {
+		for _, table := range confusableScripts {
+			if !unicode.Is(table, r) {
+				continue
+			}
+			if script != nil && script != table {
+				return false
+			}
+			script = table
+		}
+	}
+
+	return true
+}
+
+// skipWhitespace は空白を読み飛ばす。文を終えられるトークンの後では改行の手前で止まる。
+func (l *Lexer) skipWhitespace() {
+	for l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r' {
+		if l.ch == '\n' && endsStatement(l.lastType) {
+			return
+		}
+		l.readChar()
+	}
+}
+
+func (l *Lexer) readNumberToken() token.Token {
+	position := l.position
+	if tok, ok := l.readPrefixedInteger(); ok {
+		return tok
+	}
+	l.readNumber()
+
+	// 小数部 (.5) と指数部 (e-3) があれば浮動小数点数 (FLOAT)、末尾に d があれば十進数 (DECIMAL)、
+	// 整数の末尾に n があれば多倍長整数 (BIGINT) のリテラル。
+	// 1.a や 2e のように続きが数字でなければ、整数で止めて残りは別のトークンにする。
+	end := l.position
+	if l.ch == '.' && isDigit(l.peekChar()) {
+		end = skipDigits(l.input, end+1)
+	}
+	if end < len(l.input) && (l.input[end] == 'e' || l.input[end] == 'E') {
+		digits := end + 1
+		if digits < len(l.input) && (l.input[digits] == '+' || l.input[digits] == '-') {
+			digits++
+		}
+		if digits < len(l.input) && isDigit(l.input[digits]) {
+			end = skipDigits(l.input, digits)
+		}
+	}
+
+	tokenType := token.TokenType(token.INT)
+	if end > l.position {
+		tokenType = token.FLOAT
+	}
+	if hasNumberSuffix(l.input, end, 'd') {
+		tokenType = token.DECIMAL
+		end++
+	} else if tokenType == token.INT && hasNumberSuffix(l.input, end, 'n') {
+		tokenType = token.BIGINT
+		end++
+	}
+	for l.position < end {
+		l.readChar()
+	}
+	return token.Token{Type: tokenType, Literal: l.input[position:l.position]}
+}
+
+// readPrefixedInteger は 0xFF、0o755、0b1010 のような基数の接頭辞付きの整数を読む。
+// 接頭辞の後は英数字が続く限り読み、基数に合わない文字があれば全体を ILLEGAL にする (0o789 を 0o7 と 89 に分けない)。
+// 0 の後に接頭辞がなければ何も読まずに false を返す。
+func (l *Lexer) readPrefixedInteger() (token.Token, bool) {
+	if l.ch != '0' || radixName(l.peekChar()) == "" {
+		return token.Token{}, false
+	}
+
+	position := l.position
+	l.readChar()
+	l.readChar()
+	for l.ch < utf8.RuneSelf && isLetter(rune(l.ch)) || isDigit(l.ch) {
+		l.readChar()
+	}
+	literal := l.input[position:l.position]
+	if NumberError(literal) != "" {
+		return token.Token{Type: token.ILLEGAL, Literal: literal}, true
+	}
+	if strings.HasSuffix(literal, "n") {
+		return token.Token{Type: token.BIGINT, Literal: literal}, true
+	}
+	return token.Token{Type: token.INT, Literal: literal}, true
+}
+
+// NumberError は基数の接頭辞付きのリテラルの誤りを "invalid digit '8' in octal literal" のように説明する。
+// 正しいリテラルや、接頭辞付きの数でないものには空文字列を返す。
+func NumberError(literal string) string {
+	if len(literal) < 2 || literal[0] != '0' {
+		return ""
+	}
+	name := radixName(literal[1])
+	if name == "" {
+		return ""
+	}
+	digits := strings.TrimSuffix(literal[2:], "n")
+	if digits == "" {
+		return fmt.Sprintf("%s literal %s has no digits", name, literal)
+	}
+	for i := 0; i < len(digits); i++ {
+		if !isRadixDigit(literal[1], digits[i]) {
+			return fmt.Sprintf("invalid digit %q in %s literal %s", digits[i], name, literal)
+		}
+	}
+	return ""
+}
+
+func radixName(prefix byte) string {
+	switch prefix {
+	case 'x', 'X':
+		return "hexadecimal"
+	case 'o', 'O':
+		return "octal"
+	case 'b', 'B':
+		return "binary"
+	}
+	return ""
+}
+
+func isRadixDigit(prefix, ch byte) bool {
+	switch prefix {
+	case 'x', 'X':
+		return isHexDigit(ch)
+	case 'o', 'O':
+		return '0' <= ch && ch <= '7'
+	default:
+		return ch == '0' || ch == '1'
+	}
+}
+
+// hasNumberSuffix は数字の直後の input[i] が suffix で、その後に名前が続かないかどうかを返す
+func hasNumberSuffix(input string, i int, suffix byte) bool {
+	return i < len(input) && input[i] == suffix && (i+1 >= len(input) || !isLetter(rune(input[i+1])) && !isDigit(input[i+1]))
+}
+
+func isHexDigit(ch byte) bool {
+	return '0' <= ch && ch <= '9' || 'a' <= ch && ch <= 'f' || 'A' <= ch && ch <= 'F'
+}
+
+func skipDigits(input string, i int) int {
+	for i < len(input) && isDigit(input[i]) {
+		i++
+	}
+	return i
+}
+
+func (l *Lexer) readNumber() string {
+	position := l.position
+	for isDigit(l.ch) {
+		l.readChar()
+	}
+	return l.input[position:l.position]
+}
+
+// readString は " の次から閉じる " までを読み、エスケープを解釈した中身を返す。
+// \n、\t、\"、\\ と \uXXXX を解釈し、それ以外の \ は正規表現などのためにそのまま残す。
+func (l *Lexer) readString() string {
+	var out strings.Builder
+	for {
+		l.readChar()
+		if l.ch == '"' || l.ch == 0 {
+			break
+		}
+		if l.ch != '\\' {
+			out.WriteByte(l.ch)
+			continue
+		}
+		switch next := l.peekChar(); next {
+		case 'n':
+			out.WriteByte('\n')
+		case 't':
+			out.WriteByte('\t')
+		case '"', '\\':
+			out.WriteByte(next)
+		case 'u':
+			r, width, ok := l.unicodeEscape()
+			if !ok {
+				out.WriteByte(l.ch)
+				continue
+			}
+			out.WriteRune(r)
+			l.readPosition += width
+		default:
+			out.WriteByte(l.ch)
+			continue
+		}
+		l.readChar()
+	}
+	return out.String()
+}
+
+// unicodeEscape は現在位置の \u に続く 4 桁の 16 進数が表す文字と、u の後に読んだバイト数を返す。
+// JSON と同じく、上位サロゲートの直後に \u で下位サロゲートが続けば二つを組にして一つの文字にする。
+// 組にならないサロゲートは U+FFFD になる。
+func (l *Lexer) unicodeEscape() (rune, int, bool) {
+	start := l.readPosition + 1
+	r, ok := l.hexQuad(start)
+	if !ok {
+		return 0, 0, false
+	}
+	if utf16.IsSurrogate(r) && strings.HasPrefix(l.input[start+4:], "\\u") {
+		if low, ok := l.hexQuad(start + 6); ok {
+			if pair := utf16.DecodeRune(r, low); pair != unicode.ReplacementChar {
+				return pair, 10, true
+			}
+		}
+	}
+	return r, 4, true
+}
+
+// hexQuad は input[start:] の先頭 4 桁の 16 進数を読む
+func (l *Lexer) hexQuad(start int) (rune, bool) {
+	if start+4 > len(l.input) {
+		return 0, false
+	}
+	digits := l.input[start : start+4]
+	for i := 0; i < len(digits); i++ {
+		if !isHexDigit(digits[i]) {
+			return 0, false
+		}
+	}
+	n, _ := strconv.ParseUint(digits, 16, 32)
+	return rune(n), true
+}
+
+func isDigit(ch byte) bool {
+	return '0' <= ch && ch <= '9'
+}
@@ -0,0 +1,594 @@
+package lexer
+
+import (
+	"testing"
+
+	"github.com/al-keio/monkey-go/token"
+)
+
+func TestNextToken(t *testing.T) {
+	input := `let five = 5;
+let ten = 10;
+let add = fn(x, y) {
+	x + y;
+};
+let result = add(five, ten);
+!-/ *5 % 2;
+5 < 10 > 5;
+
+if (5 < 10) {
+	return true;
+} else {
+	return false;
+}
+
+10 == 10;
+10 != 9;
+"foobar"
+"foo bar"
+[1, 2];
+{"foo": "bar"}
+macro(x, y) { x + y; };
+match (x) { [a, ...b] => a };
+a ? b : c;
+12.34d 5d 5 day;
+string.split;
+`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.LET, "let"},
+		{token.IDENT, "five"},
+		{token.ASSIGN, "="},
+		{token.INT, "5"},
+		{token.SEMICOLON, ";"},
+		{token.LET, "let"},
+		{token.IDENT, "ten"},
+		{token.ASSIGN, "="},
+		{token.INT, "10"},
+		{token.SEMICOLON, ";"},
+		{token.LET, "let"},
+		{token.IDENT, "add"},
+		{token.ASSIGN, "="},
+		{token.FUNCTION, "fn"},
+		{token.LPAREN, "("},
+		{token.IDENT, "x"},
+		{token.COMMA, ","},
+		{token.IDENT, "y"},
+		{token.RPAREN, ")"},
+		{token.LBRACE, "{"},
+		{token.IDENT, "x"},
+		{token.PLUS, "+"},
+		{token.IDENT, "y"},
+		{token.SEMICOLON, ";"},
+		{token.RBRACE, "}"},
+		{token.SEMICOLON, ";"},
+		{token.LET, "let"},
+		{token.IDENT, "result"},
+		{token.ASSIGN, "="},
+		{token.IDENT, "add"},
+		{token.LPAREN, "("},
+		{token.IDENT, "five"},
+		{token.COMMA, ","},
+		{token.IDENT, "ten"},
+		{token.RPAREN, ")"},
+		{token.SEMICOLON, ";"},
+		{token.BANG, "!"},
+		{token.MINUS, "-"},
+		{token.SLASH, "/"},
+		{token.ASTERISK, "*"},
+		{token.INT, "5"},
+		{token.PERCENT, "%"},
+		{token.INT, "2"},
+		{token.SEMICOLON, ";"},
+		{token.INT, "5"},
+		{token.LT, "<"},
+		{token.INT, "10"},
+		{token.GT, ">"},
+		{token.INT, "5"},
+		{token.SEMICOLON, ";"},
+		{token.IF, "if"},
+		{token.LPAREN, "("},
+		{token.INT, "5"},
+		{token.LT, "<"},
+		{token.INT, "10"},
+		{token.RPAREN, ")"},
+		{token.LBRACE, "{"},
+		{token.RETURN, "return"},
+		{token.TRUE, "true"},
+		{token.SEMICOLON, ";"},
+		{token.RBRACE, "}"},
+		{token.ELSE, "else"},
+		{token.LBRACE, "{"},
+		{token.RETURN, "return"},
+		{token.FALSE, "false"},
+		{token.SEMICOLON, ";"},
+		{token.RBRACE, "}"},
+		{token.NEWLINE, "\n"},
+		{token.INT, "10"},
+		{token.EQ, "=="},
+		{token.INT, "10"},
+		{token.SEMICOLON, ";"},
+		{token.INT, "10"},
+		{token.NOT_EQ, "!="},
+		{token.INT, "9"},
+		{token.SEMICOLON, ";"},
+		{token.STRING, "foobar"},
+		{token.NEWLINE, "\n"},
+		{token.STRING, "foo bar"},
+		{token.NEWLINE, "\n"},
+		{token.LBRACKET, "["},
+		{token.INT, "1"},
+		{token.COMMA, ","},
+		{token.INT, "2"},
+		{token.RBRACKET, "]"},
+		{token.SEMICOLON, ";"},
+		{token.LBRACE, "{"},
+		{token.STRING, "foo"},
+		{token.COLON, ":"},
+		{token.STRING, "bar"},
+		{token.RBRACE, "}"},
+		{token.NEWLINE, "\n"},
+		{token.MACRO, "macro"},
+		{token.LPAREN, "("},
+		{token.IDENT, "x"},
+		{token.COMMA, ","},
+		{token.IDENT, "y"},
+		{token.RPAREN, ")"},
+		{token.LBRACE, "{"},
+		{token.IDENT, "x"},
+		{token.PLUS, "+"},
+		{token.IDENT, "y"},
+		{token.SEMICOLON, ";"},
+		{token.RBRACE, "}"},
+		{token.SEMICOLON, ";"},
+		{token.MATCH, "match"},
+		{token.LPAREN, "("},
+		{token.IDENT, "x"},
+		{token.RPAREN, ")"},
+		{token.LBRACE, "{"},
+		{token.LBRACKET, "["},
+		{token.IDENT, "a"},
+		{token.COMMA, ","},
+		{token.ELLIPSIS, "..."},
+		{token.IDENT, "b"},
+		{token.RBRACKET, "]"},
+		{token.ARROW, "=>"},
+		{token.IDENT, "a"},
+		{token.RBRACE, "}"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "a"},
+		{token.QUESTION, "?"},
+		{token.IDENT, "b"},
+		{token.COLON, ":"},
+		{token.IDENT, "c"},
+		{token.SEMICOLON, ";"},
+		{token.DECIMAL, "12.34d"},
+		{token.DECIMAL, "5d"},
+		{token.INT, "5"},
+		{token.IDENT, "day"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "string"},
+		{token.DOT, "."},
+		{token.IDENT, "split"},
+		{token.SEMICOLON, ";"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestUnicodeIdentifiers(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{"合計", token.IDENT, "合計"},
+		{"数える", token.IDENT, "数える"},
+		{"café", token.IDENT, "café"},
+		{"переменная", token.IDENT, "переменная"},
+		{"λ", token.IDENT, "λ"},
+		{"ｆｏｏ", token.IDENT, "foo"},
+		{"ｌｅｔ", token.ILLEGAL, "ｌｅｔ"},
+		{"pаypal", token.ILLEGAL, "pаypal"},
+		{"αb", token.ILLEGAL, "αb"},
+		{"x座標", token.IDENT, "x座標"},
+		{"→", token.ILLEGAL, "→"},
+	}
+
+	for i, tt := range tests {
+		l := New(tt.input)
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Errorf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Errorf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+
+		if tok := l.NextToken(); tok.Type != token.EOF {
+			t.Errorf("tests[%d] - expected EOF, got=%q", i, tok.Type)
+		}
+	}
+}
+
+func TestFloatLiterals(t *testing.T) {
+	input := "3.14 0.5 1e-3 2E+10 6.02e23 1.5e2d 1.a 2e x.0"
+	expected := []token.Token{
+		{Type: token.FLOAT, Literal: "3.14"},
+		{Type: token.FLOAT, Literal: "0.5"},
+		{Type: token.FLOAT, Literal: "1e-3"},
+		{Type: token.FLOAT, Literal: "2E+10"},
+		{Type: token.FLOAT, Literal: "6.02e23"},
+		{Type: token.DECIMAL, Literal: "1.5e2d"},
+		{Type: token.INT, Literal: "1"},
+		{Type: token.DOT, Literal: "."},
+		{Type: token.IDENT, Literal: "a"},
+		{Type: token.INT, Literal: "2"},
+		{Type: token.IDENT, Literal: "e"},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.DOT, Literal: "."},
+		{Type: token.INT, Literal: "0"},
+		{Type: token.EOF, Literal: ""},
+	}
+
+	l := New(input)
+	for i, tt := range expected {
+		tok := l.NextToken()
+		if tok.Type != tt.Type || tok.Literal != tt.Literal {
+			t.Errorf("tests[%d] - expected %s %q, got %s %q", i, tt.Type, tt.Literal, tok.Type, tok.Literal)
+		}
+	}
+}
+
+func TestPrefixedIntegers(t *testing.T) {
+	// 基数に合わない文字は別のトークンに分けず、リテラル全体を ILLEGAL にする
+	input := "0xFF 0Xab 0o755 0b1010 0x 0b2 0o8 0o789 0b102 0xFG 0xFFn 0 0.5"
+	expected := []token.Token{
+		{Type: token.INT, Literal: "0xFF"},
+		{Type: token.INT, Literal: "0Xab"},
+		{Type: token.INT, Literal: "0o755"},
+		{Type: token.INT, Literal: "0b1010"},
+		{Type: token.ILLEGAL, Literal: "0x"},
+		{Type: token.ILLEGAL, Literal: "0b2"},
+		{Type: token.ILLEGAL, Literal: "0o8"},
+		{Type: token.ILLEGAL, Literal: "0o789"},
+		{Type: token.ILLEGAL, Literal: "0b102"},
+		{Type: token.ILLEGAL, Literal: "0xFG"},
+		{Type: token.BIGINT, Literal: "0xFFn"},
+		{Type: token.INT, Literal: "0"},
+		{Type: token.FLOAT, Literal: "0.5"},
+		{Type: token.EOF, Literal: ""},
+	}
+
+	l := New(input)
+	for i, tt := range expected {
+		tok := l.NextToken()
+		if tok.Type != tt.Type || tok.Literal != tt.Literal {
+			t.Errorf("tests[%d] - expected %s %q, got %s %q", i, tt.Type, tt.Literal, tok.Type, tok.Literal)
+		}
+	}
+}
+
+func TestNumberError(t *testing.T) {
+	tests := []struct {
+		literal  string
+		expected string
+	}{
+		{"0o789", "invalid digit '8' in octal literal 0o789"},
+		{"0b102", "invalid digit '2' in binary literal 0b102"},
+		{"0xFG", "invalid digit 'G' in hexadecimal literal 0xFG"},
+		{"0x", "hexadecimal literal 0x has no digits"},
+		{"0xFFn", ""},
+		{"0o17", ""},
+		{"12", ""},
+	}
+
+	for _, tt := range tests {
+		if got := NumberError(tt.literal); got != tt.expected {
+			t.Errorf("NumberError(%q) = %q, want %q", tt.literal, got, tt.expected)
+		}
+	}
+}
+
+func TestIdentifierDigits(t *testing.T) {
+	input := "sha256 x1y 2a"
+	expected := []token.Token{
+		{Type: token.IDENT, Literal: "sha256"},
+		{Type: token.IDENT, Literal: "x1y"},
+		{Type: token.INT, Literal: "2"},
+		{Type: token.IDENT, Literal: "a"},
+		{Type: token.EOF, Literal: ""},
+	}
+
+	l := New(input)
+	for i, tt := range expected {
+		tok := l.NextToken()
+		if tok.Type != tt.Type || tok.Literal != tt.Literal {
+			t.Errorf("tests[%d] - expected %s %q, got %s %q", i, tt.Type, tt.Literal, tok.Type, tok.Literal)
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedType    token.TokenType
+		expectedLiteral string
+		expectedDoc     string
+	}{
+		{"// comment\nx", token.IDENT, "x", ""},
+		{"x // comment", token.IDENT, "x", ""},
+		{"/* a\n b */ x", token.IDENT, "x", ""},
+		{"/**/x", token.IDENT, "x", ""},
+		{"/// adds\n/// numbers\r\nlet", token.LET, "let", "adds\nnumbers"},
+		{"///\n///indented\nfn", token.FUNCTION, "fn", "\nindented"},
+		{"/// stale\n// plain\nlet", token.LET, "let", ""},
+		{"/// stale\n/* block */ let", token.LET, "let", ""},
+		{"4 / 2", token.INT, "4", ""},
+		{"/* open", token.ILLEGAL, "/*", ""},
+	}
+
+	for i, tt := range tests {
+		tok := New(tt.input).NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Errorf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Errorf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+		if tok.Doc != tt.expectedDoc {
+			t.Errorf("tests[%d] - doc wrong. expected=%q, got=%q", i, tt.expectedDoc, tok.Doc)
+		}
+	}
+
+	l := New("x // comment")
+	l.NextToken()
+	for i := 0; i < 3; i++ {
+		if tok := l.NextToken(); tok.Type != token.EOF {
+			t.Errorf("expected EOF, got=%q", tok.Type)
+		}
+	}
+}
+
+func TestTokenOffsets(t *testing.T) {
+	input := "let x = 10; // c\n\"s\" /* open"
+	expected := []int{0, 4, 6, 8, 10, 17, 21}
+
+	l := New(input)
+	for i, offset := range expected {
+		tok := l.NextToken()
+		if tok.Offset != offset {
+			t.Errorf("tokens[%d] %q - offset wrong. expected=%d, got=%d", i, tok.Literal, offset, tok.Offset)
+		}
+	}
+}
+
+func TestTokenLineColumn(t *testing.T) {
+	input := "let x = 10;\n  \"é\" + λ\n\n/* a\nb */ y /* open"
+	expected := []struct {
+		literal      string
+		line, column int
+	}{
+		{"let", 1, 1},
+		{"x", 1, 5},
+		{"=", 1, 7},
+		{"10", 1, 9},
+		{";", 1, 11},
+		{"é", 2, 3},
+		{"+", 2, 7},
+		{"λ", 2, 9},
+		{"\n", 2, 10},
+		{"y", 5, 6},
+		{"/*", 5, 8},
+	}
+
+	l := New(input)
+	for i, tt := range expected {
+		tok := l.NextToken()
+		if tok.Literal != tt.literal || tok.Line != tt.line || tok.Column != tt.column {
+			t.Errorf("tokens[%d] wrong. expected=%q at %d:%d, got=%q at %d:%d", i, tt.literal, tt.line, tt.column, tok.Literal, tok.Line, tok.Column)
+		}
+	}
+}
+
+func TestShebang(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedType   token.TokenType
+		expectedOffset int
+	}{
+		{"#!/usr/bin/env monkey\nlet", token.LET, 22},
+		{"#!/usr/bin/env monkey", token.EOF, 21},
+		{" #!/usr/bin/env monkey", token.ILLEGAL, 1},
+	}
+
+	for i, tt := range tests {
+		tok := New(tt.input).NextToken()
+		if tok.Type != tt.expectedType {
+			t.Errorf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Offset != tt.expectedOffset {
+			t.Errorf("tests[%d] - offset wrong. expected=%d, got=%d", i, tt.expectedOffset, tok.Offset)
+		}
+	}
+}
+
+func TestNewlines(t *testing.T) {
+	input := "x\n\n+ y // c\nf(\n1,\n)\r\nlet z = [\n]"
+	expected := []token.TokenType{
+		token.IDENT, token.NEWLINE, token.PLUS, token.IDENT, token.NEWLINE,
+		token.IDENT, token.LPAREN, token.INT, token.COMMA, token.RPAREN, token.NEWLINE,
+		token.LET, token.IDENT, token.ASSIGN, token.LBRACKET, token.RBRACKET, token.EOF,
+	}
+
+	l := New(input)
+	for i, tt := range expected {
+		tok := l.NextToken()
+		if tok.Type != tt {
+			t.Fatalf("tokens[%d] - tokentype wrong. expected=%q, got=%q", i, tt, tok.Type)
+		}
+	}
+}
+
+func TestKeywordAliases(t *testing.T) {
+	defer token.SetKeywords(token.SetKeywords(token.DefaultKeywords.WithAliases(map[string]token.TokenType{
+		"もし":       token.IF,
+		"function": token.FUNCTION,
+	})))
+
+	input := "もし function fn if"
+	expected := []token.TokenType{token.IF, token.FUNCTION, token.FUNCTION, token.IF, token.EOF}
+
+	l := New(input)
+	for i, tt := range expected {
+		tok := l.NextToken()
+		if tok.Type != tt {
+			t.Fatalf("tokens[%d] - tokentype wrong. expected=%q, got=%q", i, tt, tok.Type)
+		}
+	}
+
+	if tok := token.DefaultKeywords.Lookup("もし"); tok != token.IDENT {
+		t.Errorf("DefaultKeywords was modified. got=%q", tok)
+	}
+}
+
+func TestTokenStream(t *testing.T) {
+	s := NewTokenStream(New("let x = 1;\n  λ + y"))
+
+	if tok := s.Peek(2); tok.Type != token.ASSIGN {
+		t.Fatalf("Peek(2) wrong. got=%q", tok.Type)
+	}
+	if tok := s.Next(); tok.Type != token.LET {
+		t.Fatalf("Next() wrong. got=%q", tok.Type)
+	}
+
+	mark := s.Mark()
+	for _, expected := range []token.TokenType{token.IDENT, token.ASSIGN, token.INT, token.SEMICOLON} {
+		if tok := s.Next(); tok.Type != expected {
+			t.Fatalf("Next() wrong. expected=%q, got=%q", expected, tok.Type)
+		}
+	}
+	s.Reset(mark)
+	if tok := s.Next(); tok.Literal != "x" {
+		t.Fatalf("Next() after Reset wrong. got=%q", tok.Literal)
+	}
+
+	if tok := s.Peek(100); tok.Type != token.EOF {
+		t.Errorf("Peek past the end should be EOF, got=%q", tok.Type)
+	}
+
+	s.Reset(5)
+	if line, column := s.LineColumn(s.Offset()); line != 2 || column != 3 {
+		t.Errorf("position of λ wrong. got=%d:%d", line, column)
+	}
+	s.Next()
+	if line, column := s.LineColumn(s.Offset()); line != 2 || column != 5 {
+		t.Errorf("position of + wrong. got=%d:%d", line, column)
+	}
+
+	for i := 0; i < 3; i++ {
+		s.Next()
+	}
+	if tok := s.Next(); tok.Type != token.EOF {
+		t.Errorf("expected EOF, got=%q", tok.Type)
+	}
+}
+
+func TestTokenStreamDiscard(t *testing.T) {
+	s := NewTokenStream(New("let x = 1; y"))
+
+	s.Next()
+	s.Next()
+	s.Peek(1)
+	s.Discard()
+	if len(s.tokens) != 2 {
+		t.Errorf("Discard should keep only the lookahead, got %d tokens", len(s.tokens))
+	}
+
+	mark := s.Mark()
+	if mark != 2 {
+		t.Errorf("Mark() after Discard wrong. got=%d", mark)
+	}
+	s.Next()
+	s.Next()
+	s.Reset(mark)
+	if tok := s.Next(); tok.Type != token.ASSIGN {
+		t.Errorf("Next() after Reset wrong. got=%q", tok.Type)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Reset to a discarded token should panic")
+		}
+	}()
+	s.Reset(0)
+}
+
+func TestStringEscapes(t *testing.T) {
+	input := `"a\nb" "tab\there" "say \"hi\"" "back\\slash" "é中" "^db\.\d+$" "\u12" "end\\" "\u00e9\u4E2D" "\ud83d\ude00!" "\uD83D" "\ud83dx" "\ud83d\u0041" "\ude00\ud83d"`
+	expected := []token.Token{
+		{Type: token.STRING, Literal: "a\nb"},
+		{Type: token.STRING, Literal: "tab\there"},
+		{Type: token.STRING, Literal: `say "hi"`},
+		{Type: token.STRING, Literal: `back\slash`},
+		{Type: token.STRING, Literal: "é中"},
+		{Type: token.STRING, Literal: `^db\.\d+$`},
+		{Type: token.STRING, Literal: `\u12`},
+		{Type: token.STRING, Literal: `end\`},
+		{Type: token.STRING, Literal: "é中"},
+		{Type: token.STRING, Literal: "😀!"},
+		{Type: token.STRING, Literal: "\uFFFD"},
+		{Type: token.STRING, Literal: "\uFFFDx"},
+		{Type: token.STRING, Literal: "\uFFFDA"},
+		{Type: token.STRING, Literal: "\uFFFD\uFFFD"},
+		{Type: token.EOF, Literal: ""},
+	}
+
+	l := New(input)
+	for i, tt := range expected {
+		tok := l.NextToken()
+		if tok.Type != tt.Type || tok.Literal != tt.Literal {
+			t.Errorf("tests[%d] - expected %s %q, got %s %q", i, tt.Type, tt.Literal, tok.Type, tok.Literal)
+		}
+	}
+}
+
+func TestBigIntLiterals(t *testing.T) {
+	input := "123n 0xFFn 1.5n 7nd 2d x1n"
+	expected := []token.Token{
+		{Type: token.BIGINT, Literal: "123n"},
+		{Type: token.BIGINT, Literal: "0xFFn"},
+		{Type: token.FLOAT, Literal: "1.5"},
+		{Type: token.IDENT, Literal: "n"},
+		{Type: token.INT, Literal: "7"},
+		{Type: token.IDENT, Literal: "nd"},
+		{Type: token.DECIMAL, Literal: "2d"},
+		{Type: token.IDENT, Literal: "x1n"},
+		{Type: token.EOF, Literal: ""},
+	}
+
+	l := New(input)
+	for i, tt := range expected {
+		tok := l.NextToken()
+		if tok.Type != tt.Type || tok.Literal != tt.Literal {
+			t.Errorf("tests[%d] - expected %s %q, got %s %q", i, tt.Type, tt.Literal, tok.Type, tok.Literal)
+		}
+	}
+}
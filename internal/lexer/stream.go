@@ -0,0 +1,100 @@
+package lexer
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/al-keio/monkey-go/token"
+)
+
+// TokenStream は Lexer の出力を先読みと巻き戻しのできる列として読む。パーサーもこれを通してトークンを読む。
+// 読んだトークンは Discard するまで保持するので、Mark した位置にいつでも戻れる。
+type TokenStream struct {
+	l      *Lexer
+	tokens []token.Token // これまでに読んだトークン。最後が EOF ならそれ以上は読まない
+	pos    int           // 次に Next で返すトークンの添字
+	base   int           // tokens[0] が入力の何番目のトークンか。Discard で進む
+}
+
+func NewTokenStream(l *Lexer) *TokenStream {
+	return &TokenStream{l: l}
+}
+
+// fill は添字 i のトークンまでを読む。入力が尽きていれば false を返す。
+func (s *TokenStream) fill(i int) bool {
+	for len(s.tokens) <= i {
+		if n := len(s.tokens); n > 0 && s.tokens[n-1].Type == token.EOF {
+			return false
+		}
+		s.tokens = append(s.tokens, s.l.NextToken())
+	}
+	return true
+}
+
+// Next は次のトークンを返して進む。入力の終わりでは EOF を返し続ける。
+func (s *TokenStream) Next() token.Token {
+	tok := s.Peek(0)
+	if tok.Type != token.EOF {
+		s.pos++
+	}
+	return tok
+}
+
+// Peek は進まずに n 個先のトークンを返す。Peek(0) は次に Next が返すもの。
+func (s *TokenStream) Peek(n int) token.Token {
+	if !s.fill(s.pos + n) {
+		return s.tokens[len(s.tokens)-1]
+	}
+	return s.tokens[s.pos+n]
+}
+
+// Mark は現在の位置を返す。Reset に渡すとこの位置に戻る。
+func (s *TokenStream) Mark() int {
+	return s.base + s.pos
+}
+
+func (s *TokenStream) Reset(mark int) {
+	if mark < s.base {
+		panic("lexer: Reset to a discarded token")
+	}
+	s.pos = mark - s.base
+}
+
+// Discard は Next で読み終えたトークンを捨て、バッファを先読みの分だけにする。
+// それより前の Mark には戻れなくなる。大きな入力でも保持するトークンが増え続けないよう、
+// パーサーは最上位の文を読み始めるたびに呼ぶ。
+func (s *TokenStream) Discard() {
+	n := copy(s.tokens, s.tokens[s.pos:])
+	s.tokens = s.tokens[:n]
+	s.base += s.pos
+	s.pos = 0
+}
+
+// Offset は次のトークンの入力中のバイト位置を返す
+func (s *TokenStream) Offset() int {
+	return s.Peek(0).Offset
+}
+
+// Comments はこれまでに読んだトークンの間にあったコメントを現れた順に返す
+func (s *TokenStream) Comments() []token.Comment {
+	return s.l.comments
+}
+
+// SameLine は入力中の from から to までに改行がないかどうかを返す
+func (s *TokenStream) SameLine(from, to int) bool {
+	if to > len(s.l.input) {
+		to = len(s.l.input)
+	}
+	return from <= to && !strings.Contains(s.l.input[from:to], "\n")
+}
+
+// LineColumn は入力中のバイト位置 offset の行と桁を返す。どちらも 1 から数え、桁は文字単位。
+func (s *TokenStream) LineColumn(offset int) (line, column int) {
+	if offset > len(s.l.input) {
+		offset = len(s.l.input)
+	}
+	before := s.l.input[:offset]
+	line = strings.Count(before, "\n") + 1
+	column = utf8.RuneCountInString(before[strings.LastIndexByte(before, '\n')+1:]) + 1
+	return line, column
+}
@@ -0,0 +1,110 @@
+package parser
+
+import "github.com/al-keio/monkey-go/ast"
+
+// 一度にまとめて確保するノードの数。小さな入力で無駄にならないよう、
+// それまでに確保した数に比例させて minArenaChunk から maxArenaChunk まで増やす。
+const (
+	minArenaChunk = 8
+	maxArenaChunk = 256
+)
+
+// nodeArena は数の多いノードを種類ごとに配列でまとめて確保し、一つずつ割り当てる。
+// 確保の回数が減り、同じ種類のノードがメモリ上で近くに並ぶ。配列はふつうの Go のメモリなので
+// 明示的に解放する必要はなく、どのノードからも参照されなくなれば GC が回収する。
+// ただし配列の一部のノードでも生きていれば配列全体が残る。
+type nodeArena struct {
+	identifiers []ast.Identifier
+	integers    []ast.IntegerLiteral
+	strings     []ast.StringLiteral
+	prefixes    []ast.PrefixExpression
+	infixes     []ast.InfixExpression
+	calls       []ast.CallExpression
+	statements  []ast.ExpressionStatement
+	lets        []ast.LetStatement
+
+	allocated int // これまでに確保した配列の長さの合計
+}
+
+func (a *nodeArena) grow() int {
+	n := a.allocated / 8
+	if n < minArenaChunk {
+		n = minArenaChunk
+	} else if n > maxArenaChunk {
+		n = maxArenaChunk
+	}
+	a.allocated += n
+	return n
+}
+
+func (a *nodeArena) identifier() *ast.Identifier {
+	if len(a.identifiers) == 0 {
+		a.identifiers = make([]ast.Identifier, a.grow())
+	}
+	node := &a.identifiers[0]
+	a.identifiers = a.identifiers[1:]
+	return node
+}
+
+func (a *nodeArena) integer() *ast.IntegerLiteral {
+	if len(a.integers) == 0 {
+		a.integers = make([]ast.IntegerLiteral, a.grow())
+	}
+	node := &a.integers[0]
+	a.integers = a.integers[1:]
+	return node
+}
+
+func (a *nodeArena) string() *ast.StringLiteral {
+	if len(a.strings) == 0 {
+		a.strings = make([]ast.StringLiteral, a.grow())
+	}
+	node := &a.strings[0]
+	a.strings = a.strings[1:]
+	return node
+}
+
+func (a *nodeArena) prefix() *ast.PrefixExpression {
+	if len(a.prefixes) == 0 {
+		a.prefixes = make([]ast.PrefixExpression, a.grow())
+	}
+	node := &a.prefixes[0]
+	a.prefixes = a.prefixes[1:]
+	return node
+}
+
+func (a *nodeArena) infix() *ast.InfixExpression {
+	if len(a.infixes) == 0 {
+		a.infixes = make([]ast.InfixExpression, a.grow())
+	}
+	node := &a.infixes[0]
+	a.infixes = a.infixes[1:]
+	return node
+}
+
+func (a *nodeArena) call() *ast.CallExpression {
+	if len(a.calls) == 0 {
+		a.calls = make([]ast.CallExpression, a.grow())
+	}
+	node := &a.calls[0]
+	a.calls = a.calls[1:]
+	return node
+}
+
+func (a *nodeArena) statement() *ast.ExpressionStatement {
+	if len(a.statements) == 0 {
+		a.statements = make([]ast.ExpressionStatement, a.grow())
+	}
+	node := &a.statements[0]
+	a.statements = a.statements[1:]
+	return node
+}
+
+func (a *nodeArena) let() *ast.LetStatement {
+	if len(a.lets) == 0 {
+		a.lets = make([]ast.LetStatement, a.grow())
+	}
+	node := &a.lets[0]
+	a.lets = a.lets[1:]
+	return node
+}
@@ -0,0 +1,42 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/al-keio/monkey-go/internal/lexer"
+)
+
+// benchmarkProgram はトランスパイラが出力するような、同じ形の関数が n 個並んだプログラムを作る
+func benchmarkProgram(n int) string {
+	var out strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&out, `let f = fn(a, b, c) {
+  let x = a * %d + b - c / 2;
+  let xs = [x, a, b, c, "s%d"];
+  let h = {"a": a, "b": b, "n": %d};
+  if (x > b) { return len(xs) + h["n"]; } else { f(b, c, -a) == !true }
+};
+f(1, 2, 3);
+`, i, i, i)
+	}
+	return out.String()
+}
+
+func BenchmarkParseProgram(b *testing.B) {
+	for _, n := range []int{10, 1000} {
+		input := benchmarkProgram(n)
+		b.Run(fmt.Sprintf("functions=%d", n), func(b *testing.B) {
+			b.SetBytes(int64(len(input)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				p := New(lexer.New(input))
+				p.ParseProgram()
+				if len(p.Errors()) != 0 {
+					b.Fatal(p.Errors())
+				}
+			}
+		})
+	}
+}
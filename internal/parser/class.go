@@ -0,0 +1,97 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/al-keio/monkey-go/ast"
+	"github.com/al-keio/monkey-go/token"
+)
+
+// parseClassStatement は class Name extends Base { name(params) { ... } ... } を解析する。
+// メソッドは fn を書かない関数リテラルで、requires や ensures も書ける。
+func (p *Parser) parseClassStatement() *ast.ClassStatement {
+	defer p.untrace(p.trace("parseClassStatement"))
+
+	stmt := &ast.ClassStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	stmt.Name = p.newIdentifier()
+
+	// extends はキーワードではなく、クラス名の直後でだけ意味を持つ
+	if p.peekTokenIs(token.IDENT) && p.peekToken.Literal == "extends" {
+		p.nextToken()
+		p.nextToken()
+		stmt.Extends = p.parseClassName()
+		if stmt.Extends == nil {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	seen := map[string]bool{}
+	for p.nextToken(); !p.curTokenIs(token.RBRACE); p.nextToken() {
+		switch p.curToken.Type {
+		case token.SEMICOLON:
+			continue
+		case token.EOF:
+			p.addError(fmt.Sprintf("unterminated class %s", stmt.Name.Value))
+			return nil
+		case token.IDENT:
+		default:
+			p.addError(fmt.Sprintf("expected method name in class %s, got %s instead", stmt.Name.Value, p.curToken.Type))
+			return nil
+		}
+
+		name := p.newIdentifier()
+		if seen[name.Value] {
+			p.addError(fmt.Sprintf("method %s is defined twice in class %s", name.Value, stmt.Name.Value))
+			return nil
+		}
+		seen[name.Value] = true
+
+		function, ok := p.parseFunctionLiteral().(*ast.FunctionLiteral)
+		if !ok {
+			return nil
+		}
+		function.Token = token.Token{Type: token.FUNCTION, Literal: "fn", Offset: name.Token.Offset, Line: name.Token.Line, Column: name.Token.Column}
+		function.Doc = name.Token.Doc
+		stmt.Methods = append(stmt.Methods, &ast.ClassMethod{Name: name, Function: function})
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// parseClassName は extends や new の後のクラスを解析する。Point や shapes.Point のような名前で、
+// 続く ( を呼び出しとして読まないよう、それより強く結びつく . と [ までで止める。
+func (p *Parser) parseClassName() ast.Expression {
+	return p.parseExpression(CALL)
+}
+
+// parseNewExpression は new Point(1, 2) を解析する。引数がなくても括弧は省略できない。
+func (p *Parser) parseNewExpression() ast.Expression {
+	defer p.untrace(p.trace("parseNewExpression"))
+
+	exp := &ast.NewExpression{Token: p.curToken}
+
+	p.nextToken()
+	exp.Class = p.parseClassName()
+	if exp.Class == nil {
+		return nil
+	}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+	exp.Arguments = p.parseExpressionList(token.RPAREN)
+
+	return exp
+}
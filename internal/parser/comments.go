@@ -0,0 +1,38 @@
+package parser
+
+import (
+	"github.com/al-keio/monkey-go/ast"
+)
+
+// parseCommentedStatement は文を解析し、その前のコメントと同じ行の後ろのコメントを文に付ける。
+// 式の途中にあるコメントはどの文にも付けない。
+func (p *Parser) parseCommentedStatement() ast.Statement {
+	leading := p.takeComments(p.curToken.Offset)
+	stmt := p.parseStatement()
+
+	end := tokenEnd(p.curToken)
+	p.takeComments(end)
+	trailing := ""
+	if comments := p.tokens.Comments(); p.comment < len(comments) && p.tokens.SameLine(end, comments[p.comment].Offset) {
+		trailing = comments[p.comment].Text
+		p.comment++
+	}
+
+	if commented, ok := stmt.(ast.Commented); ok {
+		trivia := commented.Comments()
+		trivia.Leading = leading
+		trivia.Trailing = trailing
+	}
+	return stmt
+}
+
+// takeComments はまだどの文にも付けていないコメントのうち、offset より前にあるものを返す
+func (p *Parser) takeComments(offset int) []string {
+	var texts []string
+	comments := p.tokens.Comments()
+	for p.comment < len(comments) && comments[p.comment].Offset < offset {
+		texts = append(texts, comments[p.comment].Text)
+		p.comment++
+	}
+	return texts
+}
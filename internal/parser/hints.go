@@ -0,0 +1,96 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/al-keio/monkey-go/token"
+)
+
+// errorContext は構文エラーが起きたときの現在と次のトークン。expected は peekError で期待していたトークン。
+type errorContext struct {
+	cur, peek token.Token
+	at        token.Token // エラーの位置として示すトークン
+	expected  token.TokenType
+}
+
+// Hints は Errors と同じ順に、各エラーへの助言を返す。助言のないエラーには空文字列を返す。
+// 解析を終えた後でエラーの起きた位置のトークンを調べ、よくある書き間違いに当てはまるものに助言を付ける。
+func (p *Parser) Hints() []string {
+	hints := make([]string, len(p.contexts))
+	for i, ctx := range p.contexts {
+		hints[i] = ctx.hint()
+	}
+	return hints
+}
+
+// ErrorPositions は Errors と同じ順に、各エラーの起きたトークンを返す。Line と Column で位置がわかる。
+func (p *Parser) ErrorPositions() []token.Token {
+	positions := make([]token.Token, len(p.contexts))
+	for i, ctx := range p.contexts {
+		positions[i] = ctx.at
+	}
+	return positions
+}
+
+// ErrorsWithHints は Errors の前に "line 3, column 5: " の形で位置を付け、
+// 助言のあるものの後ろに "; hint: ..." を付けて返す
+func (p *Parser) ErrorsWithHints() []string {
+	errors := make([]string, len(p.errors))
+	positions := p.ErrorPositions()
+	for i, hint := range p.Hints() {
+		errors[i] = fmt.Sprintf("line %d, column %d: %s", positions[i].Line, positions[i].Column, p.errors[i])
+		if hint != "" {
+			errors[i] += "; hint: " + hint
+		}
+	}
+	return errors
+}
+
+func (ctx errorContext) hint() string {
+	cur, peek := ctx.cur, ctx.peek
+
+	switch ctx.expected {
+	case token.LPAREN:
+		switch {
+		case cur.Type == token.IF:
+			return "the condition of if goes in parentheses: if (x) { ... }"
+		case cur.Type == token.MATCH:
+			return "the subject of match goes in parentheses: match (x) { ... }"
+		case cur.Type == token.FUNCTION && peek.Type == token.IDENT:
+			return fmt.Sprintf("functions have no names; bind one with let %s = fn(...) { ... }", peek.Literal)
+		}
+	case token.LBRACE:
+		if cur.Type == token.ELSE && peek.Type == token.IF {
+			return "there is no else if; nest it in a block: else { if (...) { ... } }"
+		}
+	case token.ASSIGN:
+		if peek.Type == token.EQ {
+			return "let binds a name with =; == compares values"
+		}
+	case token.IDENT:
+		if cur.Type == token.LET && token.DefaultKeywords.Lookup(peek.Literal) != token.IDENT {
+			return fmt.Sprintf("%s is a keyword and cannot be used as a name", peek.Literal)
+		}
+	}
+
+	if peek.Type == token.EOF {
+		switch ctx.expected {
+		case token.RPAREN, token.RBRACE, token.RBRACKET:
+			return fmt.Sprintf("the input ended before the closing %s; check for a missing %s", ctx.expected, ctx.expected)
+		}
+	}
+
+	// no prefix parse function のエラーでは cur が式を始められなかったトークン
+	switch {
+	case cur.Type == token.ASSIGN:
+		return "= only binds names in let (let x = ...); compare with == (there are no <= or >= operators)"
+	case cur.Type == token.ILLEGAL && (cur.Literal == "&" || cur.Literal == "|"):
+		return "there are no && or || operators; use nested if expressions"
+	case cur.Type == token.ILLEGAL && cur.Literal == "'":
+		return "strings are written in double quotes"
+	case cur.Type == token.INT:
+		// 整数のリテラルのエラーは int64 に収まらないときだけ起きる
+		return fmt.Sprintf("integers too large for 64 bits need an n suffix: %sn", cur.Literal)
+	}
+	return ""
+}
@@ -0,0 +1,153 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/al-keio/monkey-go/ast"
+	"github.com/al-keio/monkey-go/token"
+)
+
+// operatorChars は operator 宣言で定義する演算子に使える文字
+const operatorChars = "+-*/%<>=!&|^~@$"
+
+// 組み込みの演算子と同じ記号は定義できない
+var builtinOperators = map[string]bool{
+	"+": true, "-": true, "*": true, "/": true, "%": true,
+	"==": true, "!=": true, "<": true, ">": true, "=": true, "=>": true, "!": true,
+}
+
+// operatorPrecedences は operator 宣言に書ける優先順位の名前
+var operatorPrecedences = map[string]int{
+	"equals":      EQUALS,
+	"lessgreater": LESSGREATER,
+	"sum":         SUM,
+	"product":     PRODUCT,
+}
+
+// RegisterOperator は symbol を precedence の左結合の中置演算子として登録する。
+// 以後の a symbol b は InfixExpression になり、評価器は symbol の名前で束縛された関数を呼ぶ。
+// ソース中の operator 宣言もこれで登録する。REPL のように入力ごとにパーサーを作る場合は、
+// 前のパーサーの Operators を登録し直すこと。
+func (p *Parser) RegisterOperator(symbol string, precedence int) error {
+	if !isOperatorSymbol(symbol) || strings.Contains(symbol, "//") || strings.Contains(symbol, "/*") {
+		return fmt.Errorf("invalid operator symbol %q", symbol)
+	}
+	if builtinOperators[symbol] {
+		return fmt.Errorf("cannot redefine built-in operator %s", symbol)
+	}
+	if precedence < EQUALS || precedence > PRODUCT {
+		return fmt.Errorf("precedence %d of operator %s is out of range (%d to %d)", precedence, symbol, EQUALS, PRODUCT)
+	}
+	p.operators[symbol] = precedence
+	return nil
+}
+
+// Operators は登録された演算子とその優先順位を返す
+func (p *Parser) Operators() map[string]int {
+	operators := make(map[string]int, len(p.operators))
+	for symbol, precedence := range p.operators {
+		operators[symbol] = precedence
+	}
+	return operators
+}
+
+func isOperatorSymbol(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if !strings.ContainsRune(operatorChars, c) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseOperatorStatement は operator <+> sum (a, b) { ... } を解析する。優先順位の名前は省略できる。
+func (p *Parser) parseOperatorStatement() *ast.OperatorStatement {
+	defer p.untrace(p.trace("parseOperatorStatement"))
+
+	stmt := &ast.OperatorStatement{Token: p.curToken}
+
+	if !isOperatorSymbol(p.peekToken.Literal) {
+		p.addError(fmt.Sprintf("expected operator symbol after operator, got %s instead", p.peekToken.Type))
+		return nil
+	}
+	// 字句解析器は <+> を < + > に分けるので、間に空白のない記号のトークンをつなげる
+	p.nextToken()
+	stmt.Operator = p.curToken.Literal
+	for isOperatorSymbol(p.peekToken.Literal) && p.peekToken.Offset == tokenEnd(p.curToken) {
+		p.nextToken()
+		stmt.Operator += p.curToken.Literal
+	}
+
+	precedence := SUM
+	if p.peekTokenIs(token.IDENT) {
+		p.nextToken()
+		level, ok := operatorPrecedences[p.curToken.Literal]
+		if !ok {
+			p.addError(fmt.Sprintf("unknown precedence %s for operator %s (want equals, lessgreater, sum or product)", p.curToken.Literal, stmt.Operator))
+			return nil
+		}
+		stmt.Precedence = p.curToken.Literal
+		precedence = level
+	}
+
+	// 本体の中でも使えるよう、本体より先に登録する
+	if err := p.RegisterOperator(stmt.Operator, precedence); err != nil {
+		p.addError(err.Error())
+		return nil
+	}
+
+	function, ok := p.parseFunctionLiteral().(*ast.FunctionLiteral)
+	if !ok {
+		return nil
+	}
+	function.Token = token.Token{Type: token.FUNCTION, Literal: "fn", Offset: stmt.Token.Offset, Line: stmt.Token.Line, Column: stmt.Token.Column}
+	function.Doc = stmt.Token.Doc
+	if len(function.Parameters) != 2 {
+		p.addError(fmt.Sprintf("operator %s must take 2 parameters, got %d", stmt.Operator, len(function.Parameters)))
+		return nil
+	}
+	stmt.Function = function
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// mergeCustomOperator は次のトークンから始まる間に空白のない記号のトークンの列が登録された演算子なら、
+// それらを一つの CUSTOM_INFIX のトークンにまとめて peekToken にする。複数の候補があれば最も長いものを選ぶ。
+func (p *Parser) mergeCustomOperator() {
+	if len(p.operators) == 0 || p.peekToken.Type == token.CUSTOM_INFIX || !isOperatorSymbol(p.peekToken.Literal) {
+		return
+	}
+
+	symbol, end := p.peekToken.Literal, tokenEnd(p.peekToken)
+	longest, n := "", 0
+	if _, ok := p.operators[symbol]; ok {
+		longest = symbol
+	}
+	for i := 0; ; i++ {
+		tok := p.tokens.Peek(i)
+		if tok.Offset != end || !isOperatorSymbol(tok.Literal) {
+			break
+		}
+		symbol += tok.Literal
+		end = tokenEnd(tok)
+		if _, ok := p.operators[symbol]; ok {
+			longest, n = symbol, i+1
+		}
+	}
+	if longest == "" {
+		return
+	}
+
+	for i := 0; i < n; i++ {
+		p.tokens.Next()
+	}
+	p.peekToken = token.Token{Type: token.CUSTOM_INFIX, Literal: longest, Offset: p.peekToken.Offset, Line: p.peekToken.Line, Column: p.peekToken.Column}
+}
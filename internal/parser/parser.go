@@ -0,0 +1,1028 @@
+package parser
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/al-keio/monkey-go/ast"
+	"github.com/al-keio/monkey-go/internal/lexer"
+	"github.com/al-keio/monkey-go/symbol"
+	"github.com/al-keio/monkey-go/token"
+)
+
+const (
+	_ int = iota
+	LOWEST
+	TERNARY
+	EQUALS
+	LESSGREATER
+	SUM
+	PRODUCT
+	PREFIX
+	CALL
+	INDEX
+)
+
+var precedences = map[token.TokenType]int{
+	token.QUESTION: TERNARY,
+	token.EQ:       EQUALS,
+	token.NOT_EQ:   EQUALS,
+	token.LT:       LESSGREATER,
+	token.GT:       LESSGREATER,
+	token.PLUS:     SUM,
+	token.MINUS:    SUM,
+	token.ASTERISK: PRODUCT,
+	token.SLASH:    PRODUCT,
+	token.PERCENT:  PRODUCT,
+	token.LPAREN:   CALL,
+	token.LBRACKET: INDEX,
+	token.DOT:      INDEX,
+}
+
+type (
+	prefixParseFn func() ast.Expression
+	infixParseFn  func(statement ast.Expression) ast.Expression
+)
+
+// DefaultMaxDepth は式の入れ子の深さの既定の上限
+const DefaultMaxDepth = 1000
+
+type Parser struct {
+	tokens   *lexer.TokenStream
+	errors   []string
+	contexts []errorContext // errors の各エラーが起きたときのトークン。Hints が使う
+
+	depth    int
+	maxDepth int
+	aborted  bool
+
+	tracer     Tracer
+	traceDepth int
+
+	curToken  token.Token
+	peekToken token.Token
+	comment   int // まだ文に付けていない最初のコメントの添字
+
+	// peekNewline は curToken と peekToken の間に NEWLINE があったかどうか。
+	// newlineTerminates が true (文の並びの中) なら、その改行で式を終える。
+	peekNewline       bool
+	newlineTerminates bool
+
+	prefixParseFns map[token.TokenType]prefixParseFn
+	infixParseFns  map[token.TokenType]infixParseFn
+	operators      map[string]int // operator 宣言や RegisterOperator で登録した演算子の優先順位
+
+	nodes nodeArena
+}
+
+func New(l *lexer.Lexer) *Parser {
+	p := &Parser{
+		tokens:   lexer.NewTokenStream(l),
+		errors:   []string{},
+		maxDepth: DefaultMaxDepth,
+
+		newlineTerminates: true,
+		operators:         map[string]int{},
+	}
+
+	p.prefixParseFns = make(map[token.TokenType]prefixParseFn)
+	p.registerPrefix(token.IDENT, p.parseIdentifier)
+	p.registerPrefix(token.INT, p.parseIntegerLiteral)
+	p.registerPrefix(token.BIGINT, p.parseBigIntLiteral)
+	p.registerPrefix(token.DECIMAL, p.parseDecimalLiteral)
+	// 浮動小数点数のリテラルも正確な十進数として扱う
+	p.registerPrefix(token.FLOAT, p.parseDecimalLiteral)
+	p.registerPrefix(token.STRING, p.parseStringLiteral)
+	p.registerPrefix(token.BANG, p.parsePrefixExpression)
+	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
+	p.registerPrefix(token.TRUE, p.parseBoolean)
+	p.registerPrefix(token.FALSE, p.parseBoolean)
+	p.registerPrefix(token.LPAREN, p.parseGroupedExpression)
+	p.registerPrefix(token.LBRACKET, p.parseArrayLiteral)
+	p.registerPrefix(token.LBRACE, p.parseHashLiteral)
+	p.registerPrefix(token.IF, p.parseIfExpression)
+	p.registerPrefix(token.FUNCTION, p.parseFunctionLiteral)
+	p.registerPrefix(token.MACRO, p.parseMacroLiteral)
+	p.registerPrefix(token.MATCH, p.parseMatchExpression)
+	p.registerPrefix(token.NEW, p.parseNewExpression)
+
+	p.infixParseFns = make(map[token.TokenType]infixParseFn)
+	p.registerInfix(token.PLUS, p.parseInfixExpression)
+	p.registerInfix(token.MINUS, p.parseInfixExpression)
+	p.registerInfix(token.ASTERISK, p.parseInfixExpression)
+	p.registerInfix(token.SLASH, p.parseInfixExpression)
+	p.registerInfix(token.PERCENT, p.parseInfixExpression)
+	p.registerInfix(token.EQ, p.parseInfixExpression)
+	p.registerInfix(token.NOT_EQ, p.parseInfixExpression)
+	p.registerInfix(token.LT, p.parseInfixExpression)
+	p.registerInfix(token.GT, p.parseInfixExpression)
+	p.registerInfix(token.QUESTION, p.parseConditionalExpression)
+	p.registerInfix(token.LPAREN, p.parseCallExpression)
+	p.registerInfix(token.LBRACKET, p.parseIndexExpression)
+	p.registerInfix(token.DOT, p.parseMemberExpression)
+	p.registerInfix(token.CUSTOM_INFIX, p.parseInfixExpression)
+
+	p.nextToken()
+	p.nextToken()
+
+	return p
+}
+
+func (p *Parser) nextToken() {
+	p.curToken = p.peekToken
+	p.peekToken = p.tokens.Next()
+
+	p.peekNewline = false
+	for p.peekToken.Type == token.NEWLINE {
+		p.peekNewline = true
+		p.peekToken = p.tokens.Next()
+	}
+}
+
+// peekEndsStatement は次のトークンの前の改行で式が終わるかどうかを返す
+// 次の行が . で始まるときは、メソッドの連鎖を行ごとに書けるよう前の式を続ける。
+func (p *Parser) peekEndsStatement() bool {
+	return p.peekNewline && p.newlineTerminates && !p.peekTokenIs(token.DOT)
+}
+
+// newlineMode は改行で式を終えるかどうかを切り替え、元に戻す関数を返す。
+// ブロックの中では改行で文を終え、括弧やハッシュの中では改行を無視する。
+func (p *Parser) newlineMode(terminates bool) func() {
+	saved := p.newlineTerminates
+	p.newlineTerminates = terminates
+	return func() { p.newlineTerminates = saved }
+}
+
+func (p *Parser) ParseProgram() *ast.Program {
+	program := &ast.Program{}
+	program.Statements = []ast.Statement{}
+	for p.curToken.Type != token.EOF {
+		p.tokens.Discard()
+		stmt := p.parseCommentedStatement()
+		if stmt != nil {
+			program.Statements = append(program.Statements, stmt)
+		}
+		p.nextToken()
+	}
+	program.EndComments = p.takeComments(p.curToken.Offset)
+	return program
+}
+
+// ParseExpression は入力全体を一つの式として解析する。let や return などの文、
+// 複数の式を含む入力はエラーにする。末尾のセミコロンは一つだけ許す。
+func (p *Parser) ParseExpression() ast.Expression {
+	switch p.curToken.Type {
+	case token.EOF:
+		p.addError("expected an expression, got end of input")
+		return nil
+	case token.LET, token.RETURN, token.DEFER, token.OPERATOR, token.CLASS:
+		p.addError(fmt.Sprintf("expected an expression, got %s statement", p.curToken.Literal))
+		return nil
+	}
+
+	exp := p.parseExpression(LOWEST)
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+	if !p.peekTokenIs(token.EOF) {
+		p.addError(fmt.Sprintf("unexpected %s after expression", p.peekToken.Type))
+		return nil
+	}
+	return exp
+}
+
+// ParseExpression は input を式として解析する。ルールやフィルタなど、式だけを評価したいホスト向け。
+func ParseExpression(input string) (ast.Expression, []string) {
+	p := New(lexer.New(input))
+	exp := p.ParseExpression()
+	if len(p.Errors()) > 0 {
+		return nil, p.Errors()
+	}
+	return exp, nil
+}
+
+func (p *Parser) parseStatement() ast.Statement {
+	defer p.untrace(p.trace("parseStatement"))
+
+	// 型付きの nil をそのまま返すと ast.Statement としては nil にならないので詰め替える
+	switch p.curToken.Type {
+	case token.LET:
+		if stmt := p.parseLetStatement(); stmt != nil {
+			return stmt
+		}
+	case token.RETURN:
+		if stmt := p.parseReturnStatement(); stmt != nil {
+			return stmt
+		}
+	case token.DEFER:
+		if stmt := p.parseDeferStatement(); stmt != nil {
+			return stmt
+		}
+	case token.OPERATOR:
+		if stmt := p.parseOperatorStatement(); stmt != nil {
+			return stmt
+		}
+	case token.CLASS:
+		if stmt := p.parseClassStatement(); stmt != nil {
+			return stmt
+		}
+	default:
+		if stmt := p.parseExpressionStatement(); stmt != nil {
+			return stmt
+		}
+	}
+	return nil
+}
+
+func (p *Parser) parseLetStatement() *ast.LetStatement {
+	defer p.untrace(p.trace("parseLetStatement"))
+
+	stmt := p.nodes.let()
+	stmt.Token, stmt.Doc = p.curToken, p.curToken.Doc
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+
+	stmt.Name = p.newIdentifier()
+
+	if !p.expectPeek(token.ASSIGN) {
+		return nil
+	}
+
+	p.nextToken()
+
+	stmt.Value = p.parseExpression(LOWEST)
+
+	// let の前のドキュメントコメントは束縛する関数のものとして扱う
+	if fl, ok := stmt.Value.(*ast.FunctionLiteral); ok && fl.Doc == "" {
+		fl.Doc = stmt.Doc
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+	stmt.End = tokenEnd(p.curToken)
+
+	return stmt
+}
+
+func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
+	defer p.untrace(p.trace("parseReturnStatement"))
+
+	stmt := &ast.ReturnStatement{Token: p.curToken}
+
+	p.nextToken()
+
+	stmt.ReturnValue = p.parseExpression(LOWEST)
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseDeferStatement() *ast.DeferStatement {
+	defer p.untrace(p.trace("parseDeferStatement"))
+
+	stmt := &ast.DeferStatement{Token: p.curToken}
+
+	p.nextToken()
+
+	stmt.Call = p.parseExpression(LOWEST)
+	if stmt.Call == nil {
+		return nil
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// tokenEnd はトークンの直後のバイト位置を返す。文字列の Literal には引用符が含まれない。
+func tokenEnd(tok token.Token) int {
+	if tok.Type == token.STRING {
+		return tok.Offset + len(tok.Literal) + 2
+	}
+	return tok.Offset + len(tok.Literal)
+}
+
+func (p *Parser) curTokenIs(t token.TokenType) bool {
+	return p.curToken.Type == t
+}
+
+func (p *Parser) peekTokenIs(t token.TokenType) bool {
+	return p.peekToken.Type == t
+}
+
+func (p *Parser) expectPeek(t token.TokenType) bool {
+	if p.peekTokenIs(t) {
+		p.nextToken()
+		return true
+	} else {
+		p.peekError(t)
+		return false
+	}
+}
+
+func (p *Parser) Errors() []string {
+	return p.errors
+}
+
+// SetMaxDepth は式の入れ子の深さの上限を設定する。これを超えると解析を打ち切ってエラーにする。
+func (p *Parser) SetMaxDepth(depth int) {
+	p.maxDepth = depth
+}
+
+func (p *Parser) addError(msg string) {
+	// 打ち切り後は入れ子の各段で起きる後続のエラーを報告しない
+	if p.aborted {
+		return
+	}
+	p.errors = append(p.errors, msg)
+	p.contexts = append(p.contexts, errorContext{cur: p.curToken, peek: p.peekToken, at: p.curToken})
+}
+
+// addPeekError は次のトークンについてのエラーを、そのトークンの位置で報告する
+func (p *Parser) addPeekError(msg string) {
+	p.addError(msg)
+	if !p.aborted {
+		p.contexts[len(p.contexts)-1].at = p.peekToken
+	}
+}
+
+func (p *Parser) abort(msg string) {
+	p.addError(msg)
+	p.aborted = true
+
+	for !p.curTokenIs(token.EOF) {
+		p.nextToken()
+	}
+}
+
+func (p *Parser) peekError(t token.TokenType) {
+	msg := fmt.Sprintf("expected next token to be %s, got %s instead", t, p.peekToken.Type)
+	p.addPeekError(msg)
+	if !p.aborted {
+		p.contexts[len(p.contexts)-1].expected = t
+	}
+}
+
+func (p *Parser) registerPrefix(tokenType token.TokenType, fn prefixParseFn) {
+	p.prefixParseFns[tokenType] = fn
+}
+
+func (p *Parser) registerInfix(tokenType token.TokenType, fn infixParseFn) {
+	p.infixParseFns[tokenType] = fn
+}
+
+func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
+	defer p.untrace(p.trace("parseExpressionStatement"))
+
+	stmt := p.nodes.statement()
+	stmt.Token = p.curToken
+
+	stmt.Expression = p.parseExpression(LOWEST)
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseExpression(precedence int) ast.Expression {
+	defer p.untrace(p.trace("parseExpression"))
+
+	p.depth++
+	defer func() { p.depth-- }()
+
+	if p.depth > p.maxDepth {
+		p.abort(fmt.Sprintf("expression nested too deeply (max depth %d)", p.maxDepth))
+		return nil
+	}
+
+	prefix := p.prefixParseFns[p.curToken.Type]
+	if prefix == nil {
+		if msg := lexer.NumberError(p.curToken.Literal); p.curTokenIs(token.ILLEGAL) && msg != "" {
+			p.addError(msg)
+			return nil
+		}
+		p.noPrefixParseFnError(p.curToken.Type)
+		return nil
+	}
+	leftExp := prefix()
+
+	p.mergeCustomOperator()
+	for !p.peekTokenIs(token.SEMICOLON) && !p.peekEndsStatement() && precedence < p.peekPrecedence() {
+		infix := p.infixParseFns[p.peekToken.Type]
+		if infix == nil {
+			return leftExp
+		}
+
+		p.nextToken()
+
+		leftExp = infix(leftExp)
+		p.mergeCustomOperator()
+	}
+
+	return leftExp
+}
+
+func (p *Parser) parseIdentifier() ast.Expression {
+	defer p.untrace(p.trace("parseIdentifier"))
+
+	return p.newIdentifier()
+}
+
+// newIdentifier は現在のトークンから名前を interning した識別子を作る
+func (p *Parser) newIdentifier() *ast.Identifier {
+	ident := p.nodes.identifier()
+	ident.Token, ident.Value, ident.Symbol = p.curToken, p.curToken.Literal, symbol.Intern(p.curToken.Literal)
+	return ident
+}
+
+func (p *Parser) parseIntegerLiteral() ast.Expression {
+	defer p.untrace(p.trace("parseIntegerLiteral"))
+
+	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
+
+	if err != nil {
+		msg := fmt.Sprintf("could not parse %q as integer", p.curToken.Literal)
+		p.addError(msg)
+		return nil
+	}
+
+	lit := p.nodes.integer()
+	lit.Token, lit.Value = p.curToken, value
+
+	return lit
+}
+
+func (p *Parser) parseBigIntLiteral() ast.Expression {
+	defer p.untrace(p.trace("parseBigIntLiteral"))
+
+	// 接頭辞は整数と同じく 0x、0o、0b と先頭の 0 (8 進数) を受け付ける
+	value, ok := new(big.Int).SetString(strings.TrimSuffix(p.curToken.Literal, "n"), 0)
+	if !ok {
+		msg := fmt.Sprintf("could not parse %q as integer", p.curToken.Literal)
+		p.addError(msg)
+		return nil
+	}
+
+	return &ast.BigIntLiteral{Token: p.curToken, Value: value}
+}
+
+const maxExponent = 1000
+
+func (p *Parser) parseDecimalLiteral() ast.Expression {
+	defer p.untrace(p.trace("parseDecimalLiteral"))
+
+	lit := &ast.DecimalLiteral{Token: p.curToken}
+
+	literal := strings.TrimSuffix(p.curToken.Literal, "d")
+	// 巨大な指数で桁数の大きすぎる値を作らないよう、指数に上限を設ける
+	if i := strings.IndexAny(literal, "eE"); i >= 0 {
+		if exponent, err := strconv.Atoi(literal[i+1:]); err != nil || exponent > maxExponent || exponent < -maxExponent {
+			p.addError(fmt.Sprintf("exponent of %s is out of range (at most %d)", p.curToken.Literal, maxExponent))
+			return nil
+		}
+	}
+
+	value, ok := new(big.Rat).SetString(literal)
+	if !ok {
+		msg := fmt.Sprintf("could not parse %q as decimal", p.curToken.Literal)
+		p.addError(msg)
+		return nil
+	}
+
+	lit.Value = value
+
+	return lit
+}
+
+func (p *Parser) parseStringLiteral() ast.Expression {
+	defer p.untrace(p.trace("parseStringLiteral"))
+
+	lit := p.nodes.string()
+	lit.Token, lit.Value = p.curToken, p.curToken.Literal
+	return lit
+}
+
+func (p *Parser) parseBoolean() ast.Expression {
+	defer p.untrace(p.trace("parseBoolean"))
+
+	return &ast.Boolean{Token: p.curToken, Value: p.curTokenIs(token.TRUE)}
+}
+
+func (p *Parser) parsePrefixExpression() ast.Expression {
+	defer p.untrace(p.trace("parsePrefixExpression"))
+
+	expression := p.nodes.prefix()
+	expression.Token, expression.Operator = p.curToken, p.curToken.Literal
+
+	p.nextToken()
+	expression.Right = p.parseExpression(PREFIX)
+
+	return expression
+}
+
+func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
+	defer p.untrace(p.trace("parseInfixExpression"))
+
+	expression := p.nodes.infix()
+	expression.Token, expression.Operator, expression.Left = p.curToken, p.curToken.Literal, left
+
+	precedence := p.curPrecedence()
+	p.nextToken()
+	expression.Right = p.parseExpression(precedence)
+
+	return expression
+}
+
+func (p *Parser) parseConditionalExpression(condition ast.Expression) ast.Expression {
+	defer p.untrace(p.trace("parseConditionalExpression"))
+
+	expression := &ast.ConditionalExpression{
+		Token:     p.curToken,
+		Condition: condition,
+	}
+
+	p.nextToken()
+	expression.Consequence = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.COLON) {
+		return nil
+	}
+
+	// 右結合にするため、else 側は LOWEST で解析して後続の ? を取り込む
+	p.nextToken()
+	expression.Alternative = p.parseExpression(LOWEST)
+
+	return expression
+}
+
+func (p *Parser) parseGroupedExpression() ast.Expression {
+	defer p.untrace(p.trace("parseGroupedExpression"))
+	defer p.newlineMode(false)()
+
+	p.nextToken()
+
+	exp := p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+	return exp
+}
+
+func (p *Parser) parseArrayLiteral() ast.Expression {
+	defer p.untrace(p.trace("parseArrayLiteral"))
+
+	array := &ast.ArrayLiteral{Token: p.curToken}
+
+	array.Elements = p.parseExpressionList(token.RBRACKET)
+
+	return array
+}
+
+func (p *Parser) parseHashLiteral() ast.Expression {
+	defer p.untrace(p.trace("parseHashLiteral"))
+	defer p.newlineMode(false)()
+
+	hash := &ast.HashLiteral{Token: p.curToken}
+	hash.Pairs = make(map[ast.Expression]ast.Expression)
+
+	for !p.peekTokenIs(token.RBRACE) {
+		p.nextToken()
+		key := p.parseExpression(LOWEST)
+
+		if !p.expectPeek(token.COLON) {
+			return nil
+		}
+
+		p.nextToken()
+		value := p.parseExpression(LOWEST)
+
+		hash.Pairs[key] = value
+
+		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+
+	return hash
+}
+
+func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
+	defer p.untrace(p.trace("parseIndexExpression"))
+	defer p.newlineMode(false)()
+
+	exp := &ast.IndexExpression{Token: p.curToken, Left: left}
+
+	p.nextToken()
+	exp.Index = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RBRACKET) {
+		return nil
+	}
+
+	return exp
+}
+
+func (p *Parser) parseMemberExpression(object ast.Expression) ast.Expression {
+	defer p.untrace(p.trace("parseMemberExpression"))
+
+	exp := &ast.MemberExpression{Token: p.curToken, Object: object}
+
+	// a.match や a.fn のようにキーワードもメンバー名に使える
+	if !p.peekTokenIs(token.IDENT) && token.LookupIdent(p.peekToken.Literal) != p.peekToken.Type {
+		p.addPeekError(fmt.Sprintf("expected a member name after ., got %s instead", p.peekToken.Type))
+		return nil
+	}
+	p.nextToken()
+	p.curToken.Type = token.IDENT
+	exp.Member = p.newIdentifier()
+
+	return exp
+}
+
+func (p *Parser) parseIfExpression() ast.Expression {
+	defer p.untrace(p.trace("parseIfExpression"))
+
+	expression := &ast.IfExpression{Token: p.curToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	p.nextToken()
+	restore := p.newlineMode(false)
+	expression.Condition = p.parseExpression(LOWEST)
+	restore()
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	expression.Consequence = p.parseBlockStatement()
+
+	if p.peekTokenIs(token.ELSE) {
+		p.nextToken()
+
+		if !p.expectPeek(token.LBRACE) {
+			return nil
+		}
+
+		expression.Alternative = p.parseBlockStatement()
+	}
+
+	return expression
+}
+
+func (p *Parser) parseFunctionLiteral() ast.Expression {
+	defer p.untrace(p.trace("parseFunctionLiteral"))
+
+	lit := &ast.FunctionLiteral{Token: p.curToken, Doc: p.curToken.Doc}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	lit.Parameters = p.parseFunctionParameters()
+
+	// requires と ensures はキーワードではなく、引数リストの直後でだけ意味を持つ
+	for p.peekTokenIs(token.IDENT) && (p.peekToken.Literal == "requires" || p.peekToken.Literal == "ensures") {
+		p.nextToken()
+		clause := p.curToken.Literal
+		p.nextToken()
+		cond := p.parseContractCondition()
+		if cond == nil {
+			return nil
+		}
+		if clause == "requires" {
+			lit.Requires = append(lit.Requires, cond)
+		} else {
+			lit.Ensures = append(lit.Ensures, cond)
+		}
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	lit.Body = p.parseBlockStatement()
+
+	return lit
+}
+
+// parseContractCondition は requires や ensures の条件を解析する。本体の { の前の改行で式を終えないようにする。
+func (p *Parser) parseContractCondition() ast.Expression {
+	defer p.newlineMode(false)()
+	return p.parseExpression(LOWEST)
+}
+
+func (p *Parser) parseBlockStatement() *ast.BlockStatement {
+	defer p.untrace(p.trace("parseBlockStatement"))
+	defer p.newlineMode(true)()
+
+	block := &ast.BlockStatement{Token: p.curToken}
+	block.Statements = []ast.Statement{}
+
+	p.nextToken()
+
+	for !p.curTokenIs(token.RBRACE) && !p.curTokenIs(token.EOF) {
+		stmt := p.parseCommentedStatement()
+		if stmt != nil {
+			block.Statements = append(block.Statements, stmt)
+		}
+		p.nextToken()
+	}
+	block.EndComments = p.takeComments(p.curToken.Offset)
+
+	return block
+}
+
+func (p *Parser) parseFunctionParameters() []*ast.Identifier {
+	defer p.untrace(p.trace("parseFunctionParameters"))
+
+	identifiers := []*ast.Identifier{}
+
+	if p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+		return identifiers
+	}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+
+	ident := p.newIdentifier()
+	identifiers = append(identifiers, ident)
+
+	for p.peekTokenIs(token.COMMA) {
+		p.nextToken()
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		ident := p.newIdentifier()
+		identifiers = append(identifiers, ident)
+	}
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	return identifiers
+}
+
+func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
+	defer p.untrace(p.trace("parseCallExpression"))
+
+	exp := p.nodes.call()
+	exp.Token, exp.Function = p.curToken, function
+	exp.Arguments = p.parseExpressionList(token.RPAREN)
+	return exp
+}
+
+func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
+	defer p.untrace(p.trace("parseExpressionList"))
+	defer p.newlineMode(false)()
+
+	list := []ast.Expression{}
+
+	if p.peekTokenIs(end) {
+		p.nextToken()
+		return list
+	}
+
+	p.nextToken()
+	list = append(list, p.parseExpression(LOWEST))
+
+	for p.peekTokenIs(token.COMMA) {
+		p.nextToken()
+		p.nextToken()
+		list = append(list, p.parseExpression(LOWEST))
+	}
+
+	if !p.expectPeek(end) {
+		return nil
+	}
+
+	return list
+}
+
+func (p *Parser) parseMacroLiteral() ast.Expression {
+	defer p.untrace(p.trace("parseMacroLiteral"))
+
+	lit := &ast.MacroLiteral{Token: p.curToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	lit.Parameters = p.parseFunctionParameters()
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	lit.Body = p.parseBlockStatement()
+
+	return lit
+}
+
+func (p *Parser) parseMatchExpression() ast.Expression {
+	defer p.untrace(p.trace("parseMatchExpression"))
+	defer p.newlineMode(false)()
+
+	expression := &ast.MatchExpression{Token: p.curToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	p.nextToken()
+	expression.Subject = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	expression.Arms = []*ast.MatchArm{}
+
+	for !p.peekTokenIs(token.RBRACE) {
+		p.nextToken()
+		pattern := p.parsePattern()
+		if pattern == nil {
+			return nil
+		}
+
+		if !p.expectPeek(token.ARROW) {
+			return nil
+		}
+
+		p.nextToken()
+		body := p.parseExpression(LOWEST)
+
+		expression.Arms = append(expression.Arms, &ast.MatchArm{Pattern: pattern, Body: body})
+
+		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+
+	return expression
+}
+
+func (p *Parser) parsePattern() ast.Pattern {
+	defer p.untrace(p.trace("parsePattern"))
+
+	switch p.curToken.Type {
+	case token.IDENT:
+		if p.curToken.Literal == "_" {
+			return &ast.WildcardPattern{Token: p.curToken}
+		}
+		return &ast.BindingPattern{Token: p.curToken, Name: p.newIdentifier()}
+	case token.INT, token.BIGINT, token.DECIMAL, token.FLOAT, token.STRING, token.TRUE, token.FALSE, token.MINUS:
+		pattern := &ast.LiteralPattern{Token: p.curToken}
+		pattern.Value = p.prefixParseFns[p.curToken.Type]()
+		if pattern.Value == nil {
+			return nil
+		}
+		return pattern
+	case token.LBRACKET:
+		return p.parseArrayPattern()
+	case token.LBRACE:
+		return p.parseHashPattern()
+	default:
+		msg := fmt.Sprintf("unexpected %s in match pattern", p.curToken.Type)
+		p.addError(msg)
+		return nil
+	}
+}
+
+func (p *Parser) parseArrayPattern() ast.Pattern {
+	defer p.untrace(p.trace("parseArrayPattern"))
+
+	pattern := &ast.ArrayPattern{Token: p.curToken}
+	pattern.Elements = []ast.Pattern{}
+
+	for !p.peekTokenIs(token.RBRACKET) {
+		p.nextToken()
+
+		if p.curTokenIs(token.ELLIPSIS) {
+			if !p.expectPeek(token.IDENT) {
+				return nil
+			}
+			pattern.Rest = p.newIdentifier()
+			break
+		}
+
+		element := p.parsePattern()
+		if element == nil {
+			return nil
+		}
+		pattern.Elements = append(pattern.Elements, element)
+
+		if !p.peekTokenIs(token.RBRACKET) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.RBRACKET) {
+		return nil
+	}
+
+	return pattern
+}
+
+func (p *Parser) parseHashPattern() ast.Pattern {
+	defer p.untrace(p.trace("parseHashPattern"))
+
+	pattern := &ast.HashPattern{Token: p.curToken}
+	pattern.Pairs = []ast.HashPatternPair{}
+
+	for !p.peekTokenIs(token.RBRACE) {
+		p.nextToken()
+
+		var key ast.Expression
+		switch p.curToken.Type {
+		case token.STRING, token.INT, token.TRUE, token.FALSE:
+			key = p.prefixParseFns[p.curToken.Type]()
+		default:
+			msg := fmt.Sprintf("unexpected %s as hash pattern key", p.curToken.Type)
+			p.addError(msg)
+			return nil
+		}
+
+		if !p.expectPeek(token.COLON) {
+			return nil
+		}
+
+		p.nextToken()
+		value := p.parsePattern()
+		if value == nil {
+			return nil
+		}
+
+		pattern.Pairs = append(pattern.Pairs, ast.HashPatternPair{Key: key, Value: value})
+
+		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+
+	return pattern
+}
+
+func (p *Parser) noPrefixParseFnError(t token.TokenType) {
+	msg := fmt.Sprintf("no prefix parse function for %s found", t)
+	p.addError(msg)
+}
+
+func (p *Parser) peekPrecedence() int {
+	if p.peekToken.Type == token.CUSTOM_INFIX {
+		return p.operators[p.peekToken.Literal]
+	}
+	if p, ok := precedences[p.peekToken.Type]; ok {
+		return p
+	}
+	return LOWEST
+}
+
+func (p *Parser) curPrecedence() int {
+	if p.curToken.Type == token.CUSTOM_INFIX {
+		return p.operators[p.curToken.Literal]
+	}
+	if p, ok := precedences[p.curToken.Type]; ok {
+		return p
+	}
+	return LOWEST
+}
@@ -0,0 +1,1757 @@
+package parser
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/al-keio/monkey-go/ast"
+	"github.com/al-keio/monkey-go/internal/lexer"
+)
+
+func TestLetStatements(t *testing.T) {
+	tests := []struct {
+		input              string
+		expectedIdentifier string
+		expectedValue      interface{}
+	}{
+		{"let x = 5;", "x", 5},
+		{"let y = true;", "y", true},
+		{"let foobar = y;", "foobar", "y"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statements. got=%d", len(program.Statements))
+		}
+
+		stmt := program.Statements[0]
+		if !testLetStatement(t, stmt, tt.expectedIdentifier) {
+			return
+		}
+
+		val := stmt.(*ast.LetStatement).Value
+		if !testLiteralExpression(t, val, tt.expectedValue) {
+			return
+		}
+	}
+}
+
+func TestReturnStatements(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedValue interface{}
+	}{
+		{"return 5", 5},
+		{"return true", true},
+		{"return x", "x"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		if program == nil {
+			t.Fatalf("ParseProgram() return nil")
+		}
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statements. got=%d", len(program.Statements))
+		}
+
+		stmt := program.Statements[0]
+		if stmt.TokenLiteral() != "return" {
+			t.Errorf("s.TokenLiteral is not 'return'. got=%q", stmt.TokenLiteral())
+			return
+		}
+
+		val := stmt.(*ast.ReturnStatement).ReturnValue
+		if !testLiteralExpression(t, val, tt.expectedValue) {
+			return
+		}
+	}
+}
+
+func TestDeferStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"defer close(f)", "defer close(f);"},
+		{"defer puts(1);", "defer puts(1);"},
+		{"fn() { defer a\nb }", "fn() defer a;b"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if got := program.String(); got != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, got)
+		}
+	}
+}
+
+func TestOperatorDeclarations(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"operator <+> (a, b) { a + b }; 1 <+> 2 * 3", "operator <+> fn(a, b) (a + b);(1 <+> (2 * 3))"},
+		{"operator <+> product (a, b) { a + b }; 1 <+> 2 * 3", "operator <+> product fn(a, b) (a + b);((1 <+> 2) * 3)"},
+		{"operator <=> lessgreater (a, b) { 0 }; a + 1 <=> b", "operator <=> lessgreater fn(a, b) 0;((a + 1) <=> b)"},
+		{"operator && equals (a, b) { a }; a == b && c", "operator && equals fn(a, b) a;((a == b) && c)"},
+		{"operator <+> (a, b) { a }; operator <+>> (a, b) { b }; x <+>> y <+> z", "operator <+> fn(a, b) a;operator <+>> fn(a, b) b;((x <+>> y) <+> z)"},
+		{"operator <+> (a, b) { a <+> b }", "operator <+> fn(a, b) (a <+> b);"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if got := program.String(); got != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, got)
+		}
+	}
+}
+
+func TestOperatorDeclarationErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"operator + (a, b) { a }", "cannot redefine built-in operator +"},
+		{"operator (a, b) { a }", "expected operator symbol after operator, got ( instead"},
+		{"operator <+> highest (a, b) { a }", "unknown precedence highest for operator <+> (want equals, lessgreater, sum or product)"},
+		{"operator <+> (a) { a }", "operator <+> must take 2 parameters, got 1"},
+		{"1 <+> 2", "no prefix parse function for + found"},
+		// 空白で区切った記号は宣言した演算子としてまとめない
+		{"operator <+> (a, b) { a }; x < + y", "no prefix parse function for + found"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		if errors := p.Errors(); len(errors) == 0 || errors[0] != tt.expected {
+			t.Errorf("%q: expected error %q, got %q", tt.input, tt.expected, errors)
+		}
+	}
+}
+
+func TestRegisterOperator(t *testing.T) {
+	p := New(lexer.New("a ** b * c"))
+	if err := p.RegisterOperator("**", PRODUCT+1); err == nil {
+		t.Errorf("expected an error for a precedence above PRODUCT")
+	}
+	if err := p.RegisterOperator("**", PRODUCT); err != nil {
+		t.Fatalf("RegisterOperator returned %s", err)
+	}
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if got, expected := program.String(), "((a ** b) * c)"; got != expected {
+		t.Errorf("expected=%q, got=%q", expected, got)
+	}
+	if got := p.Operators(); len(got) != 1 || got["**"] != PRODUCT {
+		t.Errorf("wrong operators: %v", got)
+	}
+}
+
+func TestClassStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"class Point { init(x, y) { {\"x\": x, \"y\": y} } norm() { self.x + self.y } }", "class Point { init(x, y) {x:x, y:y}; norm() ((self.x) + (self.y)) }"},
+		{"class Dog extends Animal {\n  speak() { \"woof\" }\n\n  name() { \"dog\" };\n}", "class Dog extends Animal { speak() woof; name() dog }"},
+		{"class Empty {}", "class Empty {  }"},
+		{"class Checked { init(x) requires x > 0 { {} } }", "class Checked { init(x) requires (x > 0) {} }"},
+		{"new Point(1, 2)", "new Point(1, 2)"},
+		{"new shapes.Point()", "new (shapes.Point)()"},
+		{"new Point(1).norm()", "(new Point(1).norm)()"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if got := program.String(); got != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, got)
+		}
+	}
+}
+
+func TestClassStatementErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"class { }", "expected next token to be IDENT, got { instead"},
+		{"class A { 1 }", "expected method name in class A, got INT instead"},
+		{"class A { f() { 1 } f() { 2 } }", "method f is defined twice in class A"},
+		{"class A { f() { 1 }", "unterminated class A"},
+		{"new Point", "expected next token to be (, got EOF instead"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		if errors := p.Errors(); len(errors) == 0 || errors[0] != tt.expected {
+			t.Errorf("%q: expected error %q, got %q", tt.input, tt.expected, errors)
+		}
+	}
+}
+
+func TestFunctionContracts(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"fn(x) requires x > 0 { x }", "fn(x) requires (x > 0) x"},
+		{"fn(x) requires x > 0 requires x < 10 ensures result > x { x * 2 }", "fn(x) requires (x > 0) requires (x < 10) ensures (result > x) (x * 2)"},
+		{"fn(x) ensures result\n{ x }", "fn(x) ensures result x"},
+		{"let requires = 1; requires", "let requires = 1;requires"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if got := program.String(); got != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, got)
+		}
+	}
+}
+
+func TestIdentifierExpression(t *testing.T) {
+	input := "foobar;"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	if len(program.Statements) != 1 {
+		t.Fatalf("program has not enough statements. got=%d", len(program.Statements))
+	}
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T", program.Statements[0])
+	}
+	testLiteralExpression(t, stmt.Expression, "foobar")
+}
+
+func TestIntegerLiteralExpression(t *testing.T) {
+	input := "5;"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program has not enough statements. got=%d", len(program.Statements))
+	}
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T", program.Statements[0])
+	}
+	testLiteralExpression(t, stmt.Expression, 5)
+}
+
+func TestDecimalLiteralExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"12.34d;", "617/50"},
+		{"5d;", "5"},
+		{"0.10d;", "1/10"},
+		{"3.14;", "157/50"},
+		{"0.5;", "1/2"},
+		{"1e-3;", "1/1000"},
+		{"2.5E+2;", "250"},
+		{"1e3d;", "1000"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		literal, ok := stmt.Expression.(*ast.DecimalLiteral)
+		if !ok {
+			t.Fatalf("exp not *ast.DecimalLiteral. got=%T", stmt.Expression)
+		}
+		if literal.Value.RatString() != tt.expected {
+			t.Errorf("literal.Value not %s. got=%s", tt.expected, literal.Value.RatString())
+		}
+		if literal.String() != strings.TrimSuffix(tt.input, ";") {
+			t.Errorf("literal.String() not %q. got=%q", tt.input, literal.String())
+		}
+	}
+}
+
+func TestBigIntLiteralExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"123456789012345678901234567890n;", "123456789012345678901234567890"},
+		{"5n;", "5"},
+		{"0xFFFFFFFFFFFFFFFFFFn;", "4722366482869645213695"},
+		{"0b101n;", "5"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		literal, ok := stmt.Expression.(*ast.BigIntLiteral)
+		if !ok {
+			t.Fatalf("exp not *ast.BigIntLiteral. got=%T", stmt.Expression)
+		}
+		if literal.Value.String() != tt.expected {
+			t.Errorf("literal.Value not %s. got=%s", tt.expected, literal.Value)
+		}
+		if literal.String() != strings.TrimSuffix(tt.input, ";") {
+			t.Errorf("literal.String() not %q. got=%q", tt.input, literal.String())
+		}
+	}
+}
+
+func TestFloatLiteralErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1e1001", "exponent of 1e1001 is out of range (at most 1000)"},
+		{"1.5e-99999999999999999999", "exponent of 1.5e-99999999999999999999 is out of range (at most 1000)"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 || errors[0] != tt.expected {
+			t.Errorf("%q: expected error %q, got %q", tt.input, tt.expected, errors)
+		}
+	}
+}
+
+func TestPrefixedIntegerErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"0o789", "invalid digit '8' in octal literal 0o789"},
+		{"let x = 0b102;", "invalid digit '2' in binary literal 0b102"},
+		{"0xFG + 1", "invalid digit 'G' in hexadecimal literal 0xFG"},
+		{"0x", "hexadecimal literal 0x has no digits"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 || errors[0] != tt.expected {
+			t.Errorf("%q: expected error %q, got %q", tt.input, tt.expected, errors)
+		}
+	}
+}
+
+func TestStringLiteralExpression(t *testing.T) {
+	input := `"hello world"`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	literal, ok := stmt.Expression.(*ast.StringLiteral)
+	if !ok {
+		t.Fatalf("exp is not ast.StringLiteral. got=%T", stmt.Expression)
+	}
+
+	if literal.Value != "hello world" {
+		t.Errorf("literal.Value is not %q. got=%q", "hello world", literal.Value)
+	}
+}
+
+func TestBooleanExpression(t *testing.T) {
+	input := "true;"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	if len(program.Statements) != 1 {
+		t.Fatalf("program has not enough statements. got=%d", len(program.Statements))
+	}
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T", program.Statements[0])
+	}
+	testLiteralExpression(t, stmt.Expression, true)
+}
+
+func TestParsingArrayLiterals(t *testing.T) {
+	input := "[1, 2 * 2, 3 + 3]"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	array, ok := stmt.Expression.(*ast.ArrayLiteral)
+	if !ok {
+		t.Fatalf("exp is not ast.ArrayLiteral. got=%T", stmt.Expression)
+	}
+
+	if len(array.Elements) != 3 {
+		t.Fatalf("len(array.Elements) is not 3. got=%d", len(array.Elements))
+	}
+
+	testIntegerLiteral(t, array.Elements[0], 1)
+	testInfixExpression(t, array.Elements[1], 2, "*", 2)
+	testInfixExpression(t, array.Elements[2], 3, "+", 3)
+
+}
+
+func TestParsingHashLiteralsStringKeys(t *testing.T) {
+	input := `{"one": 1, "two": 2, "three": 3}`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	hash, ok := stmt.Expression.(*ast.HashLiteral)
+	if !ok {
+		t.Fatalf("exp is not ast.HashLiteral. got=%T", stmt.Expression)
+	}
+
+	if len(hash.Pairs) != 3 {
+		t.Fatalf("len(hash.Pairs) is not 3. got=%d", len(hash.Pairs))
+	}
+
+	expected := map[string]int64{
+		"one":   1,
+		"two":   2,
+		"three": 3,
+	}
+
+	for key, value := range hash.Pairs {
+		literal, ok := key.(*ast.StringLiteral)
+		if !ok {
+			t.Errorf("key is not ast.StringLiteral. got=%T", key)
+		}
+
+		expectedValue := expected[literal.String()]
+
+		testIntegerLiteral(t, value, expectedValue)
+	}
+}
+
+func TestParsingEmptyHashLiterals(t *testing.T) {
+	input := `{}`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	hash, ok := stmt.Expression.(*ast.HashLiteral)
+	if !ok {
+		t.Fatalf("exp is not ast.HashLiteral. got=%T", stmt.Expression)
+	}
+
+	if len(hash.Pairs) != 0 {
+		t.Fatalf("len(hash.Pairs) is not 0. got=%d", len(hash.Pairs))
+	}
+}
+
+func TestParsingHashLiteralsWithExpressions(t *testing.T) {
+	input := `{"one": 0 + 1, "two": 10 - 8, "three": 15 / 5}`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	hash, ok := stmt.Expression.(*ast.HashLiteral)
+	if !ok {
+		t.Fatalf("exp is not ast.HashLiteral. got=%T", stmt.Expression)
+	}
+
+	if len(hash.Pairs) != 3 {
+		t.Fatalf("len(hash.Pairs) is not 3. got=%d", len(hash.Pairs))
+	}
+
+	tests := map[string]func(ast.Expression){
+		"one": func(e ast.Expression) {
+			testInfixExpression(t, e, 0, "+", 1)
+		},
+		"two": func(e ast.Expression) {
+			testInfixExpression(t, e, 10, "-", 8)
+		},
+		"three": func(e ast.Expression) {
+			testInfixExpression(t, e, 15, "/", 5)
+		},
+	}
+
+	for key, value := range hash.Pairs {
+		literal, ok := key.(*ast.StringLiteral)
+		if !ok {
+			t.Errorf("key is not ast.StringLiteral. got=%T", key)
+		}
+
+		testFunc, ok := tests[literal.String()]
+
+		if !ok {
+			t.Errorf("No test function for key %q found", literal.String())
+		}
+
+		testFunc(value)
+	}
+}
+
+func TestParsingIndexExpressions(t *testing.T) {
+	input := "myArray[1 + 1]"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	indexExp, ok := stmt.Expression.(*ast.IndexExpression)
+	if !ok {
+		t.Fatalf("exp is not ast.IndexExpression. got=%T", stmt.Expression)
+	}
+
+	if !testIdentifier(t, indexExp.Left, "myArray") {
+		return
+	}
+
+	if !testInfixExpression(t, indexExp.Index, 1, "+", 1) {
+		return
+	}
+}
+
+func TestParsingPrefixExpressions(t *testing.T) {
+	prefixTests := []struct {
+		input      string
+		operator   string
+		rightValue interface{}
+	}{
+		{"!5", "!", 5},
+		{"-15", "-", 15},
+		{"!true", "!", true},
+		{"!false", "!", false},
+	}
+
+	for _, tt := range prefixTests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain %d statements. got=%d", 1, len(program.Statements))
+		}
+
+		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T", program.Statements[0])
+		}
+
+		testPrefixExpression(t, stmt.Expression, tt.operator, tt.rightValue)
+
+	}
+}
+
+func TestParsingInfixExpressions(t *testing.T) {
+	infixTests := []struct {
+		input      string
+		leftValue  interface{}
+		operator   string
+		rightValue interface{}
+	}{
+		{"5 + 5;", 5, "+", 5},
+		{"5 - 5;", 5, "-", 5},
+		{"5 * 5;", 5, "*", 5},
+		{"5 / 5;", 5, "/", 5},
+		{"5 % 5;", 5, "%", 5},
+		{"5 > 5;", 5, ">", 5},
+		{"5 < 5;", 5, "<", 5},
+		{"5 == 5;", 5, "==", 5},
+		{"5 != 5;", 5, "!=", 5},
+		{"true == true", true, "==", true},
+		{"true != false", true, "!=", false},
+		{"false == false", false, "==", false},
+	}
+
+	for _, tt := range infixTests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain %d statements. got=%d", 1, len(program.Statements))
+		}
+
+		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T", program.Statements)
+		}
+
+		testInfixExpression(t, stmt.Expression, tt.leftValue, tt.operator, tt.rightValue)
+	}
+}
+
+func TestOperatorPrecedenceParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"-a * b",
+			"((-a) * b)",
+		},
+		{
+			"!-a",
+			"(!(-a))",
+		},
+		{
+			"a + b + c",
+			"((a + b) + c)",
+		},
+		{
+			"a + b - c",
+			"((a + b) - c)",
+		},
+		{
+			"a * b * c",
+			"((a * b) * c)",
+		},
+		{
+			"a * b / c",
+			"((a * b) / c)",
+		},
+		{
+			"a + b / c",
+			"(a + (b / c))",
+		},
+		{
+			"a + b * c + d / e - f",
+			"(((a + (b * c)) + (d / e)) - f)",
+		},
+		{
+			"3 + 4; -5 * 5",
+			"(3 + 4)((-5) * 5)",
+		},
+		{
+			"5 > 4 == 3 < 4",
+			"((5 > 4) == (3 < 4))",
+		},
+		{
+			"5 < 4 != 3 < 4",
+			"((5 < 4) != (3 < 4))",
+		},
+		{
+			"3 + 4 * 5 == 3 * 1 + 4 * 5",
+			"((3 + (4 * 5)) == ((3 * 1) + (4 * 5)))",
+		},
+		{
+			"true",
+			"true",
+		},
+		{
+			"false",
+			"false",
+		},
+		{
+			"a * [1, 2, 3, 4][b * c] * d",
+			"((a * ([1, 2, 3, 4][(b * c)])) * d)",
+		},
+		{
+			"add(a * b[2], b[1], 2 * [1, 2][1])",
+			"add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))",
+		},
+		{
+			"-a.b(c).d[1]",
+			"(-(((a.b)(c).d)[1]))",
+		},
+		{
+			"3 > 5 == false",
+			"((3 > 5) == false)",
+		},
+		{
+			"3 < 5 == true",
+			"((3 < 5) == true)",
+		},
+		{
+			"1 + (2 + 3) + 4",
+			"((1 + (2 + 3)) + 4)",
+		},
+		{
+			"(5 + 5) * 2",
+			"((5 + 5) * 2)",
+		},
+		{
+			"2 / (5 + 5)",
+			"(2 / (5 + 5))",
+		},
+		{
+			"-(5 + 5)",
+			"(-(5 + 5))",
+		},
+		{
+			"!(true == true)",
+			"(!(true == true))",
+		},
+		{
+			"a + add(b * c) + d",
+			"((a + add((b * c))) + d)",
+		},
+		{
+			"add(a, b, 1, 2 * 3, 4 + 5, add(6, 7 * 8))",
+			"add(a, b, 1, (2 * 3), (4 + 5), add(6, (7 * 8)))",
+		},
+		{
+			"add(a + b + c * d / f + g)",
+			"add((((a + b) + ((c * d) / f)) + g))",
+		},
+		{
+			"a ? b : c",
+			"(a ? b : c)",
+		},
+		{
+			"a < b ? a + 1 : b * 2",
+			"((a < b) ? (a + 1) : (b * 2))",
+		},
+		{
+			"a ? b : c ? d : e",
+			"(a ? b : (c ? d : e))",
+		},
+		{
+			"a ? b ? c : d : e",
+			"(a ? (b ? c : d) : e)",
+		},
+		{
+			"f(a ? b : c, d)",
+			"f((a ? b : c), d)",
+		},
+		{
+			"-a == b ? c : d",
+			"(((-a) == b) ? c : d)",
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		actual := program.String()
+		if actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+}
+
+func TestIfExpression(t *testing.T) {
+	input := `if (x < y) { x }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d", 1, len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T", program.Statements)
+	}
+
+	exp, ok := stmt.Expression.(*ast.IfExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.IfExpression. got=%T", stmt.Expression)
+	}
+
+	if !testInfixExpression(t, exp.Condition, "x", "<", "y") {
+		return
+	}
+
+	if len(exp.Consequence.Statements) != 1 {
+		t.Errorf("consequence is not 1 statements. got=%d", len(exp.Consequence.Statements))
+	}
+
+	consequence, ok := exp.Consequence.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("Statements[0] is not ast.ExpressionStatement. got=%T", exp.Consequence.Statements[0])
+	}
+
+	if !testIdentifier(t, consequence.Expression, "x") {
+		return
+	}
+
+	if exp.Alternative != nil {
+		t.Errorf("exp.Alternative was not nil. got=%+v", exp.Alternative)
+	}
+}
+
+func TestIfElseExpression(t *testing.T) {
+	input := `if (x < y) { x } else { y }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d", 1, len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T", program.Statements)
+	}
+
+	exp, ok := stmt.Expression.(*ast.IfExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.IfExpression. got=%T", stmt.Expression)
+	}
+
+	if !testInfixExpression(t, exp.Condition, "x", "<", "y") {
+		return
+	}
+
+	if len(exp.Consequence.Statements) != 1 {
+		t.Errorf("consequence is not 1 statements. got=%d", len(exp.Consequence.Statements))
+	}
+
+	consequence, ok := exp.Consequence.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("Statements[0] is not ast.ExpressionStatement. got=%T", exp.Consequence.Statements[0])
+	}
+
+	if !testIdentifier(t, consequence.Expression, "x") {
+		return
+	}
+
+	if exp.Alternative == nil {
+		t.Fatalf("exp.Alternative was nil.")
+	}
+
+	if len(exp.Alternative.Statements) != 1 {
+		t.Errorf("alternative is not 1 statements. got=%d", len(exp.Alternative.Statements))
+	}
+
+	alternative, ok := exp.Alternative.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("Statements[0] is not ast.ExpressionStatement. got=%T", exp.Alternative.Statements[0])
+	}
+
+	if !testIdentifier(t, alternative.Expression, "y") {
+		return
+	}
+
+}
+
+func TestFunctionLiteralParsing(t *testing.T) {
+	input := `fn(x, y) { x + y; }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statements. got=%T", 1, len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T", program.Statements[0])
+	}
+
+	function, ok := stmt.Expression.(*ast.FunctionLiteral)
+	if !ok {
+		t.Fatalf("stmt.Exression is not ast.FunctionLiteral. got=%T", stmt.Expression)
+	}
+
+	if len(function.Parameters) != 2 {
+		t.Fatalf("function literal parameters wrong. want 2, got=%T", len(function.Parameters))
+	}
+
+	testLiteralExpression(t, function.Parameters[0], "x")
+	testLiteralExpression(t, function.Parameters[1], "y")
+
+	if len(function.Body.Statements) != 1 {
+		t.Fatalf("function.Body.Statements does not contain 1 statements. got=%T", function.Body.Statements)
+	}
+
+	bodyStmt, ok := function.Body.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("function body stmt is not ast.ExpressionStatement. got=%T", function.Body.Statements[0])
+	}
+
+	testInfixExpression(t, bodyStmt.Expression, "x", "+", "y")
+}
+
+func TestFunctionParameterParsing(t *testing.T) {
+	tests := []struct {
+		input        string
+		expectParams []string
+	}{
+		{
+			input: "fn() {};", expectParams: []string{},
+		},
+		{
+			input: "fn(x) {};", expectParams: []string{"x"},
+		},
+		{
+			input: "fn(x, y, z) {};", expectParams: []string{"x", "y", "z"},
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		function := stmt.Expression.(*ast.FunctionLiteral)
+
+		if len(function.Parameters) != len(tt.expectParams) {
+			t.Errorf("length parameters wrong. want %d, got=%d", len(tt.expectParams), len(function.Parameters))
+		}
+
+		for i, ident := range tt.expectParams {
+			testLiteralExpression(t, function.Parameters[i], ident)
+		}
+	}
+
+	for _, input := range []string{"fn(1) {};", "fn(x, >) {};", "macro(x, 0) {};"} {
+		p := New(lexer.New(input))
+		p.ParseProgram()
+		if len(p.Errors()) == 0 {
+			t.Errorf("expected parser errors for %q", input)
+		}
+	}
+}
+
+func TestCallExpressionParsing(t *testing.T) {
+	input := `add(1, 2 * 3, 4 + 5)`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statements. got=%T", 1, len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T", program.Statements[0])
+	}
+
+	exp, ok := stmt.Expression.(*ast.CallExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.ast.CallExpression. got=%T", stmt.Expression)
+	}
+
+	if !testIdentifier(t, exp.Function, "add") {
+		return
+	}
+
+	if len(exp.Arguments) != 3 {
+		t.Fatalf("wrong length of arguments. got=%d", len(exp.Arguments))
+	}
+
+	testLiteralExpression(t, exp.Arguments[0], 1)
+	testInfixExpression(t, exp.Arguments[1], 2, "*", 3)
+	testInfixExpression(t, exp.Arguments[2], 4, "+", 5)
+}
+
+func TestCallParameterParsing(t *testing.T) {
+	tests := []struct {
+		input      string
+		expectArgs []string
+	}{
+		{
+			input: "add();", expectArgs: []string{},
+		},
+		{
+			input: "add(x);", expectArgs: []string{"x"},
+		},
+		{
+			input: "add(x, y, z);", expectArgs: []string{"x", "y", "z"},
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		exp := stmt.Expression.(*ast.CallExpression)
+
+		if len(exp.Arguments) != len(tt.expectArgs) {
+			t.Errorf("length parameters wrong. want %d, got=%d", len(tt.expectArgs), len(exp.Arguments))
+		}
+
+		for i, ident := range tt.expectArgs {
+			testLiteralExpression(t, exp.Arguments[i], ident)
+		}
+	}
+}
+
+func TestPostfixChainParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"f(x)[0].name(y)[2]", "(((f(x)[0]).name)(y)[2])"},
+		{"a.b.c", "((a.b).c)"},
+		{"a[0][1](2)(3)", "((a[0])[1])(2)(3)"},
+		{"f()()[0]", "(f()()[0])"},
+		{"a.match(1).fn", "((a.match)(1).fn)"},
+		{"-f(x)[0].y * 2", "((-((f(x)[0]).y)) * 2)"},
+		{"[1, 2][0].z", "(([1, 2][0]).z)"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("%q: expected=%q, got=%q", tt.input, tt.expected, program.String())
+		}
+	}
+
+	// 外側から順に Index, Call, Member, Index, Call の形になる
+	p := New(lexer.New("f(x)[0].name(y)[2]"))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	outer := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.IndexExpression)
+	call, ok := outer.Left.(*ast.CallExpression)
+	if !ok {
+		t.Fatalf("outer.Left is not ast.CallExpression. got=%T", outer.Left)
+	}
+	testLiteralExpression(t, call.Arguments[0], "y")
+	member, ok := call.Function.(*ast.MemberExpression)
+	if !ok {
+		t.Fatalf("call.Function is not ast.MemberExpression. got=%T", call.Function)
+	}
+	testIdentifier(t, member.Member, "name")
+	index, ok := member.Object.(*ast.IndexExpression)
+	if !ok {
+		t.Fatalf("member.Object is not ast.IndexExpression. got=%T", member.Object)
+	}
+	if _, ok := index.Left.(*ast.CallExpression); !ok {
+		t.Fatalf("index.Left is not ast.CallExpression. got=%T", index.Left)
+	}
+}
+
+func TestPostfixChainErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"a.1", "expected a member name after ., got INT instead"},
+		{"f(x).(y)", "expected a member name after ., got ( instead"},
+		{"a[0].", "expected a member name after ., got EOF instead"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("expected parser errors for %q", tt.input)
+			continue
+		}
+		if errors[0] != tt.expected {
+			t.Errorf("wrong error. expected=%q, got=%q", tt.expected, errors[0])
+		}
+	}
+}
+
+func TestMacroLiteralParsing(t *testing.T) {
+	input := `macro(x, y) { x + y; }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statements. got=%T", 1, len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T", program.Statements[0])
+	}
+
+	macro, ok := stmt.Expression.(*ast.MacroLiteral)
+	if !ok {
+		t.Fatalf("stmt.Exression is not ast.MacroLiteral. got=%T", stmt.Expression)
+	}
+
+	if len(macro.Parameters) != 2 {
+		t.Fatalf("macro literal parameters wrong. want 2, got=%T", len(macro.Parameters))
+	}
+
+	testLiteralExpression(t, macro.Parameters[0], "x")
+	testLiteralExpression(t, macro.Parameters[1], "y")
+
+	if len(macro.Body.Statements) != 1 {
+		t.Fatalf("macro.Body.Statements does not contain 1 statements. got=%T", macro.Body.Statements)
+	}
+
+	bodyStmt, ok := macro.Body.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("macro body stmt is not ast.ExpressionStatement. got=%T", macro.Body.Statements[0])
+	}
+
+	testInfixExpression(t, bodyStmt.Expression, "x", "+", "y")
+}
+
+func TestMatchExpressionParsing(t *testing.T) {
+	input := `match (x) { [first, ...rest] => first, {"type": "user", "id": id} => id, 1 => one, _ => 0 }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d", 1, len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T", program.Statements[0])
+	}
+
+	exp, ok := stmt.Expression.(*ast.MatchExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.MatchExpression. got=%T", stmt.Expression)
+	}
+
+	if !testIdentifier(t, exp.Subject, "x") {
+		return
+	}
+
+	if len(exp.Arms) != 4 {
+		t.Fatalf("exp.Arms does not contain 4 arms. got=%d", len(exp.Arms))
+	}
+
+	array, ok := exp.Arms[0].Pattern.(*ast.ArrayPattern)
+	if !ok {
+		t.Fatalf("exp.Arms[0].Pattern is not ast.ArrayPattern. got=%T", exp.Arms[0].Pattern)
+	}
+	if len(array.Elements) != 1 || array.Rest == nil || array.Rest.Value != "rest" {
+		t.Errorf("array pattern wrong. got=%q", array.String())
+	}
+	testIdentifier(t, exp.Arms[0].Body, "first")
+
+	hash, ok := exp.Arms[1].Pattern.(*ast.HashPattern)
+	if !ok {
+		t.Fatalf("exp.Arms[1].Pattern is not ast.HashPattern. got=%T", exp.Arms[1].Pattern)
+	}
+	if len(hash.Pairs) != 2 {
+		t.Errorf("hash pattern has wrong number of pairs. got=%d", len(hash.Pairs))
+	}
+
+	if _, ok := exp.Arms[2].Pattern.(*ast.LiteralPattern); !ok {
+		t.Errorf("exp.Arms[2].Pattern is not ast.LiteralPattern. got=%T", exp.Arms[2].Pattern)
+	}
+
+	if _, ok := exp.Arms[3].Pattern.(*ast.WildcardPattern); !ok {
+		t.Errorf("exp.Arms[3].Pattern is not ast.WildcardPattern. got=%T", exp.Arms[3].Pattern)
+	}
+}
+
+func TestMatchPatternErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"match (x) { + => 1 }", "unexpected + in match pattern"},
+		{"match (x) { {a: 1} => 1 }", "unexpected IDENT as hash pattern key"},
+		{"match (x) { [...1] => 1 }", "expected next token to be IDENT, got INT instead"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("expected parser errors for %q", tt.input)
+			continue
+		}
+		if errors[0] != tt.expected {
+			t.Errorf("wrong error. expected=%q, got=%q", tt.expected, errors[0])
+		}
+	}
+}
+
+func TestDocComments(t *testing.T) {
+	input := `
+/// adds two numbers
+let add = fn(x, y) { x + y };
+
+// not a doc comment
+let sub = fn(x, y) { x - y };
+
+/// the answer
+let answer = 42;
+
+/// anonymous
+fn() { 1 };
+`
+
+	p := New(lexer.New(input))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	tests := []struct {
+		letDoc string
+		fnDoc  string
+	}{
+		{"adds two numbers", "adds two numbers"},
+		{"", ""},
+		{"the answer", ""},
+		{"", "anonymous"},
+	}
+
+	for i, tt := range tests {
+		var fn *ast.FunctionLiteral
+		switch stmt := program.Statements[i].(type) {
+		case *ast.LetStatement:
+			if stmt.Doc != tt.letDoc {
+				t.Errorf("statements[%d] - let doc wrong. expected=%q, got=%q", i, tt.letDoc, stmt.Doc)
+			}
+			fn, _ = stmt.Value.(*ast.FunctionLiteral)
+		case *ast.ExpressionStatement:
+			fn, _ = stmt.Expression.(*ast.FunctionLiteral)
+		}
+
+		if fn != nil && fn.Doc != tt.fnDoc {
+			t.Errorf("statements[%d] - function doc wrong. expected=%q, got=%q", i, tt.fnDoc, fn.Doc)
+		}
+	}
+}
+
+func TestNestingDepthLimit(t *testing.T) {
+	input := strings.Repeat("(", 5000) + "1" + strings.Repeat(")", 5000)
+
+	p := New(lexer.New(input))
+	p.ParseProgram()
+
+	errors := p.Errors()
+	if len(errors) != 1 {
+		t.Fatalf("expected 1 error, got=%d: %v", len(errors), errors)
+	}
+	expected := fmt.Sprintf("expression nested too deeply (max depth %d)", DefaultMaxDepth)
+	if errors[0] != expected {
+		t.Errorf("wrong error. expected=%q, got=%q", expected, errors[0])
+	}
+
+	tests := []struct {
+		nesting int
+		ok      bool
+	}{
+		{9, true},
+		{10, false},
+		{11, false},
+	}
+
+	for _, tt := range tests {
+		// 文の最上位の式も 1 段として数える
+		input := strings.Repeat("(", tt.nesting) + "1" + strings.Repeat(")", tt.nesting)
+
+		p := New(lexer.New(input))
+		p.SetMaxDepth(10)
+		p.ParseProgram()
+
+		if ok := len(p.Errors()) == 0; ok != tt.ok {
+			t.Errorf("nesting %d: expected ok=%t, got errors %v", tt.nesting, tt.ok, p.Errors())
+		}
+	}
+}
+
+func TestLongInfixChainIsNotNested(t *testing.T) {
+	input := "1" + strings.Repeat(" + 1", 10000)
+
+	p := New(lexer.New(input))
+	p.SetMaxDepth(10)
+	p.ParseProgram()
+	checkParserErrors(t, p)
+}
+
+func checkParserErrors(t *testing.T, p *Parser) {
+	errors := p.Errors()
+	if len(errors) == 0 {
+		return
+	}
+
+	t.Errorf("parser has %d errors", len(errors))
+	for _, msg := range errors {
+		t.Errorf("parser error: %q", msg)
+	}
+	t.FailNow()
+}
+
+func testLetStatement(t *testing.T, s ast.Statement, name string) bool {
+	if s.TokenLiteral() != "let" {
+		t.Errorf("s.TokenLiteral is not 'let'. got=%q", s.TokenLiteral())
+		return false
+	}
+
+	letStmt, ok := s.(*ast.LetStatement)
+	if !ok {
+		t.Errorf("s is not *ast.LetStatement. got=%T", s)
+		return false
+	}
+
+	if letStmt.Name.Value != name {
+		t.Errorf("letStmt.Name.Value is not '%s'. got=%s", name, letStmt.Name.Value)
+		return false
+	}
+
+	if letStmt.Name.TokenLiteral() != name {
+		t.Errorf("letStmt.Name.TokenLiteral() is not '%s'. got=%s", name, letStmt.Name.TokenLiteral())
+		return false
+	}
+
+	return true
+}
+
+func testIntegerLiteral(t *testing.T, il ast.Expression, value int64) bool {
+	integ, ok := il.(*ast.IntegerLiteral)
+	if !ok {
+		t.Errorf("il not *ast.IntegerLiteral. got=%T", il)
+		return false
+	}
+	if integ.Value != value {
+		t.Errorf("integ.Value not %d. got=%d", value, integ.Value)
+		return false
+	}
+	if integ.TokenLiteral() != fmt.Sprintf("%d", value) {
+		t.Errorf("integ.TokenLiteral not %d. got=%s", value, integ.TokenLiteral())
+		return false
+	}
+
+	return true
+}
+
+func testIdentifier(t *testing.T, exp ast.Expression, value string) bool {
+	ident, ok := exp.(*ast.Identifier)
+	if !ok {
+		t.Errorf("exp is not *ast.Identifier. got=%T", exp)
+		return false
+	}
+
+	if ident.Value != value {
+		t.Errorf("ident.Value is not %s. got=%s", value, ident.Value)
+		return false
+	}
+
+	if ident.TokenLiteral() != value {
+		t.Errorf("ident.TokenLiteral is not %s. got=%s", value, ident.TokenLiteral())
+		return false
+	}
+
+	return true
+}
+
+func testBoolean(t *testing.T, exp ast.Expression, value bool) bool {
+	bo, ok := exp.(*ast.Boolean)
+	if !ok {
+		t.Errorf("exp is not *ast.Boolean. got=%T", exp)
+		return false
+	}
+
+	if bo.Value != value {
+		t.Errorf("bo.Value is not %t. got=%t", value, bo.Value)
+		return false
+	}
+
+	if bo.TokenLiteral() != fmt.Sprintf("%t", value) {
+		t.Errorf("bo.TokenLiteral is not %t, got=%s", value, bo.TokenLiteral())
+		return false
+	}
+	return true
+}
+
+func testLiteralExpression(
+	t *testing.T,
+	exp ast.Expression,
+	expected interface{},
+) bool {
+	switch v := expected.(type) {
+	case int:
+		return testIntegerLiteral(t, exp, int64(v))
+	case int64:
+		return testIntegerLiteral(t, exp, v)
+	case string:
+		return testIdentifier(t, exp, v)
+	case bool:
+		return testBoolean(t, exp, v)
+	}
+	t.Errorf("type of exp not handled. got=%T", exp)
+	return false
+}
+
+func testPrefixExpression(t *testing.T, exp ast.Expression, operator string, right interface{}) bool {
+	opExp, ok := exp.(*ast.PrefixExpression)
+
+	if !ok {
+		t.Errorf("exp is not ast.PrefixExpression. got=%T(%s)", exp, exp)
+		return false
+	}
+
+	if opExp.Operator != operator {
+		t.Errorf("exp.Operator is not '%s'. got=%q", operator, opExp.Operator)
+		return false
+	}
+
+	if !testLiteralExpression(t, opExp.Right, right) {
+		return false
+	}
+
+	return true
+}
+
+func testInfixExpression(t *testing.T, exp ast.Expression, left interface{}, operator string, right interface{}) bool {
+	opExp, ok := exp.(*ast.InfixExpression)
+
+	if !ok {
+		t.Errorf("exp is not ast.InfixExpression. got=%T(%s)", exp, exp)
+		return false
+	}
+
+	if !testLiteralExpression(t, opExp.Left, left) {
+		return false
+	}
+
+	if opExp.Operator != operator {
+		t.Errorf("exp.Operator is not '%s'. got=%q", operator, opExp.Operator)
+		return false
+	}
+
+	if !testLiteralExpression(t, opExp.Right, right) {
+		return false
+	}
+
+	return true
+}
+
+func TestTracer(t *testing.T) {
+	events := []TraceEvent{}
+	p := New(lexer.New("let x = -1;"))
+	p.SetTracer(TracerFunc(func(event TraceEvent) {
+		events = append(events, event)
+	}))
+	p.ParseProgram()
+	checkParserErrors(t, p)
+
+	expected := []struct {
+		kind   TraceEventKind
+		rule   string
+		depth  int
+		offset int
+	}{
+		{TraceEnter, "parseStatement", 1, 0},
+		{TraceEnter, "parseLetStatement", 2, 0},
+		{TraceEnter, "parseExpression", 3, 8},
+		{TraceEnter, "parsePrefixExpression", 4, 8},
+		{TraceEnter, "parseExpression", 5, 9},
+		{TraceEnter, "parseIntegerLiteral", 6, 9},
+		{TraceExit, "parseIntegerLiteral", 6, 9},
+		{TraceExit, "parseExpression", 5, 9},
+		{TraceExit, "parsePrefixExpression", 4, 9},
+		{TraceExit, "parseExpression", 3, 9},
+		{TraceExit, "parseLetStatement", 2, 10},
+		{TraceExit, "parseStatement", 1, 10},
+	}
+
+	if len(events) != len(expected) {
+		t.Fatalf("wrong number of events. want=%d, got=%d: %+v", len(expected), len(events), events)
+	}
+	for i, e := range expected {
+		got := events[i]
+		if got.Kind != e.kind || got.Rule != e.rule || got.Depth != e.depth || got.Token.Offset != e.offset {
+			t.Errorf("events[%d] wrong. want=%v %s depth=%d @%d, got=%v %s depth=%d @%d",
+				i, e.kind, e.rule, e.depth, e.offset, got.Kind, got.Rule, got.Depth, got.Token.Offset)
+		}
+	}
+
+	var out strings.Builder
+	p = New(lexer.New("1"))
+	p.SetTracer(NewTextTracer(&out))
+	p.ParseProgram()
+
+	if !strings.HasPrefix(out.String(), "BEGIN parseStatement (INT \"1\" @0)\n\tBEGIN parseExpressionStatement") {
+		t.Errorf("unexpected text trace:\n%s", out.String())
+	}
+}
+
+func TestParseExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		errors   []string
+	}{
+		{"1 + 2 * 3", "(1 + (2 * 3))", nil},
+		{`user["age"] > 18;`, "((user[age]) > 18)", nil},
+		{"if (x) { let y = 1; y } else { 0 }", "ifx let y = 1;yelse0", nil},
+		{"", "", []string{"expected an expression, got end of input"}},
+		{"let x = 1;", "", []string{"expected an expression, got let statement"}},
+		{"return 1", "", []string{"expected an expression, got return statement"}},
+		{"1; 2", "", []string{"unexpected INT after expression"}},
+		{"1 2", "", []string{"unexpected INT after expression"}},
+		{"1 +", "", []string{"no prefix parse function for EOF found"}},
+	}
+
+	for _, tt := range tests {
+		exp, errors := ParseExpression(tt.input)
+		if !reflect.DeepEqual(errors, tt.errors) {
+			t.Errorf("%q: errors = %q, want %q", tt.input, errors, tt.errors)
+			continue
+		}
+		if tt.errors == nil && exp.String() != tt.expected {
+			t.Errorf("%q: expected=%q, got=%q", tt.input, tt.expected, exp.String())
+		}
+	}
+}
+
+func TestCopyPreservesString(t *testing.T) {
+	inputs := []string{
+		"let x = 5; let y = x * (2 + 3); return y;",
+		`let add = fn(a, b) { a + b }; add(1, add(2, 3))`,
+		`if (a < b) { a } else { b }; if (x) { y }`,
+		`[1, "two", 3.5d, true][0]; {"key": [1]}["key"]; a.b.c(d)`,
+		`a ? b : c; -x; !y`,
+		`let m = macro(x) { quote(unquote(x) + 1) }; m(2)`,
+		`match (x) { [a, ...rest] => a, {"k": v} => v, 1 => 0, _ => null }`,
+		// 構文エラーから回復した木には nil の子が残る
+		"let = 5;",
+		"let x = ;",
+		"return ;",
+		"if (x) { } else",
+		"fn(x, 1) { x }",
+		"add(1, 2",
+		"[1, 2",
+		`{"a" 1}`,
+		"match (x) { + => 1 }",
+		"a.",
+	}
+
+	for _, input := range inputs {
+		program := New(lexer.New(input)).ParseProgram()
+
+		copied := program.Copy()
+		if copied.String() != program.String() {
+			t.Errorf("%q: Copy().String() = %q, want %q", input, copied.String(), program.String())
+		}
+	}
+}
+
+func TestNewlineTerminatesStatements(t *testing.T) {
+	tests := []struct {
+		input      string
+		statements int
+		expected   string
+	}{
+		{"let a = 1\nlet b = 2\nb", 3, "let a = 1;let b = 2;b"},
+		{"return a\nb", 2, "return a;b"},
+		{"a\n-1", 2, "a(-1)"},
+		{"f\n(x)", 2, "fx"},
+		{"a +\nb", 1, "(a + b)"},
+		{"f(a,\nb\n)", 1, "f(a, b)"},
+		{"f(a\n+ b)", 1, "f((a + b))"},
+		{"[1,\n2\n][0]", 1, "([1, 2][0])"},
+		{"{\"a\": 1\n}", 1, "{a:1}"},
+		{"if (a\n== b) { c\nd } else { e }", 1, "if(a == b) cdelsee"},
+		{"f(fn(x) { x\n-1 })", 1, "f(fn(x) x(-1))"},
+		{"match (x) {\n1 => a\n, _ => b\n}", 1, "matchx {1 => a, _ => b}"},
+		{"a; b\nc", 3, "abc"},
+		{"a\n  .b(1)\n  .c", 1, "((a.b)(1).c)"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != tt.statements {
+			t.Errorf("%q: expected %d statements, got %d (%q)", tt.input, tt.statements, len(program.Statements), program.String())
+		}
+		if program.String() != tt.expected {
+			t.Errorf("%q: expected=%q, got=%q", tt.input, tt.expected, program.String())
+		}
+	}
+}
+
+func TestStatementComments(t *testing.T) {
+	input := `// header
+/* about x */
+let x = 1; // one
+let f = fn() {
+	// inside
+	x /* mid */ + 1
+	// end of body
+}
+/// doc
+f() // call
+// trailing file comment`
+
+	p := New(lexer.New(input))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 3 {
+		t.Fatalf("program.Statements does not contain 3 statements. got=%d", len(program.Statements))
+	}
+
+	tests := []struct {
+		trivia   *ast.Trivia
+		leading  []string
+		trailing string
+	}{
+		{program.Statements[0].(ast.Commented).Comments(), []string{"// header", "/* about x */"}, "// one"},
+		{program.Statements[1].(ast.Commented).Comments(), nil, ""},
+		{program.Statements[2].(ast.Commented).Comments(), []string{"/// doc"}, "// call"},
+	}
+	for i, tt := range tests {
+		if !reflect.DeepEqual(tt.trivia.Leading, tt.leading) {
+			t.Errorf("statements[%d] leading wrong. want=%q, got=%q", i, tt.leading, tt.trivia.Leading)
+		}
+		if tt.trivia.Trailing != tt.trailing {
+			t.Errorf("statements[%d] trailing wrong. want=%q, got=%q", i, tt.trailing, tt.trivia.Trailing)
+		}
+	}
+
+	body := program.Statements[1].(*ast.LetStatement).Value.(*ast.FunctionLiteral).Body
+	if got := body.Statements[0].(ast.Commented).Comments().Leading; !reflect.DeepEqual(got, []string{"// inside"}) {
+		t.Errorf("body statement leading wrong. got=%q", got)
+	}
+	if !reflect.DeepEqual(body.EndComments, []string{"// end of body"}) {
+		t.Errorf("block EndComments wrong. got=%q", body.EndComments)
+	}
+	if !reflect.DeepEqual(program.EndComments, []string{"// trailing file comment"}) {
+		t.Errorf("program EndComments wrong. got=%q", program.EndComments)
+	}
+
+	copied := program.Copy().(*ast.Program)
+	if got := copied.Statements[0].(ast.Commented).Comments().Trailing; got != "// one" {
+		t.Errorf("Copy lost comments. got=%q", got)
+	}
+}
+
+func TestErrorHints(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"if x > 1 { 2 }", "the condition of if goes in parentheses: if (x) { ... }"},
+		{"match x { _ => 1 }", "the subject of match goes in parentheses: match (x) { ... }"},
+		{"fn add(a) { a }", "functions have no names; bind one with let add = fn(...) { ... }"},
+		{"if (a) { 1 } else if (b) { 2 }", "there is no else if; nest it in a block: else { if (...) { ... } }"},
+		{"let x == 2", "let binds a name with =; == compares values"},
+		{"let fn = 2", "fn is a keyword and cannot be used as a name"},
+		{"f(1, 2", "the input ended before the closing ); check for a missing )"},
+		{"1 <= 2", "= only binds names in let (let x = ...); compare with == (there are no <= or >= operators)"},
+		{"a && b", "there are no && or || operators; use nested if expressions"},
+		{"'s'", "strings are written in double quotes"},
+		{"99999999999999999999", "integers too large for 64 bits need an n suffix: 99999999999999999999n"},
+		{"let = 1", ""},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		hints := p.Hints()
+		if len(hints) != len(p.Errors()) || len(hints) == 0 {
+			t.Errorf("%q: expected one hint per error, got %q for %q", tt.input, hints, p.Errors())
+			continue
+		}
+		if hints[0] != tt.expected {
+			t.Errorf("%q: hint wrong. expected=%q, got=%q", tt.input, tt.expected, hints[0])
+		}
+	}
+
+	p := New(lexer.New("let x == 2"))
+	p.ParseProgram()
+	expected := "line 1, column 7: expected next token to be =, got == instead; hint: let binds a name with =; == compares values"
+	if errors := p.ErrorsWithHints(); errors[0] != expected {
+		t.Errorf("ErrorsWithHints wrong. got=%q", errors[0])
+	}
+}
+
+func TestErrorPositions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let a = 1;\nlet b = @;", "line 2, column 9: no prefix parse function for ILLEGAL found"},
+		{"let a = 1\n  let = 2", "line 2, column 7: expected next token to be IDENT, got = instead"},
+		{"x\n.1", "line 2, column 2: expected a member name after ., got INT instead"},
+		{"\"λ\" + )", "line 1, column 7: no prefix parse function for ) found"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		errors := p.ErrorsWithHints()
+		if len(errors) == 0 {
+			t.Errorf("expected parser errors for %q", tt.input)
+			continue
+		}
+		if errors[0] != tt.expected {
+			t.Errorf("%q: expected=%q, got=%q", tt.input, tt.expected, errors[0])
+		}
+	}
+}
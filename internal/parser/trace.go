@@ -0,0 +1,67 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/al-keio/monkey-go/token"
+)
+
+type TraceEventKind int
+
+const (
+	TraceEnter TraceEventKind = iota
+	TraceExit
+)
+
+func (k TraceEventKind) String() string {
+	if k == TraceEnter {
+		return "BEGIN"
+	}
+	return "END"
+}
+
+// TraceEvent は構文規則 (parse 関数) の開始と終了を表す。Token はその時点の現在のトークン。
+type TraceEvent struct {
+	Kind  TraceEventKind
+	Rule  string
+	Token token.Token
+	Depth int
+}
+
+type Tracer interface {
+	Trace(event TraceEvent)
+}
+
+type TracerFunc func(event TraceEvent)
+
+func (f TracerFunc) Trace(event TraceEvent) { f(event) }
+
+// SetTracer は構文解析の経過を tracer に通知するようにする。nil なら通知しない。
+func (p *Parser) SetTracer(tracer Tracer) {
+	p.tracer = tracer
+}
+
+func (p *Parser) trace(rule string) string {
+	if p.tracer != nil {
+		p.traceDepth++
+		p.tracer.Trace(TraceEvent{Kind: TraceEnter, Rule: rule, Token: p.curToken, Depth: p.traceDepth})
+	}
+	return rule
+}
+
+func (p *Parser) untrace(rule string) {
+	if p.tracer != nil {
+		p.tracer.Trace(TraceEvent{Kind: TraceExit, Rule: rule, Token: p.curToken, Depth: p.traceDepth})
+		p.traceDepth--
+	}
+}
+
+// NewTextTracer は書籍の parser_tracing.go と同じ形式で、規則の入れ子を字下げして w に書き出す
+func NewTextTracer(w io.Writer) Tracer {
+	return TracerFunc(func(event TraceEvent) {
+		indent := strings.Repeat("\t", event.Depth-1)
+		fmt.Fprintf(w, "%s%s %s (%s %q @%d)\n", indent, event.Kind, event.Rule, event.Token.Type, event.Token.Literal, event.Token.Offset)
+	})
+}
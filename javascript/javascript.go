@@ -0,0 +1,747 @@
+// Package javascript はマクロを展開したあとの構文木を読みやすい JavaScript に変換する。
+// 関数は function 式、ハッシュはオブジェクト、配列は配列になり、組み込み関数は先頭に置く実行時ライブラリ $m が提供する。
+// Monkey の変数は関数単位のスコープなので、let はすべて関数の先頭で宣言し、その場では代入にする。
+//
+// 数値は JavaScript の number なので、2^53 を超える整数は正確でなく、Monkey のような 64 ビットの桁あふれも起きない。
+// ハッシュのキーはプロパティ名になるので 1 と "1" は区別されず、0 での除算などの実行時エラーも再現しない。
+// 配列同士の + は連結、ハッシュ同士の + は併合になるが、それ以外の組み合わせは JavaScript の + と同じく型の誤りにならない。
+// 小数、match、クラス、operator 宣言、defer、事前・事後条件、名前空間は変換できずにエラーになる。
+package javascript
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/al-keio/monkey-go/ast"
+	"github.com/al-keio/monkey-go/internal/evaluator"
+	"github.com/al-keio/monkey-go/object"
+)
+
+// Transpile は program を Node.js やブラウザでそのまま実行できる JavaScript に変換する。
+// program のマクロは展開しておくこと。変換できない構文があればエラーを返す。
+func Transpile(program *ast.Program) (string, error) {
+	main, err := transpileMain(program)
+	if err != nil {
+		return "", err
+	}
+	return "\"use strict\";\n\n" + Runtime + "\n" + main + "\n\n$main();\n", nil
+}
+
+// transpileMain は program を本体とする関数 $main の定義を返す。トップレベルの return もそのまま書ける。
+func transpileMain(program *ast.Program) (string, error) {
+	g := &generator{}
+	g.write("function $main() ")
+	g.body(nil, program.Statements)
+	if g.err != nil {
+		return "", g.err
+	}
+	return g.out.String(), nil
+}
+
+// Runtime は変換したプログラムが使う組み込み関数と値の扱い方。Monkey と同じく null と false だけが偽になる。
+const Runtime = `const $m = {
+  truthy(x) {
+    return x !== null && x !== undefined && x !== false;
+  },
+  inspect(x) {
+    if (x === null || x === undefined) {
+      return "null";
+    }
+    if (Array.isArray(x)) {
+      return "[" + x.map($m.inspect).join(", ") + "]";
+    }
+    if (typeof x === "object") {
+      const pairs = Object.keys(x).map((k) => k + ": " + $m.inspect(x[k]));
+      return "{" + pairs.sort().join(", ") + "}";
+    }
+    return String(x);
+  },
+  len(x) {
+    return typeof x === "string" ? new TextEncoder().encode(x).length : x.length;
+  },
+  first(a) {
+    return a.length > 0 ? a[0] : null;
+  },
+  last(a) {
+    return a.length > 0 ? a[a.length - 1] : null;
+  },
+  rest(a) {
+    return a.length > 0 ? a.slice(1) : null;
+  },
+  push(a, x) {
+    return [...a, x];
+  },
+  puts(...args) {
+    args.forEach((x) => console.log($m.inspect(x)));
+    return null;
+  },
+  add(a, b) {
+    if (Array.isArray(a) && Array.isArray(b)) {
+      return [...a, ...b];
+    }
+    if ($m.isHash(a) && $m.isHash(b)) {
+      return { ...a, ...b };
+    }
+    return a + b;
+  },
+  isHash(x) {
+    return x !== null && typeof x === "object" && !Array.isArray(x);
+  },
+  map(a, f) {
+    return a.map((x) => f(x));
+  },
+  filter(a, f) {
+    return a.filter((x) => $m.truthy(f(x)));
+  },
+};
+`
+
+// runtimeBuiltins は $m が提供する組み込み関数
+var runtimeBuiltins = map[string]bool{
+	"len": true, "first": true, "last": true, "rest": true, "push": true,
+	"puts": true, "map": true, "filter": true,
+}
+
+// reserved は Monkey では識別子に使えるが JavaScript では使えない名前。後ろに $ を付けて書く。
+var reserved = map[string]bool{
+	"arguments": true, "await": true, "break": true, "case": true, "catch": true, "const": true,
+	"continue": true, "debugger": true, "default": true, "delete": true, "do": true, "enum": true,
+	"eval": true, "export": true, "extends": true, "finally": true, "for": true, "function": true,
+	"implements": true, "import": true, "in": true, "instanceof": true, "interface": true, "let": true,
+	"null": true, "package": true, "private": true, "protected": true, "public": true, "static": true,
+	"super": true, "switch": true, "this": true, "throw": true, "try": true, "typeof": true,
+	"undefined": true, "var": true, "void": true, "while": true, "with": true, "yield": true,
+	"NaN": true, "Infinity": true,
+}
+
+// 出力する JavaScript の式の優先順位。括弧が要るかどうかの判断にだけ使う
+const (
+	lowest = iota
+	ternary
+	equals
+	lessgreater
+	sum
+	product
+	prefix
+	call
+	atom
+)
+
+var precedences = map[string]int{
+	"==": equals,
+	"!=": equals,
+	"<":  lessgreater,
+	">":  lessgreater,
+	"+":  sum,
+	"-":  sum,
+	"*":  product,
+	"/":  atom, // Math.trunc(a / b) になる
+	"%":  product,
+}
+
+type generator struct {
+	out    bytes.Buffer
+	indent int
+	err    error
+	// scopes は外側の関数から順に、その関数の引数と let で宣言する名前
+	scopes []map[string]bool
+	// inArrow は値として使う if を包んだアロー関数の中にいるかどうか。そこでは return が外の関数から戻れない
+	inArrow bool
+}
+
+func (g *generator) fail(format string, a ...interface{}) {
+	if g.err == nil {
+		g.err = fmt.Errorf(format, a...)
+	}
+}
+
+func (g *generator) write(s string) {
+	g.out.WriteString(s)
+}
+
+func (g *generator) newline() {
+	g.out.WriteByte('\n')
+	g.write(strings.Repeat("  ", g.indent))
+}
+
+// body は関数の本体を { から } まで書く。let の名前を先頭で宣言し、最後の文の値を返す。
+func (g *generator) body(params []*ast.Identifier, stmts []ast.Statement) {
+	scope := map[string]bool{}
+	for _, param := range params {
+		scope[param.Value] = true
+	}
+	var declared []string
+	for _, name := range letNames(stmts) {
+		if !scope[name] {
+			scope[name] = true
+			declared = append(declared, g.name(name))
+		}
+	}
+	g.scopes = append(g.scopes, scope)
+	inArrow := g.inArrow
+	g.inArrow = false
+	defer func() {
+		g.scopes = g.scopes[:len(g.scopes)-1]
+		g.inArrow = inArrow
+	}()
+
+	g.write("{")
+	g.indent++
+	if len(declared) > 0 {
+		g.newline()
+		g.write("let " + strings.Join(declared, ", ") + ";")
+	}
+	g.statements(stmts, true)
+	g.indent--
+	g.newline()
+	g.write("}")
+}
+
+// statements は文を一行ずつ書く。tail なら最後の文の値を return する。
+func (g *generator) statements(stmts []ast.Statement, tail bool) {
+	for i, stmt := range stmts {
+		g.statement(stmt, tail && i == len(stmts)-1)
+	}
+	if tail && len(stmts) == 0 {
+		g.newline()
+		g.write("return null;")
+	}
+}
+
+func (g *generator) statement(stmt ast.Statement, tail bool) {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		if stmt.Name == nil {
+			g.fail("cannot transpile a let statement without a name")
+			return
+		}
+		g.newline()
+		g.write(g.name(stmt.Name.Value) + " = ")
+		g.expression(stmt.Value, lowest)
+		g.write(";")
+		if tail {
+			g.newline()
+			g.write("return null;")
+		}
+	case *ast.ReturnStatement:
+		if g.inArrow {
+			g.fail("cannot transpile return inside an if expression that is used as a value")
+			return
+		}
+		g.newline()
+		g.write("return ")
+		g.expression(stmt.ReturnValue, lowest)
+		g.write(";")
+	case *ast.ExpressionStatement:
+		if ie, ok := stmt.Expression.(*ast.IfExpression); ok {
+			g.ifStatement(ie, tail)
+			return
+		}
+		g.newline()
+		if tail {
+			g.write("return ")
+			g.expression(stmt.Expression, lowest)
+			g.write(";")
+			return
+		}
+		// function や { で始まる文は宣言やブロックとして読まれるので括弧で囲む
+		exp := g.sub(stmt.Expression, lowest)
+		if strings.HasPrefix(exp, "function") || strings.HasPrefix(exp, "{") {
+			exp = "(" + exp + ")"
+		}
+		g.write(exp + ";")
+	case *ast.BlockStatement:
+		g.statements(stmt.Statements, tail)
+	case *ast.DeferStatement:
+		g.fail("cannot transpile defer statements")
+	case *ast.OperatorStatement:
+		g.fail("cannot transpile operator declarations")
+	case *ast.ClassStatement:
+		g.fail("cannot transpile class declarations")
+	default:
+		g.fail("cannot transpile statement %T", stmt)
+	}
+}
+
+// ifStatement は文として使う if を書く。tail なら両方の枝の最後の値を return する。
+func (g *generator) ifStatement(ie *ast.IfExpression, tail bool) {
+	g.newline()
+	g.write("if (")
+	g.condition(ie.Condition)
+	g.write(") ")
+	g.block(ie.Consequence, tail)
+	if ie.Alternative != nil {
+		g.write(" else ")
+		g.block(ie.Alternative, tail)
+	} else if tail {
+		g.newline()
+		g.write("return null;")
+	}
+}
+
+func (g *generator) block(block *ast.BlockStatement, tail bool) {
+	if block == nil {
+		g.fail("cannot transpile a missing block")
+		return
+	}
+	if len(block.Statements) == 0 && !tail {
+		g.write("{}")
+		return
+	}
+	g.write("{")
+	g.indent++
+	g.statements(block.Statements, tail)
+	g.indent--
+	g.newline()
+	g.write("}")
+}
+
+// condition は Monkey の真偽の判定を書く。値が真偽値に決まっている式はそのまま書く。
+func (g *generator) condition(exp ast.Expression) {
+	if isBoolean(exp) {
+		g.expression(exp, lowest)
+		return
+	}
+	g.write("$m.truthy(")
+	g.expression(exp, lowest)
+	g.write(")")
+}
+
+func isBoolean(exp ast.Expression) bool {
+	switch exp := exp.(type) {
+	case *ast.Boolean:
+		return true
+	case *ast.PrefixExpression:
+		return exp.Operator == "!"
+	case *ast.InfixExpression:
+		switch exp.Operator {
+		case "==", "!=", "<", ">":
+			return true
+		}
+	case *ast.PipelineExpression:
+		return isBoolean(exp.Original)
+	}
+	return false
+}
+
+// plainAdd は + の片方が数値か文字列か真偽値と分かり、JavaScript の + のままでよいかどうかを返す。
+// どちらも配列やハッシュでありうるときは $m.add を使う。
+func plainAdd(exp *ast.InfixExpression) bool {
+	return isScalar(exp.Left) || isScalar(exp.Right)
+}
+
+func isScalar(exp ast.Expression) bool {
+	switch exp := exp.(type) {
+	case *ast.IntegerLiteral, *ast.StringLiteral:
+		return true
+	case *ast.PrefixExpression:
+		return true
+	case *ast.InfixExpression:
+		return exp.Operator != "+" || plainAdd(exp)
+	}
+	return isBoolean(exp)
+}
+
+// name は Monkey の名前を JavaScript で使える名前にする。Monkey の識別子には $ が使えないので衝突しない。
+func (g *generator) name(name string) string {
+	if reserved[name] {
+		return name + "$"
+	}
+	return name
+}
+
+func (g *generator) identifier(ident *ast.Identifier) {
+	for i := len(g.scopes) - 1; i >= 0; i-- {
+		if g.scopes[i][ident.Value] {
+			g.write(g.name(ident.Value))
+			return
+		}
+	}
+	if runtimeBuiltins[ident.Value] {
+		g.write("$m." + ident.Value)
+		return
+	}
+	if _, ok := evaluator.Doc(ident.Value, object.NewEnvironment()); ok {
+		g.fail("cannot transpile builtin `%s`", ident.Value)
+		return
+	}
+	// 定義されていない名前は実行時に ReferenceError になる
+	g.write(g.name(ident.Value))
+}
+
+// expression は exp を書く。exp の優先順位が min より低ければ括弧で囲む。
+func (g *generator) expression(exp ast.Expression, min int) {
+	if exp == nil {
+		g.fail("cannot transpile a missing expression")
+		return
+	}
+	if p := precedence(exp); p < min {
+		g.write("(")
+		defer g.write(")")
+	}
+
+	switch exp := exp.(type) {
+	case *ast.Identifier:
+		g.identifier(exp)
+	case *ast.IntegerLiteral:
+		g.write(fmt.Sprintf("%d", exp.Value))
+	case *ast.StringLiteral:
+		g.string(exp.Value)
+	case *ast.Boolean:
+		g.write(fmt.Sprintf("%t", exp.Value))
+	case *ast.ArrayLiteral:
+		g.write("[")
+		g.expressions(exp.Elements)
+		g.write("]")
+	case *ast.HashLiteral:
+		g.hash(exp)
+	case *ast.PrefixExpression:
+		g.prefix(exp)
+	case *ast.InfixExpression:
+		g.infix(exp)
+	case *ast.ConditionalExpression:
+		g.condition(exp.Condition)
+		g.write(" ? ")
+		g.expression(exp.Consequence, ternary)
+		g.write(" : ")
+		g.expression(exp.Alternative, ternary)
+	case *ast.IndexExpression:
+		g.expression(exp.Left, call)
+		g.write("[")
+		g.expression(exp.Index, lowest)
+		g.write("]")
+	case *ast.CallExpression:
+		g.expression(exp.Function, call)
+		g.write("(")
+		g.expressions(exp.Arguments)
+		g.write(")")
+	case *ast.PipelineExpression:
+		g.expression(exp.Original, min)
+	case *ast.IfExpression:
+		g.ifExpression(exp)
+	case *ast.FunctionLiteral:
+		g.function(exp)
+	case *ast.DecimalLiteral:
+		g.fail("cannot transpile decimal literal %s", exp.Token.Literal)
+	case *ast.BigIntLiteral:
+		// JavaScript の BigInt は Number と混ぜて計算できないので、整数と同じようには使えない
+		g.fail("cannot transpile big integer literal %s", exp.Token.Literal)
+	case *ast.MatchExpression:
+		g.fail("cannot transpile match expressions")
+	case *ast.NewExpression:
+		g.fail("cannot transpile new expressions")
+	case *ast.MemberExpression:
+		g.fail("cannot transpile member access %s", exp.String())
+	case *ast.MacroLiteral:
+		g.fail("cannot transpile macro literals; expand macros first")
+	default:
+		g.fail("cannot transpile expression %T", exp)
+	}
+}
+
+func precedence(exp ast.Expression) int {
+	switch exp := exp.(type) {
+	case *ast.InfixExpression:
+		if exp.Operator == "+" && !plainAdd(exp) {
+			return call
+		}
+		if p, ok := precedences[exp.Operator]; ok {
+			return p
+		}
+	case *ast.ConditionalExpression:
+		return ternary
+	case *ast.PrefixExpression:
+		return prefix
+	case *ast.CallExpression, *ast.IndexExpression:
+		return call
+	case *ast.PipelineExpression:
+		return precedence(exp.Original)
+	case *ast.IfExpression:
+		if _, _, ok := ternaryBranches(exp); ok {
+			return ternary
+		}
+		return call
+	case *ast.FunctionLiteral:
+		// 呼び出される関数式は括弧で囲む
+		return ternary
+	case *ast.IntegerLiteral:
+		if exp.Value < 0 {
+			return prefix
+		}
+	}
+	return atom
+}
+
+func (g *generator) expressions(exps []ast.Expression) {
+	for i, exp := range exps {
+		if i > 0 {
+			g.write(", ")
+		}
+		g.expression(exp, ternary)
+	}
+}
+
+func (g *generator) prefix(exp *ast.PrefixExpression) {
+	switch exp.Operator {
+	case "!":
+		if isBoolean(exp.Right) {
+			g.write("!")
+			g.expression(exp.Right, prefix)
+			return
+		}
+		g.write("!$m.truthy(")
+		g.expression(exp.Right, lowest)
+		g.write(")")
+	case "-":
+		g.write("-")
+		// --x はデクリメントとして読まれる
+		if operand := g.sub(exp.Right, prefix); strings.HasPrefix(operand, "-") {
+			g.write("(" + operand + ")")
+		} else {
+			g.write(operand)
+		}
+	default:
+		g.fail("cannot transpile prefix operator %s", exp.Operator)
+	}
+}
+
+func (g *generator) infix(exp *ast.InfixExpression) {
+	p, ok := precedences[exp.Operator]
+	if !ok {
+		g.fail("cannot transpile operator %s", exp.Operator)
+		return
+	}
+	switch exp.Operator {
+	case "/":
+		// 整数の割り算は 0 の方向に切り捨てる
+		g.write("Math.trunc(")
+		g.expression(exp.Left, product)
+		g.write(" / ")
+		g.expression(exp.Right, product+1)
+		g.write(")")
+		return
+	case "+":
+		if !plainAdd(exp) {
+			// 配列の連結とハッシュの併合は実行時に値を見て行う
+			g.write("$m.add(")
+			g.expression(exp.Left, ternary)
+			g.write(", ")
+			g.expression(exp.Right, ternary)
+			g.write(")")
+			return
+		}
+		g.expression(exp.Left, p)
+		g.write(" + ")
+	case "==":
+		g.expression(exp.Left, p)
+		g.write(" === ")
+	case "!=":
+		g.expression(exp.Left, p)
+		g.write(" !== ")
+	default:
+		g.expression(exp.Left, p)
+		g.write(" " + exp.Operator + " ")
+	}
+	g.expression(exp.Right, p+1)
+}
+
+// ternaryBranches は if の両方の枝が式一つだけなら、その式を返す。else がなければ代わりは nil。
+func ternaryBranches(ie *ast.IfExpression) (ast.Expression, ast.Expression, bool) {
+	branch := func(block *ast.BlockStatement) (ast.Expression, bool) {
+		if block == nil {
+			return nil, true
+		}
+		if len(block.Statements) != 1 {
+			return nil, len(block.Statements) == 0
+		}
+		stmt, ok := block.Statements[0].(*ast.ExpressionStatement)
+		if !ok {
+			return nil, false
+		}
+		return stmt.Expression, true
+	}
+	consequence, ok := branch(ie.Consequence)
+	if !ok {
+		return nil, nil, false
+	}
+	alternative, ok := branch(ie.Alternative)
+	return consequence, alternative, ok
+}
+
+// ifExpression は値として使う if を書く。枝が式一つなら三項演算子にし、そうでなければアロー関数を呼び出す。
+func (g *generator) ifExpression(ie *ast.IfExpression) {
+	if consequence, alternative, ok := ternaryBranches(ie); ok {
+		g.condition(ie.Condition)
+		g.write(" ? ")
+		g.optional(consequence)
+		g.write(" : ")
+		g.optional(alternative)
+		return
+	}
+
+	inArrow := g.inArrow
+	g.inArrow = true
+	g.write("(() => {")
+	g.indent++
+	g.ifStatement(ie, true)
+	g.indent--
+	g.newline()
+	g.write("})()")
+	g.inArrow = inArrow
+}
+
+// optional は三項演算子の枝を書く。空の枝は null になる
+func (g *generator) optional(exp ast.Expression) {
+	if exp == nil {
+		g.write("null")
+		return
+	}
+	g.expression(exp, ternary)
+}
+
+func (g *generator) function(fl *ast.FunctionLiteral) {
+	if len(fl.Requires) > 0 || len(fl.Ensures) > 0 {
+		g.fail("cannot transpile requires and ensures conditions")
+		return
+	}
+	if fl.Body == nil {
+		g.fail("cannot transpile a missing function body")
+		return
+	}
+	params := make([]string, len(fl.Parameters))
+	for i, param := range fl.Parameters {
+		params[i] = g.name(param.Value)
+	}
+	g.write("function (" + strings.Join(params, ", ") + ") ")
+	g.body(fl.Parameters, fl.Body.Statements)
+}
+
+// string は文字列を JSON の書き方で書く。JSON の文字列はそのまま JavaScript の文字列リテラルになる。
+func (g *generator) string(s string) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(s); err != nil {
+		g.fail("cannot transpile string %q: %s", s, err)
+		return
+	}
+	g.write(strings.TrimSuffix(buf.String(), "\n"))
+}
+
+// hash はハッシュをオブジェクトリテラルにする。リテラルでないキーは計算されたプロパティ名にする。
+// 組はキーを書いた文字列の順に並べる。
+func (g *generator) hash(hash *ast.HashLiteral) {
+	type pair struct{ key, value string }
+	pairs := make([]pair, 0, len(hash.Pairs))
+	for key, value := range hash.Pairs {
+		var k string
+		switch key := key.(type) {
+		case *ast.StringLiteral, *ast.Boolean:
+			k = g.sub(key, atom)
+		case *ast.IntegerLiteral:
+			if key.Value < 0 {
+				k = "[" + g.sub(key, lowest) + "]"
+			} else {
+				k = g.sub(key, atom)
+			}
+		default:
+			k = "[" + g.sub(key, lowest) + "]"
+		}
+		pairs = append(pairs, pair{k, g.sub(value, ternary)})
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].key < pairs[j].key })
+
+	g.write("{")
+	for i, pair := range pairs {
+		if i > 0 {
+			g.write(", ")
+		}
+		g.write(pair.key + ": " + pair.value)
+	}
+	g.write("}")
+}
+
+// sub は exp を今の字下げとスコープで書いた文字列を返す
+func (g *generator) sub(exp ast.Expression, min int) string {
+	sub := &generator{indent: g.indent, scopes: g.scopes, inArrow: g.inArrow}
+	sub.expression(exp, min)
+	if sub.err != nil {
+		g.fail("%s", sub.err)
+	}
+	return sub.out.String()
+}
+
+// letNames は関数の本体で let される名前を現れた順に返す。ブロックはスコープを作らないので if の中も含めるが、
+// 入れ子の関数の中は含めない。
+func letNames(stmts []ast.Statement) []string {
+	names := []string{}
+	seen := map[string]bool{}
+	var statement func(stmt ast.Statement)
+	var expression func(exp ast.Expression)
+	statement = func(stmt ast.Statement) {
+		switch stmt := stmt.(type) {
+		case *ast.LetStatement:
+			expression(stmt.Value)
+			if stmt.Name != nil && !seen[stmt.Name.Value] {
+				seen[stmt.Name.Value] = true
+				names = append(names, stmt.Name.Value)
+			}
+		case *ast.ReturnStatement:
+			expression(stmt.ReturnValue)
+		case *ast.ExpressionStatement:
+			expression(stmt.Expression)
+		case *ast.BlockStatement:
+			if stmt != nil {
+				for _, s := range stmt.Statements {
+					statement(s)
+				}
+			}
+		}
+	}
+	expression = func(exp ast.Expression) {
+		switch exp := exp.(type) {
+		case *ast.IfExpression:
+			expression(exp.Condition)
+			statement(exp.Consequence)
+			if exp.Alternative != nil {
+				statement(exp.Alternative)
+			}
+		case *ast.PrefixExpression:
+			expression(exp.Right)
+		case *ast.InfixExpression:
+			expression(exp.Left)
+			expression(exp.Right)
+		case *ast.ConditionalExpression:
+			expression(exp.Condition)
+			expression(exp.Consequence)
+			expression(exp.Alternative)
+		case *ast.IndexExpression:
+			expression(exp.Left)
+			expression(exp.Index)
+		case *ast.CallExpression:
+			expression(exp.Function)
+			for _, arg := range exp.Arguments {
+				expression(arg)
+			}
+		case *ast.PipelineExpression:
+			expression(exp.Original)
+		case *ast.ArrayLiteral:
+			for _, element := range exp.Elements {
+				expression(element)
+			}
+		case *ast.HashLiteral:
+			// 宣言の順が実行ごとに変わらないよう、キーの順に辿る
+			for _, key := range exp.SortedKeys() {
+				expression(key)
+				expression(exp.Pairs[key])
+			}
+		}
+	}
+	for _, stmt := range stmts {
+		statement(stmt)
+	}
+	return names
+}
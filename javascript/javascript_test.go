@@ -0,0 +1,173 @@
+package javascript
+
+import (
+	"encoding/json"
+	"math/rand"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/al-keio/monkey-go/ast"
+	"github.com/al-keio/monkey-go/conformance"
+	"github.com/al-keio/monkey-go/internal/evaluator"
+	"github.com/al-keio/monkey-go/internal/lexer"
+	"github.com/al-keio/monkey-go/internal/parser"
+	"github.com/al-keio/monkey-go/object"
+)
+
+func parse(t *testing.T, input string) *ast.Program {
+	t.Helper()
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors for %q: %v", input, p.Errors())
+	}
+	return program
+}
+
+// expand はマクロを定義して展開したプログラムを返す
+func expand(program *ast.Program) *ast.Program {
+	macroEnv := object.NewEnvironment()
+	evaluator.DefineMacros(program, macroEnv)
+	return evaluator.ExpandMacros(program, macroEnv).(*ast.Program)
+}
+
+func TestTranspile(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1 + 2 * 3", "function $main() {\n  return 1 + 2 * 3;\n}"},
+		{"(1 + 2) * -(-3) / 2", "function $main() {\n  return Math.trunc((1 + 2) * -(-3) / 2);\n}"},
+		{"1 == 2 != false", "function $main() {\n  return 1 === 2 !== false;\n}"},
+		{"a + b + 1; a + b", "function $main() {\n  $m.add(a, b) + 1;\n  return $m.add(a, b);\n}"},
+		{"!x; !(1 < 2)", "function $main() {\n  !$m.truthy(x);\n  return !(1 < 2);\n}"},
+		{
+			"let x = 5; let f = fn(a) { a * x }; f(2)",
+			"function $main() {\n  let x, f;\n  x = 5;\n  f = function (a) {\n    return a * x;\n  };\n  return f(2);\n}",
+		},
+		{
+			"let len = fn(this) { this }; len(1); first([1])",
+			"function $main() {\n  let len;\n  len = function (this$) {\n    return this$;\n  };\n  len(1);\n  return $m.first([1]);\n}",
+		},
+		{
+			"if (x) { let y = 1; y } else { 2 }",
+			"function $main() {\n  let y;\n  if ($m.truthy(x)) {\n    y = 1;\n    return y;\n  } else {\n    return 2;\n  }\n}",
+		},
+		{
+			"let a = if (1 < 2) { 3 }; a",
+			"function $main() {\n  let a;\n  a = 1 < 2 ? 3 : null;\n  return a;\n}",
+		},
+		{
+			"puts(if (true) { let b = 1; b })",
+			"function $main() {\n  let b;\n  return $m.puts((() => {\n    if (true) {\n      b = 1;\n      return b;\n    }\n    return null;\n  })());\n}",
+		},
+		{
+			"fn(x) { x }(1); {\"b\": 2, \"a\": [1]}[\"a\"]",
+			"function $main() {\n  (function (x) {\n    return x;\n  })(1);\n  return {\"a\": [1], \"b\": 2}[\"a\"];\n}",
+		},
+		{
+			"{1: true, true: 1}; x ? 1 : 2",
+			"function $main() {\n  ({1: true, true: 1});\n  return $m.truthy(x) ? 1 : 2;\n}",
+		},
+		{
+			"let unless = macro(c, a, b) { quote(if (!(unquote(c))) { unquote(a) } else { unquote(b) }) }; unless(true, 1, 2)",
+			"function $main() {\n  if (!true) {\n    return 1;\n  } else {\n    return 2;\n  }\n}",
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := transpileMain(expand(parse(t, tt.input)))
+		if err != nil {
+			t.Errorf("transpile %q failed: %s", tt.input, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("transpile %q wrong.\nexpected=%q\ngot=%q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestTranspileErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1.5d", "cannot transpile decimal literal 1.5d"},
+		{"match (1) { _ => 1 }", "cannot transpile match expressions"},
+		{"sort([2, 1])", "cannot transpile builtin `sort`"},
+		{"fn() { let x = if (true) { return 1; 2 }; x }", "cannot transpile return inside an if expression"},
+		{"class A {}", "cannot transpile class declarations"},
+	}
+
+	for _, tt := range tests {
+		_, err := Transpile(parse(t, tt.input))
+		if err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("expected error containing %q for %q, got %v", tt.input, tt.expected, err)
+		}
+	}
+}
+
+// 評価器と変換した JavaScript を node で実行した結果が一致すること。
+// 変換できないプログラムや、評価器がエラーを返すプログラムは比べない。
+func TestAgainstEvaluator(t *testing.T) {
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("node is not installed")
+	}
+
+	r := rand.New(rand.NewSource(1))
+	cases := append([]conformance.Case{}, conformance.Corpus...)
+	// 配列の連結とハッシュの併合は JavaScript の + では再現できない
+	cases = append(cases,
+		conformance.Case{Name: "array concatenation", Input: "[1] + [2]"},
+		conformance.Case{Name: "array concatenation through variables", Input: "let a = [1, 2]; let b = [3]; a + b + a"},
+		conformance.Case{Name: "hash merge", Input: `let h = {"a": 1, "b": 2}; h + {"b": 3, "c": 4}`},
+		conformance.Case{Name: "string concatenation", Input: `let s = "a"; s + "b" + s`},
+	)
+	for i := 0; i < 300; i++ {
+		// 深さ 3 までなら値が 2^53 に収まる
+		cases = append(cases, conformance.Generate(r, 3))
+	}
+
+	// node を一度だけ起動するため、すべてのプログラムを一つのスクリプトにまとめ、結果を一行ずつ JSON で出力する
+	var script strings.Builder
+	script.WriteString("\"use strict\";\n" + Runtime)
+	compared := []conformance.Case{}
+	for _, c := range cases {
+		expected := conformance.Evaluate(c.Input)
+		if expected == "" || strings.HasPrefix(expected, "ERROR") || strings.HasPrefix(expected, "PARSE ERROR") {
+			continue
+		}
+		main, err := transpileMain(expand(parse(t, c.Input)))
+		if err != nil {
+			continue
+		}
+		c.Expected = expected
+		compared = append(compared, c)
+		script.WriteString("(() => {\n" + main + "\ntry {\n  console.log(JSON.stringify($m.inspect($main())));\n} catch (e) {\n  console.log(JSON.stringify(\"THROWN: \" + e));\n}\n})();\n")
+	}
+
+	cmd := exec.Command(node)
+	cmd.Stdin = strings.NewReader(script.String())
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("node failed: %s", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(out), "\n"), "\n")
+	if len(lines) != len(compared) {
+		t.Fatalf("node printed %d results for %d programs", len(lines), len(compared))
+	}
+	for i, c := range compared {
+		var got string
+		if err := json.Unmarshal([]byte(lines[i]), &got); err != nil {
+			t.Fatalf("could not read result %q: %s", lines[i], err)
+		}
+		if got != c.Expected {
+			t.Errorf("%s: %q\nevaluator=%q\njavascript=%q", c.Name, c.Input, c.Expected, got)
+		}
+	}
+	if len(compared) < 250 {
+		t.Errorf("only %d programs were compared", len(compared))
+	}
+}
@@ -0,0 +1,96 @@
+"""Jupyter kernel for Monkey.
+
+A wrapper kernel: ipykernel handles the Jupyter (ZMQ) protocol and each cell
+is sent to a long-running `monkey --json-repl` process.
+
+Install with:
+
+    jupyter kernelspec install --user --name monkey jupyter/
+"""
+
+import json
+import subprocess
+
+from ipykernel.kernelbase import Kernel
+
+
+class MonkeyKernel(Kernel):
+    implementation = "monkey"
+    implementation_version = "0.1"
+    language = "monkey"
+    language_version = "0.1"
+    language_info = {
+        "name": "monkey",
+        "mimetype": "text/x-monkey",
+        "file_extension": ".monkey",
+    }
+    banner = "Monkey kernel"
+
+    def __init__(self, **kwargs):
+        super().__init__(**kwargs)
+        self._start()
+
+    def _start(self):
+        self.monkey = subprocess.Popen(
+            ["monkey", "--json-repl"],
+            stdin=subprocess.PIPE,
+            stdout=subprocess.PIPE,
+            universal_newlines=True,
+            bufsize=1,
+        )
+
+    def _request(self, code):
+        self.monkey.stdin.write(json.dumps({"code": code}) + "\n")
+        self.monkey.stdin.flush()
+        line = self.monkey.stdout.readline()
+        if not line:
+            self._start()
+            return {"result": None, "errors": ["the monkey process exited; it has been restarted"], "stdout": ""}
+        return json.loads(line)
+
+    def _stream(self, name, text):
+        if text:
+            self.send_response(self.iopub_socket, "stream", {"name": name, "text": text})
+
+    def do_execute(self, code, silent, store_history=True, user_expressions=None, allow_stdin=False):
+        response = self._request(code)
+
+        if not silent:
+            self._stream("stdout", response.get("stdout", ""))
+
+        errors = response.get("errors") or []
+        if errors:
+            if not silent:
+                self._stream("stderr", "\n".join(errors) + "\n")
+            return {
+                "status": "error",
+                "execution_count": self.execution_count,
+                "ename": response.get("type") or "ParseError",
+                "evalue": "\n".join(errors),
+                "traceback": [],
+            }
+
+        result = response.get("result")
+        if not silent and result is not None and response.get("type") != "NULL":
+            self.send_response(self.iopub_socket, "execute_result", {
+                "execution_count": self.execution_count,
+                "data": {"text/plain": result},
+                "metadata": {},
+            })
+
+        return {
+            "status": "ok",
+            "execution_count": self.execution_count,
+            "payload": [],
+            "user_expressions": {},
+        }
+
+    def do_shutdown(self, restart):
+        self.monkey.terminate()
+        return {"status": "ok", "restart": restart}
+
+
+if __name__ == "__main__":
+    from ipykernel.kernelapp import IPKernelApp
+
+    IPKernelApp.launch_instance(kernel_class=MonkeyKernel)
@@ -1,14 +1,88 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
 	"os/user"
+	"plugin"
+	"strings"
 
+	"github.com/al-keio/monkey-go/analysis"
+	"github.com/al-keio/monkey-go/ast"
+	"github.com/al-keio/monkey-go/format"
+	"github.com/al-keio/monkey-go/internal/evaluator"
+	"github.com/al-keio/monkey-go/internal/lexer"
+	"github.com/al-keio/monkey-go/internal/parser"
+	"github.com/al-keio/monkey-go/javascript"
+	"github.com/al-keio/monkey-go/object"
 	"github.com/al-keio/monkey-go/repl"
+	"github.com/al-keio/monkey-go/watch"
 )
 
+var builtinPlugins = flag.String("builtins", "", "comma separated list of builtin plugins (.so) to load")
+var traceParse = flag.Bool("trace-parse", false, "print the parser trace for each input")
+var watchFile = flag.Bool("watch", false, "re-run the script whenever it changes")
+var stackArgs = flag.Bool("stack-args", false, "show argument values in stack traces")
+var jsonREPL = flag.Bool("json-repl", false, "read JSON requests and write JSON responses on stdin and stdout instead of the interactive REPL")
+var rcFile = flag.String("rc", repl.DefaultRCFile(), "file of statements and REPL commands to run when the REPL starts")
+var sandbox = flag.Bool("sandbox", false, "run the script in a sandbox where builtins may only use the capabilities given by -allow")
+var traceFile = flag.String("trace", "", "write a Chrome trace of the script's function calls to this file (open it in chrome://tracing or Perfetto)")
+var allow = flag.String("allow", "", "comma separated list of capabilities (io, net, proc, time) allowed in the sandbox")
+var fuse = flag.Bool("fuse", false, "evaluate chains of map and filter calls in a single pass (callbacks then run element by element, interleaving the stages)")
+
 func main() {
+	flag.Parse()
+
+	if err := loadBuiltinPlugins(*builtinPlugins); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	repl.TraceParse = *traceParse
+	repl.RCFile = *rcFile
+	repl.Fuse = *fuse
+
+	if flag.NArg() == 2 && flag.Arg(0) == "outline" {
+		if err := printOutline(os.Stdout, flag.Arg(1)); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if flag.NArg() >= 2 && flag.Arg(0) == "expand" {
+		if err := runExpand(flag.Args()[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if flag.NArg() >= 2 && flag.Arg(0) == "js" {
+		if err := runJS(flag.Args()[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if flag.NArg() > 0 && *watchFile {
+		runWatching(flag.Arg(0), flag.Args()[1:], watch.NewPoller(flag.Arg(0)))
+		return
+	}
+	if flag.NArg() > 0 {
+		if err := runFile(flag.Arg(0), flag.Args()[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *jsonREPL {
+		repl.StartJSON(os.Stdin, os.Stdout)
+		return
+	}
+
 	user, err := user.Current()
 	if err != nil {
 		panic(err)
@@ -17,3 +91,224 @@ func main() {
 	fmt.Printf("Feel free to type in commands\n")
 	repl.Start(os.Stdin, os.Stdout)
 }
+
+// runFile はスクリプトを args を引数として実行する。先頭の #! 行は字句解析器が読み飛ばすので、
+// chmod +x したスクリプトをそのまま実行できる。
+func runFile(path string, args []string) error {
+	input, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	p := parser.New(lexer.New(string(input)))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return fmt.Errorf("%s: parser errors:\n\t%s", path, strings.Join(p.ErrorsWithHints(), "\n\t"))
+	}
+
+	options := flagOptions()
+	options.Args, options.Source, options.Filename = args, string(input), path
+	if *traceFile != "" {
+		trace, err := os.Create(*traceFile)
+		if err != nil {
+			return err
+		}
+		defer trace.Close()
+		options.Trace = trace
+	}
+
+	// マクロ本体と unquote の引数もスクリプトの一部として、サンドボックスなどの制限の下で評価する
+	macroEnv := object.NewEnvironment()
+	evaluator.DefineMacros(program, macroEnv)
+	expanded, expandErr := evaluator.ExpandMacrosWithOptions(program, macroEnv, options)
+	if expandErr != nil {
+		return runtimeError(path, expandErr)
+	}
+	if *fuse {
+		expanded = evaluator.Optimize(expanded)
+	}
+
+	if result, ok := evaluator.EvalWithOptions(expanded, object.NewEnvironment(), options).Value.(*object.Error); ok {
+		return runtimeError(path, result)
+	}
+	return nil
+}
+
+// flagOptions はコマンドラインのフラグから評価の設定を作る
+func flagOptions() evaluator.EvalOptions {
+	options := evaluator.EvalOptions{StackArguments: *stackArgs, Sandbox: *sandbox}
+	for _, capability := range strings.Split(*allow, ",") {
+		if capability = strings.TrimSpace(capability); capability != "" {
+			options.Allow = append(options.Allow, object.Capability(capability))
+		}
+	}
+	return options
+}
+
+// runtimeError は実行時エラーを助言とスタックトレース付きで path のエラーにする
+func runtimeError(path string, result *object.Error) error {
+	if result.GoStack != "" {
+		fmt.Fprintf(os.Stderr, "this is a bug in the interpreter; please report it with the following:\n%s\n", result.GoStack)
+	}
+	msg := result.Inspect()
+	if result.Hint != "" {
+		msg += "\n  hint: " + result.Hint
+	}
+	return fmt.Errorf("%s: %s", path, strings.TrimSuffix(msg+"\n"+result.StackTrace(), "\n"))
+}
+
+// printOutline は monkey outline file の実装。ファイル中の let を入れ子に従って字下げして一行ずつ表示する。
+func printOutline(out io.Writer, path string) error {
+	input, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	p := parser.New(lexer.New(string(input)))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return fmt.Errorf("%s: parser errors:\n\t%s", path, strings.Join(p.ErrorsWithHints(), "\n\t"))
+	}
+
+	line := func(offset int) int { return strings.Count(string(input[:offset]), "\n") + 1 }
+	var printSymbols func(symbols []analysis.Symbol, indent string)
+	printSymbols = func(symbols []analysis.Symbol, indent string) {
+		for _, symbol := range symbols {
+			fmt.Fprintf(out, "%s%s %s (lines %d-%d)\n", indent, symbol.Kind, symbol.Name, line(symbol.Start), line(symbol.End))
+			printSymbols(symbol.Children, indent+"  ")
+		}
+	}
+	printSymbols(analysis.Outline(program), "")
+	return nil
+}
+
+// runExpand は monkey expand file [-o output] の実装。マクロをすべて展開して定義を取り除いたソースを
+// 整形して output (省略すると標準出力) に書く。マクロに対応していない処理系にそのまま渡せる。
+func runExpand(args []string) error {
+	return convertFile("expand", "expanded.monkey", args, func(program *ast.Program) (string, error) {
+		return format.Source(program)
+	})
+}
+
+// runJS は monkey js file [-o output] の実装。マクロを展開したプログラムを JavaScript に変換して書く。
+func runJS(args []string) error {
+	return convertFile("js", "out.js", args, javascript.Transpile)
+}
+
+// convertFile は args で指定したファイルのマクロを展開して convert で変換し、-o のファイル (省略すると標準出力) に書く
+func convertFile(command, example string, args []string, convert func(*ast.Program) (string, error)) error {
+	usage := fmt.Errorf("usage: monkey %s file.monkey [-o %s]", command, example)
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	output := flags.String("o", "", "file to write to (default stdout)")
+	// -o はファイル名の前にも後ろにも書ける
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return usage
+	}
+	path := flags.Arg(0)
+	if err := flags.Parse(flags.Args()[1:]); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return usage
+	}
+
+	input, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	p := parser.New(lexer.New(string(input)))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return fmt.Errorf("%s: parser errors:\n\t%s", path, strings.Join(p.ErrorsWithHints(), "\n\t"))
+	}
+
+	macroEnv := object.NewEnvironment()
+	evaluator.DefineMacros(program, macroEnv)
+	options := flagOptions()
+	options.Source, options.Filename = string(input), path
+	expanded, expandErr := evaluator.ExpandMacrosWithOptions(program, macroEnv, options)
+	if expandErr != nil {
+		return runtimeError(path, expandErr)
+	}
+	if err := checkExpanded(expanded, macroEnv); err != nil {
+		return fmt.Errorf("%s: %s", path, err)
+	}
+
+	source, err := convert(expanded.(*ast.Program))
+	if err != nil {
+		return fmt.Errorf("%s: %s", path, err)
+	}
+	if *output == "" {
+		_, err = fmt.Println(source)
+		return err
+	}
+	return ioutil.WriteFile(*output, []byte(source+"\n"), 0644)
+}
+
+// checkExpanded は展開できずに残ったマクロの呼び出しがあればエラーを返す
+func checkExpanded(node ast.Node, macroEnv *object.Environment) error {
+	var remaining []string
+	ast.Modify(node, func(node ast.Node) ast.Node {
+		if call, ok := node.(*ast.CallExpression); ok {
+			if ident, ok := call.Function.(*ast.Identifier); ok {
+				if obj, ok := macroEnv.Get(ident.Value); ok && obj.Type() == object.MACRO_OBJ {
+					remaining = append(remaining, call.String())
+				}
+			}
+		}
+		return node
+	})
+	if len(remaining) != 0 {
+		return fmt.Errorf("could not expand macro calls:\n\t%s", strings.Join(remaining, "\n\t"))
+	}
+	return nil
+}
+
+// runWatching はスクリプトを実行し、変更されるたびに画面を消してから実行し直す。
+// エラーがあっても終了せずに表示して次の変更を待つ。
+func runWatching(path string, args []string, w watch.Watcher) {
+	for {
+		fmt.Print("\033[H\033[2J")
+		if err := runFile(path, args); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		fmt.Fprintf(os.Stderr, "watching %s for changes...\n", path)
+		w.Wait()
+	}
+}
+
+// loadBuiltinPlugins はプラグインを読み込む。プラグインは init で monkey.RegisterBuiltin を呼ぶか、
+// map[string]*object.Builtin 型の Builtins 変数を公開して組み込み関数を提供する。
+// evaluator パッケージは internal なので、プラグインから evaluator.RegisterBuiltin は呼べない。
+func loadBuiltinPlugins(paths string) error {
+	if paths == "" {
+		return nil
+	}
+
+	for _, path := range strings.Split(paths, ",") {
+		p, err := plugin.Open(path)
+		if err != nil {
+			return fmt.Errorf("could not load builtins %s: %v", path, err)
+		}
+
+		sym, err := p.Lookup("Builtins")
+		if err != nil {
+			continue
+		}
+
+		bundle, ok := sym.(*map[string]*object.Builtin)
+		if !ok {
+			return fmt.Errorf("could not load builtins %s: Builtins has type %T", path, sym)
+		}
+		for name, builtin := range *bundle {
+			if err := evaluator.RegisterBuiltin(name, builtin); err != nil {
+				return fmt.Errorf("could not load builtins %s: %v", path, err)
+			}
+		}
+	}
+
+	return nil
+}
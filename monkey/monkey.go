@@ -0,0 +1,190 @@
+// Package monkey は Go のプログラムに Monkey を組み込むための安定した API。
+//
+// このパッケージと monkey/objectapi が公開している名前は、APIVersion のメジャー番号が変わらない限り、
+// 削除したりシグネチャや意味を変えたりしない。追加はマイナー番号を上げて行う。
+// 字句解析器、構文解析器、評価器は internal 以下にあり、予告なく変わるので組み込む側からは使えない。
+// ast と object パッケージは ParseProgram や Interpreter.Environment などの受け渡し口として使えるが、
+// それらの型自体は互換性を約束しない。
+package monkey
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/al-keio/monkey-go/ast"
+	"github.com/al-keio/monkey-go/internal/evaluator"
+	"github.com/al-keio/monkey-go/internal/lexer"
+	"github.com/al-keio/monkey-go/internal/parser"
+	"github.com/al-keio/monkey-go/monkey/objectapi"
+	"github.com/al-keio/monkey-go/object"
+)
+
+// APIVersion はこのパッケージと objectapi の API の版。互換性のない変更をするときはメジャー番号を上げる。
+const APIVersion = "1.3.0"
+
+// Options は Interpreter の設定。ゼロ値は標準出力に書き、評価を制限しない。
+type Options struct {
+	// Output は puts や print の出力先。nil なら os.Stdout。
+	Output io.Writer
+	// MaxSteps が正なら、一回の Run や Call で評価できるノードの数をこれまでに制限する。
+	// Run ではマクロの展開と評価でそれぞれ別に数える。
+	MaxSteps int64
+	// MaxCallDepth が正なら、関数呼び出しの入れ子の深さをこれまでに制限する。1.1.0 で追加。
+	MaxCallDepth int
+	// Context が取り消されるか期限を過ぎると、評価は CANCELLED のエラーで終わる。
+	Context context.Context
+	// Args はスクリプトに渡すコマンドライン引数。args() で読める。
+	Args []string
+	// Filename はエラーのスタックトレースに表示するファイル名。
+	Filename string
+	// Sandbox が true なら、組み込み関数は必要とする作用がすべて Allow に含まれるときだけ呼べる。
+	// 作用は "io"、"net"、"proc"、"time" のいずれか。ほかの呼び出しは CAPABILITY_DENIED のエラーになる。
+	// RegisterBuiltin で登録した関数は作用を必要としない。1.2.0 で追加。
+	Sandbox bool
+	Allow   []string
+}
+
+// Interpreter は Run をまたいで変数とマクロの定義を保持する評価器。
+// 一つの Interpreter を複数のゴルーチンから同時に使ってはならない。
+type Interpreter struct {
+	options Options
+	env     *object.Environment
+	macros  *object.Environment
+	format  object.OutputFormat // スクリプトが set_output_format で設定した書式
+}
+
+func New(options Options) *Interpreter {
+	return &Interpreter{
+		options: options,
+		env:     object.NewEnvironment(),
+		macros:  object.NewEnvironment(),
+		format:  object.DefaultOutputFormat,
+	}
+}
+
+// ParseError は構文エラー。Errors には一つのエラーごとに、直し方の助言があればそれを含めた文を入れる。
+type ParseError struct {
+	Errors []string
+}
+
+func (e *ParseError) Error() string {
+	return "parser errors:\n\t" + strings.Join(e.Errors, "\n\t")
+}
+
+// RuntimeError はスクリプトの実行時エラー。Code は object パッケージの *_ERR 定数と同じ文字列。
+type RuntimeError struct {
+	Code       string
+	Message    string
+	Hint       string // "did you mean: len?" のような助言。なければ空
+	StackTrace string // 一行に一フレームずつのスタックトレース。なければ空
+}
+
+func (e *RuntimeError) Error() string {
+	return e.Message
+}
+
+// ParseProgram は source を構文木にする。構文エラーなら *ParseError を返す。
+func ParseProgram(source string) (*ast.Program, error) {
+	p := parser.New(lexer.New(source))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return nil, &ParseError{Errors: p.ErrorsWithHints()}
+	}
+	return program, nil
+}
+
+// ParseExpression は source 全体を一つの式として解析する。ルールやフィルタのように式だけを受け付けたいホスト向け。
+// let などの文や複数の式を含むときも *ParseError を返す。1.3.0 で追加。
+func ParseExpression(source string) (ast.Expression, error) {
+	p := parser.New(lexer.New(source))
+	exp := p.ParseExpression()
+	if len(p.Errors()) != 0 {
+		return nil, &ParseError{Errors: p.ErrorsWithHints()}
+	}
+	return exp, nil
+}
+
+// Run は source を解析してマクロを展開し、これまでの Run で束縛した名前が見える環境で評価して最後の値を返す。
+// マクロの展開にも Options の制限を課す。構文エラーなら *ParseError、実行時エラーなら *RuntimeError を返す。
+func (in *Interpreter) Run(source string) (objectapi.Value, error) {
+	program, err := ParseProgram(source)
+	if err != nil {
+		return objectapi.Null(), err
+	}
+
+	options := in.evalOptions()
+	options.Source = source
+	evaluator.DefineMacros(program, in.macros)
+	expanded, expandErr := evaluator.ExpandMacrosWithOptions(program, in.macros, options)
+	if expandErr != nil {
+		return result(expandErr)
+	}
+	return result(evaluator.EvalWithOptions(expanded, in.env, options).Value)
+}
+
+// Call は関数 fn を args で呼び出す。fn は Run が返した関数や Get で取り出した関数。
+func (in *Interpreter) Call(fn objectapi.Value, args ...objectapi.Value) (objectapi.Value, error) {
+	if fn.Kind() != objectapi.FunctionKind {
+		return objectapi.Null(), fmt.Errorf("not a function: %s", fn.Kind())
+	}
+	objs := make([]object.Object, len(args))
+	for i, arg := range args {
+		objs[i] = arg.Object()
+	}
+	return result(evaluator.ApplyWithOptions(fn.Object(), objs, in.evalOptions()).Value)
+}
+
+// Get はスクリプトが大域で束縛した name の値を返す
+func (in *Interpreter) Get(name string) (objectapi.Value, bool) {
+	obj, ok := in.env.Get(name)
+	if !ok {
+		return objectapi.Null(), false
+	}
+	return objectapi.Wrap(obj), true
+}
+
+// Set は name に value を大域で束縛する。スクリプトからは let で束縛した変数と同じに見える。
+func (in *Interpreter) Set(name string, value objectapi.Value) {
+	in.env.Set(name, value.Object())
+}
+
+// Environment は大域の環境を返す。object パッケージを使う既存のコードと環境を共有するための受け渡し口。
+func (in *Interpreter) Environment() *object.Environment {
+	return in.env
+}
+
+// RegisterBuiltin は name という名前の組み込み関数を、これから評価するすべてのスクリプトに追加する。
+// パッケージの init から呼ぶことを想定している。fn が nil のときや、同じ名前の組み込み関数が
+// すでにあるときはエラーを返し、何も追加しない。
+func RegisterBuiltin(name, doc string, fn objectapi.Func) error {
+	if fn == nil {
+		return fmt.Errorf("builtin %s has no function", name)
+	}
+	return evaluator.RegisterBuiltin(name, objectapi.Builtin(name, doc, fn))
+}
+
+func (in *Interpreter) evalOptions() evaluator.EvalOptions {
+	options := evaluator.EvalOptions{
+		Output:       in.options.Output,
+		MaxSteps:     in.options.MaxSteps,
+		MaxCallDepth: in.options.MaxCallDepth,
+		Context:      in.options.Context,
+		Args:         in.options.Args,
+		Filename:     in.options.Filename,
+		Sandbox:      in.options.Sandbox,
+		OutputFormat: &in.format,
+	}
+	for _, capability := range in.options.Allow {
+		options.Allow = append(options.Allow, object.Capability(capability))
+	}
+	return options
+}
+
+func result(obj object.Object) (objectapi.Value, error) {
+	if err, ok := obj.(*object.Error); ok {
+		return objectapi.Null(), &RuntimeError{Code: err.Code, Message: err.Message, Hint: err.Hint, StackTrace: err.StackTrace()}
+	}
+	return objectapi.Wrap(obj), nil
+}
@@ -0,0 +1,179 @@
+package monkey
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/al-keio/monkey-go/monkey/objectapi"
+	"github.com/al-keio/monkey-go/object"
+)
+
+func init() {
+	err := RegisterBuiltin("host_upper", "host_upper(s): s in upper case", func(args []objectapi.Value) (objectapi.Value, error) {
+		if len(args) != 1 {
+			return objectapi.Null(), errors.New("want 1 argument")
+		}
+		s, ok := args[0].AsString()
+		if !ok {
+			return objectapi.Null(), errors.New("argument must be a string")
+		}
+		return objectapi.String(strings.ToUpper(s)), nil
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+func TestRegisterBuiltinErrors(t *testing.T) {
+	upper := func(args []objectapi.Value) (objectapi.Value, error) { return objectapi.Null(), nil }
+	if err := RegisterBuiltin("host_upper", "", upper); err == nil {
+		t.Errorf("registering host_upper twice did not fail")
+	}
+	if err := RegisterBuiltin("host_nil", "", nil); err == nil {
+		t.Errorf("registering a nil function did not fail")
+	}
+}
+
+func TestRunKeepsBindings(t *testing.T) {
+	var out bytes.Buffer
+	in := New(Options{Output: &out})
+
+	if _, err := in.Run("let add = fn(a, b) { a + b }; let twice = macro(x) { quote(unquote(x) + unquote(x)) };"); err != nil {
+		t.Fatalf("Run failed: %s", err)
+	}
+	value, err := in.Run(`puts("hi"); twice(add(1, 2))`)
+	if err != nil {
+		t.Fatalf("Run failed: %s", err)
+	}
+	if n, ok := value.AsInt(); !ok || n != 6 {
+		t.Errorf("expected 6, got %s", value)
+	}
+	if out.String() != "hi\n" {
+		t.Errorf("output wrong. got=%q", out.String())
+	}
+
+	add, ok := in.Get("add")
+	if !ok || add.Kind() != objectapi.FunctionKind {
+		t.Fatalf("add is not bound to a function: %s", add)
+	}
+	sum, err := in.Call(add, objectapi.Integer(40), objectapi.Integer(2))
+	if err != nil || sum.Inspect() != "42" {
+		t.Errorf("Call(add) = %s, %v", sum, err)
+	}
+}
+
+func TestOutputFormatIsPerInterpreter(t *testing.T) {
+	var out bytes.Buffer
+	a, b := New(Options{Output: &out}), New(Options{Output: &out})
+
+	for _, run := range []struct {
+		in     *Interpreter
+		source string
+	}{
+		{a, `set_output_format({"hex": true})`},
+		{b, `puts(255)`},
+		{a, `puts(255)`},
+	} {
+		if _, err := run.in.Run(run.source); err != nil {
+			t.Fatalf("Run(%q) failed: %s", run.source, err)
+		}
+	}
+	if out.String() != "255\n0xff\n" {
+		t.Errorf("output wrong. got=%q", out.String())
+	}
+}
+
+func TestSetAndBuiltins(t *testing.T) {
+	in := New(Options{})
+	config, err := objectapi.FromGo(map[string]interface{}{"name": "monkey", "tags": []interface{}{"a", true}})
+	if err != nil {
+		t.Fatalf("FromGo failed: %s", err)
+	}
+	in.Set("config", config)
+
+	value, err := in.Run(`host_upper(config["name"]) + len(config["tags"])`)
+	if err == nil {
+		t.Fatalf("expected a type error, got %s", value)
+	}
+	value, err = in.Run(`[host_upper(config["name"]), config["tags"][1]]`)
+	if err != nil {
+		t.Fatalf("Run failed: %s", err)
+	}
+	if value.Inspect() != "[MONKEY, true]" {
+		t.Errorf("wrong value: %s", value)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	in := New(Options{MaxSteps: 100})
+
+	_, err := in.Run("let = 1")
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) || len(parseErr.Errors) == 0 {
+		t.Errorf("expected a ParseError, got %v", err)
+	}
+
+	tests := []struct {
+		input string
+		code  string
+		hint  string
+	}{
+		{"lenn([1])", object.UNKNOWN_IDENTIFIER_ERR, "did you mean: len?"},
+		{"host_upper(1)", object.HOST_ERR, ""},
+		{"let loop = fn() { loop() }; loop()", object.STEP_LIMIT_ERR, ""},
+		{"let m = macro() { let loop = fn() { loop() }; quote(unquote(loop())) }; m()", object.STEP_LIMIT_ERR, ""},
+	}
+	for _, tt := range tests {
+		_, err := in.Run(tt.input)
+		var runtimeErr *RuntimeError
+		if !errors.As(err, &runtimeErr) {
+			t.Errorf("%s: expected a RuntimeError, got %v", tt.input, err)
+			continue
+		}
+		if runtimeErr.Code != tt.code || runtimeErr.Hint != tt.hint {
+			t.Errorf("%s: got code=%s hint=%q, want code=%s hint=%q", tt.input, runtimeErr.Code, runtimeErr.Hint, tt.code, tt.hint)
+		}
+	}
+
+	if _, err := in.Call(objectapi.Integer(1)); err == nil {
+		t.Errorf("expected an error calling an integer")
+	}
+}
+
+func TestParseExpression(t *testing.T) {
+	exp, err := ParseExpression("user[\"age\"] > 17 == user[\"active\"];")
+	if err != nil {
+		t.Fatalf("ParseExpression failed: %s", err)
+	}
+	if exp.String() != "(((user[age]) > 17) == (user[active]))" {
+		t.Errorf("wrong expression: %s", exp.String())
+	}
+
+	for _, input := range []string{"", "let x = 1", "1; 2", "1 +"} {
+		_, err := ParseExpression(input)
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) || len(parseErr.Errors) == 0 {
+			t.Errorf("%q: expected a ParseError, got %v", input, err)
+		}
+	}
+}
+
+func TestSandbox(t *testing.T) {
+	var out bytes.Buffer
+	in := New(Options{Output: &out, Sandbox: true, Allow: []string{"io"}})
+
+	if _, err := in.Run(`puts(host_upper("a"))`); err != nil {
+		t.Fatalf("run failed: %s", err)
+	}
+	if out.String() != "A\n" {
+		t.Errorf("wrong output. got=%q", out.String())
+	}
+
+	_, err := in.Run("args()")
+	var runtimeErr *RuntimeError
+	if !errors.As(err, &runtimeErr) || runtimeErr.Code != object.CAPABILITY_DENIED_ERR {
+		t.Errorf("expected a CAPABILITY_DENIED error, got %v", err)
+	}
+}
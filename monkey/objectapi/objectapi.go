@@ -0,0 +1,328 @@
+// Package objectapi は Monkey の値を Go から作ったり読んだりするための安定した API。
+// monkey パッケージと同じく、公開している名前は monkey.APIVersion のメジャー番号が変わらない限り互換性を保つ。
+//
+// 値の実体は object パッケージの型だが、object は互換性を約束しないので、組み込む側は Value を通して扱う。
+// 既存の object.Object や object.Builtin を使うコードとは Wrap、Value.Object、Builtin で受け渡しできる。
+package objectapi
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/al-keio/monkey-go/internal/evaluator"
+	"github.com/al-keio/monkey-go/object"
+)
+
+// Kind は値の種類。将来ほかの種類が増えても、既存の定数の意味は変えない。
+type Kind int
+
+const (
+	NullKind Kind = iota
+	IntegerKind
+	BooleanKind
+	StringKind
+	ArrayKind
+	HashKind
+	FunctionKind
+	DecimalKind
+	// OtherKind は上のどれでもない値 (クオートや名前空間など)。Inspect でしか中身を読めない。
+	OtherKind
+)
+
+var kindNames = [...]string{"null", "integer", "boolean", "string", "array", "hash", "function", "decimal", "other"}
+
+func (k Kind) String() string {
+	if k < 0 || int(k) >= len(kindNames) {
+		return fmt.Sprintf("Kind(%d)", int(k))
+	}
+	return kindNames[k]
+}
+
+// Value は Monkey の値。ゼロ値は null。Value は変更できないものとして扱い、配列やハッシュもコピーを返す。
+type Value struct {
+	obj object.Object
+}
+
+// Wrap は object.Object を Value にする。nil は null になる。
+func Wrap(obj object.Object) Value {
+	return Value{obj: obj}
+}
+
+// Object は評価器に渡せる object.Object を返す。null と真偽値は評価器が使う共通の値にそろえる。
+func (v Value) Object() object.Object {
+	switch obj := v.obj.(type) {
+	case nil, *object.Null:
+		return evaluator.NULL
+	case *object.Boolean:
+		if obj.Value {
+			return evaluator.TRUE
+		}
+		return evaluator.FALSE
+	}
+	return v.obj
+}
+
+func (v Value) Kind() Kind {
+	switch v.obj.(type) {
+	case nil, *object.Null:
+		return NullKind
+	case *object.Integer:
+		return IntegerKind
+	case *object.Boolean:
+		return BooleanKind
+	case *object.String:
+		return StringKind
+	case *object.Array:
+		return ArrayKind
+	case *object.Hash:
+		return HashKind
+	case *object.Function, *object.Builtin:
+		return FunctionKind
+	case *object.Decimal:
+		return DecimalKind
+	}
+	return OtherKind
+}
+
+// Inspect は REPL が表示するのと同じ文字列を返す
+func (v Value) Inspect() string {
+	return v.Object().Inspect()
+}
+
+func (v Value) String() string {
+	return v.Inspect()
+}
+
+func (v Value) IsNull() bool {
+	return v.Kind() == NullKind
+}
+
+func (v Value) AsInt() (int64, bool) {
+	i, ok := v.obj.(*object.Integer)
+	if !ok {
+		return 0, false
+	}
+	return i.Value, true
+}
+
+func (v Value) AsBool() (bool, bool) {
+	b, ok := v.obj.(*object.Boolean)
+	if !ok {
+		return false, false
+	}
+	return b.Value, true
+}
+
+func (v Value) AsString() (string, bool) {
+	s, ok := v.obj.(*object.String)
+	if !ok {
+		return "", false
+	}
+	return s.Value, true
+}
+
+// AsArray は配列の要素のコピーを返す
+func (v Value) AsArray() ([]Value, bool) {
+	a, ok := v.obj.(*object.Array)
+	if !ok {
+		return nil, false
+	}
+	elements := make([]Value, len(a.Elements))
+	for i, element := range a.Elements {
+		elements[i] = Wrap(element)
+	}
+	return elements, true
+}
+
+// Pair はハッシュのキーと値の組
+type Pair struct {
+	Key   Value
+	Value Value
+}
+
+// AsHash はハッシュの組をキーの Inspect の順に並べて返す。プロトタイプの組は含めない。
+func (v Value) AsHash() ([]Pair, bool) {
+	h, ok := v.obj.(*object.Hash)
+	if !ok {
+		return nil, false
+	}
+	pairs := make([]Pair, 0, h.Len())
+	for _, pair := range h.PairList() {
+		pairs = append(pairs, Pair{Key: Wrap(pair.Key), Value: Wrap(pair.Value)})
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].Key.Inspect() < pairs[j].Key.Inspect() })
+	return pairs, true
+}
+
+// Get はハッシュの key の値を返す。プロトタイプの連鎖もたどる。
+func (v Value) Get(key Value) (Value, bool) {
+	h, ok := v.obj.(*object.Hash)
+	if !ok {
+		return Value{}, false
+	}
+	if object.UnhashablePart(key.Object()) != nil {
+		return Value{}, false
+	}
+	pair, ok := h.Lookup(key.Object())
+	if !ok {
+		return Value{}, false
+	}
+	return Wrap(pair.Value), true
+}
+
+func Null() Value {
+	return Value{}
+}
+
+func Integer(n int64) Value {
+	return Wrap(&object.Integer{Value: n})
+}
+
+func Boolean(b bool) Value {
+	if b {
+		return Wrap(evaluator.TRUE)
+	}
+	return Wrap(evaluator.FALSE)
+}
+
+func String(s string) Value {
+	return Wrap(&object.String{Value: s})
+}
+
+func Array(elements ...Value) Value {
+	objs := make([]object.Object, len(elements))
+	for i, element := range elements {
+		objs[i] = element.Object()
+	}
+	return Wrap(&object.Array{Elements: objs})
+}
+
+// Hash は pairs からハッシュを作る。キーが deque やそれを含む配列など、ハッシュのキーに使えない値ならエラーを返す。
+func Hash(pairs ...Pair) (Value, error) {
+	h := object.NewHash()
+	for _, pair := range pairs {
+		key := pair.Key.Object()
+		if part := object.UnhashablePart(key); part != nil {
+			return Value{}, fmt.Errorf("unusable as hash key: %s", Wrap(part).Kind())
+		}
+		h.Set(key, pair.Value.Object())
+	}
+	return Wrap(h), nil
+}
+
+// FromGo は Go の値を Monkey の値にする。使えるのは nil、bool、符号付きと符号なしの整数、string、
+// []interface{}、map[string]interface{} とそれらの入れ子、そして Value。
+func FromGo(x interface{}) (Value, error) {
+	switch x := x.(type) {
+	case nil:
+		return Null(), nil
+	case Value:
+		return x, nil
+	case bool:
+		return Boolean(x), nil
+	case int:
+		return Integer(int64(x)), nil
+	case int8:
+		return Integer(int64(x)), nil
+	case int16:
+		return Integer(int64(x)), nil
+	case int32:
+		return Integer(int64(x)), nil
+	case int64:
+		return Integer(x), nil
+	case uint8:
+		return Integer(int64(x)), nil
+	case uint16:
+		return Integer(int64(x)), nil
+	case uint32:
+		return Integer(int64(x)), nil
+	case uint:
+		if uint64(x) > 1<<63-1 {
+			return Value{}, fmt.Errorf("%d does not fit in an integer", x)
+		}
+		return Integer(int64(x)), nil
+	case uint64:
+		if x > 1<<63-1 {
+			return Value{}, fmt.Errorf("%d does not fit in an integer", x)
+		}
+		return Integer(int64(x)), nil
+	case string:
+		return String(x), nil
+	case []interface{}:
+		elements := make([]Value, len(x))
+		for i, element := range x {
+			v, err := FromGo(element)
+			if err != nil {
+				return Value{}, err
+			}
+			elements[i] = v
+		}
+		return Array(elements...), nil
+	case map[string]interface{}:
+		pairs := make([]Pair, 0, len(x))
+		for key, value := range x {
+			v, err := FromGo(value)
+			if err != nil {
+				return Value{}, err
+			}
+			pairs = append(pairs, Pair{Key: String(key), Value: v})
+		}
+		return Hash(pairs...)
+	}
+	return Value{}, fmt.Errorf("cannot convert %T to a Monkey value", x)
+}
+
+// Go は値を Go の値にする。null は nil、整数は int64、真偽値は bool、文字列は string、配列は []interface{}、
+// ハッシュはキーを Inspect した文字列で引く map[string]interface{} になる。ほかの種類は Value のまま返す。
+func (v Value) Go() interface{} {
+	switch obj := v.obj.(type) {
+	case nil, *object.Null:
+		return nil
+	case *object.Integer:
+		return obj.Value
+	case *object.Boolean:
+		return obj.Value
+	case *object.String:
+		return obj.Value
+	case *object.Array:
+		elements := make([]interface{}, len(obj.Elements))
+		for i, element := range obj.Elements {
+			elements[i] = Wrap(element).Go()
+		}
+		return elements
+	case *object.Hash:
+		m := make(map[string]interface{}, obj.Len())
+		for _, pair := range obj.PairList() {
+			m[pair.Key.Inspect()] = Wrap(pair.Value).Go()
+		}
+		return m
+	}
+	return v
+}
+
+// Func は Go で書いた組み込み関数。エラーを返すとスクリプトには HOST のエラーとして伝わる。
+type Func func(args []Value) (Value, error)
+
+// Builtin は fn を評価器に登録できる object.Builtin にする。fn の panic も HOST のエラーになる。
+func Builtin(name, doc string, fn Func) *object.Builtin {
+	return &object.Builtin{
+		Doc: doc,
+		Fn: func(args ...object.Object) (result object.Object) {
+			defer func() {
+				if r := recover(); r != nil {
+					result = &object.Error{Code: object.HOST_ERR, Message: fmt.Sprintf("%s: panic: %v", name, r)}
+				}
+			}()
+
+			values := make([]Value, len(args))
+			for i, arg := range args {
+				values[i] = Wrap(arg)
+			}
+			value, err := fn(values)
+			if err != nil {
+				return &object.Error{Code: object.HOST_ERR, Message: fmt.Sprintf("%s: %s", name, err)}
+			}
+			return value.Object()
+		},
+	}
+}
@@ -0,0 +1,100 @@
+package objectapi
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/al-keio/monkey-go/object"
+)
+
+func TestFromGoAndGo(t *testing.T) {
+	input := map[string]interface{}{
+		"n":     42,
+		"ok":    true,
+		"none":  nil,
+		"items": []interface{}{"a", uint8(1), []interface{}{}},
+	}
+	v, err := FromGo(input)
+	if err != nil {
+		t.Fatalf("FromGo failed: %s", err)
+	}
+	if v.Kind() != HashKind {
+		t.Fatalf("expected a hash, got %s", v.Kind())
+	}
+	if v.Inspect() != "{items: [a, 1, []], n: 42, none: null, ok: true}" {
+		t.Errorf("Inspect wrong: %s", v.Inspect())
+	}
+
+	expected := map[string]interface{}{
+		"n":     int64(42),
+		"ok":    true,
+		"none":  nil,
+		"items": []interface{}{"a", int64(1), []interface{}{}},
+	}
+	if got := v.Go(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Go() wrong.\nexpected=%#v\ngot=%#v", expected, got)
+	}
+
+	if _, err := FromGo(struct{}{}); err == nil {
+		t.Errorf("expected an error for a struct")
+	}
+	if _, err := FromGo(uint64(1 << 63)); err == nil {
+		t.Errorf("expected an error for an integer that does not fit")
+	}
+}
+
+func TestAccessors(t *testing.T) {
+	h, err := Hash(Pair{String("b"), Integer(2)}, Pair{Integer(1), Boolean(false)})
+	if err != nil {
+		t.Fatalf("Hash failed: %s", err)
+	}
+	pairs, ok := h.AsHash()
+	if !ok || len(pairs) != 2 || pairs[0].Key.Inspect() != "1" || pairs[1].Key.Inspect() != "b" {
+		t.Errorf("AsHash wrong: %v", pairs)
+	}
+	if v, ok := h.Get(String("b")); !ok || v.Inspect() != "2" {
+		t.Errorf("Get(b) = %s, %t", v, ok)
+	}
+	if _, ok := h.Get(Wrap(&object.Builtin{})); ok {
+		t.Errorf("Get with an unusable key must fail")
+	}
+	if _, err := Hash(Pair{Wrap(&object.Builtin{}), Null()}); err == nil {
+		t.Errorf("expected an error for a function key")
+	}
+
+	if _, ok := Integer(1).AsString(); ok {
+		t.Errorf("AsString of an integer must fail")
+	}
+	if !(Value{}).IsNull() || Null().Inspect() != "null" {
+		t.Errorf("the zero Value must be null")
+	}
+}
+
+// 組み込み関数やプラグインが作った真偽値と null も評価器の共通の値にそろうこと
+func TestObjectNormalizes(t *testing.T) {
+	if Wrap(&object.Boolean{Value: false}).Object() != Boolean(false).Object() {
+		t.Errorf("false was not normalized")
+	}
+	if Wrap(nil).Object() != Wrap(&object.Null{}).Object() {
+		t.Errorf("null was not normalized")
+	}
+}
+
+func TestBuiltin(t *testing.T) {
+	b := Builtin("pick", "pick(a)", func(args []Value) (Value, error) {
+		if len(args) == 0 {
+			return Null(), errors.New("no arguments")
+		}
+		elements, _ := args[0].AsArray()
+		return elements[5], nil
+	})
+
+	if got := b.Fn(Array(Integer(1)).Object()); got.Inspect() != "ERROR: pick: panic: runtime error: index out of range [5] with length 1" {
+		t.Errorf("panic not converted: %s", got.Inspect())
+	}
+	err, ok := b.Fn().(*object.Error)
+	if !ok || err.Code != object.HOST_ERR || err.Message != "pick: no arguments" {
+		t.Errorf("error not converted: %#v", err)
+	}
+}
@@ -0,0 +1,146 @@
+package object
+
+import "math/big"
+
+// Compare は a と b の順序を返す (a < b なら -1, a == b なら 0, a > b なら 1)。
+// 比較できない組み合わせの場合は ok が false になる。
+func Compare(a, b Object) (result int, ok bool) {
+	switch a := a.(type) {
+	case *Integer:
+		switch b := b.(type) {
+		case *Integer:
+			return compareInt64(a.Value, b.Value), true
+		case *BigInt:
+			return big.NewInt(a.Value).Cmp(b.Value), true
+		case *Decimal:
+			return new(big.Rat).SetInt64(a.Value).Cmp(b.Value), true
+		default:
+			return 0, false
+		}
+	case *BigInt:
+		switch b := b.(type) {
+		case *Integer:
+			return a.Value.Cmp(big.NewInt(b.Value)), true
+		case *BigInt:
+			return a.Value.Cmp(b.Value), true
+		case *Decimal:
+			return new(big.Rat).SetInt(a.Value).Cmp(b.Value), true
+		default:
+			return 0, false
+		}
+	case *Decimal:
+		switch b := b.(type) {
+		case *Integer:
+			return a.Value.Cmp(new(big.Rat).SetInt64(b.Value)), true
+		case *BigInt:
+			return a.Value.Cmp(new(big.Rat).SetInt(b.Value)), true
+		case *Decimal:
+			return a.Value.Cmp(b.Value), true
+		default:
+			return 0, false
+		}
+	case *String:
+		b, ok := b.(*String)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case a.Value < b.Value:
+			return -1, true
+		case a.Value > b.Value:
+			return 1, true
+		default:
+			return 0, true
+		}
+	case *Array:
+		b, ok := b.(*Array)
+		if !ok {
+			return 0, false
+		}
+		for i := 0; i < len(a.Elements) && i < len(b.Elements); i++ {
+			result, ok := Compare(a.Elements[i], b.Elements[i])
+			if !ok {
+				return 0, false
+			}
+			if result != 0 {
+				return result, true
+			}
+		}
+		return compareInt64(int64(len(a.Elements)), int64(len(b.Elements))), true
+	default:
+		return 0, false
+	}
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// KeysEqual はハッシュのキーとして a と b が同じかどうかを返す。
+// HashKey が等しいだけでは衝突と区別できないので、バケツの中ではこれで比べる。
+// 数値は == と同じく、型が違っても値が等しければ同じキーとみなす。
+func KeysEqual(a, b Object) bool {
+	if isNumber(a) && isNumber(b) {
+		result, _ := Compare(a, b)
+		return result == 0
+	}
+	if a.Type() != b.Type() {
+		return false
+	}
+
+	switch a := a.(type) {
+	case *Integer:
+		return a.Value == b.(*Integer).Value
+	case *BigInt:
+		return a.Value.Cmp(b.(*BigInt).Value) == 0
+	case *Decimal:
+		return a.Value.Cmp(b.(*Decimal).Value) == 0
+	case *String:
+		return a.Value == b.(*String).Value
+	case *Boolean:
+		return a.Value == b.(*Boolean).Value
+	case *Null:
+		return true
+	case *Array:
+		b := b.(*Array)
+		if len(a.Elements) != len(b.Elements) {
+			return false
+		}
+		for i := range a.Elements {
+			if !KeysEqual(a.Elements[i], b.Elements[i]) {
+				return false
+			}
+		}
+		return true
+	case *Hash:
+		b := b.(*Hash)
+		if a.Len() != b.Len() {
+			return false
+		}
+		for _, pair := range a.PairList() {
+			other, ok := b.Get(pair.Key)
+			if !ok || !KeysEqual(pair.Value, other.Value) {
+				return false
+			}
+		}
+		return true
+	default:
+		// 関数などは HashKey と同じく表示で区別する
+		return a == b || a.Inspect() == b.Inspect()
+	}
+}
+
+func isNumber(obj Object) bool {
+	switch obj.(type) {
+	case *Integer, *BigInt, *Decimal:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package object
+
+// Deque は両端への追加と取り出しが O(1) でできる、書き換えられる列。
+// 要素は環状のバッファに head から count 個並べ、満杯になったら倍の大きさに作り直す。
+type Deque struct {
+	buf   []Object
+	head  int
+	count int
+}
+
+func (d *Deque) Type() ObjectType { return DEQUE_OBJ }
+func (d *Deque) Inspect() string  { return DefaultOutputFormat.Inspect(d) }
+
+func (d *Deque) Len() int {
+	return d.count
+}
+
+// At は先頭から i 番目 (0 始まり) の要素を返す。i は 0 以上 Len 未満であること。
+func (d *Deque) At(i int) Object {
+	return d.buf[(d.head+i)%len(d.buf)]
+}
+
+func (d *Deque) PushBack(obj Object) {
+	d.grow()
+	d.buf[(d.head+d.count)%len(d.buf)] = obj
+	d.count++
+}
+
+func (d *Deque) PushFront(obj Object) {
+	d.grow()
+	d.head = (d.head + len(d.buf) - 1) % len(d.buf)
+	d.buf[d.head] = obj
+	d.count++
+}
+
+// PopFront は先頭の要素を取り除いて返す。空なら false を返す。
+func (d *Deque) PopFront() (Object, bool) {
+	if d.count == 0 {
+		return nil, false
+	}
+	obj := d.buf[d.head]
+	d.buf[d.head] = nil
+	d.head = (d.head + 1) % len(d.buf)
+	d.count--
+	return obj, true
+}
+
+// PopBack は末尾の要素を取り除いて返す。空なら false を返す。
+func (d *Deque) PopBack() (Object, bool) {
+	if d.count == 0 {
+		return nil, false
+	}
+	i := (d.head + d.count - 1) % len(d.buf)
+	obj := d.buf[i]
+	d.buf[i] = nil
+	d.count--
+	return obj, true
+}
+
+func (d *Deque) grow() {
+	if d.count < len(d.buf) {
+		return
+	}
+	size := 2 * len(d.buf)
+	if size == 0 {
+		size = 8
+	}
+	buf := make([]Object, size)
+	for i := 0; i < d.count; i++ {
+		buf[i] = d.At(i)
+	}
+	d.buf, d.head = buf, 0
+}
@@ -0,0 +1,113 @@
+package object
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// Diff は expected と actual を構造的に比較し、異なる箇所を "パス: 説明" の形式で返す。
+// 等しい場合は空のスライスを返す。
+func Diff(expected, actual Object) []string {
+	return diff("", expected, actual, []string{})
+}
+
+func diff(path string, expected, actual Object, out []string) []string {
+	if expected.Type() != actual.Type() {
+		return append(out, diffLine(path, fmt.Sprintf("expected %s %s, got %s %s",
+			expected.Type(), diffInspect(expected), actual.Type(), diffInspect(actual))))
+	}
+
+	switch expected := expected.(type) {
+	case *Array:
+		actual := actual.(*Array)
+		for i := 0; i < len(expected.Elements) || i < len(actual.Elements); i++ {
+			elementPath := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= len(actual.Elements):
+				out = append(out, diffLine(elementPath, "missing, expected "+diffInspect(expected.Elements[i])))
+			case i >= len(expected.Elements):
+				out = append(out, diffLine(elementPath, "unexpected "+diffInspect(actual.Elements[i])))
+			default:
+				out = diff(elementPath, expected.Elements[i], actual.Elements[i], out)
+			}
+		}
+		return out
+	case *Hash:
+		actual := actual.(*Hash)
+		for _, key := range sortedHashKeys(expected, actual) {
+			expectedPair, inExpected := expected.Get(key)
+			actualPair, inActual := actual.Get(key)
+
+			switch {
+			case !inActual:
+				keyPath := path + "[" + diffInspect(expectedPair.Key) + "]"
+				out = append(out, diffLine(keyPath, "missing, expected "+diffInspect(expectedPair.Value)))
+			case !inExpected:
+				keyPath := path + "[" + diffInspect(actualPair.Key) + "]"
+				out = append(out, diffLine(keyPath, "unexpected "+diffInspect(actualPair.Value)))
+			default:
+				keyPath := path + "[" + diffInspect(expectedPair.Key) + "]"
+				out = diff(keyPath, expectedPair.Value, actualPair.Value, out)
+			}
+		}
+		return out
+	default:
+		if !scalarEqual(expected, actual) {
+			out = append(out, diffLine(path, fmt.Sprintf("expected %s, got %s", diffInspect(expected), diffInspect(actual))))
+		}
+		return out
+	}
+}
+
+func scalarEqual(expected, actual Object) bool {
+	switch expected := expected.(type) {
+	case *Integer:
+		return expected.Value == actual.(*Integer).Value
+	case *BigInt:
+		return expected.Value.Cmp(actual.(*BigInt).Value) == 0
+	case *Decimal:
+		return expected.Value.Cmp(actual.(*Decimal).Value) == 0
+	case *String:
+		return expected.Value == actual.(*String).Value
+	case *Boolean:
+		return expected.Value == actual.(*Boolean).Value
+	case *Null:
+		return true
+	default:
+		return expected == actual
+	}
+}
+
+func sortedHashKeys(hashes ...*Hash) []Object {
+	seen := NewHash()
+	for _, h := range hashes {
+		for _, pair := range h.PairList() {
+			seen.Set(pair.Key, &String{Value: diffInspect(pair.Key)})
+		}
+	}
+
+	pairs := seen.PairList()
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].Value.(*String).Value < pairs[j].Value.(*String).Value })
+
+	keys := make([]Object, len(pairs))
+	for i, pair := range pairs {
+		keys[i] = pair.Key
+	}
+	return keys
+}
+
+func diffLine(path, message string) string {
+	if path == "" {
+		return message
+	}
+	return path + ": " + message
+}
+
+// 文字列は引用符で囲んで 1 と "1" を見分けられるようにする
+func diffInspect(obj Object) string {
+	if s, ok := obj.(*String); ok {
+		return strconv.Quote(s.Value)
+	}
+	return obj.Inspect()
+}
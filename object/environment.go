@@ -1,12 +1,18 @@
 package object
 
+import "github.com/al-keio/monkey-go/symbol"
+
+// Environment は束縛を symbol.ID で引く。名前で引く Get/Set はその都度 interning する。
 type Environment struct {
-	store map[string]Object
+	store map[symbol.ID]Object
 	outer *Environment
+	label string // 関数呼び出しの環境なら "add() called at main.monkey:10" のような説明
+
+	version uint64 // 束縛を変更するたびに増やす。Resolution が古くなったかどうかを調べるのに使う
 }
 
 func NewEnvironment() *Environment {
-	s := make(map[string]Object)
+	s := make(map[symbol.ID]Object)
 	return &Environment{store: s}
 }
 
@@ -16,15 +22,137 @@ func NewEnclosedEnvironment(outer *Environment) *Environment {
 	return env
 }
 
-func (e *Environment) Get(name string) (Object, bool) {
-	obj, ok := e.store[name]
-	if !ok && e.outer != nil {
-		obj, ok = e.outer.Get(name)
+func (e *Environment) SetLabel(label string) { e.label = label }
+func (e *Environment) Label() string         { return e.label }
+
+// Scopes は e から外側に向かって各環境のラベルを返す。ラベルのない環境は <scope>、最も外側は <global> とする。
+func (e *Environment) Scopes() []string {
+	scopes := []string{}
+	for env := e; env != nil; env = env.outer {
+		switch {
+		case env.label != "":
+			scopes = append(scopes, env.label)
+		case env.outer == nil:
+			scopes = append(scopes, "<global>")
+		default:
+			scopes = append(scopes, "<scope>")
+		}
 	}
-	return obj, ok
+	return scopes
+}
+
+// Names は e とその外側の環境で束縛されている名前を返す。順序は決まっていない。
+func (e *Environment) Names() []string {
+	seen := map[symbol.ID]bool{}
+	names := []string{}
+	for env := e; env != nil; env = env.outer {
+		for id := range env.store {
+			if !seen[id] {
+				seen[id] = true
+				names = append(names, id.String())
+			}
+		}
+	}
+	return names
+}
+
+func (e *Environment) Get(name string) (Object, bool) {
+	return e.GetSymbol(symbol.Intern(name))
 }
 
 func (e *Environment) Set(name string, val Object) Object {
-	e.store[name] = val
+	return e.SetSymbol(symbol.Intern(name), val)
+}
+
+func (e *Environment) GetSymbol(id symbol.ID) (Object, bool) {
+	for env := e; env != nil; env = env.outer {
+		if obj, ok := env.store[id]; ok {
+			return obj, true
+		}
+	}
+	return nil, false
+}
+
+func (e *Environment) SetSymbol(id symbol.ID, val Object) Object {
+	e.store[id] = val
+	e.version++
 	return val
 }
+
+// Resolution は名前を e の外側の環境の列で引いた結果を覚えておき、同じ列からもう一度引くときに
+// 各環境の表を引き直さずに済ませる。評価器が呼び出し位置ごとのキャッシュに使う。
+type Resolution struct {
+	id       symbol.ID
+	scopes   []*Environment // 引いたときの e.outer から、名前を見つけた環境 (なければ最も外側の環境) まで
+	versions []uint64
+	value    Object
+	found    bool
+}
+
+// Resolve は GetSymbol と同じく id を引き、その結果を Resolution として返す。
+// e 自身に束縛があれば外側の環境を覚えても役に立たないので、Resolution は nil になる。
+func (e *Environment) Resolve(id symbol.ID) (Object, bool, *Resolution) {
+	if obj, ok := e.store[id]; ok {
+		return obj, true, nil
+	}
+
+	r := &Resolution{id: id}
+	for env := e.outer; env != nil; env = env.outer {
+		r.scopes = append(r.scopes, env)
+		r.versions = append(r.versions, env.version)
+		if obj, ok := env.store[id]; ok {
+			r.value, r.found = obj, true
+			break
+		}
+	}
+	return r.value, r.found, r
+}
+
+// Lookup は e から引いた結果が r と同じになると確かめられれば、表を引き直さずにそれを返す。
+// e の外側の環境の列が r を作ったときと同じで、そのどれも束縛を変えていなければ同じ結果になる。
+// valid が false なら Resolve で引き直すこと。
+func (r *Resolution) Lookup(e *Environment) (obj Object, found bool, valid bool) {
+	if _, ok := e.store[r.id]; ok {
+		return nil, false, false
+	}
+	env := e.outer
+	for i, scope := range r.scopes {
+		if env != scope || scope.version != r.versions[i] {
+			return nil, false, false
+		}
+		env = env.outer
+	}
+	return r.value, r.found, true
+}
+
+// Capture は ids の束縛だけを写した新しい環境を返す。新しい環境の外側は e の最も外側の環境 (大域環境) で、
+// そこにある束縛は写さずに参照する。どこにも束縛されていない ids は unbound に返す。
+// e が大域環境なら e をそのまま返す。
+func (e *Environment) Capture(ids []symbol.ID) (captured *Environment, unbound []symbol.ID) {
+	if e.outer == nil {
+		return e, nil
+	}
+
+	global := e
+	for global.outer != nil {
+		global = global.outer
+	}
+
+	captured = NewEnclosedEnvironment(global)
+	for _, id := range ids {
+		found := false
+		for env := e; env != global; env = env.outer {
+			if obj, ok := env.store[id]; ok {
+				captured.store[id] = obj
+				found = true
+				break
+			}
+		}
+		if !found {
+			if _, ok := global.store[id]; !ok {
+				unbound = append(unbound, id)
+			}
+		}
+	}
+	return captured, unbound
+}
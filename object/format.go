@@ -0,0 +1,121 @@
+package object
+
+import (
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// OutputFormat は数値を表示するときの書式。set_output_format で評価ごとに設定し、puts などが使う。
+// 値の Inspect は常に DefaultOutputFormat で表示する。
+type OutputFormat struct {
+	ThousandsSeparator string // 整数部を 3 桁ごとに区切る文字列。空なら区切らない
+	Precision          int    // Decimal の小数点以下の桁数。負なら値に応じて決める
+	Hex                bool   // 整数を 16 進で表示する
+}
+
+var DefaultOutputFormat = OutputFormat{Precision: -1}
+
+// Inspect は obj を Inspect と同じ形で、ただし配列やハッシュの中も含めて数値を f の書式で表示する
+func (f OutputFormat) Inspect(obj Object) string {
+	return f.inspect(obj, nil)
+}
+
+// deque は配列やハッシュ越しにでも自分自身を要素に持てるので、表示中の deque を visiting に記録し、
+// もう一度出会ったら deque[...] とだけ表示する
+func (f OutputFormat) inspect(obj Object, visiting map[*Deque]bool) string {
+	switch obj := obj.(type) {
+	case *Integer:
+		return f.FormatInteger(obj.Value)
+	case *BigInt:
+		return f.FormatBigInt(obj.Value)
+	case *Decimal:
+		return f.FormatDecimal(obj.Value)
+	case *Deque:
+		if visiting[obj] {
+			return "deque[...]"
+		}
+		if visiting == nil {
+			visiting = map[*Deque]bool{}
+		}
+		visiting[obj] = true
+		defer delete(visiting, obj)
+		elements := make([]string, obj.count)
+		for i := range elements {
+			elements[i] = f.inspect(obj.At(i), visiting)
+		}
+		return "deque[" + strings.Join(elements, ", ") + "]"
+	case *Array:
+		elements := make([]string, len(obj.Elements))
+		for i, e := range obj.Elements {
+			elements[i] = f.inspect(e, visiting)
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+	case *Hash:
+		pairs := []string{}
+		for _, pair := range obj.PairList() {
+			pairs = append(pairs, f.inspect(pair.Key, visiting)+": "+f.inspect(pair.Value, visiting))
+		}
+		// map の走査順に依存しないよう、表示はキー順に揃える
+		sort.Strings(pairs)
+		return "{" + strings.Join(pairs, ", ") + "}"
+	}
+	return obj.Inspect()
+}
+
+func (f OutputFormat) FormatInteger(value int64) string {
+	if f.Hex {
+		if value < 0 {
+			return "-0x" + strconv.FormatUint(uint64(-value), 16)
+		}
+		return "0x" + strconv.FormatInt(value, 16)
+	}
+	return f.groupThousands(strconv.FormatInt(value, 10))
+}
+
+func (f OutputFormat) FormatBigInt(value *big.Int) string {
+	if f.Hex {
+		if value.Sign() < 0 {
+			return "-0x" + new(big.Int).Neg(value).Text(16)
+		}
+		return "0x" + value.Text(16)
+	}
+	return f.groupThousands(value.String())
+}
+
+func (f OutputFormat) FormatDecimal(value *big.Rat) string {
+	switch {
+	case f.Precision >= 0:
+		return f.groupThousands(value.FloatString(f.Precision))
+	case value.IsInt():
+		return f.groupThousands(value.Num().String())
+	default:
+		return f.groupThousands(value.FloatString(decimalPlaces(value)))
+	}
+}
+
+func (f OutputFormat) groupThousands(s string) string {
+	if f.ThousandsSeparator == "" {
+		return s
+	}
+
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	fraction := ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		s, fraction = s[:i], s[i:]
+	}
+
+	var out strings.Builder
+	for i, digit := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			out.WriteString(f.ThousandsSeparator)
+		}
+		out.WriteRune(digit)
+	}
+
+	return sign + out.String() + fraction
+}
@@ -2,8 +2,12 @@ package object
 
 import (
 	"bytes"
+	"encoding/binary"
 	"fmt"
+	"hash"
 	"hash/fnv"
+	"math/big"
+	"sort"
 	"strings"
 
 	"github.com/al-keio/monkey-go/ast"
@@ -13,6 +17,8 @@ type ObjectType string
 
 const (
 	INTEGER_OBJ      = "INTEGER"
+	BIGINT_OBJ       = "BIGINT"
+	DECIMAL_OBJ      = "DECIMAL"
 	STRING_OBJ       = "STRING"
 	BOOLEAN_OBJ      = "BOOLEAN"
 	ARRAY_OBJ        = "ARRAY"
@@ -24,6 +30,35 @@ const (
 	ERROR_OBJ        = "ERROR"
 	QUOTE_OBJ        = "QUOTE"
 	MACRO_OBJ        = "MACRO"
+	PROXY_OBJ        = "PROXY"
+	NAMESPACE_OBJ    = "NAMESPACE"
+	DEQUE_OBJ        = "DEQUE"
+)
+
+// エラーコード (object.Error の Code)
+const (
+	UNKNOWN_IDENTIFIER_ERR   = "UNKNOWN_IDENTIFIER"
+	TYPE_MISMATCH_ERR        = "TYPE_MISMATCH"
+	UNKNOWN_OPERATOR_ERR     = "UNKNOWN_OPERATOR"
+	WRONG_ARGUMENT_COUNT_ERR = "WRONG_ARGUMENT_COUNT"
+	WRONG_ARGUMENT_TYPE_ERR  = "WRONG_ARGUMENT_TYPE"
+	NOT_A_FUNCTION_ERR       = "NOT_A_FUNCTION"
+	UNUSABLE_HASH_KEY_ERR    = "UNUSABLE_HASH_KEY"
+	INDEX_NOT_SUPPORTED_ERR  = "INDEX_NOT_SUPPORTED"
+	DIVISION_BY_ZERO_ERR     = "DIVISION_BY_ZERO"
+	INVALID_VALUE_ERR        = "INVALID_VALUE"
+	NOT_COMPARABLE_ERR       = "NOT_COMPARABLE"
+	ASSERTION_FAILED_ERR     = "ASSERTION_FAILED"
+	STEP_LIMIT_ERR           = "STEP_LIMIT"
+	CALL_DEPTH_ERR           = "CALL_DEPTH"
+	CANCELLED_ERR            = "CANCELLED"
+	OUTPUT_LIMIT_ERR         = "OUTPUT_LIMIT"
+	PARSE_ERR                = "PARSE"
+	CONTRACT_VIOLATION_ERR   = "CONTRACT_VIOLATION"
+	UNKNOWN_MEMBER_ERR       = "UNKNOWN_MEMBER"
+	CAPABILITY_DENIED_ERR    = "CAPABILITY_DENIED"
+	HOST_ERR                 = "HOST"
+	INTERNAL_ERR             = "INTERNAL"
 )
 
 type HashKey struct {
@@ -45,11 +80,78 @@ type Integer struct {
 }
 
 func (i *Integer) Type() ObjectType { return INTEGER_OBJ }
-func (i *Integer) Inspect() string  { return fmt.Sprintf("%d", i.Value) }
+func (i *Integer) Inspect() string  { return DefaultOutputFormat.FormatInteger(i.Value) }
 func (i *Integer) HashKey() HashKey {
 	return HashKey{Type: i.Type(), Value: uint64(i.Value)}
 }
 
+// BigInt は int64 に収まらない整数。収まる値は常に Integer で表し、BigInt にはしない。
+type BigInt struct {
+	Value *big.Int
+}
+
+func (b *BigInt) Type() ObjectType { return BIGINT_OBJ }
+func (b *BigInt) Inspect() string  { return DefaultOutputFormat.FormatBigInt(b.Value) }
+func (b *BigInt) HashKey() HashKey {
+	h := fnv.New64a()
+	h.Write([]byte(b.Value.String()))
+
+	return HashKey{Type: b.Type(), Value: h.Sum64()}
+}
+
+// NewInteger は value が int64 に収まれば Integer を、収まらなければ BigInt を返す
+func NewInteger(value *big.Int) Object {
+	if value.IsInt64() {
+		return &Integer{Value: value.Int64()}
+	}
+	return &BigInt{Value: value}
+}
+
+type Decimal struct {
+	Value *big.Rat
+}
+
+func (d *Decimal) Type() ObjectType { return DECIMAL_OBJ }
+func (d *Decimal) Inspect() string  { return DefaultOutputFormat.FormatDecimal(d.Value) }
+func (d *Decimal) HashKey() HashKey {
+	// 2 == 2.0 なので、整数の値の Decimal は同じ値の整数と同じキーにする
+	if d.Value.IsInt() {
+		return NewInteger(new(big.Int).Set(d.Value.Num())).(Hashable).HashKey()
+	}
+	h := fnv.New64a()
+	h.Write([]byte(d.Value.RatString()))
+
+	return HashKey{Type: d.Type(), Value: h.Sum64()}
+}
+
+// 有限小数で表せるなら必要な桁数、表せない (1/3 など) なら maxDecimalPlaces を返す
+const maxDecimalPlaces = 20
+
+func decimalPlaces(r *big.Rat) int {
+	denom := new(big.Int).Set(r.Denom())
+	two, five := big.NewInt(2), big.NewInt(5)
+	twos, fives := 0, 0
+	mod := new(big.Int)
+
+	for denom.Cmp(big.NewInt(1)) != 0 {
+		switch {
+		case mod.Mod(denom, two).Sign() == 0:
+			denom.Quo(denom, two)
+			twos++
+		case mod.Mod(denom, five).Sign() == 0:
+			denom.Quo(denom, five)
+			fives++
+		default:
+			return maxDecimalPlaces
+		}
+	}
+
+	if twos > fives {
+		return twos
+	}
+	return fives
+}
+
 type String struct {
 	Value string
 }
@@ -80,28 +182,19 @@ func (b *Boolean) HashKey() HashKey {
 	return HashKey{Type: b.Type(), Value: value}
 }
 
+// Frozen な配列やハッシュは要素ごと変更できない。要素を変更する処理はこれを確認すること。
 type Array struct {
 	Elements []Object
+	Frozen   bool
 }
 
 func (a *Array) Type() ObjectType { return ARRAY_OBJ }
-func (a *Array) Inspect() string {
-	var out bytes.Buffer
-
-	elements := []string{}
-	for _, e := range a.Elements {
-		elements = append(elements, e.Inspect())
-	}
-
-	out.WriteString("[")
-	out.WriteString(strings.Join(elements, ", "))
-	out.WriteString("]")
-
-	return out.String()
-}
+func (a *Array) Inspect() string  { return DefaultOutputFormat.Inspect(a) }
 func (a *Array) HashKey() HashKey {
 	h := fnv.New64a()
-	h.Write([]byte(a.Inspect()))
+	for _, e := range a.Elements {
+		writeHashKey(h, e)
+	}
 
 	return HashKey{Type: a.Type(), Value: h.Sum64()}
 }
@@ -111,32 +204,149 @@ type HashPair struct {
 	Value Object
 }
 
+// Pairs は HashKey ごとのバケツ。HashKey が衝突した別のキーは同じバケツに並ぶので、
+// 直接触らずに Get/Set を使うこと。
+//
+// Proto はプロトタイプ。Lookup は自分にないキーを Proto から (さらにその Proto へと) 探す。
+// 等しさ、HashKey、Inspect には Proto は関係しない。
 type Hash struct {
-	Pairs map[HashKey]HashPair
+	Pairs  map[HashKey][]HashPair
+	Frozen bool
+	Proto  *Hash
 }
 
-func (h *Hash) Type() ObjectType { return HASH_OBJ }
-func (h *Hash) Inspect() string {
-	var out bytes.Buffer
+func NewHash() *Hash {
+	return &Hash{Pairs: map[HashKey][]HashPair{}}
+}
 
-	pairs := []string{}
-	for _, pair := range h.Pairs {
-		pairs = append(pairs, fmt.Sprintf("%s: %s", pair.Key.Inspect(), pair.Value.Inspect()))
+// Get は key と等しいキーの組を返す。key は Hashable でなければならない。
+func (h *Hash) Get(key Object) (HashPair, bool) {
+	for _, pair := range h.Pairs[key.(Hashable).HashKey()] {
+		if KeysEqual(pair.Key, key) {
+			return pair, true
+		}
 	}
+	return HashPair{}, false
+}
 
-	out.WriteString("{")
-	out.WriteString(strings.Join(pairs, ", "))
-	out.WriteString("}")
+// Set は key の値を value にする。key は Hashable でなければならない。
+func (h *Hash) Set(key, value Object) {
+	hashKey := key.(Hashable).HashKey()
+	bucket := h.Pairs[hashKey]
+	for i, pair := range bucket {
+		if KeysEqual(pair.Key, key) {
+			bucket[i] = HashPair{Key: key, Value: value}
+			return
+		}
+	}
+	h.Pairs[hashKey] = append(bucket, HashPair{Key: key, Value: value})
+}
 
-	return out.String()
+// Lookup は Get と同じく key の組を探し、なければプロトタイプの連鎖をたどって探す
+func (h *Hash) Lookup(key Object) (HashPair, bool) {
+	for hash := h; hash != nil; hash = hash.Proto {
+		if pair, ok := hash.Get(key); ok {
+			return pair, true
+		}
+	}
+	return HashPair{}, false
 }
+
+func (h *Hash) Len() int {
+	n := 0
+	for _, bucket := range h.Pairs {
+		n += len(bucket)
+	}
+	return n
+}
+
+// PairList はすべての組を順不同で返す
+func (h *Hash) PairList() []HashPair {
+	pairs := make([]HashPair, 0, len(h.Pairs))
+	for _, bucket := range h.Pairs {
+		pairs = append(pairs, bucket...)
+	}
+	return pairs
+}
+
+func (h *Hash) Type() ObjectType { return HASH_OBJ }
+func (h *Hash) Inspect() string  { return DefaultOutputFormat.Inspect(h) }
 func (h *Hash) HashKey() HashKey {
+	// バケツ内の順序は挿入順なので、組ごとのハッシュ値を求めてから並べる
+	sums := make([]uint64, 0, len(h.Pairs))
+	for _, pair := range h.PairList() {
+		pairHash := fnv.New64a()
+		writeHashKey(pairHash, pair.Key)
+		writeHashKey(pairHash, pair.Value)
+		sums = append(sums, pairHash.Sum64())
+	}
+	sort.Slice(sums, func(i, j int) bool { return sums[i] < sums[j] })
+
 	h64 := fnv.New64a()
-	h64.Write([]byte(h.Inspect()))
+	var buf [8]byte
+	for _, sum := range sums {
+		binary.LittleEndian.PutUint64(buf[:], sum)
+		h64.Write(buf[:])
+	}
 
 	return HashKey{Type: h.Type(), Value: h64.Sum64()}
 }
 
+// writeHashKey は配列やハッシュの HashKey を要素から求めるために obj を h に書き込む。
+// 表示の形に HashKey が左右されないよう、Inspect ではなく要素の HashKey を使う。
+func writeHashKey(h hash.Hash64, obj Object) {
+	if hashable, ok := obj.(Hashable); ok {
+		// 型も HashKey のものを使い、[2] と [2.0] のように等しい要素の配列を同じキーにする
+		key := hashable.HashKey()
+		h.Write([]byte(key.Type))
+		var buf [8]byte
+		binary.LittleEndian.PutUint64(buf[:], key.Value)
+		h.Write(buf[:])
+	} else {
+		h.Write([]byte(obj.Type()))
+		h.Write([]byte(obj.Inspect()))
+	}
+	h.Write([]byte{0})
+}
+
+// UnhashablePart は obj をハッシュのキーにできなければ、その原因になった値を返す。キーにできれば nil。
+// 配列やハッシュの HashKey は要素から求めるので、deque のように HashKey を持たない値を
+// 入れ子のどこかに含むものはキーにできない。そうした値は中身が変わり、キーで引けなくなる。
+func UnhashablePart(obj Object) Object {
+	switch obj := obj.(type) {
+	case *Array:
+		for _, e := range obj.Elements {
+			if part := UnhashablePart(e); part != nil {
+				return part
+			}
+		}
+		return nil
+	case *Hash:
+		for _, pair := range obj.PairList() {
+			if part := UnhashablePart(pair.Key); part != nil {
+				return part
+			}
+			if part := UnhashablePart(pair.Value); part != nil {
+				return part
+			}
+		}
+		return nil
+	case Hashable:
+		return nil
+	default:
+		return obj
+	}
+}
+
+// Namespace は string.split のように名前でまとめた組み込み関数
+type Namespace struct {
+	Name    string
+	Members map[string]*Builtin
+}
+
+func (n *Namespace) Type() ObjectType { return NAMESPACE_OBJ }
+func (n *Namespace) Inspect() string  { return "namespace " + n.Name }
+
 type Null struct{}
 
 func (n *Null) Type() ObjectType { return NULL_OBJ }
@@ -156,6 +366,9 @@ type Function struct {
 	Parameters []*ast.Identifier
 	Body       *ast.BlockStatement
 	Env        *Environment
+	Doc        string
+	Requires   []ast.Expression
+	Ensures    []ast.Expression
 }
 
 func (f *Function) Type() ObjectType { return FUNCTION_OBJ }
@@ -187,20 +400,85 @@ func (f *Function) HashKey() HashKey {
 
 type BuiltinFunction func(args ...Object) Object
 
+// Applier は組み込み関数から評価器に関数の呼び出しを依頼するためのインターフェース。
+// Apply の結果が *Error (関数の中のエラー、引数の数の誤り、ステップ数の上限超過など) なら、
+// 組み込み関数は処理を打ち切ってそれをそのまま返すこと。
+type Applier interface {
+	Apply(fn Object, args ...Object) Object
+}
+
+type ApplyingBuiltinFunction func(applier Applier, args ...Object) Object
+
+// Capability は組み込み関数が評価器の外の世界に触れる作用の種類。
+// サンドボックスでは、評価器が呼び出しのたびに許可された作用かどうかを調べる。
+type Capability string
+
+const (
+	IO_CAP   Capability = "io"   // 出力の書き込みや入力の読み込み
+	NET_CAP  Capability = "net"  // ネットワーク
+	PROC_CAP Capability = "proc" // コマンドライン引数や環境変数などプロセスの情報
+	TIME_CAP Capability = "time" // 時計の読み取りや待機
+)
+
+// Builtin は Fn か ApplyFn のどちらかを持つ。ApplyFn が設定されていればそちらが呼ばれる。
+// Doc は REPL の :doc で表示する一行の説明で、先頭にシグネチャを書く。
+// Capabilities はこの関数が必要とする作用。純粋な関数なら空。
 type Builtin struct {
-	Fn BuiltinFunction
+	Fn           BuiltinFunction
+	ApplyFn      ApplyingBuiltinFunction
+	Doc          string
+	Capabilities []Capability
 }
 
 func (b *Builtin) Type() ObjectType { return BUILTIN_OBJ }
 func (b *Builtin) Inspect() string  { return "builtin function" }
 
 type Error struct {
+	Code    string
 	Message string
+	Stack   []Frame // エラーが伝播してきた関数呼び出し。内側の呼び出しが先
+	GoStack string  // INTERNAL のエラーでは、バグ報告のために panic した時点の Go のスタックを持つ
+	Hint    string  // "did you mean: len?" のような利用者向けの助言。Inspect には含めない
 }
 
 func (e *Error) Type() ObjectType { return ERROR_OBJ }
 func (e *Error) Inspect() string  { return "ERROR: " + e.Message }
 
+// 深い再帰でトレースが長くなりすぎないよう、これを超えるフレームは内側と外側の半分ずつだけ表示する
+const maxStackTraceFrames = 20
+
+// StackTrace はスタックトレースを一行に一フレームずつ返す。フレームがなければ空文字列。
+func (e *Error) StackTrace() string {
+	var out bytes.Buffer
+
+	for i, frame := range e.Stack {
+		if len(e.Stack) > maxStackTraceFrames && i == maxStackTraceFrames/2 {
+			fmt.Fprintf(&out, "\t... %d more frames ...\n", len(e.Stack)-maxStackTraceFrames)
+		}
+		if len(e.Stack) > maxStackTraceFrames && i >= maxStackTraceFrames/2 && i < len(e.Stack)-maxStackTraceFrames/2 {
+			continue
+		}
+		fmt.Fprintf(&out, "\tat %s\n", frame)
+	}
+
+	return out.String()
+}
+
+// Frame はスタックトレースの一つの関数呼び出し
+type Frame struct {
+	Function  string
+	Arguments []string // 引数の Inspect (切り詰めたもの)。記録しない設定なら nil
+	CallSite  string   // 呼び出した位置 ("main.monkey:10" など)。分からなければ空
+}
+
+func (f Frame) String() string {
+	s := f.Function + "(" + strings.Join(f.Arguments, ", ") + ")"
+	if f.CallSite != "" {
+		s += " (" + f.CallSite + ")"
+	}
+	return s
+}
+
 type Quote struct {
 	Node ast.Node
 }
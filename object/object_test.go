@@ -1,6 +1,14 @@
 package object
 
-import "testing"
+import (
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/al-keio/monkey-go/symbol"
+)
 
 func TestStringHashKey(t *testing.T) {
 	hello1 := &String{Value: "Hello World"}
@@ -20,3 +28,373 @@ func TestStringHashKey(t *testing.T) {
 		t.Errorf("strings with different content have same hash keys.")
 	}
 }
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b     Object
+		expected int
+		ok       bool
+	}{
+		{&Integer{Value: 1}, &Integer{Value: 2}, -1, true},
+		{&Integer{Value: 2}, &Integer{Value: 2}, 0, true},
+		{&Integer{Value: 3}, &Integer{Value: 2}, 1, true},
+		{&String{Value: "a"}, &String{Value: "b"}, -1, true},
+		{&String{Value: "b"}, &String{Value: "a"}, 1, true},
+		{&String{Value: "a"}, &String{Value: "a"}, 0, true},
+		{
+			&Array{Elements: []Object{&Integer{Value: 1}, &Integer{Value: 2}}},
+			&Array{Elements: []Object{&Integer{Value: 1}, &Integer{Value: 3}}},
+			-1, true,
+		},
+		{
+			&Array{Elements: []Object{&Integer{Value: 1}, &Integer{Value: 2}}},
+			&Array{Elements: []Object{&Integer{Value: 1}}},
+			1, true,
+		},
+		{&Array{}, &Array{}, 0, true},
+		{&Integer{Value: 1}, &String{Value: "1"}, 0, false},
+		{
+			&Array{Elements: []Object{&Integer{Value: 1}}},
+			&Array{Elements: []Object{&String{Value: "1"}}},
+			0, false,
+		},
+		{&Boolean{Value: true}, &Boolean{Value: false}, 0, false},
+	}
+
+	for i, tt := range tests {
+		result, ok := Compare(tt.a, tt.b)
+		if ok != tt.ok {
+			t.Errorf("tests[%d] - comparability wrong. expected=%t, got=%t", i, tt.ok, ok)
+			continue
+		}
+		if result != tt.expected {
+			t.Errorf("tests[%d] - result wrong. expected=%d, got=%d", i, tt.expected, result)
+		}
+	}
+}
+
+func TestDecimalInspect(t *testing.T) {
+	tests := []struct {
+		value    *big.Rat
+		expected string
+	}{
+		{big.NewRat(1234, 100), "12.34"},
+		{big.NewRat(3, 1), "3"},
+		{big.NewRat(-1, 8), "-0.125"},
+		{big.NewRat(1, 3), "0.33333333333333333333"},
+		{big.NewRat(15, 10), "1.5"},
+	}
+
+	for _, tt := range tests {
+		d := &Decimal{Value: tt.value}
+		if d.Inspect() != tt.expected {
+			t.Errorf("Inspect wrong. expected=%q, got=%q", tt.expected, d.Inspect())
+		}
+	}
+
+	if (&Decimal{Value: big.NewRat(150, 100)}).HashKey() != (&Decimal{Value: big.NewRat(3, 2)}).HashKey() {
+		t.Errorf("equal decimals have different hash keys.")
+	}
+}
+
+func TestHashInspectIsDeterministic(t *testing.T) {
+	one, two := &Integer{Value: 1}, &Integer{Value: 2}
+	hash := NewHash()
+	hash.Set(two, two)
+	hash.Set(one, one)
+
+	for i := 0; i < 10; i++ {
+		if hash.Inspect() != "{1: 1, 2: 2}" {
+			t.Fatalf("hash.Inspect() wrong. got=%q", hash.Inspect())
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	str := func(s string) Object { return &String{Value: s} }
+	integer := func(i int64) Object { return &Integer{Value: i} }
+	array := func(elements ...Object) Object { return &Array{Elements: elements} }
+	hash := func(kv ...Object) Object {
+		h := NewHash()
+		for i := 0; i < len(kv); i += 2 {
+			h.Set(kv[i], kv[i+1])
+		}
+		return h
+	}
+
+	tests := []struct {
+		expected Object
+		actual   Object
+		diff     []string
+	}{
+		{integer(1), integer(1), []string{}},
+		{integer(1), integer(2), []string{"expected 1, got 2"}},
+		{integer(1), str("1"), []string{`expected INTEGER 1, got STRING "1"`}},
+		{array(integer(1), integer(2)), array(integer(1), integer(2)), []string{}},
+		{array(integer(1), integer(2)), array(integer(1), integer(3)), []string{"[1]: expected 2, got 3"}},
+		{array(integer(1), integer(2)), array(integer(1)), []string{"[1]: missing, expected 2"}},
+		{array(integer(1)), array(integer(1), str("x")), []string{`[1]: unexpected "x"`}},
+		{
+			hash(str("name"), str("bob"), str("tags"), array(str("a"))),
+			hash(str("name"), str("alice"), str("tags"), array(str("a"), str("b"))),
+			[]string{`["name"]: expected "bob", got "alice"`, `["tags"][1]: unexpected "b"`},
+		},
+		{
+			hash(str("a"), integer(1)),
+			hash(str("b"), integer(1)),
+			[]string{`["a"]: missing, expected 1`, `["b"]: unexpected 1`},
+		},
+	}
+
+	for i, tt := range tests {
+		diff := Diff(tt.expected, tt.actual)
+		if !reflect.DeepEqual(diff, tt.diff) {
+			t.Errorf("tests[%d] - diff wrong. expected=%q, got=%q", i, tt.diff, diff)
+		}
+	}
+}
+
+func TestOutputFormat(t *testing.T) {
+	hash := NewHash()
+	hash.Set(&Integer{Value: 1}, &Decimal{Value: big.NewRat(1, 4)})
+
+	tests := []struct {
+		format   OutputFormat
+		value    Object
+		expected string
+	}{
+		{DefaultOutputFormat, &Integer{Value: 1234567}, "1234567"},
+		{OutputFormat{ThousandsSeparator: ",", Precision: -1}, &Integer{Value: 1234567}, "1,234,567"},
+		{OutputFormat{ThousandsSeparator: ",", Precision: -1}, &Integer{Value: -123456}, "-123,456"},
+		{OutputFormat{ThousandsSeparator: ",", Precision: -1}, &Integer{Value: 999}, "999"},
+		{OutputFormat{ThousandsSeparator: "_", Precision: -1}, &Decimal{Value: big.NewRat(12345678, 100)}, "123_456.78"},
+		{OutputFormat{Precision: 2}, &Decimal{Value: big.NewRat(1, 3)}, "0.33"},
+		{OutputFormat{Precision: 2}, &Decimal{Value: big.NewRat(5, 1)}, "5.00"},
+		{OutputFormat{Precision: 0}, &Decimal{Value: big.NewRat(5, 2)}, "3"},
+		{OutputFormat{Precision: -1, Hex: true}, &Integer{Value: 255}, "0xff"},
+		{OutputFormat{Precision: -1, Hex: true}, &Integer{Value: -255}, "-0xff"},
+		{OutputFormat{Precision: -1, Hex: true}, &Array{Elements: []Object{&Integer{Value: 16}}}, "[0x10]"},
+		{OutputFormat{Precision: 1}, hash, "{1: 0.3}"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.format.Inspect(tt.value); got != tt.expected {
+			t.Errorf("%+v: expected %q, got %q", tt.format, tt.expected, got)
+		}
+		// 値の Inspect は書式によらず既定の書式で表示する
+		if got, want := tt.value.Inspect(), DefaultOutputFormat.Inspect(tt.value); got != want {
+			t.Errorf("Inspect of %s depends on the format. expected %q, got %q", tt.value.Type(), want, got)
+		}
+	}
+}
+
+func TestErrorStackTrace(t *testing.T) {
+	err := &Error{Message: "boom", Stack: []Frame{{Function: "f", Arguments: []string{"1"}}, {Function: "g"}}}
+	if trace := err.StackTrace(); trace != "\tat f(1)\n\tat g()\n" {
+		t.Errorf("StackTrace() = %q", trace)
+	}
+
+	deep := &Error{}
+	for i := 0; i < 100; i++ {
+		deep.Stack = append(deep.Stack, Frame{Function: "f"})
+	}
+	lines := strings.Split(strings.TrimSuffix(deep.StackTrace(), "\n"), "\n")
+	if len(lines) != maxStackTraceFrames+1 {
+		t.Fatalf("deep StackTrace() has %d lines, want %d", len(lines), maxStackTraceFrames+1)
+	}
+	if lines[maxStackTraceFrames/2] != "\t... 80 more frames ..." {
+		t.Errorf("omission line = %q", lines[maxStackTraceFrames/2])
+	}
+}
+
+func TestEnvironmentSymbols(t *testing.T) {
+	outer := NewEnvironment()
+	outer.Set("x", &Integer{Value: 1})
+	inner := NewEnclosedEnvironment(outer)
+	inner.SetSymbol(symbol.Intern("y"), &Integer{Value: 2})
+
+	if obj, ok := inner.GetSymbol(symbol.Intern("x")); !ok || obj.(*Integer).Value != 1 {
+		t.Errorf("GetSymbol(x) = %v, %t", obj, ok)
+	}
+	if obj, ok := inner.Get("y"); !ok || obj.(*Integer).Value != 2 {
+		t.Errorf("Get(y) = %v, %t", obj, ok)
+	}
+	if _, ok := outer.Get("y"); ok {
+		t.Errorf("inner binding leaked to the outer environment")
+	}
+}
+
+func TestEnvironmentCapture(t *testing.T) {
+	global := NewEnvironment()
+	global.Set("g", &Integer{Value: 1})
+	outer := NewEnclosedEnvironment(global)
+	outer.Set("x", &Integer{Value: 2})
+	outer.Set("unused", &Integer{Value: 3})
+	inner := NewEnclosedEnvironment(outer)
+	inner.Set("y", &Integer{Value: 4})
+
+	ids := []symbol.ID{symbol.Intern("g"), symbol.Intern("x"), symbol.Intern("y"), symbol.Intern("missing")}
+	captured, unbound := inner.Capture(ids)
+
+	for name, want := range map[string]int64{"g": 1, "x": 2, "y": 4} {
+		if obj, ok := captured.Get(name); !ok || obj.(*Integer).Value != want {
+			t.Errorf("Get(%s) = %v, %t", name, obj, ok)
+		}
+	}
+	if _, ok := captured.Get("unused"); ok {
+		t.Errorf("unreferenced binding was captured")
+	}
+	if len(unbound) != 1 || unbound[0] != symbol.Intern("missing") {
+		t.Errorf("unbound wrong. got=%v", unbound)
+	}
+
+	global.Set("g", &Integer{Value: 5})
+	if obj, _ := captured.Get("g"); obj.(*Integer).Value != 5 {
+		t.Errorf("global binding was copied instead of shared")
+	}
+
+	if captured, _ := global.Capture(ids); captured != global {
+		t.Errorf("Capture on the global environment should return it as is")
+	}
+}
+
+// collidingKey はすべて同じ HashKey を返すキー
+type collidingKey struct{ name string }
+
+func (k *collidingKey) Type() ObjectType { return STRING_OBJ }
+func (k *collidingKey) Inspect() string  { return k.name }
+func (k *collidingKey) HashKey() HashKey { return HashKey{Type: STRING_OBJ, Value: 42} }
+
+func TestHashCollisions(t *testing.T) {
+	const n = 1000
+	hash := NewHash()
+	for i := 0; i < n; i++ {
+		hash.Set(&collidingKey{name: strconv.Itoa(i)}, &Integer{Value: int64(i)})
+	}
+	// 半分を上書きしても組は増えない
+	for i := 0; i < n; i += 2 {
+		hash.Set(&collidingKey{name: strconv.Itoa(i)}, &Integer{Value: int64(-i)})
+	}
+
+	if len(hash.Pairs) != 1 {
+		t.Fatalf("expected a single bucket, got %d", len(hash.Pairs))
+	}
+	if hash.Len() != n {
+		t.Fatalf("hash.Len() = %d, want %d", hash.Len(), n)
+	}
+	for i := 0; i < n; i++ {
+		want := int64(i)
+		if i%2 == 0 {
+			want = int64(-i)
+		}
+		pair, ok := hash.Get(&collidingKey{name: strconv.Itoa(i)})
+		if !ok {
+			t.Fatalf("key %d not found", i)
+		}
+		if got := pair.Value.(*Integer).Value; got != want {
+			t.Errorf("key %d: got %d, want %d", i, got, want)
+		}
+	}
+	if _, ok := hash.Get(&collidingKey{name: "missing"}); ok {
+		t.Errorf("found a key that was never set")
+	}
+}
+
+func TestKeysEqual(t *testing.T) {
+	tests := []struct {
+		a, b     Object
+		expected bool
+	}{
+		{&String{Value: "a"}, &String{Value: "a"}, true},
+		{&String{Value: "a"}, &String{Value: "b"}, false},
+		// 数値は == と同じく型によらず値で比べる
+		{&Integer{Value: 1}, &Decimal{Value: big.NewRat(1, 1)}, true},
+		{&Integer{Value: 1}, &Decimal{Value: big.NewRat(3, 2)}, false},
+		{&BigInt{Value: new(big.Int).Lsh(big.NewInt(1), 70)}, &Decimal{Value: new(big.Rat).SetInt(new(big.Int).Lsh(big.NewInt(1), 70))}, true},
+		{&Integer{Value: 1}, &String{Value: "1"}, false},
+		{&Decimal{Value: big.NewRat(2, 4)}, &Decimal{Value: big.NewRat(1, 2)}, true},
+		{&Array{Elements: []Object{&Integer{Value: 1}}}, &Array{Elements: []Object{&Integer{Value: 1}}}, true},
+		{&Array{Elements: []Object{&Integer{Value: 1}}}, &Array{Elements: []Object{&Integer{Value: 2}}}, false},
+	}
+
+	for i, tt := range tests {
+		if got := KeysEqual(tt.a, tt.b); got != tt.expected {
+			t.Errorf("tests[%d] - KeysEqual(%s, %s) = %t", i, tt.a.Inspect(), tt.b.Inspect(), got)
+		}
+		// 等しいキーは同じバケツに入らなければ見つからない
+		a, aok := tt.a.(Hashable)
+		b, bok := tt.b.(Hashable)
+		if tt.expected && aok && bok && a.HashKey() != b.HashKey() {
+			t.Errorf("tests[%d] - equal keys %s and %s have different hash keys", i, tt.a.Inspect(), tt.b.Inspect())
+		}
+	}
+}
+
+func TestDeque(t *testing.T) {
+	d := &Deque{}
+	// 環状のバッファが一周して広げ直される場合も順序が保たれること
+	for i := 0; i < 20; i++ {
+		d.PushBack(&Integer{Value: int64(i)})
+		d.PushFront(&Integer{Value: int64(-i)})
+		if _, ok := d.PopBack(); !ok {
+			t.Fatalf("PopBack of a non-empty deque failed")
+		}
+	}
+	if d.Len() != 20 {
+		t.Fatalf("Len wrong. got=%d", d.Len())
+	}
+	for i := 19; i >= 0; i-- {
+		obj, ok := d.PopFront()
+		if !ok || obj.(*Integer).Value != int64(-i) {
+			t.Fatalf("PopFront wrong. expected %d, got %v", -i, obj)
+		}
+	}
+	if _, ok := d.PopFront(); ok {
+		t.Errorf("PopFront of an empty deque succeeded")
+	}
+	if _, ok := d.PopBack(); ok {
+		t.Errorf("PopBack of an empty deque succeeded")
+	}
+
+	d.PushFront(&Integer{Value: 2})
+	d.PushFront(&Integer{Value: 1})
+	d.PushBack(&Integer{Value: 3})
+	if d.Inspect() != "deque[1, 2, 3]" {
+		t.Errorf("Inspect wrong. got=%q", d.Inspect())
+	}
+	// 自分自身を (配列越しにも) 含む deque の表示が止まること
+	d.PushBack(d)
+	d.PushBack(&Array{Elements: []Object{d}})
+	if d.Inspect() != "deque[1, 2, 3, deque[...], [deque[...]]]" {
+		t.Errorf("Inspect of a cyclic deque wrong. got=%q", d.Inspect())
+	}
+	// 同じ deque が二度出てくるだけなら循環ではない
+	e := &Deque{}
+	e.PushBack(&Integer{Value: 1})
+	pair := &Array{Elements: []Object{e, e}}
+	if pair.Inspect() != "[deque[1], deque[1]]" {
+		t.Errorf("Inspect of a shared deque wrong. got=%q", pair.Inspect())
+	}
+}
+
+func TestUnhashablePart(t *testing.T) {
+	d := &Deque{}
+	nested := NewHash()
+	nested.Set(&String{Value: "a"}, &Array{Elements: []Object{&Integer{Value: 1}, d}})
+
+	tests := []struct {
+		obj      Object
+		expected Object
+	}{
+		{&Integer{Value: 1}, nil},
+		{&Array{Elements: []Object{&String{Value: "a"}, &Array{}}}, nil},
+		{d, d},
+		{&Array{Elements: []Object{&Integer{Value: 1}, d}}, d},
+		{nested, d},
+	}
+	for _, tt := range tests {
+		if part := UnhashablePart(tt.obj); part != tt.expected {
+			t.Errorf("UnhashablePart(%s): expected %v, got %v", tt.obj.Inspect(), tt.expected, part)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package object
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Proxy はホストの Go の値をスクリプトに公開する。
+// スクリプトからは proxy["Name"] でメソッドや構造体の公開フィールドを参照する。
+type Proxy struct {
+	Value   reflect.Value
+	allowed map[string]bool
+}
+
+// NewProxy は value を包む Proxy を返す。members を指定した場合はその名前のメンバーだけを公開し、
+// 省略した場合は公開されているメソッドとフィールドをすべて公開する。
+func NewProxy(value interface{}, members ...string) *Proxy {
+	p := &Proxy{Value: reflect.ValueOf(value)}
+	if len(members) > 0 {
+		p.allowed = make(map[string]bool, len(members))
+		for _, name := range members {
+			p.allowed[name] = true
+		}
+	}
+	return p
+}
+
+// Nested は p のメソッドやフィールドから得た value を包む Proxy を返す。
+// 公開するメンバーは p と同じに制限するので、許可していない名前が入れ子の値を通して見えることはない。
+func (p *Proxy) Nested(value interface{}) *Proxy {
+	return &Proxy{Value: reflect.ValueOf(value), allowed: p.allowed}
+}
+
+// Allows は name がスクリプトから参照できるメンバーかどうかを返す。
+func (p *Proxy) Allows(name string) bool {
+	if p.allowed == nil {
+		return true
+	}
+	return p.allowed[name]
+}
+
+func (p *Proxy) Type() ObjectType { return PROXY_OBJ }
+func (p *Proxy) Inspect() string {
+	if !p.Value.IsValid() {
+		return "proxy(nil)"
+	}
+	return fmt.Sprintf("proxy(%s)", p.Value.Type())
+}
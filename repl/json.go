@@ -0,0 +1,73 @@
+package repl
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/al-keio/monkey-go/object"
+)
+
+// jsonRequest は JSON モードの一行分の要求。id はそのまま応答に返す。
+type jsonRequest struct {
+	ID   json.RawMessage `json:"id,omitempty"`
+	Code string          `json:"code"`
+}
+
+type jsonResponse struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Result *string         `json:"result"` // 値の Inspect。値がなければ null
+	Type   string          `json:"type,omitempty"`
+	Errors []string        `json:"errors"`
+	Hint   string          `json:"hint,omitempty"` // エラーの値への助言
+	Stdout string          `json:"stdout"`
+}
+
+// StartJSON はエディタやノートブックから使うための REPL を動かす。
+// 一行に一つ {"code": "..."} の形の要求を読み、一行に一つ
+// {"result", "type", "errors", "stdout"} の形の応答を書く。エラーに助言があれば "hint" も付ける。
+// プロンプトや色は出さない。
+func StartJSON(in io.Reader, out io.Writer) {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	s := &session{
+		env:      object.NewEnvironment(),
+		macroEnv: object.NewEnvironment(),
+		format:   object.DefaultOutputFormat,
+	}
+	encoder := json.NewEncoder(out)
+
+	for scanner.Scan() {
+		var req jsonRequest
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			encoder.Encode(jsonResponse{Errors: []string{"invalid request: " + err.Error()}})
+			continue
+		}
+		encoder.Encode(s.evalJSON(req))
+	}
+}
+
+func (s *session) evalJSON(req jsonRequest) jsonResponse {
+	var stdout bytes.Buffer
+	s.out = &stdout
+
+	res := jsonResponse{ID: req.ID, Errors: []string{}}
+	result, errors := s.eval(req.Code, false)
+	res.Stdout = stdout.String()
+	if len(errors) != 0 {
+		res.Errors = errors
+		return res
+	}
+
+	if value := result.Value; value != nil {
+		inspected := s.format.Inspect(value)
+		res.Result = &inspected
+		res.Type = string(value.Type())
+		if err, ok := value.(*object.Error); ok {
+			res.Errors = []string{err.Message}
+			res.Hint = err.Hint
+		}
+	}
+	return res
+}
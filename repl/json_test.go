@@ -0,0 +1,63 @@
+package repl
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestStartJSON(t *testing.T) {
+	requests := []string{
+		`{"id": 1, "code": "let x = 2; puts(x); x * 3"}`,
+		`{"code": "x"}`,
+		`{"code": "let y = 1;"}`,
+		`{"id": "a", "code": "lenn([1])"}`,
+		`{"code": "puts(\"before\"); 1 + \"x\""}`,
+		`not json`,
+	}
+	expected := []string{
+		`{"id":1,"result":"6","type":"INTEGER","errors":[],"stdout":"2\n"}`,
+		`{"result":"2","type":"INTEGER","errors":[],"stdout":""}`,
+		`{"result":null,"errors":[],"stdout":""}`,
+		`{"id":"a","result":"ERROR: identifier not found: lenn","type":"ERROR","errors":["identifier not found: lenn"],"hint":"did you mean: len?","stdout":""}`,
+		`{"result":"ERROR: type mismatch: INTEGER + STRING","type":"ERROR","errors":["type mismatch: INTEGER + STRING"],"stdout":"before\n"}`,
+		`{"result":null,"errors":["invalid request: invalid character 'o' in literal null (expecting 'u')"],"stdout":""}`,
+	}
+
+	var out bytes.Buffer
+	StartJSON(strings.NewReader(strings.Join(requests, "\n")), &out)
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != len(expected) {
+		t.Fatalf("expected %d responses, got %d: %q", len(expected), len(lines), out.String())
+	}
+	for i, line := range lines {
+		if line != expected[i] {
+			t.Errorf("response to %s:\nexpected %s\ngot      %s", requests[i], expected[i], line)
+		}
+	}
+}
+
+func TestStartJSONParseErrors(t *testing.T) {
+	var out bytes.Buffer
+	StartJSON(strings.NewReader(`{"id": 7, "code": "let = 1"}`+"\n"+`{"code": "1 +"}`), &out)
+
+	decoder := json.NewDecoder(&out)
+	for decoder.More() {
+		var res map[string]interface{}
+		if err := decoder.Decode(&res); err != nil {
+			t.Fatalf("invalid response: %s", err)
+		}
+		if res["result"] != nil {
+			t.Errorf("expected no result, got %v", res["result"])
+		}
+		if _, ok := res["type"]; ok {
+			t.Errorf("expected no type, got %v", res["type"])
+		}
+		errors, ok := res["errors"].([]interface{})
+		if !ok || len(errors) == 0 || !strings.HasPrefix(errors[0].(string), "line 1, column ") {
+			t.Errorf("expected parser errors, got %v", res["errors"])
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package repl
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/al-keio/monkey-go/internal/lexer"
+	"github.com/al-keio/monkey-go/token"
+)
+
+// 入力した行の括弧が閉じていなければ、閉じるまで続きの行を読んでまとめて評価する
+const indentUnit = "  "
+
+const colorMatch = "\033[7m"
+
+var closing = map[token.TokenType]token.TokenType{
+	token.RPAREN:   token.LPAREN,
+	token.RBRACE:   token.LBRACE,
+	token.RBRACKET: token.LBRACKET,
+}
+
+// bracketPair は閉じ括弧 close と、それが閉じた開き括弧 open
+type bracketPair struct {
+	open, close token.Token
+}
+
+// scanBrackets は input を字句解析し、閉じられていない開き括弧を開いた順に、
+// 閉じた括弧の組を閉じた順に返す。対応しない閉じ括弧があれば ok は false になる。
+// 文字列やコメントの中の括弧は字句解析器が読み飛ばすので数えない。
+func scanBrackets(input string) (open []token.Token, pairs []bracketPair, ok bool) {
+	l := lexer.New(input)
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		switch tok.Type {
+		case token.LPAREN, token.LBRACE, token.LBRACKET:
+			open = append(open, tok)
+		case token.RPAREN, token.RBRACE, token.RBRACKET:
+			if len(open) == 0 || open[len(open)-1].Type != closing[tok.Type] {
+				return open, pairs, false
+			}
+			pairs = append(pairs, bracketPair{open: open[len(open)-1], close: tok})
+			open = open[:len(open)-1]
+		}
+	}
+	return open, pairs, true
+}
+
+// continuationPrompt は続きの行のプロンプト。prompt と同じ幅の . に、まだ開いている括弧の数だけ字下げを加える。
+func continuationPrompt(prompt string, depth int) string {
+	trimmed := strings.TrimRight(prompt, " ")
+	dots := strings.Repeat(".", utf8.RuneCountInString(trimmed)) + prompt[len(trimmed):]
+	return dots + strings.Repeat(indentUnit, depth)
+}
+
+// matchedOpening は入力の最後の行で閉じた括弧のうち、それより前の行で開いたものの中で最も外側の組を返す。
+// 最後の行の中で開いて閉じた括弧は見ればわかるので返さない。
+func matchedOpening(pairs []bracketPair, lastLine int) (bracketPair, bool) {
+	for i := len(pairs) - 1; i >= 0; i-- {
+		if pairs[i].close.Line == lastLine && pairs[i].open.Line < lastLine {
+			return pairs[i], true
+		}
+	}
+	return bracketPair{}, false
+}
+
+// highlightColumn は line の column 文字目 (1 始まり) を反転表示にする
+func highlightColumn(line string, column int) string {
+	runes := []rune(line)
+	if column < 1 || column > len(runes) {
+		return line
+	}
+	i := column - 1
+	return string(runes[:i]) + colorMatch + string(runes[i]) + colorReset + string(runes[i+1:])
+}
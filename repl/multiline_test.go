@@ -0,0 +1,56 @@
+package repl
+
+import "testing"
+
+func TestContinuationPrompt(t *testing.T) {
+	tests := []struct {
+		prompt   string
+		depth    int
+		expected string
+	}{
+		{">> ", 0, ".. "},
+		{">> ", 1, "..   "},
+		{">> ", 2, "..     "},
+		{"monkey> ", 1, ".......   "},
+		{"λ ", 0, ". "},
+		{">>", 1, "..  "},
+	}
+	for _, tt := range tests {
+		if got := continuationPrompt(tt.prompt, tt.depth); got != tt.expected {
+			t.Errorf("continuationPrompt(%q, %d): expected %q, got %q", tt.prompt, tt.depth, tt.expected, got)
+		}
+	}
+}
+
+func TestScanBrackets(t *testing.T) {
+	tests := []struct {
+		input string
+		open  int
+		ok    bool
+	}{
+		{"let f = fn(x) {", 1, true},
+		{"let f = fn(x) {\n  [1, (2", 3, true},
+		{"let f = fn(x) {\n  [1, (2)]\n}", 0, true},
+		{`puts("{[(")`, 0, true},
+		{"1 // {", 0, true},
+		{"{ ]", 1, false},
+		{")", 0, false},
+	}
+	for _, tt := range tests {
+		open, _, ok := scanBrackets(tt.input)
+		if len(open) != tt.open || ok != tt.ok {
+			t.Errorf("scanBrackets(%q): expected depth %d and ok %t, got %d and %t", tt.input, tt.open, tt.ok, len(open), ok)
+		}
+	}
+}
+
+func TestMatchedOpening(t *testing.T) {
+	_, pairs, _ := scanBrackets("let f = fn(x) {\n  [1, (2)]\n}")
+	pair, ok := matchedOpening(pairs, 3)
+	if !ok || pair.open.Line != 1 || pair.open.Column != 15 {
+		t.Errorf("expected the brace on line 1, column 15, got %+v (ok %t)", pair.open, ok)
+	}
+	if _, ok := matchedOpening(pairs, 2); ok {
+		t.Errorf("brackets opened and closed on the last line should not be reported")
+	}
+}
@@ -3,22 +3,72 @@ package repl
 import (
 	"bufio"
 	"fmt"
-	"github.com/al-keio/monkey-go/evaluator"
-	"github.com/al-keio/monkey-go/lexer"
-	"github.com/al-keio/monkey-go/object"
-	"github.com/al-keio/monkey-go/parser"
 	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/al-keio/monkey-go/internal/evaluator"
+	"github.com/al-keio/monkey-go/internal/lexer"
+	"github.com/al-keio/monkey-go/internal/parser"
+	"github.com/al-keio/monkey-go/object"
 )
 
 const PROMPT = ">> "
 
+// TraceParse が true なら入力ごとに構文解析の経過を出力する
+var TraceParse = false
+
+// RCFile は起動時に読み込む設定ファイル。空なら読み込まない。
+// 中身は Monkey の文と :prompt などの REPL コマンドで、エラーがあっても起動は続ける。
+var RCFile = ""
+
+// Fuse が true なら map と filter の呼び出しの連鎖を evaluator.Optimize で一度の走査にまとめる。
+// コールバックの呼ばれる順序が変わるので既定では行わない。
+var Fuse = false
+
+const (
+	colorRed   = "\033[31m"
+	colorReset = "\033[0m"
+)
+
+type session struct {
+	out           io.Writer
+	env           *object.Environment
+	macroEnv      *object.Environment
+	prompt        string
+	color         bool
+	showExpansion bool
+	transcript    *transcript
+	operators     map[string]int      // これまでの入力の operator 宣言
+	format        object.OutputFormat // set_output_format で設定した、入力をまたいで使う表示の書式
+}
+
 func Start(in io.Reader, out io.Writer) {
 	scanner := bufio.NewScanner(in)
-	env := object.NewEnvironment()
-	macroEnv := object.NewEnvironment()
+	s := &session{
+		out:      out,
+		env:      object.NewEnvironment(),
+		macroEnv: object.NewEnvironment(),
+		prompt:   PROMPT,
+		format:   object.DefaultOutputFormat,
+	}
 
+	if RCFile != "" {
+		s.loadRC(RCFile)
+	}
+	defer s.stopTranscript()
+
+	// lines は括弧が閉じるのを待っている入力の行
+	var lines []string
+	depth := 0
 	for {
-		fmt.Printf(PROMPT)
+		if len(lines) == 0 {
+			fmt.Print(s.prompt)
+		} else {
+			fmt.Print(continuationPrompt(s.prompt, depth))
+		}
 		scanned := scanner.Scan()
 
 		if !scanned {
@@ -26,24 +76,231 @@ func Start(in io.Reader, out io.Writer) {
 		}
 
 		line := scanner.Text()
-		l := lexer.New(line)
-		p := parser.New(l)
+		if len(lines) == 0 && strings.HasPrefix(line, ":transcript") {
+			s.transcriptCommand(strings.TrimSpace(strings.TrimPrefix(line, ":transcript")))
+			continue
+		}
 
-		program := p.ParseProgram()
-		if len(p.Errors()) != 0 {
-			printParseErrors(out, p.Errors())
+		lines = append(lines, line)
+		input := strings.Join(lines, "\n")
+		open, pairs, ok := scanBrackets(input)
+		if s.color && len(lines) > 1 {
+			s.showMatch(lines, pairs)
+		}
+		// 空行を入力すれば括弧が閉じていなくても評価して、構文エラーとして報告させる
+		if ok && len(open) > 0 && strings.TrimSpace(line) != "" {
+			depth = len(open)
 			continue
 		}
+		lines, depth = nil, 0
+		s.record(input, func() {
+			if !s.command(input) {
+				s.run(input)
+			}
+		})
+	}
+}
 
-		evaluator.DefineMacros(program, macroEnv)
-		expanded := evaluator.ExpandMacros(program, macroEnv)
+// showMatch は最後の行で閉じた括弧に対応する、前の行の開き括弧をその行ごと反転表示で示す
+func (s *session) showMatch(lines []string, pairs []bracketPair) {
+	pair, ok := matchedOpening(pairs, len(lines))
+	if !ok {
+		return
+	}
+	fmt.Fprintf(s.out, "%s matches line %d: %s\n", pair.close.Literal, pair.open.Line, highlightColumn(lines[pair.open.Line-1], pair.open.Column))
+}
 
-		evaluated := evaluator.Eval(expanded, env)
-		if evaluated != nil {
-			io.WriteString(out, evaluated.Inspect())
-			io.WriteString(out, "\n")
+// command は : で始まる REPL コマンドを実行する。コマンドでなければ false を返す。
+func (s *session) command(line string) bool {
+	switch {
+	case strings.HasPrefix(line, ":hygiene "):
+		printHygieneReport(s.out, strings.TrimPrefix(line, ":hygiene "), s.macroEnv)
+	case strings.HasPrefix(line, ":show-expansion"):
+		if on, ok := parseSwitch(strings.TrimPrefix(line, ":show-expansion")); ok {
+			s.showExpansion = on
+		} else {
+			io.WriteString(s.out, "usage: :show-expansion on|off\n")
+		}
+	case strings.HasPrefix(line, ":color"):
+		if on, ok := parseSwitch(strings.TrimPrefix(line, ":color")); ok {
+			s.color = on
+		} else {
+			io.WriteString(s.out, "usage: :color on|off\n")
 		}
+	case strings.HasPrefix(line, ":prompt"):
+		arg := strings.TrimSpace(strings.TrimPrefix(line, ":prompt"))
+		// 末尾の空白を含められるよう、引用符で囲まれていれば Go の文字列として読む
+		if prompt, err := strconv.Unquote(arg); err == nil {
+			s.prompt = prompt
+		} else if arg != "" {
+			s.prompt = arg + " "
+		} else {
+			io.WriteString(s.out, "usage: :prompt \"text\"\n")
+		}
+	case strings.HasPrefix(line, ":time "):
+		if result := s.run(strings.TrimPrefix(line, ":time ")); result != nil {
+			fmt.Fprintf(s.out, "time: %s, steps: %d\n", result.Stats.WallTime, result.Stats.Steps)
+		}
+	case strings.HasPrefix(line, ":mem "):
+		if result := s.run(strings.TrimPrefix(line, ":mem ")); result != nil {
+			fmt.Fprintf(s.out, "allocations: ~%d, max call depth: %d\n", result.Stats.Allocations, result.Stats.MaxCallDepth)
+		}
+	case strings.HasPrefix(line, ":doc "):
+		printDoc(s.out, strings.TrimSpace(strings.TrimPrefix(line, ":doc ")), s.env, s.macroEnv)
+	default:
+		return false
+	}
+	return true
+}
+
+// transcriptCommand は :transcript file で記録を始め、:transcript off で止める。
+// 記録そのものの操作は記録しない。
+func (s *session) transcriptCommand(arg string) {
+	switch arg {
+	case "":
+		io.WriteString(s.out, "usage: :transcript file.md|off\n")
+	case "off":
+		s.stopTranscript()
+	default:
+		s.startTranscript(arg)
+	}
+}
+
+func parseSwitch(arg string) (on bool, ok bool) {
+	switch strings.TrimSpace(arg) {
+	case "on":
+		return true, true
+	case "off":
+		return false, true
+	default:
+		return false, false
+	}
+}
+
+// run は入力を評価して結果を表示する。構文エラーなら nil を返す。
+func (s *session) run(input string) *evaluator.EvalResult {
+	result, errors := s.eval(input, s.showExpansion)
+	if len(errors) != 0 {
+		s.printParseErrors(errors)
+		return nil
+	}
+
+	switch evaluated := result.Value; {
+	case evaluated == nil:
+	case evaluated.Type() == object.ERROR_OBJ:
+		s.printError(errorText(evaluated.(*object.Error)))
+	default:
+		io.WriteString(s.out, s.format.Inspect(evaluated))
+		io.WriteString(s.out, "\n")
 	}
+	return result
+}
+
+// eval は入力を構文解析し、マクロを展開して評価する。構文エラーがあれば評価せずにそれを返す。
+func (s *session) eval(input string, showExpansion bool) (*evaluator.EvalResult, []string) {
+	p := parser.New(lexer.New(input))
+	if TraceParse {
+		p.SetTracer(parser.NewTextTracer(s.out))
+	}
+	for symbol, precedence := range s.operators {
+		p.RegisterOperator(symbol, precedence)
+	}
+
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return nil, p.ErrorsWithHints()
+	}
+	s.operators = p.Operators()
+
+	// マクロの展開も評価と同じ設定の下で行う
+	options := evaluator.EvalOptions{Output: s.out, OutputFormat: &s.format}
+	evaluator.DefineMacros(program, s.macroEnv)
+	expanded, err := evaluator.ExpandMacrosWithOptions(program, s.macroEnv, options)
+	if err != nil {
+		return &evaluator.EvalResult{Value: err}, nil
+	}
+	// ExpandMacrosWithOptions はマクロ呼び出しがなければ program をそのまま返す
+	if showExpansion && expanded != program {
+		io.WriteString(s.out, "expansion: "+expanded.String()+"\n")
+	}
+	if Fuse {
+		expanded = evaluator.Optimize(expanded)
+	}
+
+	return evaluator.EvalWithOptions(expanded, s.env, options), nil
+}
+
+// loadRC は設定ファイルを先頭から順に実行する。: で始まる行は REPL コマンド、
+// それ以外は次のコマンドかファイルの終わりまでをまとめて Monkey のプログラムとして評価する。
+// 評価結果は表示せず、エラーだけをファイル名付きで表示する。
+func (s *session) loadRC(path string) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		// 既定の ~/.monkeyrc はなくてよい
+		if !os.IsNotExist(err) || path != DefaultRCFile() {
+			s.printError(err.Error())
+		}
+		return
+	}
+
+	var source []string
+	flush := func() {
+		if len(source) == 0 {
+			return
+		}
+		result, errors := s.eval(strings.Join(source, "\n"), false)
+		source = nil
+
+		if len(errors) != 0 {
+			s.printError(path + ": parser errors:\n\t" + strings.Join(errors, "\n\t"))
+		} else if result.Value != nil && result.Value.Type() == object.ERROR_OBJ {
+			s.printError(path + ": " + errorText(result.Value.(*object.Error)))
+		}
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		if strings.HasPrefix(line, ":") {
+			flush()
+			if !s.command(line) {
+				s.printError(path + ": unknown command " + strings.Fields(line)[0])
+			}
+			continue
+		}
+		source = append(source, line)
+	}
+	flush()
+}
+
+// DefaultRCFile は ~/.monkeyrc のパスを返す。ホームディレクトリがわからなければ空文字列。
+func DefaultRCFile() string {
+	home := os.Getenv("HOME")
+	if home == "" {
+		return ""
+	}
+	return home + string(os.PathSeparator) + ".monkeyrc"
+}
+
+// errorText はエラーの Inspect に、助言があれば次の行に付けて返す
+func errorText(err *object.Error) string {
+	if err.Hint == "" {
+		return err.Inspect()
+	}
+	return err.Inspect() + "\n  hint: " + err.Hint
+}
+
+func (s *session) printError(msg string) {
+	if s.color {
+		msg = colorRed + msg + colorReset
+	}
+	io.WriteString(s.out, msg+"\n")
+}
+
+func (s *session) printParseErrors(errors []string) {
+	lines := []string{" parser errors:"}
+	for _, msg := range errors {
+		lines = append(lines, "\t"+msg)
+	}
+	s.printError(strings.Join(lines, "\n"))
 }
 
 func printParseErrors(out io.Writer, errors []string) {
@@ -52,3 +309,40 @@ func printParseErrors(out io.Writer, errors []string) {
 		io.WriteString(out, "\t"+msg+"\n")
 	}
 }
+
+func printHygieneReport(out io.Writer, input string, macroEnv *object.Environment) {
+	l := lexer.New(input)
+	p := parser.New(l)
+
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		printParseErrors(out, p.ErrorsWithHints())
+		return
+	}
+
+	evaluator.DefineMacros(program, macroEnv)
+	report := evaluator.TraceMacroHygiene(program, macroEnv)
+
+	io.WriteString(out, "expansion: "+report.Expanded.String()+"\n")
+	for _, ident := range report.Identifiers {
+		origin := "call site"
+		if ident.FromMacro {
+			origin = "macro definition"
+		}
+		if ident.Captures {
+			origin += " (captures call-site " + ident.Name + ")"
+		}
+		io.WriteString(out, "\t"+ident.Name+"\t"+origin+"\n")
+	}
+}
+
+func printDoc(out io.Writer, name string, env, macroEnv *object.Environment) {
+	doc, ok := evaluator.Doc(name, env)
+	if !ok {
+		doc, ok = evaluator.Doc(name, macroEnv)
+	}
+	if !ok {
+		doc = "no documentation for " + name
+	}
+	io.WriteString(out, doc+"\n")
+}
@@ -0,0 +1,72 @@
+package repl
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// transcript は入力と出力を Markdown のコードブロックとして書き出す
+type transcript struct {
+	file   *os.File
+	output bytes.Buffer // 記録中の入力に対する出力
+}
+
+// startTranscript は path への記録を始める。記録中のものがあれば閉じる。
+func (s *session) startTranscript(path string) {
+	s.stopTranscript()
+
+	file, err := os.Create(path)
+	if err != nil {
+		s.printError(err.Error())
+		return
+	}
+	s.transcript = &transcript{file: file}
+	fmt.Fprintf(s.out, "recording transcript to %s\n", path)
+}
+
+func (s *session) stopTranscript() {
+	if s.transcript == nil {
+		return
+	}
+	if err := s.transcript.file.Close(); err != nil {
+		s.printError(err.Error())
+	}
+	s.transcript = nil
+}
+
+// record は line を処理する間の出力を s.out に加えて記録し、入力とともに書き出す
+func (s *session) record(line string, process func()) {
+	if s.transcript == nil {
+		process()
+		return
+	}
+
+	t := s.transcript
+	out := s.out
+	s.out = io.MultiWriter(out, &t.output)
+	process()
+	s.out = out
+
+	var entry bytes.Buffer
+	entry.WriteString("```monkey\n" + line + "\n```\n\n")
+	if output := stripColor(t.output.String()); output != "" {
+		entry.WriteString("```\n" + output)
+		if !strings.HasSuffix(output, "\n") {
+			entry.WriteString("\n")
+		}
+		entry.WriteString("```\n\n")
+	}
+	t.output.Reset()
+
+	if _, err := t.file.Write(entry.Bytes()); err != nil {
+		s.printError(err.Error())
+		s.stopTranscript()
+	}
+}
+
+func stripColor(s string) string {
+	return strings.NewReplacer(colorRed, "", colorMatch, "", colorReset, "").Replace(s)
+}
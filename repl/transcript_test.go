@@ -0,0 +1,48 @@
+package repl
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTranscript(t *testing.T) {
+	dir, err := ioutil.TempDir("", "transcript")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "session.md")
+
+	input := strings.Join([]string{
+		":transcript " + path,
+		"let x = 1",
+		"let add = fn(a, b) {",
+		"  a + b",
+		"}",
+		`puts("hi"); add(x, 1)`,
+		"lenn(x)",
+		":transcript off",
+		"x",
+	}, "\n")
+	var out bytes.Buffer
+	Start(strings.NewReader(input), &out)
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "```monkey\nlet x = 1\n```\n\n" +
+		"```monkey\nlet add = fn(a, b) {\n  a + b\n}\n```\n\n" +
+		"```monkey\nputs(\"hi\"); add(x, 1)\n```\n\n```\nhi\n2\n```\n\n" +
+		"```monkey\nlenn(x)\n```\n\n```\nERROR: identifier not found: lenn\n  hint: did you mean: len?\n```\n\n"
+	if string(content) != expected {
+		t.Errorf("wrong transcript:\nexpected %q\ngot      %q", expected, content)
+	}
+	if !strings.HasPrefix(out.String(), "recording transcript to "+path+"\n") || !strings.HasSuffix(out.String(), "\n1\n") {
+		t.Errorf("wrong output: %q", out.String())
+	}
+}
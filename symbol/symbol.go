@@ -0,0 +1,43 @@
+// Package symbol は識別子の名前を小さな整数に interning する。
+package symbol
+
+import "sync"
+
+// ID は interning された名前。0 はどの名前にも対応しない。
+type ID uint32
+
+var (
+	mu    sync.RWMutex
+	ids   = map[string]ID{}
+	names = []string{""}
+)
+
+// Intern は name に対応する ID を返す。同じ名前には常に同じ ID が返る。
+func Intern(name string) ID {
+	mu.RLock()
+	id, ok := ids[name]
+	mu.RUnlock()
+	if ok {
+		return id
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if id, ok := ids[name]; ok {
+		return id
+	}
+	id = ID(len(names))
+	ids[name] = id
+	names = append(names, name)
+	return id
+}
+
+// String は ID の元の名前を返す
+func (id ID) String() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	if int(id) >= len(names) {
+		return ""
+	}
+	return names[id]
+}
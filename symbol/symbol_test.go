@@ -0,0 +1,46 @@
+package symbol
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestIntern(t *testing.T) {
+	a := Intern("alpha")
+	b := Intern("beta")
+
+	if a == 0 || b == 0 {
+		t.Fatalf("Intern returned the zero ID: alpha=%d, beta=%d", a, b)
+	}
+	if a == b {
+		t.Errorf("different names got the same ID %d", a)
+	}
+	if again := Intern("alpha"); again != a {
+		t.Errorf("Intern(alpha) = %d, then %d", a, again)
+	}
+	if a.String() != "alpha" || b.String() != "beta" {
+		t.Errorf("String() = %q, %q", a.String(), b.String())
+	}
+	if ID(0).String() != "" {
+		t.Errorf("ID(0).String() = %q", ID(0).String())
+	}
+}
+
+func TestInternConcurrent(t *testing.T) {
+	var wg sync.WaitGroup
+	got := make([]ID, 16)
+	for i := range got {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			got[i] = Intern("concurrent")
+		}(i)
+	}
+	wg.Wait()
+
+	for _, id := range got {
+		if id != got[0] {
+			t.Fatalf("Intern returned different IDs: %v", got)
+		}
+	}
+}
@@ -5,6 +5,16 @@ type TokenType string
 type Token struct {
 	Type    TokenType
 	Literal string
+	Doc     string // 直前のドキュメントコメント (/// ...)
+	Offset  int    // 入力の先頭からのバイト位置
+	Line    int    // 1 から数える行
+	Column  int    // 1 から数える桁。文字単位
+}
+
+// Comment は字句解析器が読み飛ばしたコメント。Text は // や /* */ を含めて書かれたとおり。
+type Comment struct {
+	Text   string
+	Offset int
 }
 
 const (
@@ -12,9 +22,12 @@ const (
 	EOF     = "EOF"
 
 	// 識別子 + リテラル
-	IDENT  = "IDENT" // add, foobar, x, y, ...
-	INT    = "INT"
-	STRING = "STRING"
+	IDENT   = "IDENT" // add, foobar, x, y, ...
+	INT     = "INT"
+	BIGINT  = "BIGINT"  // 123n
+	DECIMAL = "DECIMAL" // 12.34d
+	FLOAT   = "FLOAT"   // 3.14, 1e-3
+	STRING  = "STRING"
 
 	// 演算子
 	ASSIGN   = "="
@@ -23,16 +36,26 @@ const (
 	BANG     = "!"
 	ASTERISK = "*"
 	SLASH    = "/"
+	PERCENT  = "%"
 
 	EQ     = "=="
 	NOT_EQ = "!="
 	LT     = "<"
 	GT     = ">"
 
+	QUESTION = "?"
+	ARROW    = "=>"
+	ELLIPSIS = "..."
+
+	// CUSTOM_INFIX は operator 宣言で定義した演算子。字句解析器は作らず、パーサーが隣り合う記号のトークンをまとめて作る
+	CUSTOM_INFIX = "CUSTOM_INFIX"
+
 	// デリミタ
 	COMMA     = ","
 	SEMICOLON = ";"
 	COLON     = ":"
+	DOT       = "."
+	NEWLINE   = "NEWLINE" // 文を終えられるトークンの後の改行だけが NEWLINE になる
 
 	LPAREN   = "("
 	RPAREN   = ")"
@@ -50,22 +73,63 @@ const (
 	ELSE     = "ELSE"
 	RETURN   = "RETURN"
 	MACRO    = "MACRO"
+	MATCH    = "MATCH"
+	DEFER    = "DEFER"
+	OPERATOR = "OPERATOR"
+	CLASS    = "CLASS"
+	NEW      = "NEW"
 )
 
-var keywords = map[string]TokenType{
-	"fn":     FUNCTION,
-	"let":    LET,
-	"true":   TRUE,
-	"false":  FALSE,
-	"if":     IF,
-	"else":   ELSE,
-	"return": RETURN,
-	"macro":  MACRO,
+// DefaultKeywords は標準のキーワード。書き換えずに WithAliases で別の表を作ること。
+var DefaultKeywords = KeywordTable{
+	"fn":       FUNCTION,
+	"let":      LET,
+	"true":     TRUE,
+	"false":    FALSE,
+	"if":       IF,
+	"else":     ELSE,
+	"return":   RETURN,
+	"macro":    MACRO,
+	"match":    MATCH,
+	"defer":    DEFER,
+	"operator": OPERATOR,
+	"class":    CLASS,
+	"new":      NEW,
 }
 
-func LookupIdent(ident string) TokenType {
-	if tok, ok := keywords[ident]; ok {
+// KeywordTable は語とキーワードのトークン種別の対応
+type KeywordTable map[string]TokenType
+
+// WithAliases は kt に aliases を加えた表を返す。kt 自体は変更しない。
+// 教育用の別名や英語以外のキーワードを標準のものと併用するときに使う。
+func (kt KeywordTable) WithAliases(aliases map[string]TokenType) KeywordTable {
+	table := make(KeywordTable, len(kt)+len(aliases))
+	for word, tok := range kt {
+		table[word] = tok
+	}
+	for word, tok := range aliases {
+		table[word] = tok
+	}
+	return table
+}
+
+func (kt KeywordTable) Lookup(ident string) TokenType {
+	if tok, ok := kt[ident]; ok {
 		return tok
 	}
 	return IDENT
 }
+
+var keywords = DefaultKeywords
+
+// SetKeywords は以後の LookupIdent で使う表を設定し、それまでの表を返す。
+// 字句解析と並行して呼んではならない。
+func SetKeywords(table KeywordTable) KeywordTable {
+	previous := keywords
+	keywords = table
+	return previous
+}
+
+func LookupIdent(ident string) TokenType {
+	return keywords.Lookup(ident)
+}
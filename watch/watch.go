@@ -0,0 +1,73 @@
+// Package watch はスクリプトの変更を検出して再実行するための仕組みを提供する。
+package watch
+
+import (
+	"os"
+	"time"
+)
+
+// Watcher は監視しているファイルのどれかが変更されるまで待つ。
+// ポーリング以外 (inotify など) の実装に差し替えられるようインターフェースにしておく。
+type Watcher interface {
+	Wait()
+}
+
+type fileState struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+// Poller は一定間隔でファイルの更新時刻とサイズを調べて変更を検出する Watcher
+type Poller struct {
+	Paths    []string
+	Interval time.Duration // 調べる間隔
+	Debounce time.Duration // 変更が続く間は待ち、この時間変更がなければ戻る (保存途中での再実行を避ける)
+
+	last map[string]fileState
+}
+
+func NewPoller(paths ...string) *Poller {
+	p := &Poller{Paths: paths, Interval: 200 * time.Millisecond, Debounce: 100 * time.Millisecond}
+	p.last = p.snapshot()
+	return p
+}
+
+// Wait は変更を検出し、それが Debounce の間落ち着くまで待つ
+func (p *Poller) Wait() {
+	for !p.Changed() {
+		time.Sleep(p.Interval)
+	}
+	for {
+		time.Sleep(p.Debounce)
+		if !p.Changed() {
+			return
+		}
+	}
+}
+
+// Changed は前回調べたときからファイルが変更 (作成・削除を含む) されたかどうかを返す
+func (p *Poller) Changed() bool {
+	current := p.snapshot()
+	changed := false
+	for path, state := range current {
+		if p.last[path] != state {
+			changed = true
+		}
+	}
+	p.last = current
+	return changed
+}
+
+func (p *Poller) snapshot() map[string]fileState {
+	states := make(map[string]fileState, len(p.Paths))
+	for _, path := range p.Paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			states[path] = fileState{}
+			continue
+		}
+		states[path] = fileState{exists: true, size: info.Size(), modTime: info.ModTime()}
+	}
+	return states
+}
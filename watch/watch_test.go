@@ -0,0 +1,66 @@
+package watch
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestPollerChanged(t *testing.T) {
+	file, err := ioutil.TempFile("", "monkey")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := file.Name()
+	file.Close()
+	defer os.Remove(path)
+
+	p := NewPoller(path)
+	if p.Changed() {
+		t.Errorf("Changed() = true before any change")
+	}
+
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if !p.Changed() {
+		t.Errorf("Changed() = false after modification time changed")
+	}
+	if p.Changed() {
+		t.Errorf("Changed() = true twice for the same change")
+	}
+
+	os.Remove(path)
+	if !p.Changed() {
+		t.Errorf("Changed() = false after the file was removed")
+	}
+}
+
+func TestPollerWait(t *testing.T) {
+	file, err := ioutil.TempFile("", "monkey")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := file.Name()
+	file.Close()
+	defer os.Remove(path)
+
+	p := NewPoller(path)
+	p.Interval, p.Debounce = time.Millisecond, 5*time.Millisecond
+
+	done := make(chan struct{})
+	go func() {
+		p.Wait()
+		close(done)
+	}()
+
+	ioutil.WriteFile(path, []byte("puts(1)"), 0644)
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Wait did not return after the file changed")
+	}
+}